@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bookstack/config"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/repository"
+	"bookstack/internal/service"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Nhập sách từ dòng lệnh:
+//
+//	go run ./cmd/import -user 1 -shelve 2 [-format markdown|epub|html] [-title "..."] [-restricted] path/to/book.zip
+func main() {
+	userId := flag.Int("user", 0, "ID of the user creating the book")
+	shelveId := flag.Uint("shelve", 0, "ID of the shelve to put the book in")
+	format := flag.String("format", "", "markdown, epub or html (detected from the file name when empty)")
+	title := flag.String("title", "", "override the book title")
+	restricted := flag.Bool("restricted", false, "restrict the imported book")
+	flag.Parse()
+
+	if flag.NArg() != 1 || *userId == 0 || *shelveId == 0 {
+		fmt.Fprintln(os.Stderr, "usage: import -user <id> -shelve <id> [-format f] [-title t] [-restricted] <file>")
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", path, err)
+	}
+
+	conf, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	db := config.ConnectDB(conf)
+
+	bookService := service.NewBookServiceImpl(
+		repository.NewBookRepositoryImpl(db),
+		repository.NewEntityPermissionRepositoryImpl(db),
+		repository.NewCommentRepositoryImpl(db),
+		config.NewEventBus(conf, config.NewMessageBroker(conf)),
+	)
+	book, warnings, err := bookService.ImportBook(*userId, request.ImportBookRequest{
+		ShelveID:   *shelveId,
+		Format:     *format,
+		Title:      *title,
+		Restricted: *restricted,
+	}, filepath.Base(path), data)
+	if err != nil {
+		log.Fatalf("Failed to import %s: %v", path, err)
+	}
+
+	pages := 0
+	for _, chapter := range book.Chapters {
+		pages += len(chapter.Pages)
+	}
+	fmt.Printf("Imported book #%d %q: %d chapters, %d pages\n", book.ID, book.Title, len(book.Chapters), pages)
+	for _, warning := range warnings {
+		fmt.Printf("warning: %s: %s\n", warning.File, warning.Message)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bookstack/internal/repository"
 	"bookstack/internal/wire"
 	"bookstack/routes"
+	"context"
 	"fmt"
 	"log"
 
@@ -42,12 +43,32 @@ func main() {
 
 	// Seed database roles and permissions
 	repository.SeedRolesAndPermissions()
+	// Tạo chỉ mục tìm kiếm cho dữ liệu cũ
+	repository.BackfillSearchVectors()
 
 	// Define routes
 	routes.AuthRoute(*app.AuthenticationController, router)
 	routes.UserRoute(*app.UserController, app.Middleware, router)
-	routes.BookRoute(*app.BookController, router)
-	routes.OrderRoute(*app.OrderController, router)
+	routes.BookRoute(*app.BookController, app.Middleware, router)
+	routes.OrderRoute(*app.OrderController, app.Middleware, router)
+	routes.CartRoute(*app.CartController, router)
+	routes.PaymentRoute(*app.PaymentController, app.Middleware, router)
+	routes.CouponRoute(*app.CouponController, app.Middleware, router)
+	routes.CurrencyRoute(*app.CurrencyController, app.Middleware, router)
+	routes.InventoryRoute(*app.InventoryController, app.Middleware, router)
+	routes.LibraryRoute(*app.LibraryController, router)
+	routes.NotificationRoute(*app.NotificationController, router)
+	routes.MessageRoute(*app.MessageController, app.Middleware, router)
+	routes.EventRoute(*app.EventController, router)
+	routes.WebhookRoute(*app.WebhookController, app.Middleware, router)
+
+	// Phát sự kiện đã ghi vào outbox lên bus sự kiện
+	app.OrderController.StartOutboxRelay(context.Background())
+	// Giao sự kiện nghiệp vụ tới webhook của đối tác
+	app.WebhookController.StartDeliveringWebhooks(context.Background())
+
+	// Nhận thông báo từ Redis cho các kết nối SSE/WebSocket của instance này
+	app.NotificationController.StartListening(context.Background())
 
 	// Setup Swagger
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -4,6 +4,7 @@ import (
 	"bookstack/cmd/service2/routes"
 	"bookstack/config"
 	"bookstack/internal/wire"
+	"context"
 	"log"
 
 	"github.com/gin-gonic/gin"
@@ -29,8 +30,14 @@ func main() {
 	}
 
 	// Start listening for new orders
-	app.ShipperController.StartListeningForNewOrders()
+	app.ShipperController.StartListeningForNewOrders(context.Background())
+	// Mời shipper tiếp theo khi lời mời hết hạn
+	app.ShipperController.StartOfferSweeper(context.Background())
+
+	// Nhận thông báo từ Redis cho các kết nối SSE/WebSocket của instance này
+	app.NotificationController.StartListening(context.Background())
 
 	routes.ShipperRoutes(router, app.Middleware, app.ShipperController)
+	routes.NotificationRoutes(router, app.NotificationController)
 	router.Run(":8081")
 }
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"bookstack/internal/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NotificationRoutes(router *gin.Engine, notificationController *controller.NotificationController) {
+	notificationRouter := router.Group("/notifications")
+	{
+		// Token lấy từ header Authorization hoặc query access_token
+		notificationRouter.GET("/stream", notificationController.Stream)
+		notificationRouter.GET("/ws", notificationController.WebSocket)
+	}
+}
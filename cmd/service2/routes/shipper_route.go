@@ -12,11 +12,32 @@ func ShipperRoutes(router *gin.Engine, mw *middleware.Middleware, shipperControl
 	shipperRouter := router.Group("/shippers")
 	{
 		shipperRouter.GET("", mw.AuthorizeRole(constant.ReadUser), shipperController.GetAllShipper)
-		// Lấy tất cả order có địa chỉ trùng với nơi làm việc của shipper
+		// Lấy tất cả order có địa chỉ trùng với nơi làm việc của shipper hoặc nằm trong bán kính quanh một điểm
 		shipperRouter.GET("/orders", shipperController.GetOrderInRange)
 		// Nhận đơn hàng
 		shipperRouter.POST("/orders/:orderId", mw.AuthorizeRole(constant.ReceiveOrder), shipperController.ReceiveOrder)
 		// Lấy tất cả đơn hàng đã  nhận
 		shipperRouter.GET("/orders/received", mw.AuthorizeRole(constant.ReceiveOrder), shipperController.GetReceivedOrders)
+		// Cập nhật trạng thái đơn hàng được giao (Shipped); Delivered/Failed đi qua deliver/fail kèm bằng chứng
+		shipperRouter.PUT("/orders/:orderId/status", mw.AuthorizeRole(constant.UpdateOrderStatus), shipperController.UpdateOrderStatus)
+		// Hoàn tất đơn kèm bằng chứng giao hàng hoặc ghi nhận giao thất bại
+		shipperRouter.POST("/orders/:orderId/deliver", mw.AuthorizeRole(constant.UpdateOrderStatus), shipperController.Deliver)
+		shipperRouter.POST("/orders/:orderId/fail", mw.AuthorizeRole(constant.UpdateOrderStatus), shipperController.FailDelivery)
+		shipperRouter.GET("/orders/:orderId/proofs", mw.AuthorizeRole(constant.ManageOrders), shipperController.GetDeliveryProofs)
+		shipperRouter.GET("/proofs/:proofId/:kind", mw.AuthorizeRole(constant.ManageOrders), shipperController.GetDeliveryProofFile)
+		// Báo thời gian dự kiến giao tới cho khách
+		shipperRouter.PUT("/orders/:orderId/eta", mw.AuthorizeRole(constant.UpdateOrderStatus), shipperController.SetDeliveryETA)
+		// Lời mời giao đơn từ bộ điều phối
+		shipperRouter.GET("/offers", mw.AuthorizeRole(constant.ReceiveOrder), shipperController.GetOffers)
+		shipperRouter.POST("/offers/:offerId/accept", mw.AuthorizeRole(constant.ReceiveOrder), shipperController.AcceptOffer)
+		shipperRouter.POST("/offers/:offerId/decline", mw.AuthorizeRole(constant.ReceiveOrder), shipperController.DeclineOffer)
+		shipperRouter.PUT("/availability", mw.AuthorizeRole(constant.ReceiveOrder), shipperController.SetAvailability)
+		// Vùng phục vụ theo tọa độ, dùng để ghép đơn theo khoảng cách
+		shipperRouter.GET("/service-area", mw.AuthorizeRole(constant.ReceiveOrder), shipperController.GetServiceArea)
+		shipperRouter.PUT("/service-area", mw.AuthorizeRole(constant.ReceiveOrder), shipperController.SetServiceArea)
+		shipperRouter.DELETE("/service-area", mw.AuthorizeRole(constant.ReceiveOrder), shipperController.ClearServiceArea)
+		// Đơn không shipper nào nhận, nhân viên gán tay
+		shipperRouter.GET("/dispatch/manual", mw.AuthorizeRole(constant.ManageOrders), shipperController.GetManualDispatchOrders)
+		shipperRouter.PUT("/orders/:orderId/assign", mw.AuthorizeRole(constant.ManageOrders), shipperController.AssignOrder)
 	}
 }
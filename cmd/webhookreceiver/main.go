@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/webhook"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Nhận webhook ở máy cục bộ để kiểm tra subscription: in từng request và kết quả xác thực chữ ký.
+// -status trả mã khác 2xx để thử cơ chế gửi lại.
+//
+//	go run ./cmd/webhookreceiver -secret whsec_... [-addr :9090] [-status 200]
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	secret := flag.String("secret", "", "secret of the webhook subscription")
+	status := flag.Int("status", http.StatusOK, "status code to answer with")
+	flag.Parse()
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		verified := "not checked (no -secret)"
+		if *secret != "" {
+			verified = "ok"
+			if err := webhook.Verify(*secret, r.Header, body, time.Now(), constant.WebhookSignatureMaxAge); err != nil {
+				verified = err.Error()
+			}
+		}
+		fmt.Printf("%s %s event=%s delivery=%s signature=%s\n%s\n\n", time.Now().Format(time.RFC3339), r.URL.Path,
+			r.Header.Get(webhook.HeaderEvent), r.Header.Get(webhook.HeaderDelivery), verified, body)
+		if *secret != "" && verified != "ok" {
+			http.Error(w, verified, http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(*status)
+	})
+	log.Printf("Listening for webhooks on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -1,6 +1,11 @@
 package config
 
 import (
+	"bookstack/internal/events"
+	"bookstack/internal/geo"
+	"bookstack/internal/messaging"
+	"bookstack/internal/paypalwebhook"
+	"bookstack/internal/storage"
 	"log"
 
 	"github.com/plutov/paypal/v4"
@@ -13,3 +18,50 @@ func ConnectPaypal(config *Config) (*paypal.Client, error) {
 	}
 	return c, nil
 }
+
+// NewPaypalWebhookVerifier tạo bộ xác thực chữ ký webhook cho PAYPAL_WEBHOOK_ID.
+// Khi chưa cấu hình webhook ID, mọi webhook đều bị từ chối.
+func NewPaypalWebhookVerifier(config *Config) *paypalwebhook.Verifier {
+	return paypalwebhook.NewVerifier(config.PaypalWebhookID, paypalwebhook.NewHTTPCertFetcher())
+}
+
+// NewGeocoder nạp gazetteer từ GAZETTEER_FILE, chưa cấu hình thì dùng gazetteer đi kèm mã nguồn
+func NewGeocoder(config *Config) (*geo.Gazetteer, error) {
+	if config.GazetteerFile == "" {
+		return geo.DefaultGazetteer(), nil
+	}
+	return geo.LoadGazetteer(config.GazetteerFile)
+}
+
+// NewDeliveryProofStore mở kho lưu ảnh giao hàng và chữ ký tại DELIVERY_PROOF_DIR
+func NewDeliveryProofStore(config *Config) *storage.LocalStore {
+	return storage.NewLocalStore(config.DeliveryProofDir)
+}
+
+// NewMessageBroker tạo client RabbitMQ dùng chung, kết nối được mở ở lần publish/consume đầu tiên
+func NewMessageBroker(config *Config) *messaging.Client {
+	policy := messaging.DefaultRetryPolicy
+	if config.RabbitMQAttempts > 0 {
+		policy.MaxAttempts = config.RabbitMQAttempts
+	}
+	if config.RabbitMQDelay > 0 {
+		policy.BaseDelay = config.RabbitMQDelay
+		policy.MaxDelay = max(policy.MaxDelay, policy.BaseDelay)
+	}
+	return messaging.NewClient(RabbitMQURL(config), policy)
+}
+
+// Giá trị của EVENT_BUS
+const (
+	EventBusRabbitMQ  = "rabbitmq"
+	EventBusInProcess = "inprocess"
+)
+
+// NewEventBus tạo bus sự kiện nghiệp vụ theo EVENT_BUS. Bus trong process chỉ phát tới handler của chính
+// process nên chỉ dùng khi chạy một service duy nhất.
+func NewEventBus(config *Config, broker *messaging.Client) events.EventBus {
+	if config.EventBus == EventBusInProcess {
+		return events.NewInProcessBus()
+	}
+	return events.NewRabbitMQBus(broker)
+}
@@ -5,17 +5,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/url"
 
-	"github.com/rabbitmq/amqp091-go"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var (
-	DB           *gorm.DB
-	RedisClient  *redis.Client
-	RabbitMQConn *amqp091.Connection
+	DB          *gorm.DB
+	RedisClient *redis.Client
 )
 
 func ConnectDB(config *Config) *gorm.DB {
@@ -39,13 +38,37 @@ func ConnectDB(config *Config) *gorm.DB {
 		&models.Book{},
 		&models.Chapter{},
 		&models.Page{},
+		&models.PageRevision{},
 		&models.Shelve{},
 		&models.Tag{},
+		&models.Comment{},
 		&models.RefreshToken{},
+		&models.PasswordResetToken{},
 		&models.Permission{},
 		&models.RolePermission{},
+		&models.EntityPermission{},
 		&models.Order{},
 		&models.OrderDetail{},
+		&models.OrderStatusHistory{},
+		&models.DeliveryOffer{},
+		&models.ShipperServiceArea{},
+		&models.DeliveryProof{},
+		&models.Payment{},
+		&models.PaymentWebhookEvent{},
+		&models.Refund{},
+		&models.RefundLine{},
+		&models.CartItem{},
+		&models.ExchangeRate{},
+		&models.BookEntitlement{},
+		&models.Coupon{},
+		&models.CouponRedemption{},
+		&models.OrderDiscount{},
+		&models.BookStock{},
+		&models.StockMovement{},
+		&models.OutboxMessage{},
+		&models.ProcessedMessage{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
 	}
 	for _, model := range modelsToMigrate {
 		err := db.AutoMigrate(model)
@@ -58,18 +81,9 @@ func ConnectDB(config *Config) *gorm.DB {
 	return db
 }
 
-// ConnectRabbitMQ thiết lập kết nối RabbitMQ
-func ConnectRabbitMQ(config *Config) *amqp091.Connection {
-	// Chuỗi kết nối RabbitMQ
-	RabbitMQConnStr := fmt.Sprintf("amqp://%s:%s@%s:%s/", config.RabbitMqUser, config.RabbitMQPassword, config.RabbitMQHost, config.RabbitMQPort)
-	fmt.Println(RabbitMQConnStr)
-	// Kết nối RabbitMQ
-	conn, err := amqp091.Dial(RabbitMQConnStr)
-	if err != nil {
-		log.Fatalf("Failed to connect rabbitMQ: %v", err)
-	}
-	RabbitMQConn = conn
-	return conn
+// RabbitMQURL là chuỗi kết nối RabbitMQ
+func RabbitMQURL(config *Config) string {
+	return fmt.Sprintf("amqp://%s:%s@%s:%s/", url.QueryEscape(config.RabbitMqUser), url.QueryEscape(config.RabbitMQPassword), config.RabbitMQHost, config.RabbitMQPort)
 }
 
 // ConnectRedis thiết lập kết nối Redis
@@ -93,5 +107,4 @@ func ConnectRedis(config *Config) *redis.Client {
 func Connect(config *Config) {
 	ConnectDB(config)
 	ConnectRedis(config)
-	ConnectRabbitMQ(config)
 }
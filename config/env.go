@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -31,9 +33,28 @@ type Config struct {
 	RabbitMQPort     string
 	RabbitMqUser     string
 	RabbitMQPassword string
+	RabbitMQAttempts int           // Số lần xử lý một message trước khi vào dead-letter queue, 0 là dùng mặc định
+	RabbitMQDelay    time.Duration // Thời gian chờ trước lần thử lại đầu tiên, nhân đôi sau mỗi lần
 
-	PaypalClientID string
-	PaypalSecret   string
+	PaypalClientID  string
+	PaypalSecret    string
+	PaypalWebhookID string // ID webhook đăng ký trên PayPal, dùng để xác thực chữ ký
+
+	DownloadLinkSecret string        // Khóa ký link tải sách điện tử, mặc định dùng ACCESS_TOKEN_SECRET
+	DownloadLinkTTL    time.Duration // Thời hạn link tải sách điện tử, 0 là dùng mặc định
+
+	GazetteerFile string // File CSV danh mục địa danh để tra tọa độ địa chỉ, trống là dùng gazetteer đi kèm
+
+	DeliveryProofDir string // Thư mục lưu ảnh giao hàng và chữ ký người nhận
+
+	EventBus string // Bus sự kiện nghiệp vụ: "rabbitmq" (mặc định) hoặc "inprocess"
+
+	AppBaseURL               string        // Địa chỉ gốc của API dùng trong link gửi qua email
+	EmailVerificationSecret  string        // Khóa ký token xác nhận email, mặc định dùng ACCESS_TOKEN_SECRET
+	EmailVerificationTTL     time.Duration // Thời hạn token xác nhận email, 0 là dùng mặc định
+	RequireEmailVerification bool          // Chặn đặt hàng khi người dùng chưa xác nhận email
+	PasswordResetTTL         time.Duration // Thời hạn token đặt lại mật khẩu, 0 là dùng mặc định
+	PasswordResetURL         string        // Trang nhập mật khẩu mới trong email, mặc định là form GET /auth/password/reset
 }
 
 // Load Config tu file env
@@ -73,6 +94,87 @@ func LoadConfig() (*Config, error) {
 		return &Config{}, fmt.Errorf("invalid value for REFRESH_TOKEN_MAXAGE: %v", err)
 	}
 
+	// Link tải sách điện tử (không bắt buộc)
+	var downloadLinkTTL time.Duration
+	if ttl := os.Getenv("DOWNLOAD_LINK_TTL"); ttl != "" {
+		downloadLinkTTL, err = time.ParseDuration(ttl)
+		if err != nil {
+			return &Config{}, fmt.Errorf("invalid format for DOWNLOAD_LINK_TTL: %v", err)
+		}
+	}
+	downloadLinkSecret := os.Getenv("DOWNLOAD_LINK_SECRET")
+	if downloadLinkSecret == "" {
+		downloadLinkSecret = os.Getenv("ACCESS_TOKEN_SECRET")
+	}
+
+	// Thử lại message RabbitMQ (không bắt buộc)
+	var rabbitMQAttempts int
+	if attempts := os.Getenv("RABBITMQ_MAX_ATTEMPTS"); attempts != "" {
+		rabbitMQAttempts, err = strconv.Atoi(attempts)
+		if err != nil || rabbitMQAttempts < 1 {
+			return &Config{}, fmt.Errorf("invalid value for RABBITMQ_MAX_ATTEMPTS: %s", attempts)
+		}
+	}
+	var rabbitMQDelay time.Duration
+	if delay := os.Getenv("RABBITMQ_RETRY_DELAY"); delay != "" {
+		rabbitMQDelay, err = time.ParseDuration(delay)
+		if err != nil {
+			return &Config{}, fmt.Errorf("invalid format for RABBITMQ_RETRY_DELAY: %v", err)
+		}
+	}
+
+	eventBus := os.Getenv("EVENT_BUS")
+	switch eventBus {
+	case "":
+		eventBus = EventBusRabbitMQ
+	case EventBusRabbitMQ, EventBusInProcess:
+	default:
+		return &Config{}, fmt.Errorf("invalid value for EVENT_BUS: %s", eventBus)
+	}
+
+	// Xác nhận email (không bắt buộc)
+	appBaseURL := strings.TrimSuffix(os.Getenv("APP_BASE_URL"), "/")
+	if appBaseURL == "" {
+		appBaseURL = "http://localhost:8080"
+	}
+	emailVerificationSecret := os.Getenv("EMAIL_VERIFICATION_SECRET")
+	if emailVerificationSecret == "" {
+		emailVerificationSecret = os.Getenv("ACCESS_TOKEN_SECRET")
+	}
+	var emailVerificationTTL time.Duration
+	if ttl := os.Getenv("EMAIL_VERIFICATION_TTL"); ttl != "" {
+		emailVerificationTTL, err = time.ParseDuration(ttl)
+		if err != nil {
+			return &Config{}, fmt.Errorf("invalid format for EMAIL_VERIFICATION_TTL: %v", err)
+		}
+	}
+	var passwordResetTTL time.Duration
+	if ttl := os.Getenv("PASSWORD_RESET_TTL"); ttl != "" {
+		passwordResetTTL, err = time.ParseDuration(ttl)
+		if err != nil {
+			return &Config{}, fmt.Errorf("invalid format for PASSWORD_RESET_TTL: %v", err)
+		}
+	}
+	passwordResetURL := os.Getenv("PASSWORD_RESET_URL")
+	if passwordResetURL == "" {
+		passwordResetURL = appBaseURL + "/auth/password/reset"
+	}
+	if _, err := url.ParseRequestURI(passwordResetURL); err != nil {
+		return &Config{}, fmt.Errorf("invalid value for PASSWORD_RESET_URL: %v", err)
+	}
+	var requireEmailVerification bool
+	if require := os.Getenv("REQUIRE_EMAIL_VERIFICATION"); require != "" {
+		requireEmailVerification, err = strconv.ParseBool(require)
+		if err != nil {
+			return &Config{}, fmt.Errorf("invalid value for REQUIRE_EMAIL_VERIFICATION: %v", err)
+		}
+	}
+
+	deliveryProofDir := os.Getenv("DELIVERY_PROOF_DIR")
+	if deliveryProofDir == "" {
+		deliveryProofDir = "storage/delivery-proofs"
+	}
+
 	// Parse REDIS_DB
 	redisDB, err := strconv.Atoi(os.Getenv("REDIS_DB"))
 	if err != nil {
@@ -97,7 +199,22 @@ func LoadConfig() (*Config, error) {
 		RabbitMQPort:          os.Getenv("RABBITMQ_PORT"),
 		RabbitMqUser:          os.Getenv("RABBITMQ_USER"),
 		RabbitMQPassword:      os.Getenv("RABBITMQ_PASSWORD"),
+		RabbitMQAttempts:      rabbitMQAttempts,
+		RabbitMQDelay:         rabbitMQDelay,
 		PaypalClientID:        os.Getenv("PAYPAL_CLIENT_ID"),
 		PaypalSecret:          os.Getenv("PAYPAL_SECRET"),
+		PaypalWebhookID:       os.Getenv("PAYPAL_WEBHOOK_ID"),
+		DownloadLinkSecret:    downloadLinkSecret,
+		DownloadLinkTTL:       downloadLinkTTL,
+		GazetteerFile:         os.Getenv("GAZETTEER_FILE"),
+		DeliveryProofDir:      deliveryProofDir,
+		EventBus:              eventBus,
+
+		AppBaseURL:               appBaseURL,
+		EmailVerificationSecret:  emailVerificationSecret,
+		EmailVerificationTTL:     emailVerificationTTL,
+		RequireEmailVerification: requireEmailVerification,
+		PasswordResetTTL:         passwordResetTTL,
+		PasswordResetURL:         passwordResetURL,
 	}, nil
 }
@@ -0,0 +1,12 @@
+package constant
+
+import "time"
+
+const (
+	DefaultEmailVerificationTTL     = 24 * time.Hour // Thời hạn token xác nhận email khi không cấu hình
+	EmailVerificationResendInterval = time.Minute    // Khoảng cách tối thiểu giữa hai lần gửi email xác nhận cho một người dùng
+
+	DefaultPasswordResetTTL      = time.Hour   // Thời hạn token đặt lại mật khẩu khi không cấu hình
+	PasswordResetRequestInterval = time.Minute // Khoảng cách tối thiểu giữa hai lần gửi email đặt lại mật khẩu cho một người dùng
+	MinPasswordLength            = 8           // Độ dài tối thiểu của mật khẩu mới khi đặt lại hoặc đổi mật khẩu
+)
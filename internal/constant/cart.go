@@ -0,0 +1,9 @@
+package constant
+
+import "time"
+
+const (
+	CartTokenHeader = "X-Cart-Token"     // Header chứa mã giỏ hàng của khách chưa đăng nhập
+	GuestCartTTL    = 7 * 24 * time.Hour // Giỏ hàng của khách hết hạn sau 7 ngày không thay đổi
+	MaxCartQuantity = 99                 // Số lượng tối đa của một dòng trong giỏ
+)
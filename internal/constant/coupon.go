@@ -0,0 +1,21 @@
+package constant
+
+import "bookstack/internal/money"
+
+// Loại coupon / khuyến mãi
+const (
+	CouponPercentage   = "percentage"    // Giảm theo phần trăm
+	CouponFixedAmount  = "fixed"         // Giảm một số tiền cố định
+	CouponBuyXGetY     = "buy_x_get_y"   // Mua X tặng Y (tặng các cuốn rẻ nhất)
+	CouponFreeShipping = "free_shipping" // Miễn phí vận chuyển
+)
+
+// Phạm vi áp dụng của coupon
+const (
+	CouponScopeAll    = ""       // Toàn bộ đơn hàng
+	CouponScopeShelve = "shelve" // Sách thuộc một kệ
+	CouponScopeTag    = "tag"    // Sách có tag (name hoặc name:value)
+)
+
+// Phí vận chuyển tiêu chuẩn của một đơn hàng, theo tiền tệ gốc
+var StandardShippingFee = money.NewFromInt(3)
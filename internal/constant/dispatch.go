@@ -0,0 +1,38 @@
+package constant
+
+import "time"
+
+// Trạng thái điều phối shipper của đơn hàng
+const (
+	DispatchNone     = ""         // Chưa điều phối
+	DispatchOffering = "offering" // Đang chờ một shipper nhận lời mời
+	DispatchAssigned = "assigned" // Đã có shipper
+	DispatchManual   = "manual"   // Không shipper nào nhận, chờ nhân viên gán tay
+)
+
+// Trạng thái lời mời giao đơn gửi cho shipper
+const (
+	OfferPending   = "pending"
+	OfferAccepted  = "accepted"
+	OfferDeclined  = "declined"
+	OfferExpired   = "expired"   // Shipper không trả lời trước khi hết hạn
+	OfferCancelled = "cancelled" // Đơn đã có shipper khác hoặc không còn cần giao
+)
+
+const (
+	DeliveryOfferTimeout   = 2 * time.Minute  // Thời gian shipper có để nhận/từ chối một lời mời
+	DispatchSweepInterval  = 15 * time.Second // Chu kỳ quét lời mời hết hạn
+	MaxActiveOrdersShipper = 5                // Shipper đang giữ từ chừng này đơn trở lên không nhận thêm lời mời
+)
+
+// Kết quả một lần giao hàng được ghi trong bằng chứng giao hàng
+const (
+	DeliveryOutcomeDelivered = "delivered"
+	DeliveryOutcomeFailed    = "failed"
+)
+
+const (
+	DeliveryCodeLength      = 6               // Số chữ số của mã nhận hàng khách nhận khi đặt đơn
+	MaxDeliveryCodeAttempts = 5               // Nhập sai chừng này lần thì mã nhận hàng bị khóa
+	MaxDeliveryProofSize    = 5 * 1024 * 1024 // Dung lượng tối đa của ảnh giao hàng/chữ ký
+)
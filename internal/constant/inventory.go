@@ -0,0 +1,14 @@
+package constant
+
+// Các loại biến động trong sổ kho
+const (
+	StockReceipt     = "receipt"     // Nhập kho
+	StockReservation = "reservation" // Giữ hàng khi tạo đơn
+	StockRelease     = "release"     // Trả lại hàng đã giữ khi hủy đơn
+	StockSale        = "sale"        // Xuất kho khi đơn đã giao
+	StockAdjustment  = "adjustment"  // Điều chỉnh thủ công (kiểm kê, hư hỏng, ...)
+	StockReturn      = "return"      // Nhập lại sách khách trả khi hoàn tiền
+)
+
+// Ngưỡng cảnh báo sắp hết hàng mặc định cho sách mới
+const DefaultLowStockThreshold = 5
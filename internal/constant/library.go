@@ -0,0 +1,12 @@
+package constant
+
+import "time"
+
+// Loại dòng đơn hàng
+const (
+	OrderLinePhysical = "physical" // Sách in, giữ hàng trong kho và giao bởi shipper
+	OrderLineDigital  = "digital"  // Sách điện tử, thanh toán xong thì được đọc toàn bộ sách
+)
+
+// Link tải sách điện tử trong thư viện hết hạn sau khoảng thời gian này (mặc định khi không cấu hình)
+const DefaultDownloadLinkTTL = 15 * time.Minute
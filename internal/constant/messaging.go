@@ -0,0 +1,19 @@
+package constant
+
+import "time"
+
+// Thời gian chờ RabbitMQ xác nhận một message, để request và relay không bị treo khi broker không phản hồi
+const MessagePublishTimeout = 5 * time.Second
+
+const (
+	OutboxRelayInterval = 2 * time.Second    // Chu kỳ relay quét outbox tìm sự kiện chưa publish
+	OutboxBatchSize     = 100                // Số sự kiện tối đa mỗi lần relay
+	OutboxMaxBackoff    = 5 * time.Minute    // Thời gian chờ tối đa giữa các lần publish lại một sự kiện
+	OutboxPurgeInterval = time.Hour          // Chu kỳ dọn sự kiện đã publish và dấu message đã xử lý
+	OutboxRetention     = 7 * 24 * time.Hour // Thời gian giữ sự kiện đã publish và dấu message đã xử lý
+)
+
+// Tên consumer khi đánh dấu message đã xử lý, mỗi consumer loại trùng độc lập
+const (
+	ConsumerDispatch = "dispatch" // Mời shipper cho đơn mới ở service2
+)
@@ -0,0 +1,17 @@
+package constant
+
+import "time"
+
+// Loại sự kiện đẩy tới người dùng qua SSE/WebSocket
+const (
+	EventOrderStatusChanged = "order.status_changed" // Đơn chuyển trạng thái (gửi người đặt và shipper của đơn)
+	EventShipperAssigned    = "order.shipper_assigned"
+	EventDeliveryETA        = "order.delivery_eta" // Shipper cập nhật thời gian dự kiến giao tới
+	EventDeliveryOffer      = "delivery.offer"     // Lời mời giao đơn mới cho shipper
+)
+
+const (
+	NotificationChannel    = "bookstack:notifications" // Kênh Redis pub/sub chia sự kiện cho mọi instance
+	NotificationBufferSize = 16                        // Số sự kiện được đệm cho mỗi kết nối, kết nối chậm hơn bị bỏ bớt sự kiện
+	NotificationKeepAlive  = 25 * time.Second          // Chu kỳ gửi keep-alive để proxy không đóng kết nối
+)
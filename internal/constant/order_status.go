@@ -1,5 +1,7 @@
 package constant
 
+import "strings"
+
 type OrderStatus int
 
 const (
@@ -12,3 +14,39 @@ const (
 	Returned                      // 6 - Đã trả hàng
 	Failed                        // 7 - Thất bại
 )
+
+var orderStatusNames = map[OrderStatus]string{
+	Pending:    "Pending",
+	Confirmed:  "Confirmed",
+	Processing: "Processing",
+	Shipped:    "Shipped",
+	Delivered:  "Delivered",
+	Cancelled:  "Cancelled",
+	Returned:   "Returned",
+	Failed:     "Failed",
+}
+
+func (s OrderStatus) String() string {
+	if name, ok := orderStatusNames[s]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// ParseOrderStatus chuyển tên trạng thái (không phân biệt hoa thường) thành OrderStatus
+func ParseOrderStatus(name string) (OrderStatus, bool) {
+	for status, statusName := range orderStatusNames {
+		if strings.EqualFold(statusName, name) {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// Vai trò của người thực hiện chuyển trạng thái đơn hàng
+const (
+	OrderActorCustomer = "customer" // Người đặt đơn
+	OrderActorShipper  = "shipper"  // Shipper được giao đơn
+	OrderActorStaff    = "staff"    // Nhân viên có quyền manage:orders
+	OrderActorSystem   = "system"   // Hệ thống (webhook thanh toán, ...)
+)
@@ -0,0 +1,31 @@
+package constant
+
+// Trạng thái thanh toán của đơn hàng và của từng giao dịch, cập nhật từ cổng thanh toán
+const (
+	PaymentUnpaid            = ""                   // Chưa thanh toán
+	PaymentPending           = "pending"            // Đã tạo giao dịch, chờ người mua thanh toán
+	PaymentPaid              = "paid"               // Đã thu tiền
+	PaymentDenied            = "denied"             // Cổng thanh toán từ chối
+	PaymentRefunded          = "refunded"           // Đã hoàn toàn bộ tiền
+	PaymentPartiallyRefunded = "partially_refunded" // Đã hoàn một phần
+	PaymentCancelled         = "cancelled"          // Giao dịch bị hủy cùng đơn hàng
+)
+
+// Trạng thái của một lần hoàn tiền. Lần hoàn tiền được giữ chỗ (pending) trước khi gọi cổng thanh toán để
+// các lần hoàn tiền đồng thời không vượt quá số tiền đã thu.
+const (
+	RefundPending   = "pending"   // Đã giữ chỗ, đang gọi cổng thanh toán
+	RefundCompleted = "completed" // Cổng thanh toán đã hoàn tiền và đã được ghi nhận
+	RefundFailed    = "failed"    // Cổng thanh toán từ chối, phần giữ chỗ được trả lại
+)
+
+// Tên các cổng thanh toán
+const (
+	PaymentProviderPaypal         = "paypal"
+	PaymentProviderCashOnDelivery = "cod" // Thu tiền khi shipper giao hàng thành công
+	PaymentProviderFake           = "fake"
+)
+
+// BaseCurrency là tiền tệ gốc của giá sách, coupon và phí vận chuyển;
+// tỉ giá của các tiền tệ khác được tính theo một đơn vị tiền tệ này
+const BaseCurrency = "USD"
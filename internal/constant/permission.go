@@ -13,6 +13,51 @@ const (
 	ManageRoles = "manage:roles"
 )
 
+// Book Permissions
+const (
+	ManageEntityPermissions = "manage:entity:permissions"
+	ModerateComments        = "moderate:comments"
+)
+
+// Entity types có thể gán quyền riêng (shelve chỉ dùng cho bình luận)
+const (
+	EntityTypeBook    = "book"
+	EntityTypeChapter = "chapter"
+	EntityTypePage    = "page"
+	EntityTypeShelve  = "shelve"
+)
+
+// Entity actions, dùng khi kiểm tra quyền trên sách/chương/trang bị giới hạn
+const (
+	EntityView   = "view"
+	EntityCreate = "create"
+	EntityUpdate = "update"
+	EntityDelete = "delete"
+)
+
+// Inventory Permissions
+const (
+	ManageInventory = "manage:inventory"
+)
+
+// Order Permissions
+const (
+	ManageOrders  = "manage:orders"
+	ManageCoupons = "manage:coupons"
+)
+
+// Payment Permissions
+const (
+	ManagePayments   = "manage:payments"
+	ManageCurrencies = "manage:currencies" // Quản lý bảng tỉ giá
+)
+
+// Messaging Permissions
+const (
+	ManageMessaging = "manage:messaging" // Xem và gửi lại message trong dead-letter queue
+	ManageWebhooks  = "manage:webhooks"  // Quản lý webhook gửi sự kiện tới hệ thống đối tác
+)
+
 // Shipper Permissions
 const (
 	ReceiveOrder      = "receive:order"
@@ -0,0 +1,25 @@
+package constant
+
+import "time"
+
+// Trạng thái một lần giao webhook
+const (
+	WebhookDeliveryPending   = "pending"   // Chờ gửi hoặc chờ gửi lại
+	WebhookDeliverySucceeded = "succeeded" // Endpoint trả mã 2xx
+	WebhookDeliveryFailed    = "failed"    // Hết số lần thử
+)
+
+// WebhookTestEvent là loại sự kiện của lần giao thử do quản trị viên gửi
+const WebhookTestEvent = "webhook.test"
+
+const (
+	WebhookDeliveryInterval = 5 * time.Second     // Chu kỳ quét các lần giao tới hạn
+	WebhookBatchSize        = 50                  // Số lần giao tối đa mỗi lượt quét
+	WebhookTimeout          = 10 * time.Second    // Thời gian chờ endpoint phản hồi
+	WebhookMaxAttempts      = 8                   // Số lần gửi trước khi bỏ cuộc
+	WebhookBaseBackoff      = 30 * time.Second    // Thời gian chờ trước lần gửi lại đầu tiên, nhân đôi sau mỗi lần
+	WebhookMaxBackoff       = 6 * time.Hour       // Thời gian chờ tối đa giữa hai lần gửi
+	WebhookPurgeInterval    = time.Hour           // Chu kỳ dọn nhật ký giao
+	WebhookRetention        = 30 * 24 * time.Hour // Thời gian giữ nhật ký giao đã xong
+	WebhookSignatureMaxAge  = 5 * time.Minute     // Độ lệch timestamp tối đa bên nhận nên chấp nhận
+)
@@ -2,12 +2,17 @@ package controller
 
 import (
 	"bookstack/config"
+	"bookstack/internal/constant"
 	"bookstack/internal/dto/request"
 	"bookstack/internal/dto/response"
 	"bookstack/internal/service"
 	"bookstack/utils"
+	"bytes"
+	"errors"
+	"html/template"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
@@ -15,11 +20,15 @@ import (
 
 type AuthenticationController struct {
 	AuthenticationService service.AuthService
+	CartService           service.CartService
+	UserService           service.UserService
 }
 
-func NewAuthenticationController(authenticationService service.AuthService) *AuthenticationController {
+func NewAuthenticationController(authenticationService service.AuthService, cartService service.CartService, userService service.UserService) *AuthenticationController {
 	return &AuthenticationController{
 		AuthenticationService: authenticationService,
+		CartService:           cartService,
+		UserService:           userService,
 	}
 }
 
@@ -71,7 +80,7 @@ func (controller *AuthenticationController) Register(c *gin.Context) {
 		return
 	}
 	// Gửi email xác nhận
-	err = utils.SendVerificationEmail(user.Email, user.FullName)
+	err = controller.AuthenticationService.SendVerificationEmail(user.ID)
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -141,6 +150,12 @@ func (controller *AuthenticationController) Login(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, webResponse)
 		return
 	}
+	// Gộp giỏ hàng đã tạo khi chưa đăng nhập vào giỏ của người dùng
+	if cartToken := c.Request.Header.Get(constant.CartTokenHeader); cartToken != "" {
+		if err := controller.CartService.MergeGuestCart(cartToken, userId); err != nil {
+			log.Printf("Failed to merge guest cart: %v", err)
+		}
+	}
 	webResponse = response.WebResponse{
 		Code:    http.StatusOK,
 		Status:  "success",
@@ -256,3 +271,322 @@ func (controller *AuthenticationController) RefreshToken(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, response.WebResponse{Code: http.StatusOK, Status: "ok", Message: "Refresh token success", Data: response.LoginResponse{TokenType: "Bearer Token", RefreshToken: newRefreshToken, AccessToken: newAccessToken}})
 }
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirms the user's email with the signed token from the verification email. Tokens expire and stop working when the user changes their email
+// @Tags Authentication
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /auth/verify [get]
+func (controller *AuthenticationController) VerifyEmail(c *gin.Context) {
+	var webResponse response.WebResponse
+	user, err := controller.AuthenticationService.VerifyEmail(c.Query("token"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidVerificationToken) || errors.Is(err, service.ErrVerificationTokenExpired) {
+			code = http.StatusBadRequest
+		}
+		webResponse = response.WebResponse{
+			Code:    code,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(code, webResponse)
+		return
+	}
+	var userResponse response.UserRegisterResponse
+	if err := copier.Copy(&userResponse, &user); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Email verified successfully",
+		Data:    userResponse,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// ResendVerificationEmail godoc
+// @Summary Resend verification email
+// @Description Sends a new verification link to the current user. Limited to one email per minute
+// @Tags Authentication
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} response.WebResponse
+// @Failure 401 {object} response.WebResponse
+// @Failure 409 {object} response.WebResponse
+// @Failure 429 {object} response.WebResponse
+// @Router /auth/verify/resend [post]
+func (controller *AuthenticationController) ResendVerificationEmail(c *gin.Context) {
+	var webResponse response.WebResponse
+	userId, err := controller.UserService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Unauthorized",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	err = controller.AuthenticationService.SendVerificationEmail(userId)
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrEmailAlreadyVerified):
+			code = http.StatusConflict
+		case errors.Is(err, service.ErrVerificationRateLimited):
+			code = http.StatusTooManyRequests
+			c.Header("Retry-After", strconv.Itoa(int(constant.EmailVerificationResendInterval.Seconds())))
+		}
+		webResponse = response.WebResponse{
+			Code:    code,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(code, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Verification email sent, please check your inbox",
+		Data:    nil,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Emails a single-use, expiring password reset link. Always succeeds so the response does not reveal which emails are registered
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body request.ForgotPasswordRequest true "Account email"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /auth/password/forgot [post]
+func (controller *AuthenticationController) ForgotPassword(c *gin.Context) {
+	var forgotRequest request.ForgotPasswordRequest
+	var webResponse response.WebResponse
+	if err := c.ShouldBindJSON(&forgotRequest); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if err := controller.AuthenticationService.ForgotPassword(forgotRequest.Email); err != nil {
+		log.Printf("Failed to process password reset request: %v", err)
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Failed to process password reset request",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "If the email is registered, a password reset link has been sent",
+		Data:    nil,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// resetPasswordPage là form nhập mật khẩu mới mà link trong email đặt lại mật khẩu trỏ tới. Form gửi token và
+// mật khẩu mới dạng JSON tới POST /auth/password/reset.
+var resetPasswordPage = template.Must(template.New("reset_password").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Reset your password</title>
+</head>
+<body>
+<h1>Reset your password</h1>
+<form id="reset-form">
+<input type="hidden" name="token" value="{{.Token}}">
+<label>New password <input type="password" name="new_password" minlength="{{.MinLength}}" required autocomplete="new-password"></label>
+<button type="submit">Reset password</button>
+</form>
+<p id="result" role="status"></p>
+<script>
+document.getElementById("reset-form").addEventListener("submit", async function (event) {
+	event.preventDefault();
+	const result = document.getElementById("result");
+	try {
+		const res = await fetch("{{.Action}}", {
+			method: "POST",
+			headers: {"Content-Type": "application/json"},
+			body: JSON.stringify({token: this.token.value, new_password: this.new_password.value}),
+		});
+		const body = await res.json();
+		result.textContent = body.message;
+		if (res.ok) {
+			this.remove();
+		}
+	} catch (err) {
+		result.textContent = "Failed to reset password, please try again";
+	}
+});
+</script>
+</body>
+</html>
+`))
+
+// ResetPasswordForm godoc
+// @Summary Password reset form
+// @Description Serves the page the password reset email links to. The page submits the token and new password to POST /auth/password/reset
+// @Tags Authentication
+// @Produce html
+// @Param token query string true "Reset token"
+// @Success 200 {string} string "HTML form"
+// @Router /auth/password/reset [get]
+func (controller *AuthenticationController) ResetPasswordForm(c *gin.Context) {
+	// Token nằm trong URL nên không gửi kèm Referer và không cho cache trang
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Cache-Control", "no-store")
+	var page bytes.Buffer
+	err := resetPasswordPage.Execute(&page, gin.H{
+		"Token":     c.Query("token"),
+		"MinLength": constant.MinPasswordLength,
+		"Action":    c.Request.URL.Path,
+	})
+	if err != nil {
+		log.Printf("Failed to render password reset form: %v", err)
+		c.String(http.StatusInternalServerError, "Failed to render password reset form")
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Sets a new password using the token from the password reset email. The token works once and all refresh tokens of the user are revoked
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body request.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /auth/password/reset [post]
+func (controller *AuthenticationController) ResetPassword(c *gin.Context) {
+	var resetRequest request.ResetPasswordRequest
+	var webResponse response.WebResponse
+	if err := c.ShouldBindJSON(&resetRequest); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	err := controller.AuthenticationService.ResetPassword(resetRequest.Token, resetRequest.NewPassword)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidResetToken) || errors.Is(err, service.ErrWeakPassword) {
+			code = http.StatusBadRequest
+		}
+		webResponse = response.WebResponse{
+			Code:    code,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(code, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Password has been reset, please log in again",
+		Data:    nil,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Changes the current user's password after checking the current one. All refresh tokens of the user are revoked
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body request.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 401 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Router /auth/password/change [post]
+func (controller *AuthenticationController) ChangePassword(c *gin.Context) {
+	var changeRequest request.ChangePasswordRequest
+	var webResponse response.WebResponse
+	userId, err := controller.UserService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Unauthorized",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	if err := c.ShouldBindJSON(&changeRequest); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	err = controller.AuthenticationService.ChangePassword(userId, changeRequest.CurrentPassword, changeRequest.NewPassword)
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrIncorrectPassword):
+			code = http.StatusForbidden
+		case errors.Is(err, service.ErrWeakPassword):
+			code = http.StatusBadRequest
+		}
+		webResponse = response.WebResponse{
+			Code:    code,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(code, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Password changed, please log in again",
+		Data:    nil,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
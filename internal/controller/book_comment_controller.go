@@ -0,0 +1,291 @@
+package controller
+
+import (
+	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/models"
+	"bookstack/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetComments godoc
+// @Summary List threaded comments of a book or shelve
+// @Description Comments are nested by parent; hidden comments are only shown to moderators
+// @Tags Comment
+// @Produce json
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /book/{bookId}/comments [get]
+// @Router /book/shelve/{shelveId}/comments [get]
+func (controller *BookController) GetComments(entityType string, idParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var webResponse response.WebResponse
+		entityId, err := strconv.ParseUint(c.Param(idParam), 10, 32)
+		if err != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: "cant get " + idParam,
+				Data:    nil,
+			}
+			c.JSON(http.StatusBadRequest, webResponse)
+			return
+		}
+		comments, err := controller.bookSerivce.GetComments(entityType, uint(entityId), controller.currentUserId(c))
+		if controller.accessDenied(c, err) {
+			return
+		}
+		if err != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusNotFound,
+				Status:  "error",
+				Message: err.Error(),
+				Data:    nil,
+			}
+			c.JSON(http.StatusNotFound, webResponse)
+			return
+		}
+		webResponse = response.WebResponse{
+			Code:    http.StatusOK,
+			Status:  "success",
+			Message: "Comments",
+			Data:    comments,
+		}
+		c.JSON(http.StatusOK, webResponse)
+	}
+}
+
+// CreateComment godoc
+// @Summary Comment on or rate a book or shelve
+// @Description Post a comment, a reply (parent_id) or a 1-5 rating; each user can rate an item only once
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param comment body request.CommentRequest true "Comment request body"
+// @Success 201 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Failure 409 {object} response.WebResponse
+// @Router /book/{bookId}/comments [post]
+// @Router /book/shelve/{shelveId}/comments [post]
+func (controller *BookController) CreateComment(entityType string, idParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var webResponse response.WebResponse
+		var request request.CommentRequest
+		entityId, err := strconv.ParseUint(c.Param(idParam), 10, 32)
+		if err != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: "cant get " + idParam,
+				Data:    nil,
+			}
+			c.JSON(http.StatusBadRequest, webResponse)
+			return
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: "invalid request",
+				Data:    err.Error(),
+			}
+			c.JSON(http.StatusBadRequest, webResponse)
+			return
+		}
+		comment, err := controller.bookSerivce.CreateComment(entityType, uint(entityId), controller.currentUserId(c), request)
+		if controller.commentError(c, err) {
+			return
+		}
+		webResponse = response.WebResponse{
+			Code:    http.StatusCreated,
+			Status:  "success",
+			Message: "Comment created successfully",
+			Data:    controller.CoppyToCommentResponse(comment),
+		}
+		c.JSON(http.StatusCreated, webResponse)
+	}
+}
+
+// UpdateComment godoc
+// @Summary Edit a comment
+// @Description Only the author can edit the text or rating of a comment
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param commentId path int true "Comment ID"
+// @Param comment body request.CommentRequest true "Comment request body"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Failure 409 {object} response.WebResponse
+// @Router /book/comments/{commentId} [put]
+func (controller *BookController) UpdateComment(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.CommentRequest
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get commentId",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request",
+			Data:    err.Error(),
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	comment, err := controller.bookSerivce.UpdateComment(commentId, controller.currentUserId(c), request)
+	if controller.commentError(c, err) {
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Comment updated successfully",
+		Data:    controller.CoppyToCommentResponse(comment),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// DeleteComment godoc
+// @Summary Delete a comment
+// @Description The author or a moderator can delete a comment together with its replies
+// @Tags Comment
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param commentId path int true "Comment ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Router /book/comments/{commentId} [delete]
+func (controller *BookController) DeleteComment(c *gin.Context) {
+	var webResponse response.WebResponse
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get commentId",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	err = controller.bookSerivce.DeleteComment(commentId, controller.currentUserId(c))
+	if controller.commentError(c, err) {
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Comment deleted successfully",
+		Data:    nil,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// SetCommentVisibility godoc
+// @Summary Hide or unhide a comment
+// @Description Moderators can hide a comment; hidden ratings are excluded from the book rating
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param commentId path int true "Comment ID"
+// @Param visibility body request.CommentVisibilityRequest true "Visibility request body"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Router /book/comments/{commentId}/visibility [put]
+func (controller *BookController) SetCommentVisibility(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.CommentVisibilityRequest
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get commentId",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request",
+			Data:    err.Error(),
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	comment, err := controller.bookSerivce.SetCommentHidden(commentId, controller.currentUserId(c), request.Hidden)
+	if controller.commentError(c, err) {
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Comment visibility updated",
+		Data:    controller.CoppyToCommentResponse(comment),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// commentError ghi lỗi của các thao tác bình luận ra response, trả về true nếu có lỗi
+func (controller *BookController) commentError(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	if controller.accessDenied(c, err) {
+		return true
+	}
+	status := http.StatusBadRequest
+	if errors.Is(err, service.ErrDuplicateRating) {
+		status = http.StatusConflict
+	}
+	webResponse := response.WebResponse{
+		Code:    status,
+		Status:  "error",
+		Message: err.Error(),
+		Data:    nil,
+	}
+	c.JSON(status, webResponse)
+	return true
+}
+
+func (controller *BookController) CoppyToCommentResponse(comment models.Comment) response.CommentResponse {
+	return response.CommentResponse{
+		ID:         comment.ID,
+		EntityType: comment.EntityType,
+		EntityID:   comment.EntityID,
+		ParentID:   comment.ParentID,
+		Text:       comment.Text,
+		Rating:     comment.Rating,
+		CreatedBy:  comment.CreatedBy,
+		Hidden:     comment.Hidden,
+		CreatedAt:  comment.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:  comment.UpdatedAt.Format("2006-01-02 15:04:05"),
+		Replies:    []response.CommentResponse{},
+	}
+}
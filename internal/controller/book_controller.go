@@ -1,10 +1,16 @@
 package controller
 
 import (
+	"bookstack/internal/constant"
 	"bookstack/internal/dto/request"
 	"bookstack/internal/dto/response"
+	"bookstack/internal/exporter"
+	"bookstack/internal/importer"
 	"bookstack/internal/models"
 	"bookstack/internal/service"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
 
@@ -12,6 +18,9 @@ import (
 	"github.com/jinzhu/copier"
 )
 
+// Giới hạn kích thước file nhập sách (50MB)
+const maxImportFileSize = 50 << 20
+
 type BookController struct {
 	bookSerivce service.BookService
 	userService service.UserService
@@ -210,7 +219,10 @@ func (controller *BookController) CreateBook(c *gin.Context) {
 // @Router /book [get]
 func (controller *BookController) GetBooks(c *gin.Context) {
 	var webResponse response.WebResponse
-	books, err := controller.bookSerivce.GetAllBook()
+	books, err := controller.bookSerivce.GetAllBook(controller.currentUserId(c))
+	if controller.accessDenied(c, err) {
+		return
+	}
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -221,6 +233,21 @@ func (controller *BookController) GetBooks(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, webResponse)
 		return
 	}
+	var bookIds []uint
+	for _, book := range books {
+		bookIds = append(bookIds, book.ID)
+	}
+	ratings, err := controller.bookSerivce.GetRatingSummaries(constant.EntityTypeBook, bookIds)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "cant get ratings",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
 	var booksResponse []response.BookResponse
 	// Copy dữ liệu từng cuốn sách vào response
 	for _, book := range books {
@@ -240,6 +267,7 @@ func (controller *BookController) GetBooks(c *gin.Context) {
 		}
 		bookResponse.CreatedBy = user.FullName
 		bookResponse.Shelve = book.Shelve.Name
+		bookResponse.Rating = ratings[book.ID]
 		booksResponse = append(booksResponse, bookResponse)
 	}
 
@@ -285,7 +313,10 @@ func (controller *BookController) CreateChapter(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, webResponse)
 		return
 	}
-	chapter, err := controller.bookSerivce.CreateChapter(bookId, request)
+	chapter, err := controller.bookSerivce.CreateChapter(bookId, controller.currentUserId(c), request)
+	if controller.accessDenied(c, err) {
+		return
+	}
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -332,7 +363,10 @@ func (controller *BookController) GetChapters(c *gin.Context) {
 	}
 
 	// Gọi service để lấy chapters của book
-	chapters, err := controller.bookSerivce.GetChaptersOfBook(bookId)
+	chapters, err := controller.bookSerivce.GetChaptersOfBook(bookId, controller.currentUserId(c))
+	if controller.accessDenied(c, err) {
+		return
+	}
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -388,7 +422,10 @@ func (controller *BookController) AddPage(c *gin.Context) {
 	}
 	chapterId := uint(chapterId64) // Ép kiểu thành uint
 
-	page, err := controller.bookSerivce.AddPage(chapterId, request)
+	page, err := controller.bookSerivce.AddPage(chapterId, controller.currentUserId(c), request)
+	if controller.accessDenied(c, err) {
+		return
+	}
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -436,7 +473,10 @@ func (controller *BookController) GetPages(c *gin.Context) {
 		return
 	}
 
-	pages, err = controller.bookSerivce.GetPageChapter(ChapterId)
+	pages, err = controller.bookSerivce.GetPageChapter(ChapterId, controller.currentUserId(c))
+	if controller.accessDenied(c, err) {
+		return
+	}
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -653,7 +693,10 @@ func (controller *BookController) DeleteBook(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, webResponse)
 		return
 	}
-	err = controller.bookSerivce.DeleteBook(bookId)
+	err = controller.bookSerivce.DeleteBook(bookId, controller.currentUserId(c))
+	if controller.accessDenied(c, err) {
+		return
+	}
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -713,7 +756,10 @@ func (controller *BookController) UpdateBook(c *gin.Context) {
 		return
 	}
 
-	book, err := controller.bookSerivce.UpdateBook(bookId, request)
+	book, err := controller.bookSerivce.UpdateBook(bookId, controller.currentUserId(c), request)
+	if controller.accessDenied(c, err) {
+		return
+	}
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -833,7 +879,10 @@ func (controller *BookController) DeleteChapter(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, webResponse)
 		return
 	}
-	err = controller.bookSerivce.DeleteChapter(chapterId)
+	err = controller.bookSerivce.DeleteChapter(chapterId, controller.currentUserId(c))
+	if controller.accessDenied(c, err) {
+		return
+	}
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -877,7 +926,10 @@ func (controller *BookController) DeletePage(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, webResponse)
 		return
 	}
-	err = controller.bookSerivce.DeletePage(pageId)
+	err = controller.bookSerivce.DeletePage(pageId, controller.currentUserId(c))
+	if controller.accessDenied(c, err) {
+		return
+	}
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -925,7 +977,10 @@ func (controller *BookController) UpdateChapter(c *gin.Context) {
 		return
 	}
 
-	chapter, err := controller.bookSerivce.UpdateChapter(chapterId, request)
+	chapter, err := controller.bookSerivce.UpdateChapter(chapterId, controller.currentUserId(c), request)
+	if controller.accessDenied(c, err) {
+		return
+	}
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -947,10 +1002,11 @@ func (controller *BookController) UpdateChapter(c *gin.Context) {
 
 // UpdatePage godoc
 // @Summary Update a page
-// @Description Update a page by ID
+// @Description Update a page by ID, the previous content is kept as a revision
 // @Tags Page
 // @Accept json
 // @Produce json
+// @Param Authorization header string true "Authorization token"
 // @Param pageId path int true "Page ID"
 // @Param page body request.PageRequest true "Page request body"
 // @Success 200 {object} response.WebResponse
@@ -973,7 +1029,34 @@ func (controller *BookController) UpdatePage(c *gin.Context) {
 		return
 	}
 
-	page, err := controller.bookSerivce.UpdatePage(pageId, request)
+	header := c.Request.Header.Get("Authorization")
+	userId, err := controller.userService.GetUserIdByToken(header)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request body",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+
+	page, err := controller.bookSerivce.UpdatePage(pageId, userId, request)
+	if controller.accessDenied(c, err) {
+		return
+	}
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -992,3 +1075,736 @@ func (controller *BookController) UpdatePage(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, webResponse)
 }
+
+// GetPageRevisions godoc
+// @Summary Get revisions of a page
+// @Description Retrieve the revision history of a page, newest first
+// @Tags Page
+// @Produce json
+// @Param chapterId path int true "Chapter ID"
+// @Param pageId path int true "Page ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /book/chapter/{chapterId}/page/{pageId}/revisions [get]
+func (controller *BookController) GetPageRevisions(c *gin.Context) {
+	var webResponse response.WebResponse
+	pageId, err := strconv.Atoi(c.Param("pageId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get pageId",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	revisions, err := controller.bookSerivce.GetPageRevisions(pageId, controller.currentUserId(c))
+	if controller.accessDenied(c, err) {
+		return
+	}
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "cant get revisions",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	var revisionResponses []response.PageRevisionResponse
+	for _, revision := range revisions {
+		revisionResponse := controller.CoppyToPageRevisionResponse(revision)
+		revisionResponse.Content = ""
+		revisionResponses = append(revisionResponses, revisionResponse)
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Revisions",
+		Data:    revisionResponses,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// GetPageRevision godoc
+// @Summary Get a page revision
+// @Description Retrieve the content of a page at a given revision number
+// @Tags Page
+// @Produce json
+// @Param chapterId path int true "Chapter ID"
+// @Param pageId path int true "Page ID"
+// @Param revision path int true "Revision number"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /book/chapter/{chapterId}/page/{pageId}/revisions/{revision} [get]
+func (controller *BookController) GetPageRevision(c *gin.Context) {
+	var webResponse response.WebResponse
+	pageId, errPage := strconv.Atoi(c.Param("pageId"))
+	revisionNumber, errRevision := strconv.Atoi(c.Param("revision"))
+	if errPage != nil || errRevision != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get pageId or revision",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	revision, err := controller.bookSerivce.GetPageRevision(pageId, revisionNumber, controller.currentUserId(c))
+	if controller.accessDenied(c, err) {
+		return
+	}
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusNotFound,
+			Status:  "error",
+			Message: "revision not found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusNotFound, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Revision",
+		Data:    controller.CoppyToPageRevisionResponse(revision),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// DiffPageRevisions godoc
+// @Summary Diff two page revisions
+// @Description Compare the content of two revisions of a page line by line
+// @Tags Page
+// @Produce json
+// @Param chapterId path int true "Chapter ID"
+// @Param pageId path int true "Page ID"
+// @Param from query int true "Base revision number"
+// @Param to query int true "Compared revision number"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /book/chapter/{chapterId}/page/{pageId}/revisions/diff [get]
+func (controller *BookController) DiffPageRevisions(c *gin.Context) {
+	var webResponse response.WebResponse
+	pageId, errPage := strconv.Atoi(c.Param("pageId"))
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errPage != nil || errFrom != nil || errTo != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "pageId, from and to must be integer",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	diff, err := controller.bookSerivce.DiffPageRevisions(pageId, from, to, controller.currentUserId(c))
+	if controller.accessDenied(c, err) {
+		return
+	}
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Revision diff",
+		Data:    diff,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// RestorePageRevision godoc
+// @Summary Restore a page revision
+// @Description Replace the page content with a previous revision, the current content is kept as a new revision
+// @Tags Page
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param chapterId path int true "Chapter ID"
+// @Param pageId path int true "Page ID"
+// @Param revision path int true "Revision number"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /book/chapter/{chapterId}/page/{pageId}/revisions/{revision}/restore [post]
+func (controller *BookController) RestorePageRevision(c *gin.Context) {
+	var webResponse response.WebResponse
+	header := c.Request.Header.Get("Authorization")
+	userId, err := controller.userService.GetUserIdByToken(header)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	pageId, errPage := strconv.Atoi(c.Param("pageId"))
+	revisionNumber, errRevision := strconv.Atoi(c.Param("revision"))
+	if errPage != nil || errRevision != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get pageId or revision",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	page, err := controller.bookSerivce.RestorePageRevision(pageId, revisionNumber, userId)
+	if controller.accessDenied(c, err) {
+		return
+	}
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "cant restore revision: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "revision restored",
+		Data:    page,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *BookController) CoppyToPageRevisionResponse(revision models.PageRevision) response.PageRevisionResponse {
+	return response.PageRevisionResponse{
+		ID:             revision.ID,
+		PageID:         revision.PageId,
+		RevisionNumber: revision.RevisionNumber,
+		Title:          revision.Title,
+		Content:        revision.Content,
+		Summary:        revision.Summary,
+		CreatedBy:      revision.CreatedBy,
+		CreatedAt:      revision.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// Search godoc
+// @Summary Full-text search
+// @Description Search books, chapters and pages. Tags can be filtered with "[name:value]" in the query, e.g. "[genre:scifi] warp drive"
+// @Tags Search
+// @Produce json
+// @Param q query string true "Search query"
+// @Param page query int false "Result page, starts at 1"
+// @Param page_size query int false "Results per page"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /search [get]
+func (controller *BookController) Search(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.SearchRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "query q is required",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	results, err := controller.bookSerivce.Search(request, controller.currentUserId(c))
+	if controller.accessDenied(c, err) {
+		return
+	}
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "search failed: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Search results",
+		Data:    results,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// currentUserId trả về ID người dùng nếu request có token hợp lệ, ngược lại trả về 0 (khách)
+func (controller *BookController) currentUserId(c *gin.Context) int {
+	header := c.Request.Header.Get("Authorization")
+	if header == "" {
+		return 0
+	}
+	userId, err := controller.userService.GetUserIdByToken(header)
+	if err != nil {
+		return 0
+	}
+	return userId
+}
+
+// accessDenied trả về 403 nếu lỗi là do không có quyền trên sách/chương/trang bị giới hạn
+func (controller *BookController) accessDenied(c *gin.Context, err error) bool {
+	if !errors.Is(err, service.ErrAccessDenied) {
+		return false
+	}
+	webResponse := response.WebResponse{
+		Code:    http.StatusForbidden,
+		Status:  "error",
+		Message: "you do not have permission to access this content",
+		Data:    nil,
+	}
+	c.JSON(http.StatusForbidden, webResponse)
+	return true
+}
+
+// GetEntityPermissions godoc
+// @Summary Get permissions of a restricted book, chapter or page
+// @Description List the roles granted access to a specific book, chapter or page
+// @Tags Permission
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Success 200 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /book/{bookId}/permissions [get]
+// @Router /book/{bookId}/chapter/{chapterId}/permissions [get]
+// @Router /book/chapter/{chapterId}/page/{pageId}/permissions [get]
+func (controller *BookController) GetEntityPermissions(entityType string, idParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var webResponse response.WebResponse
+		entityId, err := strconv.ParseUint(c.Param(idParam), 10, 32)
+		if err != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: "cant get " + idParam,
+				Data:    nil,
+			}
+			c.JSON(http.StatusBadRequest, webResponse)
+			return
+		}
+		permissions, err := controller.bookSerivce.GetEntityPermissions(entityType, uint(entityId))
+		if err != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusNotFound,
+				Status:  "error",
+				Message: err.Error(),
+				Data:    nil,
+			}
+			c.JSON(http.StatusNotFound, webResponse)
+			return
+		}
+		var permissionResponses []response.EntityPermissionResponse
+		for _, permission := range permissions {
+			permissionResponses = append(permissionResponses, controller.CoppyToEntityPermissionResponse(permission))
+		}
+		webResponse = response.WebResponse{
+			Code:    http.StatusOK,
+			Status:  "success",
+			Message: "Permissions",
+			Data:    permissionResponses,
+		}
+		c.JSON(http.StatusOK, webResponse)
+	}
+}
+
+// SetEntityPermission godoc
+// @Summary Grant a role access to a restricted book, chapter or page
+// @Description Create or replace the view/create/update/delete permission of a role on a specific entity
+// @Tags Permission
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param permission body request.EntityPermissionRequest true "Permission request body"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /book/{bookId}/permissions [put]
+// @Router /book/{bookId}/chapter/{chapterId}/permissions [put]
+// @Router /book/chapter/{chapterId}/page/{pageId}/permissions [put]
+func (controller *BookController) SetEntityPermission(entityType string, idParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var webResponse response.WebResponse
+		var request request.EntityPermissionRequest
+		entityId, err := strconv.ParseUint(c.Param(idParam), 10, 32)
+		if err != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: "cant get " + idParam,
+				Data:    nil,
+			}
+			c.JSON(http.StatusBadRequest, webResponse)
+			return
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: "invalid request",
+				Data:    nil,
+			}
+			c.JSON(http.StatusBadRequest, webResponse)
+			return
+		}
+		permission, err := controller.bookSerivce.SetEntityPermission(entityType, uint(entityId), request)
+		if err != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: err.Error(),
+				Data:    nil,
+			}
+			c.JSON(http.StatusBadRequest, webResponse)
+			return
+		}
+		webResponse = response.WebResponse{
+			Code:    http.StatusOK,
+			Status:  "success",
+			Message: "permission saved",
+			Data:    controller.CoppyToEntityPermissionResponse(permission),
+		}
+		c.JSON(http.StatusOK, webResponse)
+	}
+}
+
+// RevokeEntityPermission godoc
+// @Summary Revoke a role's access to a restricted book, chapter or page
+// @Description Remove the entity permission of a role
+// @Tags Permission
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param roleId path int true "Role ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /book/{bookId}/permissions/{roleId} [delete]
+// @Router /book/{bookId}/chapter/{chapterId}/permissions/{roleId} [delete]
+// @Router /book/chapter/{chapterId}/page/{pageId}/permissions/{roleId} [delete]
+func (controller *BookController) RevokeEntityPermission(entityType string, idParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var webResponse response.WebResponse
+		entityId, errEntity := strconv.ParseUint(c.Param(idParam), 10, 32)
+		roleId, errRole := strconv.ParseUint(c.Param("roleId"), 10, 32)
+		if errEntity != nil || errRole != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: "cant get " + idParam + " or roleId",
+				Data:    nil,
+			}
+			c.JSON(http.StatusBadRequest, webResponse)
+			return
+		}
+		err := controller.bookSerivce.RevokeEntityPermission(entityType, uint(entityId), uint(roleId))
+		if err != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: err.Error(),
+				Data:    nil,
+			}
+			c.JSON(http.StatusBadRequest, webResponse)
+			return
+		}
+		webResponse = response.WebResponse{
+			Code:    http.StatusOK,
+			Status:  "success",
+			Message: "permission revoked",
+			Data:    nil,
+		}
+		c.JSON(http.StatusOK, webResponse)
+	}
+}
+
+func (controller *BookController) CoppyToEntityPermissionResponse(permission models.EntityPermission) response.EntityPermissionResponse {
+	return response.EntityPermissionResponse{
+		EntityType: permission.EntityType,
+		EntityID:   permission.EntityID,
+		RoleID:     permission.RoleID,
+		Role:       permission.Role.Name,
+		View:       permission.CanView,
+		Create:     permission.CanCreate,
+		Update:     permission.CanUpdate,
+		Delete:     permission.CanDelete,
+	}
+}
+
+// ExportBook godoc
+// @Summary Export a book
+// @Description Download a book with its chapters and pages as EPUB, standalone HTML, a zip of markdown files or plain text
+// @Tags Export
+// @Produce octet-stream
+// @Param bookId path int true "Book ID"
+// @Param format path string true "Export format" Enums(epub, html, markdown, txt)
+// @Success 200 {file} file
+// @Failure 400 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /book/{bookId}/export/{format} [get]
+func (controller *BookController) ExportBook(c *gin.Context) {
+	bookId, err := strconv.Atoi(c.Param("bookId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get bookId",
+			Data:    nil,
+		})
+		return
+	}
+	file, err := controller.bookSerivce.ExportBook(bookId, controller.currentUserId(c), c.Param("format"))
+	controller.writeExport(c, file, err)
+}
+
+// ExportChapter godoc
+// @Summary Export a chapter
+// @Description Download a chapter with its pages as EPUB, standalone HTML, a zip of markdown files or plain text
+// @Tags Export
+// @Produce octet-stream
+// @Param bookId path int true "Book ID"
+// @Param chapterId path int true "Chapter ID"
+// @Param format path string true "Export format" Enums(epub, html, markdown, txt)
+// @Success 200 {file} file
+// @Failure 400 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /book/{bookId}/chapter/{chapterId}/export/{format} [get]
+func (controller *BookController) ExportChapter(c *gin.Context) {
+	chapterId, err := strconv.Atoi(c.Param("chapterId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get chapterId",
+			Data:    nil,
+		})
+		return
+	}
+	file, err := controller.bookSerivce.ExportChapter(chapterId, controller.currentUserId(c), c.Param("format"))
+	controller.writeExport(c, file, err)
+}
+
+// ExportPage godoc
+// @Summary Export a page
+// @Description Download a single page as EPUB, standalone HTML, a zip of markdown files or plain text
+// @Tags Export
+// @Produce octet-stream
+// @Param chapterId path int true "Chapter ID"
+// @Param pageId path int true "Page ID"
+// @Param format path string true "Export format" Enums(epub, html, markdown, txt)
+// @Success 200 {file} file
+// @Failure 400 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /book/chapter/{chapterId}/page/{pageId}/export/{format} [get]
+func (controller *BookController) ExportPage(c *gin.Context) {
+	pageId, err := strconv.Atoi(c.Param("pageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get pageId",
+			Data:    nil,
+		})
+		return
+	}
+	file, err := controller.bookSerivce.ExportPage(pageId, controller.currentUserId(c), c.Param("format"))
+	controller.writeExport(c, file, err)
+}
+
+// writeExport trả file xuất về dưới dạng tải xuống
+func (controller *BookController) writeExport(c *gin.Context, file exporter.File, err error) {
+	if controller.accessDenied(c, err) {
+		return
+	}
+	if errors.Is(err, exporter.ErrUnknownFormat) {
+		c.JSON(http.StatusBadRequest, response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "format must be one of epub, html, markdown, txt",
+			Data:    nil,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "cant export: " + err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+// ImportBook godoc
+// @Summary Import a book
+// @Description Create a book with its chapters and pages from a zip of markdown files (directory = chapter, file = page), an EPUB or a single HTML file
+// @Tags Import
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param file formData file true "Markdown zip, EPUB or HTML file"
+// @Param shelve_id formData int true "Shelve ID"
+// @Param format formData string false "Import format, detected from the file name when empty" Enums(markdown, epub, html)
+// @Param title formData string false "Override the book title"
+// @Param restricted formData bool false "Restrict the imported book"
+// @Success 201 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /book/import [post]
+func (controller *BookController) ImportBook(c *gin.Context) {
+	var webResponse response.WebResponse
+	var importRequest request.ImportBookRequest
+
+	header := c.Request.Header.Get("Authorization")
+	userId, err := controller.userService.GetUserIdByToken(header)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	user, err := controller.userService.GetUserById(userId)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant find user",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if err := c.ShouldBind(&importRequest); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request",
+			Data:    err.Error(),
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "file is required",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "file is too large",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant read file",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxImportFileSize))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant read file",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+
+	book, warnings, err := controller.bookSerivce.ImportBook(userId, importRequest, fileHeader.Filename, data)
+	if errors.Is(err, importer.ErrUnknownFormat) {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "format must be one of markdown (.zip), epub, html",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant import book: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+
+	importResponse := response.ImportBookResponse{
+		Chapters: len(book.Chapters),
+		Warnings: []response.ImportWarningResponse{},
+	}
+	copier.Copy(&importResponse.Book, book)
+	importResponse.Book.CreatedBy = user.FullName
+	importResponse.Book.Shelve = book.Shelve.Name
+	var tag response.TagResponse
+	for _, t := range book.Tags {
+		copier.Copy(&tag, t)
+		importResponse.Book.Tags = append(importResponse.Book.Tags, tag)
+	}
+	for _, chapter := range book.Chapters {
+		importResponse.Pages += len(chapter.Pages)
+	}
+	for _, warning := range warnings {
+		importResponse.Warnings = append(importResponse.Warnings, response.ImportWarningResponse{
+			File:    warning.File,
+			Message: warning.Message,
+		})
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusCreated,
+		Status:  "success",
+		Message: "Book imported successfully",
+		Data:    importResponse,
+	}
+	c.JSON(http.StatusCreated, webResponse)
+}
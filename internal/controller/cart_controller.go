@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/repository"
+	"bookstack/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CartController struct {
+	service     service.CartService
+	userService service.UserService
+}
+
+func NewCartController(serv service.CartService, userService service.UserService) *CartController {
+	return &CartController{
+		service:     serv,
+		userService: userService,
+	}
+}
+
+// GetCart godoc
+// @Summary Get the current cart
+// @Description Get the cart of the logged-in user, or of a guest identified by the X-Cart-Token header. Prices are re-validated against the current book prices
+// @Tags Cart
+// @Produce json
+// @Param Authorization header string false "Authorization token"
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /cart [get]
+func (controller *CartController) GetCart(c *gin.Context) {
+	owner, ok := controller.cartOwner(c, false)
+	if !ok {
+		return
+	}
+	cart, err := controller.service.GetCart(owner)
+	controller.writeCart(c, owner, cart, err, "get cart successfully")
+}
+
+// AddCartItem godoc
+// @Summary Add a book to the cart
+// @Description Add a book to the cart. Guests without a cart token get a new one in the X-Cart-Token response header
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Authorization token"
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Param request body request.CartItemRequest true "Book and quantity"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /cart/items [post]
+func (controller *CartController) AddCartItem(c *gin.Context) {
+	var request request.CartItemRequest
+	owner, ok := controller.cartOwner(c, true)
+	if !ok {
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse := response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	cart, err := controller.service.AddItem(owner, request)
+	controller.writeCart(c, owner, cart, err, "item added to cart")
+}
+
+// UpdateCartItem godoc
+// @Summary Update the quantity of a cart line
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Authorization token"
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Param bookId path int true "Book ID"
+// @Param request body request.CartQuantityRequest true "New quantity (0 removes the line)"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /cart/items/{bookId} [put]
+func (controller *CartController) UpdateCartItem(c *gin.Context) {
+	var request request.CartQuantityRequest
+	owner, ok := controller.cartOwner(c, false)
+	if !ok {
+		return
+	}
+	bookId, ok := controller.bookIdParam(c)
+	if !ok {
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse := response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	cart, err := controller.service.UpdateItem(owner, bookId, request)
+	controller.writeCart(c, owner, cart, err, "cart updated")
+}
+
+// RemoveCartItem godoc
+// @Summary Remove a book from the cart
+// @Tags Cart
+// @Produce json
+// @Param Authorization header string false "Authorization token"
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Param bookId path int true "Book ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /cart/items/{bookId} [delete]
+func (controller *CartController) RemoveCartItem(c *gin.Context) {
+	owner, ok := controller.cartOwner(c, false)
+	if !ok {
+		return
+	}
+	bookId, ok := controller.bookIdParam(c)
+	if !ok {
+		return
+	}
+	cart, err := controller.service.RemoveItem(owner, bookId)
+	controller.writeCart(c, owner, cart, err, "item removed from cart")
+}
+
+// ClearCart godoc
+// @Summary Remove every line from the cart
+// @Tags Cart
+// @Produce json
+// @Param Authorization header string false "Authorization token"
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /cart [delete]
+func (controller *CartController) ClearCart(c *gin.Context) {
+	owner, ok := controller.cartOwner(c, false)
+	if !ok {
+		return
+	}
+	err := controller.service.Clear(owner)
+	controller.writeCart(c, owner, response.CartResponse{Items: []response.CartItemResponse{}}, err, "cart cleared")
+}
+
+// cartOwner xác định giỏ hàng của request: người dùng nếu có token hợp lệ, ngược lại là giỏ của khách.
+// create = true thì tạo mã giỏ mới cho khách chưa có.
+func (controller *CartController) cartOwner(c *gin.Context, create bool) (repository.CartOwner, bool) {
+	if header := c.Request.Header.Get("Authorization"); header != "" {
+		userId, err := controller.userService.GetUserIdByToken(header)
+		if err != nil {
+			webResponse := response.WebResponse{
+				Code:    http.StatusUnauthorized,
+				Status:  "error",
+				Message: "invalid token",
+				Data:    nil,
+			}
+			c.JSON(http.StatusUnauthorized, webResponse)
+			return repository.CartOwner{}, false
+		}
+		return repository.CartOwner{UserID: uint(userId)}, true
+	}
+
+	owner := repository.CartOwner{GuestToken: c.Request.Header.Get(constant.CartTokenHeader)}
+	if owner.GuestToken == "" && create {
+		token, err := controller.service.NewGuestToken()
+		if err != nil {
+			webResponse := response.WebResponse{
+				Code:    http.StatusInternalServerError,
+				Status:  "error",
+				Message: "Server error",
+				Data:    nil,
+			}
+			c.JSON(http.StatusInternalServerError, webResponse)
+			return repository.CartOwner{}, false
+		}
+		owner.GuestToken = token
+	}
+	return owner, true
+}
+
+func (controller *CartController) bookIdParam(c *gin.Context) (uint, bool) {
+	bookId, err := strconv.ParseUint(c.Param("bookId"), 10, 32)
+	if err != nil {
+		webResponse := response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get bookId",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return 0, false
+	}
+	return uint(bookId), true
+}
+
+func (controller *CartController) writeCart(c *gin.Context, owner repository.CartOwner, cart response.CartResponse, err error, message string) {
+	var webResponse response.WebResponse
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if owner.IsGuest() && owner.GuestToken != "" {
+		c.Header(constant.CartTokenHeader, owner.GuestToken)
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: message,
+		Data:    cart,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/models"
+	"bookstack/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CouponController struct {
+	service service.CouponService
+}
+
+func NewCouponController(serv service.CouponService) *CouponController {
+	return &CouponController{
+		service: serv,
+	}
+}
+
+// GetCoupons godoc
+// @Summary List coupons and promotions
+// @Tags Coupon
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Success 200 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /coupons [get]
+func (controller *CouponController) GetCoupons(c *gin.Context) {
+	var webResponse response.WebResponse
+	coupons, err := controller.service.GetCoupons()
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Server error",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	couponResponses := []response.CouponResponse{}
+	for _, coupon := range coupons {
+		couponResponses = append(couponResponses, CoppyToCouponResponse(coupon))
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get coupons successfully",
+		Data:    couponResponses,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// CreateCoupon godoc
+// @Summary Create a coupon or promotion
+// @Description Create a coupon (percentage, fixed amount, buy-X-get-Y or free shipping), optionally scoped to a shelve or tag. Promotions with auto_apply are applied without a code
+// @Tags Coupon
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param request body request.CouponRequest true "Coupon"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /coupons [post]
+func (controller *CouponController) CreateCoupon(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.CouponRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	coupon, err := controller.service.CreateCoupon(request)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "coupon created",
+		Data:    CoppyToCouponResponse(coupon),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// UpdateCoupon godoc
+// @Summary Update a coupon or promotion
+// @Tags Coupon
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param couponId path int true "Coupon ID"
+// @Param request body request.CouponRequest true "Coupon"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /coupons/{couponId} [put]
+func (controller *CouponController) UpdateCoupon(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.CouponRequest
+	couponId, err := strconv.ParseUint(c.Param("couponId"), 10, 32)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get couponId",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	coupon, err := controller.service.UpdateCoupon(uint(couponId), request)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "coupon updated",
+		Data:    CoppyToCouponResponse(coupon),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// DeleteCoupon godoc
+// @Summary Delete a coupon or promotion
+// @Tags Coupon
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param couponId path int true "Coupon ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /coupons/{couponId} [delete]
+func (controller *CouponController) DeleteCoupon(c *gin.Context) {
+	var webResponse response.WebResponse
+	couponId, err := strconv.ParseUint(c.Param("couponId"), 10, 32)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get couponId",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if err := controller.service.DeleteCoupon(uint(couponId)); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "coupon deleted",
+		Data:    nil,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+func CoppyToCouponResponse(coupon models.Coupon) response.CouponResponse {
+	couponResponse := response.CouponResponse{
+		ID:            coupon.ID,
+		Code:          coupon.Code,
+		Name:          coupon.Name,
+		Type:          coupon.Type,
+		Value:         coupon.Value,
+		BuyQuantity:   coupon.BuyQuantity,
+		GetQuantity:   coupon.GetQuantity,
+		ScopeType:     coupon.ScopeType,
+		ScopeShelveID: coupon.ScopeShelveID,
+		ScopeTagName:  coupon.ScopeTagName,
+		ScopeTagValue: coupon.ScopeTagValue,
+		MinSubtotal:   coupon.MinSubtotal,
+		MaxDiscount:   coupon.MaxDiscount,
+		UsageLimit:    coupon.UsageLimit,
+		PerUserLimit:  coupon.PerUserLimit,
+		AutoApply:     coupon.AutoApply,
+		Active:        coupon.Active,
+	}
+	if coupon.StartsAt != nil {
+		couponResponse.StartsAt = coupon.StartsAt.Format(time.RFC3339)
+	}
+	if coupon.EndsAt != nil {
+		couponResponse.EndsAt = coupon.EndsAt.Format(time.RFC3339)
+	}
+	return couponResponse
+}
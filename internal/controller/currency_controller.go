@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/models"
+	"bookstack/internal/money"
+	"bookstack/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CurrencyController struct {
+	service     service.CurrencyService
+	userService service.UserService
+}
+
+func NewCurrencyController(serv service.CurrencyService, userService service.UserService) *CurrencyController {
+	return &CurrencyController{
+		service:     serv,
+		userService: userService,
+	}
+}
+
+// GetCurrencies godoc
+// @Summary List payable currencies
+// @Description List the base currency of book prices and the exchange rates of the other currencies an order can be paid in
+// @Tags Currency
+// @Produce json
+// @Success 200 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /currencies [get]
+func (controller *CurrencyController) GetCurrencies(c *gin.Context) {
+	var webResponse response.WebResponse
+	rates, err := controller.service.GetRates()
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Server error",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	currencies := response.CurrenciesResponse{
+		BaseCurrency: constant.BaseCurrency,
+		Rates:        []response.ExchangeRateResponse{},
+	}
+	for _, rate := range rates {
+		currencies.Rates = append(currencies.Rates, CoppyToExchangeRateResponse(rate))
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get currencies successfully",
+		Data:    currencies,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// SetExchangeRate godoc
+// @Summary Set the exchange rate of a currency
+// @Description Create or update the rate of a currency against the base currency. Existing orders keep the rate locked at creation
+// @Tags Currency
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param currency path string true "ISO 4217 currency code, e.g. VND"
+// @Param request body request.ExchangeRateRequest true "Rate"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /currencies/{currency} [put]
+func (controller *CurrencyController) SetExchangeRate(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.ExchangeRateRequest
+
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	rate, err := controller.service.SetRate(c.Param("currency"), request, userId)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, service.ErrCurrencyNotSupported) {
+			code = http.StatusBadRequest
+		}
+		webResponse = response.WebResponse{
+			Code:    code,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(code, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "exchange rate updated",
+		Data:    CoppyToExchangeRateResponse(rate),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// DeleteExchangeRate godoc
+// @Summary Stop accepting a currency
+// @Tags Currency
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param currency path string true "ISO 4217 currency code"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /currencies/{currency} [delete]
+func (controller *CurrencyController) DeleteExchangeRate(c *gin.Context) {
+	var webResponse response.WebResponse
+	err := controller.service.DeleteRate(c.Param("currency"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrCurrencyNotSupported):
+			code = http.StatusBadRequest
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			code = http.StatusNotFound
+		}
+		webResponse = response.WebResponse{
+			Code:    code,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(code, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "exchange rate deleted",
+		Data:    nil,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+func CoppyToExchangeRateResponse(rate models.ExchangeRate) response.ExchangeRateResponse {
+	return response.ExchangeRateResponse{
+		Currency:   rate.Currency,
+		Rate:       rate.Rate,
+		MinorUnits: money.MinorUnits(rate.Currency),
+		UpdatedAt:  rate.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"bookstack/internal/dto/response"
+	"bookstack/internal/events"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventController công bố danh mục sự kiện nghiệp vụ và JSON Schema để các hệ thống khác đăng ký nhận
+type EventController struct{}
+
+func NewEventController() *EventController {
+	return &EventController{}
+}
+
+// GetEventSchemas godoc
+// @Summary List domain event schemas
+// @Description List every domain event published on the bookstack.events exchange (routing key = event type) with its JSON Schema
+// @Tags Event
+// @Produce json
+// @Success 200 {object} response.WebResponse
+// @Router /events/schemas [get]
+func (controller *EventController) GetEventSchemas(c *gin.Context) {
+	schemas := []response.EventSchemaResponse{}
+	for _, definition := range events.Catalogue {
+		schemas = append(schemas, CoppyToEventSchemaResponse(definition))
+	}
+	webResponse := response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get event schemas successfully",
+		Data:    schemas,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// GetEventSchema godoc
+// @Summary Get a domain event schema
+// @Description Get the JSON Schema of one domain event, e.g. order.paid
+// @Tags Event
+// @Produce json
+// @Param type path string true "Event type"
+// @Success 200 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /events/schemas/{type} [get]
+func (controller *EventController) GetEventSchema(c *gin.Context) {
+	definition, ok := events.Lookup(c.Param("type"))
+	if !ok {
+		c.JSON(http.StatusNotFound, response.WebResponse{
+			Code:    http.StatusNotFound,
+			Status:  "error",
+			Message: "unknown event type",
+			Data:    nil,
+		})
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get event schema successfully",
+		Data:    CoppyToEventSchemaResponse(definition),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+func CoppyToEventSchemaResponse(definition events.Definition) response.EventSchemaResponse {
+	return response.EventSchemaResponse{
+		Type:        definition.Event.EventType(),
+		Version:     definition.Event.EventVersion(),
+		Description: definition.Description,
+		Schema:      events.Schema(definition),
+	}
+}
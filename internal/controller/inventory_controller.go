@@ -0,0 +1,328 @@
+package controller
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/events"
+	"bookstack/internal/models"
+	"bookstack/internal/service"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type InventoryController struct {
+	service     service.InventoryService
+	userService service.UserService
+	bus         events.EventBus
+}
+
+func NewInventoryController(serv service.InventoryService, userService service.UserService, bus events.EventBus) *InventoryController {
+	return &InventoryController{
+		service:     serv,
+		userService: userService,
+		bus:         bus,
+	}
+}
+
+// GetStocks godoc
+// @Summary List book stock levels
+// @Description List on-hand, reserved and available quantity of every book
+// @Tags Inventory
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Success 200 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /inventory [get]
+func (controller *InventoryController) GetStocks(c *gin.Context) {
+	controller.listStocks(c, false)
+}
+
+// GetLowStocks godoc
+// @Summary List books running low on stock
+// @Description List books whose available quantity is at or below their low-stock threshold
+// @Tags Inventory
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Success 200 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /inventory/low-stock [get]
+func (controller *InventoryController) GetLowStocks(c *gin.Context) {
+	controller.listStocks(c, true)
+}
+
+func (controller *InventoryController) listStocks(c *gin.Context, lowOnly bool) {
+	var webResponse response.WebResponse
+	stocks, err := controller.service.GetStocks(lowOnly)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Server error",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	stockResponses := []response.StockResponse{}
+	for _, stock := range stocks {
+		stockResponses = append(stockResponses, CoppyToStockResponse(stock))
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get stock successfully",
+		Data:    stockResponses,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// GetStock godoc
+// @Summary Get stock level of a book
+// @Tags Inventory
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param bookId path int true "Book ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /inventory/{bookId} [get]
+func (controller *InventoryController) GetStock(c *gin.Context) {
+	var webResponse response.WebResponse
+	bookId, ok := controller.bookIdParam(c)
+	if !ok {
+		return
+	}
+	stock, err := controller.service.GetStock(bookId)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Server error",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get stock successfully",
+		Data:    CoppyToStockResponse(stock),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// AdjustStock godoc
+// @Summary Receive or adjust stock of a book
+// @Description Record a stock receipt (positive quantity) or a manual adjustment (positive or negative) in the stock ledger
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param bookId path int true "Book ID"
+// @Param request body request.StockAdjustmentRequest true "Stock adjustment"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 409 {object} response.WebResponse
+// @Router /inventory/{bookId}/adjust [post]
+func (controller *InventoryController) AdjustStock(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.StockAdjustmentRequest
+	bookId, ok := controller.bookIdParam(c)
+	if !ok {
+		return
+	}
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	stock, err := controller.service.AdjustStock(bookId, userId, request)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, service.ErrInsufficientStock) || errors.Is(err, service.ErrInvalidStockLevel) {
+			code = http.StatusConflict
+		}
+		webResponse = response.WebResponse{
+			Code:    code,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(code, webResponse)
+		return
+	}
+	if stock.IsLow() {
+		PublishLowStockAlerts(controller.bus, []models.BookStock{stock})
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "stock adjusted",
+		Data:    CoppyToStockResponse(stock),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// SetLowStockThreshold godoc
+// @Summary Set the low-stock threshold of a book
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param bookId path int true "Book ID"
+// @Param request body request.LowStockThresholdRequest true "Threshold"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /inventory/{bookId}/threshold [put]
+func (controller *InventoryController) SetLowStockThreshold(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.LowStockThresholdRequest
+	bookId, ok := controller.bookIdParam(c)
+	if !ok {
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	stock, err := controller.service.SetLowStockThreshold(bookId, request)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "threshold updated",
+		Data:    CoppyToStockResponse(stock),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// GetMovements godoc
+// @Summary Get the stock ledger of a book
+// @Description List the latest stock movements (receipts, reservations, releases, sales, adjustments) of a book
+// @Tags Inventory
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param bookId path int true "Book ID"
+// @Param limit query int false "Number of movements (default 50)"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /inventory/{bookId}/movements [get]
+func (controller *InventoryController) GetMovements(c *gin.Context) {
+	var webResponse response.WebResponse
+	bookId, ok := controller.bookIdParam(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	movements, err := controller.service.GetMovements(bookId, limit)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Server error",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	movementResponses := []response.StockMovementResponse{}
+	for _, movement := range movements {
+		movementResponses = append(movementResponses, response.StockMovementResponse{
+			ID:            movement.ID,
+			BookID:        movement.BookID,
+			OrderID:       movement.OrderID,
+			Type:          movement.Type,
+			Quantity:      movement.Quantity,
+			OnHandAfter:   movement.OnHandAfter,
+			ReservedAfter: movement.ReservedAfter,
+			Reason:        movement.Reason,
+			CreatedBy:     movement.CreatedBy,
+			CreatedAt:     movement.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get movements successfully",
+		Data:    movementResponses,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+func (controller *InventoryController) bookIdParam(c *gin.Context) (uint, bool) {
+	bookId, err := strconv.ParseUint(c.Param("bookId"), 10, 32)
+	if err != nil {
+		webResponse := response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get bookId",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return 0, false
+	}
+	return uint(bookId), true
+}
+
+func CoppyToStockResponse(stock models.BookStock) response.StockResponse {
+	return response.StockResponse{
+		BookID:            stock.BookID,
+		OnHand:            stock.OnHand,
+		Reserved:          stock.Reserved,
+		Available:         stock.Available(),
+		LowStockThreshold: stock.LowStockThreshold,
+		LowStock:          stock.IsLow(),
+	}
+}
+
+// PublishLowStockAlerts phát sự kiện sắp hết hàng lên bus sự kiện, lỗi chỉ được ghi log
+func PublishLowStockAlerts(bus events.EventBus, stocks []models.BookStock) {
+	if len(stocks) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constant.MessagePublishTimeout)
+	defer cancel()
+	for _, stock := range stocks {
+		event := events.BookStockLow{BookID: stock.BookID, Available: stock.Available(), Threshold: stock.LowStockThreshold}
+		if err := bus.Publish(ctx, event); err != nil {
+			logrus.Printf("Failed to publish low stock alert for book %d: %v", stock.BookID, err)
+		}
+	}
+}
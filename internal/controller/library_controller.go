@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/exporter"
+	"bookstack/internal/models"
+	"bookstack/internal/service"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LibraryController struct {
+	service     service.LibraryService
+	userService service.UserService
+}
+
+func NewLibraryController(serv service.LibraryService, userService service.UserService) *LibraryController {
+	return &LibraryController{
+		service:     serv,
+		userService: userService,
+	}
+}
+
+// GetLibrary godoc
+// @Summary My library
+// @Description List the ebooks the current user has bought. Chapters and pages of these books can be read even when restricted
+// @Tags Library
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Success 200 {object} response.WebResponse
+// @Failure 401 {object} response.WebResponse
+// @Router /library [get]
+func (controller *LibraryController) GetLibrary(c *gin.Context) {
+	var webResponse response.WebResponse
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	entitlements, err := controller.service.GetLibrary(userId)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Server error",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	books := []response.LibraryBookResponse{}
+	for _, entitlement := range entitlements {
+		books = append(books, CoppyToLibraryBookResponse(entitlement))
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get library successfully",
+		Data:    books,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// CreateDownloadLink godoc
+// @Summary Create a download link for a bought ebook
+// @Description Create a signed link to download an ebook from the library in an export format. The link works without a token until it expires
+// @Tags Library
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param bookId path int true "Book ID"
+// @Param format path string true "epub, html, markdown or txt"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Router /library/{bookId}/download/{format} [post]
+func (controller *LibraryController) CreateDownloadLink(c *gin.Context) {
+	var webResponse response.WebResponse
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	bookId, err := strconv.Atoi(c.Param("bookId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get bookId",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	link, err := controller.service.CreateDownloadLink(userId, uint(bookId), c.Param("format"))
+	if err != nil {
+		LibraryError(c, err)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "download link created",
+		Data:    link,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// Download godoc
+// @Summary Download a bought ebook
+// @Description Download an ebook through a signed link created by the library. Links expire and stop working once the ebook is refunded
+// @Tags Library
+// @Produce octet-stream
+// @Param bookId path int true "Book ID"
+// @Param format path string true "epub, html, markdown or txt"
+// @Param user query int true "User ID the link was created for"
+// @Param expires query int true "Expiry (Unix time)"
+// @Param signature query string true "Link signature"
+// @Success 200 {file} file
+// @Failure 403 {object} response.WebResponse
+// @Failure 410 {object} response.WebResponse
+// @Router /library/downloads/{bookId}/{format} [get]
+func (controller *LibraryController) Download(c *gin.Context) {
+	var request request.DownloadRequest
+	bookId, err := strconv.Atoi(c.Param("bookId"))
+	if err == nil {
+		err = c.ShouldBindQuery(&request)
+	}
+	if err != nil {
+		c.JSON(http.StatusForbidden, response.WebResponse{
+			Code:    http.StatusForbidden,
+			Status:  "error",
+			Message: service.ErrInvalidDownloadLink.Error(),
+			Data:    nil,
+		})
+		return
+	}
+	file, err := controller.service.Download(uint(bookId), c.Param("format"), request)
+	if err != nil {
+		LibraryError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+// LibraryError trả về mã lỗi tương ứng với lỗi của thư viện sách điện tử
+func LibraryError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	message := "Server error"
+	switch {
+	case errors.Is(err, exporter.ErrUnknownFormat):
+		code, message = http.StatusBadRequest, "format must be one of epub, html, markdown, txt"
+	case errors.Is(err, service.ErrDownloadLinkExpired):
+		code, message = http.StatusGone, err.Error()
+	case errors.Is(err, service.ErrInvalidDownloadLink), errors.Is(err, service.ErrAccessDenied):
+		code, message = http.StatusForbidden, err.Error()
+	}
+	c.JSON(code, response.WebResponse{
+		Code:    code,
+		Status:  "error",
+		Message: message,
+		Data:    nil,
+	})
+}
+
+func CoppyToLibraryBookResponse(entitlement models.BookEntitlement) response.LibraryBookResponse {
+	return response.LibraryBookResponse{
+		BookID:      entitlement.BookID,
+		Title:       entitlement.Book.Title,
+		Description: entitlement.Book.Description,
+		Slug:        entitlement.Book.Slug,
+		OrderID:     entitlement.OrderID,
+		PurchasedAt: entitlement.CreatedAt.Format("2006-01-02 15:04:05"),
+		Formats:     exporter.Formats(),
+	}
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/messaging"
+	"bookstack/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MessageController struct {
+	service service.MessageService
+}
+
+func NewMessageController(serv service.MessageService) *MessageController {
+	return &MessageController{
+		service: serv,
+	}
+}
+
+// GetDeadLetters godoc
+// @Summary List dead-lettered messages
+// @Description Peek at the messages of a queue that exhausted their retries or could not be processed, without removing them. Queues: new_orders, low_stock_alerts, webhooks
+// @Tags Message
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param queue path string true "Queue name"
+// @Param limit query int false "Number of messages (default 50, max 500)"
+// @Success 200 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Failure 503 {object} response.WebResponse
+// @Router /admin/messages/{queue}/dead-letters [get]
+func (controller *MessageController) GetDeadLetters(c *gin.Context) {
+	var webResponse response.WebResponse
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	letters, err := controller.service.GetDeadLetters(c.Param("queue"), limit)
+	if err != nil {
+		MessageError(c, err)
+		return
+	}
+	letterResponses := []response.DeadLetterResponse{}
+	for _, letter := range letters {
+		letterResponses = append(letterResponses, CoppyToDeadLetterResponse(letter))
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get dead letters successfully",
+		Data:    letterResponses,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// ReplayDeadLetters godoc
+// @Summary Replay dead-lettered messages
+// @Description Send dead-lettered messages back to their queue with the retry count reset. An empty ids list replays the whole dead-letter queue; messages that are not valid envelopes stay there
+// @Tags Message
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param queue path string true "Queue name"
+// @Param request body request.ReplayDeadLettersRequest false "Message IDs"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Failure 503 {object} response.WebResponse
+// @Router /admin/messages/{queue}/dead-letters/replay [post]
+func (controller *MessageController) ReplayDeadLetters(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.ReplayDeadLettersRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: "invalid request: " + err.Error(),
+				Data:    nil,
+			}
+			c.JSON(http.StatusBadRequest, webResponse)
+			return
+		}
+	}
+	replayed, err := controller.service.ReplayDeadLetters(c.Param("queue"), request.IDs)
+	if err != nil {
+		MessageError(c, err)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "dead letters replayed",
+		Data:    response.ReplayDeadLettersResponse{Replayed: replayed},
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// MessageError trả lỗi quản trị message: queue không tồn tại là 404, còn lại là RabbitMQ không sẵn sàng
+func MessageError(c *gin.Context, err error) {
+	code := http.StatusServiceUnavailable
+	if errors.Is(err, service.ErrUnknownQueue) {
+		code = http.StatusNotFound
+	}
+	c.JSON(code, response.WebResponse{
+		Code:    code,
+		Status:  "error",
+		Message: err.Error(),
+		Data:    nil,
+	})
+}
+
+func CoppyToDeadLetterResponse(letter messaging.DeadLetter) response.DeadLetterResponse {
+	letterResponse := response.DeadLetterResponse{
+		ID:       letter.MessageID,
+		Error:    letter.Error,
+		FailedAt: letter.FailedAt,
+	}
+	if letter.Envelope.ID == "" {
+		letterResponse.Body = string(letter.Body)
+		return letterResponse
+	}
+	letterResponse.ID = letter.Envelope.ID
+	letterResponse.Type = letter.Envelope.Type
+	letterResponse.Version = letter.Envelope.Version
+	letterResponse.Attempt = letter.Envelope.Attempt
+	letterResponse.OccurredAt = letter.Envelope.OccurredAt.Format(time.RFC3339)
+	letterResponse.Payload = letter.Envelope.Payload
+	return letterResponse
+}
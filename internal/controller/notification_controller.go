@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/service"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+type NotificationController struct {
+	service     service.NotificationService
+	userService service.UserService
+}
+
+func NewNotificationController(serv service.NotificationService, userService service.UserService) *NotificationController {
+	return &NotificationController{
+		service:     serv,
+		userService: userService,
+	}
+}
+
+// StartListening nhận sự kiện từ Redis cho các kết nối trên instance này cho tới khi ctx bị hủy
+func (controller *NotificationController) StartListening(ctx context.Context) {
+	controller.service.Listen(ctx)
+}
+
+// Stream godoc
+// @Summary Notification stream (SSE)
+// @Description Server-Sent Events stream of the current user's order events: status changes, shipper assigned, delivery ETA and, for shippers, new delivery offers. Browsers that cannot set headers may pass the token as access_token
+// @Tags Notification
+// @Produce text/event-stream
+// @Param Authorization header string false "Authorization token"
+// @Param access_token query string false "Access token, when the Authorization header cannot be set"
+// @Success 200 {string} string "event stream"
+// @Failure 401 {object} response.WebResponse
+// @Router /notifications/stream [get]
+func (controller *NotificationController) Stream(c *gin.Context) {
+	userId, ok := controller.authenticate(c)
+	if !ok {
+		return
+	}
+	events, unsubscribe := controller.service.Subscribe(userId)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	keepAlive := time.NewTicker(constant.NotificationKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Status(http.StatusOK)
+	io.WriteString(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, open := <-events:
+			if !open {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+		case <-keepAlive.C:
+			io.WriteString(w, ": keep-alive\n\n")
+		}
+		return true
+	})
+}
+
+// WebSocket godoc
+// @Summary Notification WebSocket
+// @Description WebSocket carrying the same events as the SSE stream, one JSON message per event. Browsers may pass the token as access_token
+// @Tags Notification
+// @Param Authorization header string false "Authorization token"
+// @Param access_token query string false "Access token, when the Authorization header cannot be set"
+// @Success 101 {string} string "switching protocols"
+// @Failure 401 {object} response.WebResponse
+// @Router /notifications/ws [get]
+func (controller *NotificationController) WebSocket(c *gin.Context) {
+	userId, ok := controller.authenticate(c)
+	if !ok {
+		return
+	}
+	server := websocket.Server{Handler: func(conn *websocket.Conn) {
+		events, unsubscribe := controller.service.Subscribe(userId)
+		defer unsubscribe()
+
+		// Client không gửi gì, chỉ đọc để biết khi nào kết nối đóng
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var message string
+			for websocket.Message.Receive(conn, &message) == nil {
+			}
+		}()
+
+		keepAlive := time.NewTicker(constant.NotificationKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-closed:
+				return
+			case event, open := <-events:
+				if !open || websocket.JSON.Send(conn, event) != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if websocket.Message.Send(conn, `{"type":"keep-alive"}`) != nil {
+					return
+				}
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (controller *NotificationController) authenticate(c *gin.Context) (int, bool) {
+	token := c.Request.Header.Get("Authorization")
+	if token == "" {
+		token = c.Query("access_token")
+	}
+	userId, err := controller.userService.GetUserIdByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		})
+		return 0, false
+	}
+	return userId, true
+}
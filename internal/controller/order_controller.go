@@ -5,136 +5,236 @@ import (
 	"bookstack/internal/constant"
 	"bookstack/internal/dto/request"
 	"bookstack/internal/dto/response"
-	"bookstack/internal/messaging"
+	"bookstack/internal/events"
 	"bookstack/internal/models"
 	"bookstack/internal/service"
 	"bookstack/utils"
-	"log"
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 type OrderController struct {
-	service     service.OrderService
-	userService service.UserService
+	service          service.OrderService
+	userService      service.UserService
+	inventoryService service.InventoryService
+	cartService      service.CartService
+	outboxService    service.OutboxService
+	bus              events.EventBus
 }
 
-func (controller *OrderController) HandlePaypalWebhook(c *gin.Context) {
+func NewOrderController(serv service.OrderService, userService service.UserService, inventoryService service.InventoryService, cartService service.CartService, outboxService service.OutboxService, bus events.EventBus) *OrderController {
+	return &OrderController{
+		service:          serv,
+		userService:      userService,
+		inventoryService: inventoryService,
+		cartService:      cartService,
+		outboxService:    outboxService,
+		bus:              bus,
+	}
+}
+
+// StartOutboxRelay phát các sự kiện trong outbox lên bus sự kiện trong nền cho tới khi ctx bị hủy
+func (controller *OrderController) StartOutboxRelay(ctx context.Context) {
+	controller.outboxService.StartRelay(ctx)
+}
+
+// CreateOrder godoc
+// @Summary Create a new order
+// @Description Create an order based on the provided request data. Lines of type "digital" buy the ebook: no stock or shipping, and the book is added to the buyer's library once paid
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param order body request.OrderRequest true "Order request payload"
+// @Success 200 {object} response.WebResponse "Order created successfully"
+// @Failure 400 {object} response.WebResponse "Invalid request"
+// @Failure 500 {object} response.WebResponse "Server error"
+// @Router /order [post]
+func (controller *OrderController) CreateOrder(c *gin.Context) {
 	var webResponse response.WebResponse
-	var webhookPayload map[string]interface{}
+	var request request.OrderRequest
 
-	// Đọc body yêu cầu POST
-	if err := c.ShouldBindJSON(&webhookPayload); err != nil {
+	header := c.Request.Header.Get("Authorization")
+	userId, err := controller.userService.GetUserIdByToken(header)
+	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusBadRequest,
 			Status:  "error",
-			Message: "invalid request: " + err.Error(),
+			Message: "no token found",
 			Data:    nil,
 		}
 		c.JSON(http.StatusBadRequest, webResponse)
 		return
 	}
-
-	// Kiểm tra dữ liệu webhook trả về từ PayPal
-	eventType, ok := webhookPayload["event_type"].(string)
-	if !ok {
+	userEmail, err := controller.userService.GetUserEmail(userId)
+	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusBadRequest,
 			Status:  "error",
-			Message: "missing or invalid event_type",
+			Message: "no user found",
 			Data:    nil,
 		}
 		c.JSON(http.StatusBadRequest, webResponse)
 		return
 	}
 
-	// Xử lý các loại sự kiện của PayPal
-	if eventType == "PAYMENT.SALE.COMPLETED" {
-		// Xử lý thanh toán đã hoàn tất
-		controller.service.UpdateOrderStatus(webhookPayload)
-		// Cập nhật trạng thái đơn hàng hoặc thực hiện các thao tác cần thiết
-		log.Printf("payment completed for order %v", webhookPayload)
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request" + err.Error(),
+			Data:    err,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	order, err := controller.service.CreateOrder(request, userId)
+	if errors.Is(err, service.ErrCouponNotApplicable) || errors.Is(err, service.ErrCurrencyNotSupported) || errors.Is(err, service.ErrInvalidOrderLine) {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if errors.Is(err, service.ErrInsufficientStock) {
+		webResponse = response.WebResponse{
+			Code:    http.StatusConflict,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusConflict, webResponse)
+		return
+	}
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Server error" + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
 	}
 
+	orderResponse := controller.afterOrderCreated(order, userEmail)
 	webResponse = response.WebResponse{
 		Code:    http.StatusOK,
 		Status:  "Success",
-		Message: "Webhook Processed",
-		Data:    nil,
+		Message: "Order Created",
+		Data:    orderResponse,
 	}
 	c.JSON(http.StatusOK, webResponse)
 }
 
-func NewOrderController(serv service.OrderService, userService service.UserService) *OrderController {
-	return &OrderController{
-		service:     serv,
-		userService: userService,
-	}
-}
-
-// @Summary Create PayPal order
-// @Description Create a PayPal order for the specified order ID
-// @Tags orders
+// QuoteOrder godoc
+// @Summary Quote an order
+// @Description Compute the price breakdown (subtotal, discounts, shipping, total) of an order without creating it
+// @Tags Order
 // @Accept json
 // @Produce json
-// @Param orderId path int true "Order ID"
-// @Success 200 {object} gin.H "Successfully created PayPal order"
-// @Failure 400 {object} gin.H "Invalid order ID"
-// @Failure 500 {object} gin.H "Failed to create PayPal order"
-// @Router /order/{orderId}/paypal [post]
-func (controller *OrderController) CreatePaypalOrder(c *gin.Context) {
-	orderId := c.Param("orderId")
-	orderIdInt, err := strconv.Atoi(orderId)
+// @Param Authorization header string true "Authorization token"
+// @Param order body request.OrderRequest true "Order request payload"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse "Invalid request or coupon"
+// @Router /order/quote [post]
+func (controller *OrderController) QuoteOrder(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.OrderRequest
+
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
 		return
 	}
-	conf, err := config.LoadConfig()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config"})
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
 		return
 	}
-	paypalClient, err := config.ConnectPaypal(conf)
+	order, err := controller.service.QuoteOrder(request, userId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to PayPal"})
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
 		return
 	}
-	paypalOrder, err := controller.service.CreatePaypalOrder(paypalClient, orderIdInt)
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "Success",
+		Message: "Order quoted",
+		Data:    controller.CoppyToOrderResponse(order),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// afterOrderCreated gửi cảnh báo sắp hết hàng và email xác nhận cho đơn vừa tạo. Sự kiện đơn mới cho shipper
+// đã được ghi vào outbox cùng transaction tạo đơn.
+func (controller *OrderController) afterOrderCreated(order models.Order, userEmail string) response.OrderResponse {
+	// Cảnh báo các sách vừa chạm ngưỡng sắp hết hàng sau khi giữ hàng cho đơn
+	var bookIds []uint
+	for _, item := range order.OrderDetail {
+		bookIds = append(bookIds, item.BookID)
+	}
+	lowStocks, err := controller.inventoryService.LowStockAlerts(bookIds)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create PayPal order" + err.Error()})
-		return
+		logrus.Printf("Failed to check low stock: %v", err)
 	}
-	c.JSON(http.StatusOK, gin.H{"order": paypalOrder})
+	PublishLowStockAlerts(controller.bus, lowStocks)
+
+	utils.OrderNotificationEmail(userEmail, strconv.FormatUint(uint64(order.ID), 10))
+	return controller.CoppyToOrderResponse(order)
 }
 
-// CreateOrder godoc
-// @Summary Create a new order
-// @Description Create an order based on the provided request data
+// Checkout godoc
+// @Summary Checkout the cart
+// @Description Convert the cart of the current user into an order. Fails with 409 if book prices changed since they were added; the cart is updated and must be reviewed again
 // @Tags Order
 // @Accept json
 // @Produce json
-// @Param order body request.OrderRequest true "Order request payload"
+// @Param Authorization header string true "Authorization token"
+// @Param request body request.CheckoutRequest true "Delivery information"
 // @Success 200 {object} response.WebResponse "Order created successfully"
-// @Failure 400 {object} response.WebResponse "Invalid request"
+// @Failure 400 {object} response.WebResponse "Invalid request or empty cart"
+// @Failure 409 {object} response.WebResponse "Cart changed or insufficient stock"
 // @Failure 500 {object} response.WebResponse "Server error"
-// @Router /order [post]
-func (controller *OrderController) CreateOrder(c *gin.Context) {
+// @Router /order/checkout [post]
+func (controller *OrderController) Checkout(c *gin.Context) {
 	var webResponse response.WebResponse
-	var request request.OrderRequest
+	var request request.CheckoutRequest
 
-	header := c.Request.Header.Get("Authorization")
-	userId, err := controller.userService.GetUserIdByToken(header)
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
 	if err != nil {
 		webResponse = response.WebResponse{
-			Code:    http.StatusBadRequest,
+			Code:    http.StatusUnauthorized,
 			Status:  "error",
 			Message: "no token found",
 			Data:    nil,
 		}
-		c.JSON(http.StatusBadRequest, webResponse)
+		c.JSON(http.StatusUnauthorized, webResponse)
 		return
 	}
 	userEmail, err := controller.userService.GetUserEmail(userId)
@@ -148,99 +248,43 @@ func (controller *OrderController) CreateOrder(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, webResponse)
 		return
 	}
-
 	if err := c.ShouldBindJSON(&request); err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusBadRequest,
 			Status:  "error",
-			Message: "invalid request" + err.Error(),
-			Data:    err,
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
 		}
 		c.JSON(http.StatusBadRequest, webResponse)
 		return
 	}
-	order, err := controller.service.CreateOrder(request, userId)
+
+	order, err := controller.cartService.Checkout(userId, request)
 	if err != nil {
+		code := http.StatusInternalServerError
+		message := "Server error"
+		switch {
+		case errors.Is(err, service.ErrCartEmpty), errors.Is(err, service.ErrCouponNotApplicable), errors.Is(err, service.ErrCurrencyNotSupported),
+			errors.Is(err, service.ErrInvalidOrderLine):
+			code, message = http.StatusBadRequest, err.Error()
+		case errors.Is(err, service.ErrCartChanged), errors.Is(err, service.ErrInsufficientStock):
+			code, message = http.StatusConflict, err.Error()
+		}
 		webResponse = response.WebResponse{
-			Code:    http.StatusInternalServerError,
+			Code:    code,
 			Status:  "error",
-			Message: "Server error" + err.Error(),
+			Message: message,
 			Data:    nil,
 		}
-		c.JSON(http.StatusInternalServerError, webResponse)
+		c.JSON(code, webResponse)
 		return
 	}
 
-	// Publish new order notification to RabbitMQ
-	conf, err := config.LoadConfig()
-	if err != nil {
-		logrus.Printf("Failed to load config: %v", err)
-	} else {
-		rabbitmq, err := messaging.NewRabbitMQ(conf)
-		if err != nil {
-			logrus.Printf("Failed to connect to RabbitMQ: %v", err)
-		} else {
-			err = rabbitmq.PublishNewOrder(order.ID, order.Address)
-			if err != nil {
-				logrus.Printf("Failed to publish new order notification: %v", err)
-			}
-		}
-	}
-
-	var orderResponse response.OrderResponse
-	// Copy basic order information
-	orderResponse.OrderID = order.ID
-	orderResponse.UserID = order.UserID
-	orderResponse.TotalPrice = order.TotalPrice
-	orderResponse.Address = order.Address
-	orderResponse.Phone = order.Phone
-	orderResponse.CreatedAt = order.CreatedAt.Format("2006-01-02 15:04:05")
-	orderResponse.UpdatedAt = order.UpdatedAt.Format("2006-01-02 15:04:05")
-
-	// Convert status from constant to string
-	switch order.Status {
-	case constant.Pending:
-		orderResponse.Status = "Pending"
-	case constant.Confirmed:
-		orderResponse.Status = "Confirmed"
-	case constant.Processing:
-		orderResponse.Status = "Processing"
-	case constant.Shipped:
-		orderResponse.Status = "Shipped"
-	case constant.Delivered:
-		orderResponse.Status = "Delivered"
-	case constant.Cancelled:
-		orderResponse.Status = "Cancelled"
-	case constant.Returned:
-		orderResponse.Status = "Returned"
-	case constant.Failed:
-		orderResponse.Status = "Failed"
-	}
-
-	// Copy order details
-	for _, item := range order.OrderDetail {
-		var orderDetailResponse response.OrderDetailResponse
-		orderDetailResponse.Quantity = item.Quantity
-		orderDetailResponse.Price = item.Price
-
-		// Copy book information
-		var bookResponse response.BookOrderResponse
-		bookResponse.ID = item.Book.ID
-		bookResponse.Title = item.Book.Title
-		bookResponse.Price = item.Book.Price
-		bookResponse.Slug = item.Book.Slug
-		bookResponse.CreatedBy = strconv.FormatUint(uint64(item.Book.CreatedBy), 10)
-
-		orderDetailResponse.Book = bookResponse
-		orderResponse.OrderDetail = append(orderResponse.OrderDetail, orderDetailResponse)
-	}
-
-	utils.OrderNotificationEmail(userEmail, strconv.FormatUint(uint64(order.ID), 10))
 	webResponse = response.WebResponse{
 		Code:    http.StatusOK,
 		Status:  "Success",
 		Message: "Order Created",
-		Data:    orderResponse,
+		Data:    controller.afterOrderCreated(order, userEmail),
 	}
 	c.JSON(http.StatusOK, webResponse)
 }
@@ -318,16 +362,22 @@ func (controller *OrderController) GetUserOrder(c *gin.Context) {
 
 // CancelOrder godoc
 // @Summary Cancel an order by ID
-// @Description Cancel an order by ID
+// @Description Cancel an order of the current user. Only pending or confirmed orders can be cancelled by the customer
 // @Tags Order
+// @Accept json
 // @Produce json
+// @Param Authorization header string true "Authorization token"
 // @Param orderId path int true "Order ID"
+// @Param request body request.CancelOrderRequest false "Cancellation reason"
 // @Success 200 {object} response.WebResponse "Order cancelled successfully"
 // @Failure 400 {object} response.WebResponse "Invalid request"
+// @Failure 403 {object} response.WebResponse "Not allowed"
+// @Failure 409 {object} response.WebResponse "Order can no longer be cancelled"
 // @Failure 500 {object} response.WebResponse "Server error"
 // @Router /order/{orderId}/cancel [post]
 func (controller *OrderController) CancelOrder(c *gin.Context) {
 	var webResponse response.WebResponse
+	var request request.CancelOrderRequest
 	orderIdStr := c.Param("orderId")
 	orderId, err := strconv.Atoi(orderIdStr)
 	if err != nil {
@@ -340,7 +390,34 @@ func (controller *OrderController) CancelOrder(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, webResponse)
 		return
 	}
-	err = controller.service.CancelOrder(orderId)
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	// Lý do hủy là tùy chọn
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			webResponse = response.WebResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: "invalid request: " + err.Error(),
+				Data:    nil,
+			}
+			c.JSON(http.StatusBadRequest, webResponse)
+			return
+		}
+	}
+	err = controller.service.CancelOrder(orderId, userId, request.Reason)
+	if OrderStatusError(c, err) {
+		return
+	}
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -360,14 +437,239 @@ func (controller *OrderController) CancelOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, webResponse)
 }
 
+// ChangeOrderStatus godoc
+// @Summary Change the status of an order
+// @Description Move an order to a new status following the order status transition table (staff only)
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Param request body request.OrderStatusRequest true "New status and reason"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Failure 409 {object} response.WebResponse
+// @Router /order/{orderId}/status [put]
+func (controller *OrderController) ChangeOrderStatus(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.OrderStatusRequest
+	orderId, err := strconv.Atoi(c.Param("orderId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid order ID",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	order, err := controller.service.ChangeOrderStatus(orderId, userId, request)
+	if OrderStatusError(c, err) {
+		return
+	}
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Server error",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "Success",
+		Message: "Order status updated",
+		Data:    controller.CoppyToOrderResponse(order),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// GetOrderTimeline godoc
+// @Summary Get the status timeline of an order
+// @Description Get every status change of an order with actor, time and reason. Readable by the customer, the assigned shipper and staff
+// @Tags Order
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /order/{orderId}/timeline [get]
+func (controller *OrderController) GetOrderTimeline(c *gin.Context) {
+	var webResponse response.WebResponse
+	orderId, err := strconv.Atoi(c.Param("orderId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid order ID",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	order, history, err := controller.service.GetOrderTimeline(orderId, userId)
+	if errors.Is(err, service.ErrAccessDenied) {
+		webResponse = response.WebResponse{
+			Code:    http.StatusForbidden,
+			Status:  "error",
+			Message: "you do not have permission to view this order",
+			Data:    nil,
+		}
+		c.JSON(http.StatusForbidden, webResponse)
+		return
+	}
+	if OrderStatusError(c, err) {
+		return
+	}
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Server error",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "Success",
+		Message: "Order timeline retrieved successfully",
+		Data:    CoppyToOrderTimelineResponse(order, history),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// OrderStatusError trả về response phù hợp nếu lỗi là do chuyển trạng thái đơn không hợp lệ
+func OrderStatusError(c *gin.Context, err error) bool {
+	var code int
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, service.ErrTransitionNotAllowed):
+		code = http.StatusForbidden
+	case errors.Is(err, service.ErrInvalidTransition):
+		code = http.StatusConflict
+	default:
+		return false
+	}
+	webResponse := response.WebResponse{
+		Code:    code,
+		Status:  "error",
+		Message: err.Error(),
+		Data:    nil,
+	}
+	c.JSON(code, webResponse)
+	return true
+}
+
+func CoppyToOrderTimelineResponse(order models.Order, history []models.OrderStatusHistory) response.OrderTimelineResponse {
+	timeline := response.OrderTimelineResponse{
+		OrderID: order.ID,
+		Status:  order.Status.String(),
+		History: []response.OrderStatusHistoryResponse{},
+	}
+	for _, entry := range history {
+		var fromStatus string
+		if entry.FromStatus != nil {
+			fromStatus = entry.FromStatus.String()
+		}
+		timeline.History = append(timeline.History, response.OrderStatusHistoryResponse{
+			FromStatus: fromStatus,
+			ToStatus:   entry.ToStatus.String(),
+			ActorID:    entry.ActorID,
+			ActorRole:  entry.ActorRole,
+			Reason:     entry.Reason,
+			CreatedAt:  entry.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return timeline
+}
+
+// CoppyToAddressResponse trả về nil khi đơn không có địa chỉ có cấu trúc (đơn cũ hoặc không tra được)
+func CoppyToAddressResponse(address models.Address) *response.AddressResponse {
+	if address == (models.Address{}) {
+		return nil
+	}
+	return &response.AddressResponse{
+		Province:  address.Province,
+		District:  address.District,
+		Ward:      address.Ward,
+		Street:    address.Street,
+		Lat:       address.Lat,
+		Lng:       address.Lng,
+		Precision: address.Precision,
+	}
+}
+
 func (controller *OrderController) CoppyToOrderResponse(order models.Order) response.OrderResponse {
 	var orderResponse response.OrderResponse
 
 	// Copy basic order information
 	orderResponse.OrderID = order.ID
 	orderResponse.UserID = order.UserID
+	orderResponse.Currency = order.Currency
+	orderResponse.ExchangeRate = order.ExchangeRate
+	orderResponse.Subtotal = order.Subtotal
+	orderResponse.DiscountTotal = order.DiscountTotal
+	orderResponse.ShippingFee = order.ShippingFee
 	orderResponse.TotalPrice = order.TotalPrice
+	orderResponse.CouponCode = order.CouponCode
+	orderResponse.PaymentStatus = order.PaymentStatus
+	orderResponse.DispatchState = order.DispatchState
+	if order.DeliveryETA != nil {
+		orderResponse.DeliveryETA = order.DeliveryETA.Format("2006-01-02 15:04:05")
+	}
+	for _, discount := range order.Discounts {
+		orderResponse.Discounts = append(orderResponse.Discounts, response.OrderDiscountResponse{
+			Code:   discount.Code,
+			Name:   discount.Name,
+			Type:   discount.Type,
+			Amount: discount.Amount,
+		})
+	}
 	orderResponse.Address = order.Address
+	orderResponse.Location = CoppyToAddressResponse(order.Location)
+	orderResponse.DeliveryCode = order.DeliveryCode
 	orderResponse.Phone = order.Phone
 	orderResponse.CreatedAt = order.CreatedAt.Format("2006-01-02 15:04:05")
 	orderResponse.UpdatedAt = order.UpdatedAt.Format("2006-01-02 15:04:05")
@@ -395,6 +697,7 @@ func (controller *OrderController) CoppyToOrderResponse(order models.Order) resp
 	// Copy order details
 	for _, item := range order.OrderDetail {
 		var orderDetailResponse response.OrderDetailResponse
+		orderDetailResponse.Type = item.Type
 		orderDetailResponse.Quantity = item.Quantity
 		orderDetailResponse.Price = item.Price
 
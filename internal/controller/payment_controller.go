@@ -0,0 +1,519 @@
+package controller
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/models"
+	"bookstack/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PaymentController struct {
+	service     service.PaymentService
+	userService service.UserService
+}
+
+func NewPaymentController(serv service.PaymentService, userService service.UserService) *PaymentController {
+	return &PaymentController{
+		service:     serv,
+		userService: userService,
+	}
+}
+
+// GetProviders godoc
+// @Summary List payment providers
+// @Tags Payment
+// @Produce json
+// @Success 200 {object} response.WebResponse
+// @Router /payments/providers [get]
+func (controller *PaymentController) GetProviders(c *gin.Context) {
+	webResponse := response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get payment providers successfully",
+		Data:    controller.service.Providers(),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// CreatePayment godoc
+// @Summary Pay for an order
+// @Description Create a payment for a pending order with the chosen provider. PayPal returns an approval_url for the buyer; cash on delivery confirms the order and is settled when the shipper delivers it
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Param request body request.PaymentRequest true "Payment provider"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Failure 409 {object} response.WebResponse
+// @Router /order/{orderId}/payments [post]
+func (controller *PaymentController) CreatePayment(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.PaymentRequest
+	orderId, err := strconv.Atoi(c.Param("orderId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid order ID",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	payment, err := controller.service.CreatePayment(orderId, userId, request)
+	if PaymentError(c, err) {
+		return
+	}
+	if err != nil {
+		logrus.Errorf("failed to create payment for order %d: %v", orderId, err)
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadGateway,
+			Status:  "error",
+			Message: "failed to create payment with " + request.Provider,
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadGateway, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "payment created",
+		Data:    CoppyToPaymentResponse(payment),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// GetOrderPayments godoc
+// @Summary List payments of an order
+// @Tags Payment
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /order/{orderId}/payments [get]
+func (controller *PaymentController) GetOrderPayments(c *gin.Context) {
+	var webResponse response.WebResponse
+	orderId, err := strconv.Atoi(c.Param("orderId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid order ID",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	payments, err := controller.service.GetOrderPayments(orderId, userId)
+	if PaymentError(c, err) {
+		return
+	}
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Server error",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	paymentResponses := []response.PaymentResponse{}
+	for _, payment := range payments {
+		paymentResponses = append(paymentResponses, CoppyToPaymentResponse(payment))
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get payments successfully",
+		Data:    paymentResponses,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// CapturePayment godoc
+// @Summary Capture an approved payment
+// @Description Capture a payment the buyer approved on the provider (e.g. PayPal) and confirm the order. Capturing an already captured payment returns it unchanged
+// @Tags Payment
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Param paymentId path int true "Payment ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 403 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Failure 409 {object} response.WebResponse
+// @Failure 502 {object} response.WebResponse
+// @Router /order/{orderId}/payments/{paymentId}/capture [post]
+func (controller *PaymentController) CapturePayment(c *gin.Context) {
+	var webResponse response.WebResponse
+	orderId, err := strconv.Atoi(c.Param("orderId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid order ID",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	paymentId, err := strconv.Atoi(c.Param("paymentId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid payment ID",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	payment, err := controller.service.CapturePayment(orderId, paymentId, userId)
+	if PaymentError(c, err) {
+		return
+	}
+	if err != nil {
+		logrus.Errorf("failed to capture payment %d of order %d: %v", paymentId, orderId, err)
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadGateway,
+			Status:  "error",
+			Message: "failed to capture payment",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadGateway, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "payment captured",
+		Data:    CoppyToPaymentResponse(payment),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// RefundOrder godoc
+// @Summary Refund an order
+// @Description Refund a captured payment in full (no lines) or per order line. Returned books are restocked and a delivered order moves to Returned once every book has been refunded
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Param request body request.RefundRequest true "Refunded lines and reason"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Failure 409 {object} response.WebResponse
+// @Failure 502 {object} response.WebResponse
+// @Router /order/{orderId}/refunds [post]
+func (controller *PaymentController) RefundOrder(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.RefundRequest
+	orderId, err := strconv.Atoi(c.Param("orderId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid order ID",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "no token found",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	refund, err := controller.service.RefundOrder(orderId, userId, request)
+	if PaymentError(c, err) || OrderStatusError(c, err) {
+		return
+	}
+	if err != nil {
+		logrus.Errorf("failed to refund order %d: %v", orderId, err)
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadGateway,
+			Status:  "error",
+			Message: "failed to refund payment",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadGateway, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "order refunded",
+		Data:    CoppyToRefundResponse(refund),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// GetRefunds godoc
+// @Summary List refunds of an order
+// @Tags Payment
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /order/{orderId}/refunds [get]
+func (controller *PaymentController) GetRefunds(c *gin.Context) {
+	var webResponse response.WebResponse
+	orderId, err := strconv.Atoi(c.Param("orderId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid order ID",
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	refunds, err := controller.service.GetRefunds(orderId)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Server error",
+			Data:    nil,
+		}
+		c.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	refundResponses := []response.RefundResponse{}
+	for _, refund := range refunds {
+		refundResponses = append(refundResponses, CoppyToRefundResponse(refund))
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get refunds successfully",
+		Data:    refundResponses,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// HandleWebhook godoc
+// @Summary Payment provider webhook
+// @Description Receive a signed webhook event from a payment provider. Events already processed are acknowledged without changes
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param provider path string true "Payment provider"
+// @Success 200 {object} response.WebResponse "Webhook processed"
+// @Failure 400 {object} response.WebResponse "Invalid event body"
+// @Failure 401 {object} response.WebResponse "Invalid signature"
+// @Failure 500 {object} response.WebResponse "Server error"
+// @Router /payments/webhook/{provider} [post]
+func (controller *PaymentController) HandleWebhook(c *gin.Context) {
+	controller.handleWebhook(c, c.Param("provider"))
+}
+
+// HandlePaypalWebhook godoc
+// @Summary PayPal webhook
+// @Description Receive a signed PayPal webhook event (same as /payments/webhook/paypal)
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.WebResponse "Webhook processed"
+// @Failure 400 {object} response.WebResponse "Invalid event body"
+// @Failure 401 {object} response.WebResponse "Invalid signature"
+// @Failure 500 {object} response.WebResponse "Server error"
+// @Router /paypal/webhook [post]
+func (controller *PaymentController) HandlePaypalWebhook(c *gin.Context) {
+	controller.handleWebhook(c, constant.PaymentProviderPaypal)
+}
+
+func (controller *PaymentController) handleWebhook(c *gin.Context, provider string) {
+	var webResponse response.WebResponse
+
+	// Chữ ký được tính trên body gốc nên phải đọc nguyên văn, không bind JSON
+	body, err := c.GetRawData()
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+
+	err = controller.service.HandleWebhook(provider, c.Request.Header, body)
+	var code int
+	var message string
+	switch {
+	case err == nil:
+		webResponse = response.WebResponse{
+			Code:    http.StatusOK,
+			Status:  "Success",
+			Message: "Webhook Processed",
+			Data:    nil,
+		}
+		c.JSON(http.StatusOK, webResponse)
+		return
+	case errors.Is(err, service.ErrUnknownProvider), errors.Is(err, service.ErrUnsupportedCurrency):
+		code, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, service.ErrInvalidSignature):
+		logrus.Warnf("rejected %s webhook: %v", provider, err)
+		code, message = http.StatusUnauthorized, "invalid webhook signature"
+	case errors.Is(err, service.ErrInvalidWebhookData):
+		code, message = http.StatusBadRequest, err.Error()
+	default:
+		// Trả lỗi 5xx để cổng thanh toán gửi lại sự kiện
+		logrus.Errorf("failed to process %s webhook: %v", provider, err)
+		code, message = http.StatusInternalServerError, "failed to process webhook"
+	}
+	webResponse = response.WebResponse{
+		Code:    code,
+		Status:  "error",
+		Message: message,
+		Data:    nil,
+	}
+	c.JSON(code, webResponse)
+}
+
+// PaymentError ghi response lỗi nghiệp vụ của thanh toán, trả về false nếu err không thuộc các lỗi này
+func PaymentError(c *gin.Context, err error) bool {
+	var code int
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, service.ErrAccessDenied):
+		code = http.StatusForbidden
+	case errors.Is(err, service.ErrUnknownProvider), errors.Is(err, service.ErrUnsupportedCurrency):
+		code = http.StatusBadRequest
+	case errors.Is(err, service.ErrPaymentNotAllowed), errors.Is(err, service.ErrRefundNotAllowed):
+		code = http.StatusConflict
+	default:
+		return false
+	}
+	webResponse := response.WebResponse{
+		Code:    code,
+		Status:  "error",
+		Message: err.Error(),
+		Data:    nil,
+	}
+	c.JSON(code, webResponse)
+	return true
+}
+
+func CoppyToPaymentResponse(payment models.Payment) response.PaymentResponse {
+	return response.PaymentResponse{
+		ID:             payment.ID,
+		OrderID:        payment.OrderID,
+		Provider:       payment.Provider,
+		ExternalID:     payment.ExternalID,
+		Status:         payment.Status,
+		Amount:         payment.Amount,
+		CapturedAmount: payment.CapturedAmount,
+		RefundedAmount: payment.RefundedAmount,
+		Currency:       payment.Currency,
+		ApprovalURL:    payment.ApprovalURL,
+		CreatedAt:      payment.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func CoppyToRefundResponse(refund models.Refund) response.RefundResponse {
+	refundResponse := response.RefundResponse{
+		ID:         refund.ID,
+		OrderID:    refund.OrderID,
+		PaymentID:  refund.PaymentID,
+		ExternalID: refund.ExternalID,
+		Amount:     refund.Amount,
+		Reason:     refund.Reason,
+		CreatedBy:  refund.CreatedBy,
+		Lines:      []response.RefundLineResponse{},
+		CreatedAt:  refund.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	for _, line := range refund.Lines {
+		refundResponse.Lines = append(refundResponse.Lines, response.RefundLineResponse{
+			OrderDetailID: line.OrderDetailID,
+			BookID:        line.BookID,
+			Quantity:      line.Quantity,
+			Amount:        line.Amount,
+		})
+	}
+	return refundResponse
+}
@@ -1,26 +1,38 @@
 package controller
 
 import (
-	"bookstack/config"
 	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
 	"bookstack/internal/dto/response"
+	"bookstack/internal/events"
+	"bookstack/internal/geo"
 	"bookstack/internal/messaging"
 	"bookstack/internal/models"
 	"bookstack/internal/service"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type ShipperController struct {
 	ShipperOrderManageService service.ShipperOrderManageService
 	UserService               service.UserService
+	DispatchService           service.DispatchService
+	DeliveryService           service.DeliveryService
+	Bus                       events.EventBus
 }
 
-func NewShipperController(shipperOrderManageService service.ShipperOrderManageService, userService service.UserService) *ShipperController {
-	return &ShipperController{ShipperOrderManageService: shipperOrderManageService, UserService: userService}
+func NewShipperController(shipperOrderManageService service.ShipperOrderManageService, userService service.UserService, dispatchService service.DispatchService, deliveryService service.DeliveryService, bus events.EventBus) *ShipperController {
+	return &ShipperController{ShipperOrderManageService: shipperOrderManageService, UserService: userService, DispatchService: dispatchService, DeliveryService: deliveryService, Bus: bus}
 }
 
 // @Summary Get received orders for shipper
@@ -132,6 +144,373 @@ func (c *ShipperController) ReceiveOrder(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, webResponse)
 }
 
+// @Summary Update status of an assigned order
+// @Description Move an order assigned to the shipper to Shipped. Delivered and Failed go through the deliver and fail endpoints with proof
+// @Tags shipper
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Param request body request.OrderStatusRequest true "New status and reason"
+// @Success 200 {object} response.WebResponse "Order status updated"
+// @Failure 400 {object} response.WebResponse "Invalid request"
+// @Failure 403 {object} response.WebResponse "Order is not assigned to the shipper"
+// @Failure 409 {object} response.WebResponse "Invalid status transition"
+// @Router /shippers/orders/{orderId}/status [put]
+func (c *ShipperController) UpdateOrderStatus(ctx *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.OrderStatusRequest
+	orderId, err := strconv.Atoi(ctx.Param("orderId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid order ID format",
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	userId, err := c.UserService.GetUserIdByToken(ctx.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Unauthorized",
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	status, ok := constant.ParseOrderStatus(request.Status)
+	if !ok {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "unknown status " + request.Status,
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	order, err := c.ShipperOrderManageService.UpdateOrderStatus(uint(orderId), status, uint(userId), request.Reason)
+	if OrderStatusError(ctx, err) {
+		return
+	}
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Order status updated",
+		Data:    c.CoppyToOrderResponse(order),
+	}
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// @Summary Set delivery ETA
+// @Description Tell the customer how many minutes until the order arrives, for an order assigned to the current shipper
+// @Tags shipper
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Param request body request.DeliveryETARequest true "ETA"
+// @Success 200 {object} response.WebResponse "ETA updated"
+// @Failure 400 {object} response.WebResponse "Invalid request"
+// @Failure 404 {object} response.WebResponse "Order not found or not being delivered by this shipper"
+// @Router /shippers/orders/{orderId}/eta [put]
+func (c *ShipperController) SetDeliveryETA(ctx *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.DeliveryETARequest
+	orderId, err := strconv.Atoi(ctx.Param("orderId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid order ID format",
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	userId, err := c.UserService.GetUserIdByToken(ctx.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Unauthorized",
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	eta := time.Now().Add(time.Duration(request.Minutes) * time.Minute)
+	order, err := c.ShipperOrderManageService.SetDeliveryETA(uint(orderId), uint(userId), eta)
+	if DispatchError(ctx, err) {
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "ETA updated",
+		Data:    c.CoppyToOrderResponse(order),
+	}
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// @Summary Deliver order with proof
+// @Description Complete an order assigned to the current shipper. At least one proof is required: a photo, the recipient's drawn signature (image) or the delivery code the customer received at checkout
+// @Tags shipper
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Param photo formData file false "Photo of the delivered parcel"
+// @Param signature formData file false "Recipient's signature as PNG, JPEG or WebP"
+// @Param code formData string false "Delivery code read by the customer"
+// @Param recipient_name formData string false "Recipient name"
+// @Param reason formData string false "Note"
+// @Param lat formData number false "Shipper latitude"
+// @Param lng formData number false "Shipper longitude"
+// @Success 200 {object} response.WebResponse "Order delivered"
+// @Failure 400 {object} response.WebResponse "Missing or invalid proof, wrong delivery code"
+// @Failure 403 {object} response.WebResponse "Order is not assigned to this shipper"
+// @Failure 409 {object} response.WebResponse "Order cannot be delivered from its current status"
+// @Failure 429 {object} response.WebResponse "Delivery code locked after too many wrong attempts"
+// @Router /shippers/orders/{orderId}/deliver [post]
+func (c *ShipperController) Deliver(ctx *gin.Context) {
+	c.recordDelivery(ctx, true)
+}
+
+// @Summary Record failed delivery
+// @Description Record a failed delivery attempt with a reason (and optionally a photo) and move the order to Failed
+// @Tags shipper
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Param reason formData string true "Why the delivery failed"
+// @Param photo formData file false "Photo taken at the address"
+// @Param lat formData number false "Shipper latitude"
+// @Param lng formData number false "Shipper longitude"
+// @Success 200 {object} response.WebResponse "Delivery failure recorded"
+// @Failure 400 {object} response.WebResponse "Reason is required"
+// @Failure 403 {object} response.WebResponse "Order is not assigned to this shipper"
+// @Failure 409 {object} response.WebResponse "Order cannot fail from its current status"
+// @Router /shippers/orders/{orderId}/fail [post]
+func (c *ShipperController) FailDelivery(ctx *gin.Context) {
+	c.recordDelivery(ctx, false)
+}
+
+func (c *ShipperController) recordDelivery(ctx *gin.Context, delivered bool) {
+	var webResponse response.WebResponse
+	var request request.DeliveryProofRequest
+	orderId, err := strconv.Atoi(ctx.Param("orderId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid order ID format",
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	userId, err := c.UserService.GetUserIdByToken(ctx.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Unauthorized",
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	if err := ctx.ShouldBind(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	photo, err := readProofFile(ctx, service.ProofPhoto)
+	if DeliveryError(ctx, err) {
+		return
+	}
+
+	var order models.Order
+	message := "Order delivered"
+	if delivered {
+		signature, err := readProofFile(ctx, service.ProofSignature)
+		if DeliveryError(ctx, err) {
+			return
+		}
+		order, err = c.DeliveryService.Deliver(uint(orderId), uint(userId), request, photo, signature)
+		if DeliveryError(ctx, err) {
+			return
+		}
+	} else {
+		message = "Delivery failure recorded"
+		order, err = c.DeliveryService.FailDelivery(uint(orderId), uint(userId), request, photo)
+		if DeliveryError(ctx, err) {
+			return
+		}
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: message,
+		Data:    c.CoppyToOrderResponse(order),
+	}
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// @Summary Get delivery proofs
+// @Description Get the delivery attempts recorded for an order, with links to their photo and signature
+// @Tags shipper
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Success 200 {object} response.WebResponse "Delivery proofs"
+// @Router /shippers/orders/{orderId}/proofs [get]
+func (c *ShipperController) GetDeliveryProofs(ctx *gin.Context) {
+	orderId, err := strconv.Atoi(ctx.Param("orderId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid order ID format",
+			Data:    nil,
+		})
+		return
+	}
+	proofs, err := c.DeliveryService.GetProofs(uint(orderId))
+	if DeliveryError(ctx, err) {
+		return
+	}
+	proofResponse := []response.DeliveryProofResponse{}
+	for _, proof := range proofs {
+		proofResponse = append(proofResponse, CoppyToDeliveryProofResponse(proof))
+	}
+	ctx.JSON(http.StatusOK, response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Success",
+		Data:    proofResponse,
+	})
+}
+
+// @Summary Get delivery proof file
+// @Description Download the photo or signature of a delivery proof
+// @Tags shipper
+// @Produce image/png,image/jpeg,image/webp
+// @Param Authorization header string true "Authorization token"
+// @Param proofId path int true "Delivery proof ID"
+// @Param kind path string true "photo or signature"
+// @Success 200 {file} file "Image"
+// @Failure 404 {object} response.WebResponse "Proof or file not found"
+// @Router /shippers/proofs/{proofId}/{kind} [get]
+func (c *ShipperController) GetDeliveryProofFile(ctx *gin.Context) {
+	proofId, err := strconv.Atoi(ctx.Param("proofId"))
+	kind := ctx.Param("kind")
+	if err != nil || (kind != service.ProofPhoto && kind != service.ProofSignature) {
+		ctx.JSON(http.StatusBadRequest, response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid proof ID or kind",
+			Data:    nil,
+		})
+		return
+	}
+	file, err := c.DeliveryService.OpenProofFile(uint(proofId), kind)
+	if DeliveryError(ctx, err) {
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if DeliveryError(ctx, err) {
+		return
+	}
+	http.ServeContent(ctx.Writer, ctx.Request, info.Name(), info.ModTime(), file)
+}
+
+// readProofFile đọc file bằng chứng trong trường field của form, không gửi thì trả về nil
+func readProofFile(ctx *gin.Context, field string) ([]byte, error) {
+	fileHeader, err := ctx.FormFile(field)
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fileHeader.Size > constant.MaxDeliveryProofSize {
+		return nil, fmt.Errorf("%w: %s is too large", service.ErrInvalidProofFile, field)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, constant.MaxDeliveryProofSize+1))
+}
+
+// DeliveryError trả lỗi của bằng chứng giao hàng và chuyển trạng thái đơn với mã HTTP tương ứng
+func DeliveryError(ctx *gin.Context, err error) bool {
+	if err == nil || OrderStatusError(ctx, err) {
+		return err != nil
+	}
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		code = http.StatusNotFound
+	case errors.Is(err, service.ErrDeliveryProofRequired), errors.Is(err, service.ErrInvalidProofFile),
+		errors.Is(err, service.ErrInvalidDeliveryCode), errors.Is(err, http.ErrNotMultipart):
+		code = http.StatusBadRequest
+	case errors.Is(err, service.ErrDeliveryCodeLocked):
+		code = http.StatusTooManyRequests
+	}
+	ctx.JSON(code, response.WebResponse{
+		Code:    code,
+		Status:  "error",
+		Message: err.Error(),
+		Data:    nil,
+	})
+	return true
+}
+
 // @Summary Get all shippers
 // @Description Get a list of all shippers in the system
 // @Tags shipper
@@ -165,21 +544,39 @@ func (c *ShipperController) GetAllShipper(ctx *gin.Context) {
 }
 
 // @Summary Get orders in range
-// @Description Get orders that are within the shipper's working area
+// @Description Get orders that are within the shipper's working area, by place name or by distance from a point
 // @Tags shipper
 // @Accept multipart/form-data
 // @Produce json
-// @Param place formData string true "Working area/place"
+// @Param place formData string false "Working area/place"
+// @Param lat formData number false "Latitude of the search center"
+// @Param lng formData number false "Longitude of the search center"
+// @Param radius_km formData number false "Search radius in km"
 // @Success 200 {object} response.WebResponse "Successfully retrieved orders"
-// @Failure 400 {object} response.WebResponse "Place is required"
+// @Failure 400 {object} response.WebResponse "Place or a valid lat/lng/radius_km is required"
 // @Failure 500 {object} response.WebResponse "Server error"
 // @Router /shippers/orders/in-range [get]
 func (c *ShipperController) GetOrderInRange(ctx *gin.Context) {
 	var webResponse response.WebResponse
 
-	// Lấy giá trị từ form-data
+	// Lấy giá trị từ form-data: có tọa độ thì tìm theo khoảng cách, không thì theo tên khu vực
 	place := ctx.PostForm("place")
-	if place == "" {
+	var orders []models.Order
+	var err error
+	if ctx.PostForm("lat") != "" || ctx.PostForm("lng") != "" || ctx.PostForm("radius_km") != "" {
+		center, radiusKm, ok := parseSearchCircle(ctx)
+		if !ok {
+			webResponse = response.WebResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: "lat, lng and a positive radius_km are required",
+				Data:    nil,
+			}
+			ctx.JSON(http.StatusBadRequest, webResponse)
+			return
+		}
+		orders, err = c.ShipperOrderManageService.GetOrdersNear(center, radiusKm)
+	} else if place == "" {
 		webResponse = response.WebResponse{
 			Code:    http.StatusBadRequest,
 			Status:  "error",
@@ -188,9 +585,9 @@ func (c *ShipperController) GetOrderInRange(ctx *gin.Context) {
 		}
 		ctx.JSON(http.StatusBadRequest, webResponse)
 		return
+	} else {
+		orders, err = c.ShipperOrderManageService.GetOrderInRange(place)
 	}
-
-	orders, err := c.ShipperOrderManageService.GetOrderInRange(place)
 	if err != nil {
 		webResponse = response.WebResponse{
 			Code:    http.StatusInternalServerError,
@@ -228,8 +625,28 @@ func (controller *ShipperController) CoppyToOrderResponse(order models.Order) re
 	// Copy basic order information
 	orderResponse.OrderID = order.ID
 	orderResponse.UserID = order.UserID
+	orderResponse.Currency = order.Currency
+	orderResponse.ExchangeRate = order.ExchangeRate
+	orderResponse.Subtotal = order.Subtotal
+	orderResponse.DiscountTotal = order.DiscountTotal
+	orderResponse.ShippingFee = order.ShippingFee
 	orderResponse.TotalPrice = order.TotalPrice
+	orderResponse.CouponCode = order.CouponCode
+	orderResponse.PaymentStatus = order.PaymentStatus
+	orderResponse.DispatchState = order.DispatchState
+	if order.DeliveryETA != nil {
+		orderResponse.DeliveryETA = order.DeliveryETA.Format("2006-01-02 15:04:05")
+	}
+	for _, discount := range order.Discounts {
+		orderResponse.Discounts = append(orderResponse.Discounts, response.OrderDiscountResponse{
+			Code:   discount.Code,
+			Name:   discount.Name,
+			Type:   discount.Type,
+			Amount: discount.Amount,
+		})
+	}
 	orderResponse.Address = order.Address
+	orderResponse.Location = CoppyToAddressResponse(order.Location)
 	orderResponse.Phone = order.Phone
 	orderResponse.CreatedAt = order.CreatedAt.Format("2006-01-02 15:04:05")
 	orderResponse.UpdatedAt = order.UpdatedAt.Format("2006-01-02 15:04:05")
@@ -257,6 +674,7 @@ func (controller *ShipperController) CoppyToOrderResponse(order models.Order) re
 	// Copy order details
 	for _, item := range order.OrderDetail {
 		var orderDetailResponse response.OrderDetailResponse
+		orderDetailResponse.Type = item.Type
 		orderDetailResponse.Quantity = item.Quantity
 		orderDetailResponse.Price = item.Price
 
@@ -275,39 +693,485 @@ func (controller *ShipperController) CoppyToOrderResponse(order models.Order) re
 	return orderResponse
 }
 
-func (controller *ShipperController) StartListeningForNewOrders() {
-	conf, err := config.LoadConfig()
+// StartListeningForNewOrders nhận sự kiện đơn mới từ bus trong nền cho tới khi ctx bị hủy. Lỗi dispatch được thử lại
+// theo RetryPolicy, riêng đơn không tồn tại thì đưa thẳng vào dead-letter queue.
+func (controller *ShipperController) StartListeningForNewOrders(ctx context.Context) {
+	controller.Bus.Subscribe(ctx, messaging.QueueNewOrders, func(ctx context.Context, env messaging.Envelope) error {
+		order, err := events.Decode[events.OrderCreated](env)
+		if err != nil {
+			return err
+		}
+		log.Printf("Processing new order: ID=%d, Address=%s", order.OrderID, order.Address)
+		// Mời shipper phù hợp nhất, các lần mời tiếp theo do shipper từ chối hoặc lời mời hết hạn
+		err = controller.DispatchService.DispatchNewOrder(env.ID, order.OrderID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return messaging.Permanent(err)
+		}
+		if err != nil {
+			log.Printf("Failed to dispatch order %d: %v", order.OrderID, err)
+		}
+		return err
+	}, events.TypeOrderCreated)
+}
+
+// StartOfferSweeper chạy nền việc hết hạn các lời mời giao đơn không được trả lời và mời shipper tiếp theo
+func (controller *ShipperController) StartOfferSweeper(ctx context.Context) {
+	controller.DispatchService.StartOfferSweeper(ctx)
+}
+
+// @Summary Get delivery offers
+// @Description Get the delivery offers waiting for the current shipper to accept or decline
+// @Tags shipper
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Success 200 {object} response.WebResponse "Successfully retrieved offers"
+// @Failure 401 {object} response.WebResponse "Unauthorized"
+// @Router /shippers/offers [get]
+func (c *ShipperController) GetOffers(ctx *gin.Context) {
+	var webResponse response.WebResponse
+	userId, err := c.UserService.GetUserIdByToken(ctx.Request.Header.Get("Authorization"))
 	if err != nil {
-		log.Printf("Failed to load config: %v", err)
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Unauthorized",
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusUnauthorized, webResponse)
 		return
 	}
+	offers, err := c.DispatchService.GetOffers(userId)
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	offerResponse := []response.DeliveryOfferResponse{}
+	for _, offer := range offers {
+		offerResponse = append(offerResponse, CoppyToDeliveryOfferResponse(offer))
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Success",
+		Data:    offerResponse,
+	}
+	ctx.JSON(http.StatusOK, webResponse)
+}
 
-	rabbitmq, err := messaging.NewRabbitMQ(conf)
+// @Summary Accept a delivery offer
+// @Description Accept an offer before it expires; the order is assigned to the shipper
+// @Tags shipper
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param offerId path int true "Offer ID"
+// @Success 200 {object} response.WebResponse "Offer accepted"
+// @Failure 404 {object} response.WebResponse "Offer not found"
+// @Failure 409 {object} response.WebResponse "Offer expired or closed"
+// @Router /shippers/offers/{offerId}/accept [post]
+func (c *ShipperController) AcceptOffer(ctx *gin.Context) {
+	c.respondOffer(ctx, true)
+}
+
+// @Summary Decline a delivery offer
+// @Description Decline an offer; the order is offered to the next shipper
+// @Tags shipper
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param offerId path int true "Offer ID"
+// @Param request body request.DeclineOfferRequest false "Reason"
+// @Success 200 {object} response.WebResponse "Offer declined"
+// @Failure 404 {object} response.WebResponse "Offer not found"
+// @Failure 409 {object} response.WebResponse "Offer already closed"
+// @Router /shippers/offers/{offerId}/decline [post]
+func (c *ShipperController) DeclineOffer(ctx *gin.Context) {
+	c.respondOffer(ctx, false)
+}
+
+func (c *ShipperController) respondOffer(ctx *gin.Context, accept bool) {
+	var webResponse response.WebResponse
+	offerId, err := strconv.Atoi(ctx.Param("offerId"))
 	if err != nil {
-		log.Printf("Failed to connect to RabbitMQ: %v", err)
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid offer ID format",
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	userId, err := c.UserService.GetUserIdByToken(ctx.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Unauthorized",
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusUnauthorized, webResponse)
 		return
 	}
 
-	err = rabbitmq.ConsumeNewOrders(func(orderID uint, address string) {
-		log.Printf("Processing new order: ID=%d, Address=%s", orderID, address)
+	var offer models.DeliveryOffer
+	message := "Offer accepted"
+	if accept {
+		offer, err = c.DispatchService.AcceptOffer(uint(offerId), userId)
+	} else {
+		var request request.DeclineOfferRequest
+		// Lý do không bắt buộc, body rỗng vẫn hợp lệ
+		_ = ctx.ShouldBindJSON(&request)
+		offer, err = c.DispatchService.DeclineOffer(uint(offerId), userId, request.Reason)
+		message = "Offer declined"
+	}
+	if DispatchError(ctx, err) {
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: message,
+		Data:    CoppyToDeliveryOfferResponse(offer),
+	}
+	ctx.JSON(http.StatusOK, webResponse)
+}
 
-		// Get all shippers
-		shippers, err := controller.ShipperOrderManageService.GetAllShipper("shipper")
-		if err != nil {
-			log.Printf("Failed to get shippers: %v", err)
-			return
+// @Summary Set shipper availability
+// @Description Turn on or off receiving delivery offers for the current shipper
+// @Tags shipper
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param request body request.ShipperAvailabilityRequest true "Availability"
+// @Success 200 {object} response.WebResponse "Availability updated"
+// @Failure 400 {object} response.WebResponse "Invalid request"
+// @Router /shippers/availability [put]
+func (c *ShipperController) SetAvailability(ctx *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.ShipperAvailabilityRequest
+	userId, err := c.UserService.GetUserIdByToken(ctx.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Unauthorized",
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if err := c.DispatchService.SetAvailability(userId, *request.Available); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
 		}
+		ctx.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Availability updated",
+		Data:    gin.H{"available": *request.Available},
+	}
+	ctx.JSON(http.StatusOK, webResponse)
+}
 
-		// Find shippers in the same area
-		for _, shipper := range shippers {
-			if shipper.WorkingArea == address {
-				log.Printf("Found matching shipper: ID=%d, Area=%s", shipper.ID, shipper.WorkingArea)
-				// Here you can add logic to notify the shipper
-				// For example, send a notification or update a database
-			}
+// @Summary Get orders waiting for manual assignment
+// @Description Get the orders that no shipper accepted and need to be assigned by staff
+// @Tags shipper
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Success 200 {object} response.WebResponse "Successfully retrieved orders"
+// @Router /shippers/dispatch/manual [get]
+func (c *ShipperController) GetManualDispatchOrders(ctx *gin.Context) {
+	var webResponse response.WebResponse
+	orders, err := c.DispatchService.GetManualOrders()
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusInternalServerError, webResponse)
+		return
+	}
+	orderResponse := []response.OrderResponse{}
+	for _, order := range orders {
+		orderResponse = append(orderResponse, c.CoppyToOrderResponse(order))
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Success",
+		Data:    orderResponse,
+	}
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// @Summary Assign an order to a shipper
+// @Description Manually assign (or reassign) an order that has not been shipped yet; pending offers are cancelled
+// @Tags shipper
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param orderId path int true "Order ID"
+// @Param request body request.AssignShipperRequest true "Shipper"
+// @Success 200 {object} response.WebResponse "Order assigned"
+// @Failure 400 {object} response.WebResponse "Invalid request"
+// @Failure 409 {object} response.WebResponse "Order does not need a shipper"
+// @Router /shippers/orders/{orderId}/assign [put]
+func (c *ShipperController) AssignOrder(ctx *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.AssignShipperRequest
+	orderId, err := strconv.Atoi(ctx.Param("orderId"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid order ID format",
+			Data:    nil,
 		}
+		ctx.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		ctx.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	order, err := c.DispatchService.AssignOrder(uint(orderId), request.ShipperID)
+	if DispatchError(ctx, err) {
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Order assigned",
+		Data:    c.CoppyToOrderResponse(order),
+	}
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// DispatchError trả về mã lỗi tương ứng với lỗi điều phối shipper. Trả về false nếu err == nil.
+// @Summary Get shipper service area
+// @Description Get the service area used to match orders to the current shipper by distance
+// @Tags shipper
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Success 200 {object} response.WebResponse "Service area"
+// @Failure 404 {object} response.WebResponse "No service area, orders are matched by working area"
+// @Router /shippers/service-area [get]
+func (c *ShipperController) GetServiceArea(ctx *gin.Context) {
+	userId, err := c.UserService.GetUserIdByToken(ctx.Request.Header.Get("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Unauthorized",
+			Data:    nil,
+		})
+		return
+	}
+	area, err := c.DispatchService.GetServiceArea(userId)
+	if DispatchError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Success",
+		Data:    CoppyToServiceAreaResponse(area),
 	})
+}
+
+// @Summary Set shipper service area
+// @Description Set the current shipper's service area as a circle (center + radius_km) or a polygon
+// @Tags shipper
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param request body request.ServiceAreaRequest true "Service area"
+// @Success 200 {object} response.WebResponse "Service area updated"
+// @Failure 400 {object} response.WebResponse "Invalid service area"
+// @Router /shippers/service-area [put]
+func (c *ShipperController) SetServiceArea(ctx *gin.Context) {
+	var request request.ServiceAreaRequest
+	userId, err := c.UserService.GetUserIdByToken(ctx.Request.Header.Get("Authorization"))
 	if err != nil {
-		log.Printf("Failed to start consuming new orders: %v", err)
+		ctx.JSON(http.StatusUnauthorized, response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Unauthorized",
+			Data:    nil,
+		})
+		return
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+	area, err := c.DispatchService.SetServiceArea(userId, geo.ServiceArea{
+		Center:   request.Center,
+		RadiusKm: request.RadiusKm,
+		Polygon:  request.Polygon,
+	})
+	if DispatchError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Service area updated",
+		Data:    CoppyToServiceAreaResponse(area),
+	})
+}
+
+// @Summary Clear shipper service area
+// @Description Remove the current shipper's service area, orders are matched by working area again
+// @Tags shipper
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Success 200 {object} response.WebResponse "Service area removed"
+// @Router /shippers/service-area [delete]
+func (c *ShipperController) ClearServiceArea(ctx *gin.Context) {
+	userId, err := c.UserService.GetUserIdByToken(ctx.Request.Header.Get("Authorization"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Unauthorized",
+			Data:    nil,
+		})
+		return
+	}
+	if DispatchError(ctx, c.DispatchService.ClearServiceArea(userId)) {
+		return
+	}
+	ctx.JSON(http.StatusOK, response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Service area removed",
+		Data:    nil,
+	})
+}
+
+// parseSearchCircle đọc tâm (lat, lng) và bán kính radius_km từ form-data
+func parseSearchCircle(ctx *gin.Context) (geo.Point, float64, bool) {
+	lat, latErr := strconv.ParseFloat(ctx.PostForm("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(ctx.PostForm("lng"), 64)
+	radiusKm, radiusErr := strconv.ParseFloat(ctx.PostForm("radius_km"), 64)
+	center := geo.Point{Lat: lat, Lng: lng}
+	if latErr != nil || lngErr != nil || radiusErr != nil || radiusKm <= 0 || !center.Valid() {
+		return geo.Point{}, 0, false
+	}
+	return center, radiusKm, true
+}
+
+func DispatchError(ctx *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, service.ErrNotShipper), errors.Is(err, service.ErrInvalidServiceArea):
+		code = http.StatusBadRequest
+	case errors.Is(err, service.ErrOfferClosed), errors.Is(err, service.ErrNotDispatchable):
+		code = http.StatusConflict
+	}
+	ctx.JSON(code, response.WebResponse{
+		Code:    code,
+		Status:  "error",
+		Message: err.Error(),
+		Data:    nil,
+	})
+	return true
+}
+
+func CoppyToDeliveryOfferResponse(offer models.DeliveryOffer) response.DeliveryOfferResponse {
+	offerResponse := response.DeliveryOfferResponse{
+		ID:        offer.ID,
+		OrderID:   offer.OrderID,
+		ShipperID: offer.ShipperID,
+		Address:   offer.Order.Address,
+		Location:  CoppyToAddressResponse(offer.Order.Location),
+		Phone:     offer.Order.Phone,
+		Status:    offer.Status,
+		Score:     offer.Score,
+		ExpiresAt: offer.ExpiresAt.Format("2006-01-02 15:04:05"),
+		Reason:    offer.Reason,
+	}
+	if offer.RespondedAt != nil {
+		offerResponse.RespondedAt = offer.RespondedAt.Format("2006-01-02 15:04:05")
+	}
+	return offerResponse
+}
+
+func CoppyToServiceAreaResponse(area models.ShipperServiceArea) response.ServiceAreaResponse {
+	areaResponse := response.ServiceAreaResponse{
+		ShipperID: area.UserID,
+		UpdatedAt: area.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if serviceArea := area.Area(); serviceArea.IsPolygon() {
+		areaResponse.Type = "polygon"
+		areaResponse.Polygon = serviceArea.Polygon
+	} else {
+		areaResponse.Type = "radius"
+		areaResponse.Center = &serviceArea.Center
+		areaResponse.RadiusKm = serviceArea.RadiusKm
+	}
+	return areaResponse
+}
+
+func CoppyToDeliveryProofResponse(proof models.DeliveryProof) response.DeliveryProofResponse {
+	proofResponse := response.DeliveryProofResponse{
+		ID:            proof.ID,
+		OrderID:       proof.OrderID,
+		ShipperID:     proof.ShipperID,
+		Outcome:       proof.Outcome,
+		CodeVerified:  proof.CodeVerified,
+		RecipientName: proof.RecipientName,
+		Reason:        proof.Reason,
+		Lat:           proof.Lat,
+		Lng:           proof.Lng,
+		CreatedAt:     proof.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if proof.PhotoPath != "" {
+		proofResponse.PhotoURL = fmt.Sprintf("/shippers/proofs/%d/%s", proof.ID, service.ProofPhoto)
+	}
+	if proof.SignaturePath != "" {
+		proofResponse.SignatureURL = fmt.Sprintf("/shippers/proofs/%d/%s", proof.ID, service.ProofSignature)
 	}
+	return proofResponse
 }
@@ -0,0 +1,389 @@
+package controller
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/models"
+	"bookstack/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController struct {
+	service     service.WebhookService
+	userService service.UserService
+}
+
+func NewWebhookController(serv service.WebhookService, userService service.UserService) *WebhookController {
+	return &WebhookController{
+		service:     serv,
+		userService: userService,
+	}
+}
+
+// GetWebhooks godoc
+// @Summary List webhook subscriptions
+// @Tags Webhook
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Success 200 {object} response.WebResponse
+// @Failure 500 {object} response.WebResponse
+// @Router /admin/webhooks [get]
+func (controller *WebhookController) GetWebhooks(c *gin.Context) {
+	var webResponse response.WebResponse
+	subscriptions, err := controller.service.GetSubscriptions()
+	if err != nil {
+		WebhookError(c, err)
+		return
+	}
+	subscriptionResponses := []response.WebhookSubscriptionResponse{}
+	for _, subscription := range subscriptions {
+		subscriptionResponses = append(subscriptionResponses, CoppyToWebhookSubscriptionResponse(subscription, false))
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get webhooks successfully",
+		Data:    subscriptionResponses,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook subscription
+// @Tags Webhook
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param webhookId path int true "Webhook ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /admin/webhooks/{webhookId} [get]
+func (controller *WebhookController) GetWebhook(c *gin.Context) {
+	var webResponse response.WebResponse
+	webhookId, ok := webhookIdParam(c, "webhookId")
+	if !ok {
+		return
+	}
+	subscription, err := controller.service.GetSubscription(webhookId)
+	if err != nil {
+		WebhookError(c, err)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get webhook successfully",
+		Data:    CoppyToWebhookSubscriptionResponse(subscription, false),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// CreateWebhook godoc
+// @Summary Create a webhook subscription
+// @Description Subscribe a partner endpoint to domain events (see /events/schemas, "*" for all). Deliveries are POSTed as the event envelope and signed with HMAC-SHA256 of "<X-Bookstack-Timestamp>.<body>" in X-Bookstack-Signature ("sha256=<hex>"). The secret is generated when omitted and only returned by this call
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param request body request.WebhookSubscriptionRequest true "Webhook"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Router /admin/webhooks [post]
+func (controller *WebhookController) CreateWebhook(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.WebhookSubscriptionRequest
+	userId, err := controller.userService.GetUserIdByToken(c.Request.Header.Get("Authorization"))
+	if err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Unauthorized",
+			Data:    nil,
+		}
+		c.JSON(http.StatusUnauthorized, webResponse)
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	subscription, err := controller.service.CreateSubscription(userId, request)
+	if err != nil {
+		WebhookError(c, err)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "webhook created",
+		Data:    CoppyToWebhookSubscriptionResponse(subscription, true),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook subscription
+// @Description Update the endpoint, event types or active flag. An empty secret keeps the current one
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param webhookId path int true "Webhook ID"
+// @Param request body request.WebhookSubscriptionRequest true "Webhook"
+// @Success 200 {object} response.WebResponse
+// @Failure 400 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /admin/webhooks/{webhookId} [put]
+func (controller *WebhookController) UpdateWebhook(c *gin.Context) {
+	var webResponse response.WebResponse
+	var request request.WebhookSubscriptionRequest
+	webhookId, ok := webhookIdParam(c, "webhookId")
+	if !ok {
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		webResponse = response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request: " + err.Error(),
+			Data:    nil,
+		}
+		c.JSON(http.StatusBadRequest, webResponse)
+		return
+	}
+	subscription, err := controller.service.UpdateSubscription(webhookId, request)
+	if err != nil {
+		WebhookError(c, err)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "webhook updated",
+		Data:    CoppyToWebhookSubscriptionResponse(subscription, request.Secret != ""),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook subscription
+// @Description Delete the subscription and its pending deliveries; the log of finished deliveries is kept
+// @Tags Webhook
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param webhookId path int true "Webhook ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /admin/webhooks/{webhookId} [delete]
+func (controller *WebhookController) DeleteWebhook(c *gin.Context) {
+	var webResponse response.WebResponse
+	webhookId, ok := webhookIdParam(c, "webhookId")
+	if !ok {
+		return
+	}
+	if err := controller.service.DeleteSubscription(webhookId); err != nil {
+		WebhookError(c, err)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "webhook deleted",
+		Data:    nil,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary List webhook deliveries
+// @Description Delivery log of a subscription, newest first, with the response code and body of the latest attempt
+// @Tags Webhook
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param webhookId path int true "Webhook ID"
+// @Param limit query int false "Number of deliveries (default 50, max 500)"
+// @Success 200 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /admin/webhooks/{webhookId}/deliveries [get]
+func (controller *WebhookController) GetWebhookDeliveries(c *gin.Context) {
+	var webResponse response.WebResponse
+	webhookId, ok := webhookIdParam(c, "webhookId")
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	deliveries, err := controller.service.GetDeliveries(webhookId, limit)
+	if err != nil {
+		WebhookError(c, err)
+		return
+	}
+	deliveryResponses := []response.WebhookDeliveryResponse{}
+	for _, delivery := range deliveries {
+		deliveryResponses = append(deliveryResponses, CoppyToWebhookDeliveryResponse(delivery))
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "get webhook deliveries successfully",
+		Data:    deliveryResponses,
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// SendTestWebhook godoc
+// @Summary Send a test event
+// @Description Immediately deliver a signed "webhook.test" event to the subscription and return the delivery with the endpoint's response
+// @Tags Webhook
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param webhookId path int true "Webhook ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /admin/webhooks/{webhookId}/test [post]
+func (controller *WebhookController) SendTestWebhook(c *gin.Context) {
+	var webResponse response.WebResponse
+	webhookId, ok := webhookIdParam(c, "webhookId")
+	if !ok {
+		return
+	}
+	delivery, err := controller.service.SendTestEvent(webhookId)
+	if err != nil {
+		WebhookError(c, err)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "test event sent",
+		Data:    CoppyToWebhookDeliveryResponse(delivery),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// RedeliverWebhook godoc
+// @Summary Redeliver a webhook delivery
+// @Description Immediately resend a delivery with its attempt count reset, e.g. after it failed
+// @Tags Webhook
+// @Produce json
+// @Param Authorization header string true "Authorization token"
+// @Param webhookId path int true "Webhook ID"
+// @Param deliveryId path int true "Delivery ID"
+// @Success 200 {object} response.WebResponse
+// @Failure 404 {object} response.WebResponse
+// @Router /admin/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver [post]
+func (controller *WebhookController) RedeliverWebhook(c *gin.Context) {
+	var webResponse response.WebResponse
+	webhookId, ok := webhookIdParam(c, "webhookId")
+	if !ok {
+		return
+	}
+	deliveryId, ok := webhookIdParam(c, "deliveryId")
+	if !ok {
+		return
+	}
+	delivery, err := controller.service.Redeliver(webhookId, deliveryId)
+	if err != nil {
+		WebhookError(c, err)
+		return
+	}
+	webResponse = response.WebResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "delivery resent",
+		Data:    CoppyToWebhookDeliveryResponse(delivery),
+	}
+	c.JSON(http.StatusOK, webResponse)
+}
+
+// StartDeliveringWebhooks chạy nền việc nhận sự kiện và giao tới webhook của đối tác cho tới khi ctx bị hủy
+func (controller *WebhookController) StartDeliveringWebhooks(ctx context.Context) {
+	controller.service.StartDelivering(ctx)
+}
+
+// webhookIdParam đọc ID trên path, trả lỗi 400 khi không hợp lệ
+func webhookIdParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.WebResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "cant get " + name,
+			Data:    nil,
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// WebhookError trả lỗi quản lý webhook: không tìm thấy là 404, request sai là 400, còn lại là lỗi server
+func WebhookError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidWebhook):
+		code = http.StatusBadRequest
+	}
+	c.JSON(code, response.WebResponse{
+		Code:    code,
+		Status:  "error",
+		Message: err.Error(),
+		Data:    nil,
+	})
+}
+
+func CoppyToWebhookSubscriptionResponse(subscription models.WebhookSubscription, withSecret bool) response.WebhookSubscriptionResponse {
+	subscriptionResponse := response.WebhookSubscriptionResponse{
+		ID:          subscription.ID,
+		URL:         subscription.URL,
+		EventTypes:  subscription.EventTypeList(),
+		Description: subscription.Description,
+		Active:      subscription.Active,
+		CreatedBy:   subscription.CreatedBy,
+		CreatedAt:   subscription.CreatedAt.Format(time.RFC3339),
+	}
+	if withSecret {
+		subscriptionResponse.Secret = subscription.Secret
+	}
+	return subscriptionResponse
+}
+
+func CoppyToWebhookDeliveryResponse(delivery models.WebhookDelivery) response.WebhookDeliveryResponse {
+	deliveryResponse := response.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseCode:   delivery.ResponseCode,
+		ResponseBody:   delivery.ResponseBody,
+		Error:          delivery.LastError,
+		DurationMs:     delivery.DurationMs,
+		Payload:        json.RawMessage(delivery.Payload),
+		CreatedAt:      delivery.CreatedAt.Format(time.RFC3339),
+	}
+	if !json.Valid(deliveryResponse.Payload) {
+		deliveryResponse.Payload = nil
+	}
+	if delivery.Status == constant.WebhookDeliveryPending {
+		deliveryResponse.NextAttemptAt = delivery.NextAttemptAt.Format(time.RFC3339)
+	}
+	if delivery.DeliveredAt != nil {
+		deliveryResponse.DeliveredAt = delivery.DeliveredAt.Format(time.RFC3339)
+	}
+	return deliveryResponse
+}
@@ -0,0 +1,103 @@
+// Package dispatch chấm điểm và xếp hạng các shipper ứng viên cho một đơn hàng cần giao.
+package dispatch
+
+import (
+	"bookstack/internal/geo"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Điểm của từng tiêu chí. Khớp khu vực quan trọng hơn tải: shipper đúng khu vực luôn đứng trước
+// shipper chỉ khớp một phần, dù đang giữ nhiều đơn hơn.
+const (
+	ExactAreaScore   = 100 // Khu vực làm việc trùng với địa chỉ giao
+	PartialAreaScore = 50  // Địa chỉ giao nằm trong khu vực làm việc (địa chỉ chứa tên khu vực)
+	LoadPenalty      = 5   // Trừ cho mỗi đơn shipper đang giữ
+
+	// Khi cả đơn và shipper có tọa độ: nơi giao nằm trong vùng phục vụ được ServiceAreaScore,
+	// trừ DistancePenalty cho mỗi km từ tâm vùng nhưng không thấp hơn MinServiceAreaScore
+	ServiceAreaScore    = 100
+	DistancePenalty     = 2
+	MinServiceAreaScore = 10
+)
+
+// Destination là nơi giao đơn: địa chỉ dạng chữ và tọa độ nếu tra được
+type Destination struct {
+	Address string
+	Point   *geo.Point
+}
+
+// Candidate là một shipper có thể được mời giao đơn
+type Candidate struct {
+	ShipperID    uint
+	WorkingArea  string
+	ServiceArea  *geo.ServiceArea // Vùng phục vụ theo tọa độ, nil khi shipper chưa khai báo
+	Available    bool             // Shipper đang nhận đơn
+	ActiveOrders int              // Số đơn đang giao (chưa giao xong/hủy/thất bại)
+	DistanceKm   float64          // Khoảng cách từ tâm vùng phục vụ tới nơi giao, 0 khi chấm theo tên khu vực
+	Score        int
+}
+
+// AreaScore chấm điểm khớp khu vực giữa địa chỉ giao và khu vực làm việc của shipper, 0 là không khớp
+func AreaScore(address string, workingArea string) int {
+	address = normalize(address)
+	area := normalize(workingArea)
+	switch {
+	case area == "" || address == "":
+		return 0
+	case address == area:
+		return ExactAreaScore
+	case strings.Contains(address, area):
+		return PartialAreaScore
+	}
+	return 0
+}
+
+// ServiceAreaMatch chấm điểm nơi giao theo vùng phục vụ, trả về điểm và khoảng cách tới tâm vùng; 0 là ngoài vùng
+func ServiceAreaMatch(area geo.ServiceArea, point geo.Point) (int, float64) {
+	if !area.Covers(point) {
+		return 0, 0
+	}
+	distance := geo.Distance(area.Origin(), point)
+	score := ServiceAreaScore - int(math.Round(distance*DistancePenalty))
+	if score < MinServiceAreaScore {
+		score = MinServiceAreaScore
+	}
+	return score, distance
+}
+
+// Rank lọc và xếp hạng ứng viên cho nơi giao: bỏ shipper không nhận đơn, đã đủ maxActive đơn,
+// không khớp khu vực hoặc nằm trong excluded (đã từ chối/bỏ lỡ lời mời của đơn này).
+// Khu vực được so theo tọa độ khi cả nơi giao và shipper có dữ liệu, ngược lại so theo tên khu vực.
+// Điểm cao đứng trước, bằng điểm thì ID nhỏ đứng trước để kết quả ổn định.
+func Rank(destination Destination, candidates []Candidate, excluded map[uint]bool, maxActive int) []Candidate {
+	var ranked []Candidate
+	for _, candidate := range candidates {
+		if !candidate.Available || excluded[candidate.ShipperID] || candidate.ActiveOrders >= maxActive {
+			continue
+		}
+		var area int
+		if destination.Point != nil && candidate.ServiceArea != nil {
+			area, candidate.DistanceKm = ServiceAreaMatch(*candidate.ServiceArea, *destination.Point)
+		} else {
+			area = AreaScore(destination.Address, candidate.WorkingArea)
+		}
+		if area == 0 {
+			continue
+		}
+		candidate.Score = area - LoadPenalty*candidate.ActiveOrders
+		ranked = append(ranked, candidate)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].ShipperID < ranked[j].ShipperID
+	})
+	return ranked
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
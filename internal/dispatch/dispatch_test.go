@@ -0,0 +1,66 @@
+package dispatch
+
+import (
+	"bookstack/internal/geo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAreaScore(t *testing.T) {
+	assert.Equal(t, ExactAreaScore, AreaScore("Cau Giay", " cau  giay"))
+	assert.Equal(t, PartialAreaScore, AreaScore("12 Tran Thai Tong, Cau Giay, Ha Noi", "Cau Giay"))
+	assert.Equal(t, 0, AreaScore("Hoan Kiem", "Cau Giay"))
+	assert.Equal(t, 0, AreaScore("Cau Giay", ""))
+}
+
+func TestRank(t *testing.T) {
+	address := Destination{Address: "Cau Giay"}
+	candidates := []Candidate{
+		{ShipperID: 1, WorkingArea: "Cau Giay", Available: true, ActiveOrders: 3},
+		{ShipperID: 2, WorkingArea: "Cau Giay", Available: true, ActiveOrders: 0},
+		{ShipperID: 3, WorkingArea: "Cau Giay", Available: false},
+		{ShipperID: 4, WorkingArea: "Hoan Kiem", Available: true},
+		{ShipperID: 5, WorkingArea: "Cau Giay", Available: true, ActiveOrders: 5},
+		{ShipperID: 6, WorkingArea: "Cau Giay", Available: true, ActiveOrders: 0},
+		{ShipperID: 7, WorkingArea: "Cau Giay", Available: true, ActiveOrders: 1},
+	}
+
+	ranked := Rank(address, candidates, map[uint]bool{6: true}, 5)
+	var ids []uint
+	for _, candidate := range ranked {
+		ids = append(ids, candidate.ShipperID)
+	}
+	// 3 không nhận đơn, 4 khác khu vực, 5 đã đủ tải, 6 đã từ chối
+	assert.Equal(t, []uint{2, 7, 1}, ids)
+	assert.Equal(t, ExactAreaScore-3*LoadPenalty, ranked[2].Score)
+
+	partial := Rank(Destination{Address: "5 Xuan Thuy, Cau Giay"}, []Candidate{
+		{ShipperID: 1, WorkingArea: "Cau Giay", Available: true, ActiveOrders: 2},
+		{ShipperID: 2, WorkingArea: "5 Xuan Thuy, Cau Giay", Available: true, ActiveOrders: 4},
+	}, nil, 5)
+	assert.Equal(t, uint(2), partial[0].ShipperID)
+}
+
+func TestRankByServiceArea(t *testing.T) {
+	point := geo.Point{Lat: 21.0352, Lng: 105.7946}
+	near := &geo.ServiceArea{Center: geo.Point{Lat: 21.0362, Lng: 105.7906}, RadiusKm: 5}
+	far := &geo.ServiceArea{Center: geo.Point{Lat: 21.0288, Lng: 105.8525}, RadiusKm: 8}
+	outside := &geo.ServiceArea{Center: geo.Point{Lat: 21.0288, Lng: 105.8525}, RadiusKm: 2}
+
+	ranked := Rank(Destination{Address: "Dich Vong, Cau Giay", Point: &point}, []Candidate{
+		{ShipperID: 1, WorkingArea: "Cau Giay", ServiceArea: far, Available: true},
+		{ShipperID: 2, WorkingArea: "Hoan Kiem", ServiceArea: near, Available: true},
+		{ShipperID: 3, WorkingArea: "Cau Giay", ServiceArea: outside, Available: true},
+		{ShipperID: 4, WorkingArea: "Cau Giay", Available: true, ActiveOrders: 4},
+	}, nil, 5)
+	var ids []uint
+	for _, candidate := range ranked {
+		ids = append(ids, candidate.ShipperID)
+	}
+	// 2 gần nơi giao nhất dù tên khu vực khác, 3 ngoài vùng phục vụ, 4 chưa có vùng nên so theo tên
+	assert.Equal(t, []uint{2, 1, 4}, ids)
+	assert.InDelta(t, 0.4, ranked[0].DistanceKm, 0.1)
+	assert.Equal(t, ServiceAreaScore-1, ranked[0].Score)
+	assert.Equal(t, PartialAreaScore-4*LoadPenalty, ranked[2].Score)
+}
@@ -1,5 +1,7 @@
 package request
 
+import "bookstack/internal/money"
+
 type CompleteBookCreateRequest struct {
 	BookCreateRequest  BookCreateRequest    `json:"book"`
 	BookChapterRequest []BookChapterRequest `json:"chapters"`
@@ -7,14 +9,15 @@ type CompleteBookCreateRequest struct {
 }
 
 type BookCreateRequest struct {
-	Title       string       `json:"title" binding:"required"`     // Tiêu đề của sách (bắt buộc)
-	Description string       `json:"description"`                  // Mô tả của sách
-	Slug        string       `json:"slug"`                         // Đường dẫn thân thiện
-	ShelveID    uint         `json:"shelve_id" binding:"required"` // ID của kệ sách chứa nó (bắt buộc)
-	Restricted  bool         `json:"restricted"`                   // Trạng thái kiểm soát quyền truy cập
-	CreatedBy   uint         `json:"created_by"`                   // ID của người tạo sách
-	Price       float64      `json:"price"`
-	Tags        []TagRequest `json:"tags"` // Danh sách tag
+	Title        string        `json:"title" binding:"required"`     // Tiêu đề của sách (bắt buộc)
+	Description  string        `json:"description"`                  // Mô tả của sách
+	Slug         string        `json:"slug"`                         // Đường dẫn thân thiện
+	ShelveID     uint          `json:"shelve_id" binding:"required"` // ID của kệ sách chứa nó (bắt buộc)
+	Restricted   bool          `json:"restricted"`                   // Trạng thái kiểm soát quyền truy cập
+	CreatedBy    uint          `json:"created_by"`                   // ID của người tạo sách
+	Price        money.Decimal `json:"price"`                        // Giá theo tiền tệ gốc
+	DigitalPrice money.Decimal `json:"digital_price"`                // Giá bản điện tử theo tiền tệ gốc, 0 là không bán
+	Tags         []TagRequest  `json:"tags"`                         // Danh sách tag
 }
 
 type TagRequest struct {
@@ -41,7 +44,36 @@ type PageRequest struct {
 	Content   string `json:"content"`                  // Nội dung trang (markdown, HTML,...)
 	Order     int    `json:"order"`                    // Thứ tự sắp xếp trang
 	ChapterId int    `json:"chapter_id"`
+	Summary   string `json:"summary"` // Tóm tắt thay đổi khi cập nhật trang
 }
 
 type CommentRequest struct {
+	Text     string `json:"text"`                         // Nội dung bình luận
+	Rating   int    `json:"rating" binding:"min=0,max=5"` // Điểm đánh giá 1-5, 0 nếu chỉ bình luận
+	ParentID uint   `json:"parent_id"`                    // ID bình luận được trả lời, 0 nếu là bình luận gốc
+}
+
+type CommentVisibilityRequest struct {
+	Hidden bool `json:"hidden"`
+}
+
+type SearchRequest struct {
+	Query    string `form:"q" binding:"required"` // Cú pháp: "[name:value] từ khóa", ví dụ "[genre:scifi] warp drive"
+	Page     int    `form:"page"`                 // Trang kết quả, bắt đầu từ 1
+	PageSize int    `form:"page_size"`            // Số kết quả mỗi trang
+}
+
+type EntityPermissionRequest struct {
+	Role   string `json:"role" binding:"required"` // Tên role được cấp quyền
+	View   bool   `json:"view"`
+	Create bool   `json:"create"`
+	Update bool   `json:"update"`
+	Delete bool   `json:"delete"`
+}
+
+type ImportBookRequest struct {
+	ShelveID   uint   `form:"shelve_id" binding:"required"` // Kệ chứa sách được nhập
+	Format     string `form:"format"`                       // markdown, epub hoặc html; bỏ trống để đoán theo tên file
+	Title      string `form:"title"`                        // Ghi đè tiêu đề đọc được từ file
+	Restricted bool   `form:"restricted"`
 }
@@ -0,0 +1,18 @@
+package request
+
+type CartItemRequest struct {
+	BookID   uint `json:"book_id" binding:"required"`        // ID của sách
+	Quantity int  `json:"quantity" binding:"required,min=1"` // Số lượng thêm vào giỏ
+}
+
+type CartQuantityRequest struct {
+	Quantity int `json:"quantity" binding:"min=0"` // Số lượng mới, 0 để xóa dòng khỏi giỏ
+}
+
+type CheckoutRequest struct {
+	Address    string          `json:"address" binding:"required"` // Địa chỉ giao hàng
+	Location   *AddressRequest `json:"location"`                   // Địa chỉ giao có cấu trúc (không bắt buộc)
+	Phone      string          `json:"phone" binding:"required"`   // Số điện thoại
+	CouponCode string          `json:"coupon_code"`                // Mã giảm giá (không bắt buộc)
+	Currency   string          `json:"currency"`                   // Tiền tệ thanh toán, bỏ trống là tiền tệ gốc
+}
@@ -0,0 +1,27 @@
+package request
+
+import (
+	"bookstack/internal/money"
+	"time"
+)
+
+type CouponRequest struct {
+	Code          string        `json:"code"`                                                                     // Mã coupon, bắt buộc nếu không tự áp dụng
+	Name          string        `json:"name" binding:"required"`                                                  // Tên hiển thị của chương trình
+	Type          string        `json:"type" binding:"required,oneof=percentage fixed buy_x_get_y free_shipping"` // Loại giảm giá
+	Value         money.Decimal `json:"value"`                                                                    // Phần trăm hoặc số tiền giảm theo tiền tệ gốc
+	BuyQuantity   int           `json:"buy_quantity"`                                                             // X của mua X tặng Y
+	GetQuantity   int           `json:"get_quantity"`                                                             // Y của mua X tặng Y
+	ScopeType     string        `json:"scope_type" binding:"omitempty,oneof=shelve tag"`                          // Rỗng là toàn bộ đơn hàng
+	ScopeShelveID uint          `json:"scope_shelve_id"`
+	ScopeTagName  string        `json:"scope_tag_name"`
+	ScopeTagValue string        `json:"scope_tag_value"`
+	MinSubtotal   money.Decimal `json:"min_subtotal"`
+	MaxDiscount   money.Decimal `json:"max_discount"`
+	StartsAt      *time.Time    `json:"starts_at"`
+	EndsAt        *time.Time    `json:"ends_at"`
+	UsageLimit    int           `json:"usage_limit" binding:"min=0"`    // 0 là không giới hạn
+	PerUserLimit  int           `json:"per_user_limit" binding:"min=0"` // 0 là không giới hạn
+	AutoApply     bool          `json:"auto_apply"`                     // Khuyến mãi tự áp dụng, không cần mã
+	Active        bool          `json:"active"`
+}
@@ -0,0 +1,7 @@
+package request
+
+import "bookstack/internal/money"
+
+type ExchangeRateRequest struct {
+	Rate money.Decimal `json:"rate"` // Số đơn vị tiền tệ của một đơn vị tiền tệ gốc, ví dụ 25400 với VND
+}
@@ -0,0 +1,36 @@
+package request
+
+import "bookstack/internal/geo"
+
+type DeclineOfferRequest struct {
+	Reason string `json:"reason"` // Lý do từ chối (không bắt buộc)
+}
+
+type ShipperAvailabilityRequest struct {
+	Available *bool `json:"available" binding:"required"` // true: đang nhận lời mời giao đơn
+}
+
+type DeliveryETARequest struct {
+	Minutes int `json:"eta_minutes" binding:"required,min=1,max=1440"` // Số phút nữa đơn dự kiến giao tới
+}
+
+// DeliveryProofRequest là phần chữ của bằng chứng giao hàng (multipart/form-data), ảnh và chữ ký là file
+// trong các trường photo và signature
+type DeliveryProofRequest struct {
+	Code          string   `form:"code"`                                     // Mã nhận hàng khách đọc cho shipper
+	RecipientName string   `form:"recipient_name"`                           // Người nhận hàng
+	Reason        string   `form:"reason"`                                   // Lý do giao thất bại (bắt buộc khi thất bại) hoặc ghi chú
+	Lat           *float64 `form:"lat" binding:"omitempty,min=-90,max=90"`   // Vị trí shipper (không bắt buộc)
+	Lng           *float64 `form:"lng" binding:"omitempty,min=-180,max=180"` // Vị trí shipper (không bắt buộc)
+}
+
+type AssignShipperRequest struct {
+	ShipperID uint `json:"shipper_id" binding:"required"` // Shipper được gán đơn
+}
+
+// ServiceAreaRequest khai báo vùng phục vụ: hình tròn (center + radius_km) hoặc đa giác (polygon, từ 3 điểm)
+type ServiceAreaRequest struct {
+	Center   geo.Point   `json:"center"`    // Tâm vùng khi dùng bán kính
+	RadiusKm float64     `json:"radius_km"` // Bán kính (km)
+	Polygon  []geo.Point `json:"polygon"`   // Các đỉnh đa giác theo thứ tự
+}
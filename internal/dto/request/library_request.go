@@ -0,0 +1,8 @@
+package request
+
+// DownloadRequest là các tham số đã ký của link tải sách điện tử
+type DownloadRequest struct {
+	UserID    uint   `form:"user" binding:"required"`      // Người được cấp link
+	Expires   int64  `form:"expires" binding:"required"`   // Thời điểm hết hạn (Unix time)
+	Signature string `form:"signature" binding:"required"` // Chữ ký HMAC của link
+}
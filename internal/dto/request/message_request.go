@@ -0,0 +1,5 @@
+package request
+
+type ReplayDeadLettersRequest struct {
+	IDs []string `json:"ids"` // ID envelope cần gửi lại, để trống là gửi lại toàn bộ dead-letter queue
+}
@@ -1,12 +1,47 @@
 package request
 
 type OrderDetailRequest struct {
-	BookID   uint `json:"book_id" binding:"required"`  // ID của sách
-	Quantity int  `json:"quantity" binding:"required"` // Số lượng sách đặt
+	BookID   uint   `json:"book_id" binding:"required"`  // ID của sách
+	Quantity int    `json:"quantity" binding:"required"` // Số lượng sách đặt (tối đa constant.MaxCartQuantity), sách điện tử chỉ mua được 1 bản
+	Type     string `json:"type"`                        // physical (mặc định) hoặc digital
 }
 
 type OrderRequest struct {
 	OrderDetails []OrderDetailRequest `json:"order_details" binding:"required"` // Danh sách sách trong đơn
 	Address      string               `json:"address"`                          // Địa chỉ giao hàng
+	Location     *AddressRequest      `json:"location"`                         // Địa chỉ giao có cấu trúc (không bắt buộc), dùng để tra tọa độ
 	Phone        string               `json:"phone"`                            // Số điện thoại
+	CouponCode   string               `json:"coupon_code"`                      // Mã giảm giá (không bắt buộc)
+	Currency     string               `json:"currency"`                         // Tiền tệ thanh toán, ví dụ VND; bỏ trống là tiền tệ gốc
+}
+
+// AddressRequest là địa chỉ giao có cấu trúc. Không gửi kèm tọa độ (hoặc chỉ gửi một trong hai)
+// thì tọa độ được tra từ gazetteer theo tỉnh/quận/phường.
+type AddressRequest struct {
+	Province  string   `json:"province"`                                 // Tỉnh/thành phố
+	District  string   `json:"district"`                                 // Quận/huyện
+	Ward      string   `json:"ward"`                                     // Phường/xã
+	Street    string   `json:"street"`                                   // Số nhà, tên đường
+	Lat       *float64 `json:"lat" binding:"omitempty,min=-90,max=90"`   // Vĩ độ (không bắt buộc)
+	Lng       *float64 `json:"lng" binding:"omitempty,min=-180,max=180"` // Kinh độ (không bắt buộc)
+	Precision string   `json:"-"`                                        // Mức chính xác của tọa độ, do hệ thống điền
+}
+
+type StockAdjustmentRequest struct {
+	Quantity int    `json:"quantity" binding:"required"` // Số lượng thay đổi; receipt phải dương, adjustment có thể âm
+	Type     string `json:"type"`                        // receipt hoặc adjustment (mặc định)
+	Reason   string `json:"reason" binding:"required"`   // Lý do điều chỉnh, lưu vào sổ kho
+}
+
+type LowStockThresholdRequest struct {
+	Threshold int `json:"threshold"` // Ngưỡng cảnh báo sắp hết hàng
+}
+
+type OrderStatusRequest struct {
+	Status string `json:"status" binding:"required"` // Tên trạng thái mới: Confirmed, Processing, Shipped, ...
+	Reason string `json:"reason"`                    // Lý do, lưu vào lịch sử đơn hàng
+}
+
+type CancelOrderRequest struct {
+	Reason string `json:"reason"` // Lý do hủy đơn
 }
@@ -0,0 +1,15 @@
+package request
+
+type PaymentRequest struct {
+	Provider string `json:"provider" binding:"required"` // Cổng thanh toán: paypal hoặc cod
+}
+
+type RefundLineRequest struct {
+	OrderDetailID uint `json:"order_detail_id" binding:"required"` // Dòng đơn hàng được trả
+	Quantity      int  `json:"quantity" binding:"required,min=1"`  // Số cuốn được trả
+}
+
+type RefundRequest struct {
+	Lines  []RefundLineRequest `json:"lines" binding:"dive"`      // Để trống để hoàn toàn bộ số tiền còn lại
+	Reason string              `json:"reason" binding:"required"` // Lý do hoàn tiền, lưu vào lịch sử đơn hàng
+}
@@ -13,8 +13,22 @@ type UserLoginRequest struct {
 	Password string `json:"password"`
 }
 
+// UserUpdateRequest cập nhật thông tin cá nhân, mật khẩu được đổi qua /auth/password/change
 type UserUpdateRequest struct {
 	FullName string `json:"name"`
 	Email    string `json:"email"`
-	Password string `json:"password"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`        // Token trong email đặt lại mật khẩu
+	NewPassword string `json:"new_password" binding:"required"` // Tối thiểu constant.MinPasswordLength ký tự
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"` // Tối thiểu constant.MinPasswordLength ký tự
 }
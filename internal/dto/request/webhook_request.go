@@ -0,0 +1,9 @@
+package request
+
+type WebhookSubscriptionRequest struct {
+	URL         string   `json:"url" binding:"required,url"`           // Endpoint nhận sự kiện (http hoặc https)
+	EventTypes  []string `json:"event_types" binding:"required,min=1"` // Loại sự kiện trong danh mục /events/schemas, "*" là mọi sự kiện
+	Secret      string   `json:"secret"`                               // Khóa ký HMAC, để trống thì tự sinh khi tạo hoặc giữ nguyên khi sửa
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"` // Mặc định là bật
+}
@@ -1,15 +1,16 @@
 package response
 
 type BookResponse struct {
-	ID          uint          `json:"id"`
-	Title       string        `json:"title" binding:"required"`     // Tiêu đề của sách (bắt buộc)
-	Description string        `json:"description"`                  // Mô tả của sách
-	Slug        string        `json:"slug"`                         // Đường dẫn thân thiện
-	ShelveID    uint          `json:"shelve_id" binding:"required"` // ID của kệ sách chứa nó (bắt buộc)
-	Restricted  bool          `json:"restricted"`                   // Trạng thái kiểm soát quyền truy cập
-	CreatedBy   string        `json:"created_by"`                   // Name của người tạo sách
-	Tags        []TagResponse `json:"tags"`
-	Shelve      string        `json:"shelve"`
+	ID          uint                  `json:"id"`
+	Title       string                `json:"title" binding:"required"`     // Tiêu đề của sách (bắt buộc)
+	Description string                `json:"description"`                  // Mô tả của sách
+	Slug        string                `json:"slug"`                         // Đường dẫn thân thiện
+	ShelveID    uint                  `json:"shelve_id" binding:"required"` // ID của kệ sách chứa nó (bắt buộc)
+	Restricted  bool                  `json:"restricted"`                   // Trạng thái kiểm soát quyền truy cập
+	CreatedBy   string                `json:"created_by"`                   // Name của người tạo sách
+	Tags        []TagResponse         `json:"tags"`
+	Shelve      string                `json:"shelve"`
+	Rating      RatingSummaryResponse `json:"rating"` // Điểm đánh giá trung bình và phân bố điểm
 }
 
 type ShelveResponse struct {
@@ -25,3 +26,79 @@ type TagResponse struct {
 	ID   uint   `json:"id"`
 	Name string `json:"name"`
 }
+
+type PageRevisionResponse struct {
+	ID             uint   `json:"id"`
+	PageID         int    `json:"page_id"`
+	RevisionNumber int    `json:"revision_number"`
+	Title          string `json:"title"`
+	Content        string `json:"content,omitempty"` // Bỏ trống khi trả về danh sách
+	Summary        string `json:"summary"`
+	CreatedBy      uint   `json:"created_by"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type PageRevisionDiffResponse struct {
+	PageID int                `json:"page_id"`
+	From   int                `json:"from"` // Số phiên bản gốc
+	To     int                `json:"to"`   // Số phiên bản so sánh
+	Lines  []DiffLineResponse `json:"lines"`
+}
+
+type DiffLineResponse struct {
+	Type string `json:"type"` // equal, insert, delete
+	Text string `json:"text"`
+}
+
+type SearchResultResponse struct {
+	EntityType string  `json:"entity_type"` // book, chapter, page
+	EntityID   uint    `json:"entity_id"`
+	BookID     uint    `json:"book_id"`
+	ChapterID  uint    `json:"chapter_id,omitempty"`
+	Title      string  `json:"title"`
+	Snippet    string  `json:"snippet"` // Đoạn trích, từ khóa được bao bởi <mark></mark>
+	Rank       float64 `json:"rank"`
+}
+
+type EntityPermissionResponse struct {
+	EntityType string `json:"entity_type"`
+	EntityID   uint   `json:"entity_id"`
+	RoleID     uint   `json:"role_id"`
+	Role       string `json:"role"`
+	View       bool   `json:"view"`
+	Create     bool   `json:"create"`
+	Update     bool   `json:"update"`
+	Delete     bool   `json:"delete"`
+}
+
+type ImportWarningResponse struct {
+	File    string `json:"file"`
+	Message string `json:"message"`
+}
+
+type ImportBookResponse struct {
+	Book     BookResponse            `json:"book"`
+	Chapters int                     `json:"chapters"` // Số chương đã tạo
+	Pages    int                     `json:"pages"`    // Số trang đã tạo
+	Warnings []ImportWarningResponse `json:"warnings"`
+}
+
+type RatingSummaryResponse struct {
+	Average   float64     `json:"average"`
+	Count     int         `json:"count"`
+	Histogram map[int]int `json:"histogram"` // Điểm (1-5) -> số lượt đánh giá
+}
+
+type CommentResponse struct {
+	ID         uint              `json:"id"`
+	EntityType string            `json:"entity_type"`
+	EntityID   uint              `json:"entity_id"`
+	ParentID   int               `json:"parent_id"`
+	Text       string            `json:"text"`
+	Rating     int               `json:"rating"`
+	CreatedBy  int               `json:"created_by"`
+	Hidden     bool              `json:"hidden"`
+	CreatedAt  string            `json:"created_at"`
+	UpdatedAt  string            `json:"updated_at"`
+	Replies    []CommentResponse `json:"replies"`
+}
@@ -0,0 +1,23 @@
+package response
+
+import "bookstack/internal/money"
+
+type CartItemResponse struct {
+	BookID       uint          `json:"book_id"`
+	Title        string        `json:"title"`
+	Slug         string        `json:"slug"`
+	Quantity     int           `json:"quantity"`
+	Price        money.Decimal `json:"price"`         // Giá hiện tại của sách
+	AddedPrice   money.Decimal `json:"added_price"`   // Giá lúc thêm vào giỏ
+	PriceChanged bool          `json:"price_changed"` // Giá đã thay đổi kể từ lúc thêm vào giỏ
+	Available    bool          `json:"available"`     // false nếu sách đã bị xóa
+	Subtotal     money.Decimal `json:"subtotal"`
+}
+
+type CartResponse struct {
+	GuestToken   string             `json:"guest_token,omitempty"` // Mã giỏ hàng của khách, gửi lại qua header X-Cart-Token
+	Items        []CartItemResponse `json:"items"`
+	Currency     string             `json:"currency"` // Giá trong giỏ theo tiền tệ gốc, quy đổi khi đặt hàng
+	TotalPrice   money.Decimal      `json:"total_price"`
+	PriceChanged bool               `json:"price_changed"` // Có ít nhất một dòng đổi giá hoặc không còn bán
+}
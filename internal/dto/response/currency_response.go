@@ -0,0 +1,15 @@
+package response
+
+import "bookstack/internal/money"
+
+type ExchangeRateResponse struct {
+	Currency   string        `json:"currency"`
+	Rate       money.Decimal `json:"rate"`        // Số đơn vị Currency của một đơn vị tiền tệ gốc
+	MinorUnits int           `json:"minor_units"` // Số chữ số thập phân khi hiển thị và thanh toán
+	UpdatedAt  string        `json:"updated_at"`
+}
+
+type CurrenciesResponse struct {
+	BaseCurrency string                 `json:"base_currency"` // Tiền tệ của giá sách
+	Rates        []ExchangeRateResponse `json:"rates"`
+}
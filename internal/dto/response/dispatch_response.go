@@ -0,0 +1,41 @@
+package response
+
+import "bookstack/internal/geo"
+
+type DeliveryOfferResponse struct {
+	ID          uint             `json:"id"`
+	OrderID     uint             `json:"order_id"`
+	ShipperID   uint             `json:"shipper_id"`
+	Address     string           `json:"address"` // Địa chỉ giao của đơn
+	Location    *AddressResponse `json:"location,omitempty"`
+	Phone       string           `json:"phone"`
+	Status      string           `json:"status"` // pending, accepted, declined, expired, cancelled
+	Score       int              `json:"score"`
+	ExpiresAt   string           `json:"expires_at"`
+	RespondedAt string           `json:"responded_at,omitempty"`
+	Reason      string           `json:"reason,omitempty"`
+}
+
+type ServiceAreaResponse struct {
+	ShipperID uint        `json:"shipper_id"`
+	Type      string      `json:"type"` // radius hoặc polygon
+	Center    *geo.Point  `json:"center,omitempty"`
+	RadiusKm  float64     `json:"radius_km,omitempty"`
+	Polygon   []geo.Point `json:"polygon,omitempty"`
+	UpdatedAt string      `json:"updated_at"`
+}
+
+type DeliveryProofResponse struct {
+	ID            uint     `json:"id"`
+	OrderID       uint     `json:"order_id"`
+	ShipperID     uint     `json:"shipper_id"`
+	Outcome       string   `json:"outcome"` // delivered hoặc failed
+	PhotoURL      string   `json:"photo_url,omitempty"`
+	SignatureURL  string   `json:"signature_url,omitempty"`
+	CodeVerified  bool     `json:"code_verified"`
+	RecipientName string   `json:"recipient_name,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
+	Lat           *float64 `json:"lat,omitempty"`
+	Lng           *float64 `json:"lng,omitempty"`
+	CreatedAt     string   `json:"created_at"`
+}
@@ -0,0 +1,8 @@
+package response
+
+type EventSchemaResponse struct {
+	Type        string         `json:"type"`
+	Version     int            `json:"version"`
+	Description string         `json:"description"`
+	Schema      map[string]any `json:"schema"` // JSON Schema (draft 2020-12) của envelope chứa sự kiện
+}
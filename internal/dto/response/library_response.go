@@ -0,0 +1,17 @@
+package response
+
+type LibraryBookResponse struct {
+	BookID      uint     `json:"book_id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Slug        string   `json:"slug"`
+	OrderID     uint     `json:"order_id"`     // Đơn hàng đã mua sách
+	PurchasedAt string   `json:"purchased_at"` // Thời điểm được cấp quyền đọc
+	Formats     []string `json:"formats"`      // Các định dạng có thể tải về
+}
+
+type DownloadLinkResponse struct {
+	URL       string `json:"url"` // Đường dẫn tải, dùng được không cần token cho tới khi hết hạn
+	Format    string `json:"format"`
+	ExpiresAt string `json:"expires_at"`
+}
@@ -0,0 +1,19 @@
+package response
+
+import "encoding/json"
+
+type DeadLetterResponse struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	Attempt    int             `json:"attempt"` // Số lần xử lý đã thất bại
+	OccurredAt string          `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Body       string          `json:"body,omitempty"` // Body gốc khi message không phải envelope hợp lệ
+	Error      string          `json:"error"`          // Lỗi của lần xử lý cuối
+	FailedAt   string          `json:"failed_at"`
+}
+
+type ReplayDeadLettersResponse struct {
+	Replayed int `json:"replayed"`
+}
@@ -1,28 +1,119 @@
 package response
 
+import "bookstack/internal/money"
+
 type OrderResponse struct {
-	OrderID     uint                  `json:"order_id"`
-	UserID      uint                  `json:"user_id"`
-	Status      string                `json:"status"`
-	TotalPrice  float64               `json:"total_price"`
-	Address     string                `json:"address"` // Địa chỉ giao hàng
-	Phone       string                `json:"phone"`   // Số điện thoại
-	ShiperID    uint                  `json:"shiper_id"`
-	OrderDetail []OrderDetailResponse `json:"order_detail"`
-	CreatedAt   string                `json:"created_at"`
-	UpdatedAt   string                `json:"updated_at"`
+	OrderID       uint                    `json:"order_id"`
+	UserID        uint                    `json:"user_id"`
+	Status        string                  `json:"status"`
+	Currency      string                  `json:"currency"`       // Các số tiền của đơn theo tiền tệ này
+	ExchangeRate  money.Decimal           `json:"exchange_rate"`  // Tỉ giá từ tiền tệ gốc, chốt khi tạo đơn
+	Subtotal      money.Decimal           `json:"subtotal"`       // Tổng tiền sách trước giảm giá
+	DiscountTotal money.Decimal           `json:"discount_total"` // Tổng tiền được giảm
+	ShippingFee   money.Decimal           `json:"shipping_fee"`
+	TotalPrice    money.Decimal           `json:"total_price"`
+	CouponCode    string                  `json:"coupon_code"`
+	PaymentStatus string                  `json:"payment_status"` // pending, paid, denied, refunded, cancelled hoặc rỗng khi chưa thanh toán
+	Discounts     []OrderDiscountResponse `json:"discounts"`
+	Address       string                  `json:"address"`            // Địa chỉ giao hàng
+	Location      *AddressResponse        `json:"location,omitempty"` // Địa chỉ giao có cấu trúc và tọa độ
+	Phone         string                  `json:"phone"`              // Số điện thoại
+	ShiperID      uint                    `json:"shiper_id"`
+	DispatchState string                  `json:"dispatch_state"`          // offering, assigned, manual hoặc rỗng khi chưa điều phối
+	DeliveryETA   string                  `json:"delivery_eta,omitempty"`  // Thời gian dự kiến giao tới
+	DeliveryCode  string                  `json:"delivery_code,omitempty"` // Mã nhận hàng khách đọc cho shipper, không trả về cho shipper
+	OrderDetail   []OrderDetailResponse   `json:"order_detail"`
+	CreatedAt     string                  `json:"created_at"`
+	UpdatedAt     string                  `json:"updated_at"`
+}
+
+type AddressResponse struct {
+	Province  string   `json:"province"`
+	District  string   `json:"district"`
+	Ward      string   `json:"ward"`
+	Street    string   `json:"street"`
+	Lat       *float64 `json:"lat"`
+	Lng       *float64 `json:"lng"`
+	Precision string   `json:"precision"` // exact, ward, district, province hoặc rỗng khi chưa có tọa độ
+}
+
+type OrderDiscountResponse struct {
+	Code   string        `json:"code"`
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	Amount money.Decimal `json:"amount"`
 }
 
 type OrderDetailResponse struct {
 	Book     BookOrderResponse `json:"book"`
+	Type     string            `json:"type"` // physical hoặc digital
 	Quantity int               `json:"quantity"`
-	Price    float64           `json:"price"`
+	Price    money.Decimal     `json:"price"`
 }
 
 type BookOrderResponse struct {
-	ID        uint    `json:"id"`
-	Title     string  `json:"title"`
-	Price     float64 `json:"price"`
-	Slug      string  `json:"slug"`
-	CreatedBy string  `json:"created_by"`
+	ID        uint          `json:"id"`
+	Title     string        `json:"title"`
+	Price     money.Decimal `json:"price"`
+	Slug      string        `json:"slug"`
+	CreatedBy string        `json:"created_by"`
+}
+
+type StockResponse struct {
+	BookID            uint `json:"book_id"`
+	OnHand            int  `json:"on_hand"`
+	Reserved          int  `json:"reserved"`
+	Available         int  `json:"available"`
+	LowStockThreshold int  `json:"low_stock_threshold"`
+	LowStock          bool `json:"low_stock"`
+}
+
+type StockMovementResponse struct {
+	ID            uint   `json:"id"`
+	BookID        uint   `json:"book_id"`
+	OrderID       *uint  `json:"order_id"`
+	Type          string `json:"type"`
+	Quantity      int    `json:"quantity"`
+	OnHandAfter   int    `json:"on_hand_after"`
+	ReservedAfter int    `json:"reserved_after"`
+	Reason        string `json:"reason"`
+	CreatedBy     uint   `json:"created_by"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type OrderStatusHistoryResponse struct {
+	FromStatus string `json:"from_status"` // Rỗng với mốc tạo đơn
+	ToStatus   string `json:"to_status"`
+	ActorID    uint   `json:"actor_id"`
+	ActorRole  string `json:"actor_role"`
+	Reason     string `json:"reason"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type OrderTimelineResponse struct {
+	OrderID uint                         `json:"order_id"`
+	Status  string                       `json:"status"`
+	History []OrderStatusHistoryResponse `json:"history"`
+}
+
+type CouponResponse struct {
+	ID            uint          `json:"id"`
+	Code          string        `json:"code"`
+	Name          string        `json:"name"`
+	Type          string        `json:"type"`
+	Value         money.Decimal `json:"value"`
+	BuyQuantity   int           `json:"buy_quantity"`
+	GetQuantity   int           `json:"get_quantity"`
+	ScopeType     string        `json:"scope_type"`
+	ScopeShelveID uint          `json:"scope_shelve_id"`
+	ScopeTagName  string        `json:"scope_tag_name"`
+	ScopeTagValue string        `json:"scope_tag_value"`
+	MinSubtotal   money.Decimal `json:"min_subtotal"`
+	MaxDiscount   money.Decimal `json:"max_discount"`
+	StartsAt      string        `json:"starts_at"`
+	EndsAt        string        `json:"ends_at"`
+	UsageLimit    int           `json:"usage_limit"`
+	PerUserLimit  int           `json:"per_user_limit"`
+	AutoApply     bool          `json:"auto_apply"`
+	Active        bool          `json:"active"`
 }
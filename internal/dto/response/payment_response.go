@@ -0,0 +1,36 @@
+package response
+
+import "bookstack/internal/money"
+
+type PaymentResponse struct {
+	ID             uint          `json:"id"`
+	OrderID        uint          `json:"order_id"`
+	Provider       string        `json:"provider"`
+	ExternalID     string        `json:"external_id"`
+	Status         string        `json:"status"`
+	Amount         money.Decimal `json:"amount"`
+	CapturedAmount money.Decimal `json:"captured_amount"`
+	RefundedAmount money.Decimal `json:"refunded_amount"`
+	Currency       string        `json:"currency"`
+	ApprovalURL    string        `json:"approval_url,omitempty"` // Chuyển người mua tới trang này để xác nhận thanh toán
+	CreatedAt      string        `json:"created_at"`
+}
+
+type RefundResponse struct {
+	ID         uint                 `json:"id"`
+	OrderID    uint                 `json:"order_id"`
+	PaymentID  uint                 `json:"payment_id"`
+	ExternalID string               `json:"external_id"`
+	Amount     money.Decimal        `json:"amount"`
+	Reason     string               `json:"reason"`
+	CreatedBy  uint                 `json:"created_by"`
+	Lines      []RefundLineResponse `json:"lines"`
+	CreatedAt  string               `json:"created_at"`
+}
+
+type RefundLineResponse struct {
+	OrderDetailID uint          `json:"order_detail_id"`
+	BookID        uint          `json:"book_id"`
+	Quantity      int           `json:"quantity"`
+	Amount        money.Decimal `json:"amount"`
+}
@@ -0,0 +1,31 @@
+package response
+
+import "encoding/json"
+
+type WebhookSubscriptionResponse struct {
+	ID          uint     `json:"id"`
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"event_types"`
+	Description string   `json:"description"`
+	Active      bool     `json:"active"`
+	Secret      string   `json:"secret,omitempty"` // Chỉ trả về khi tạo hoặc đổi secret
+	CreatedBy   uint     `json:"created_by"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint            `json:"id"`
+	SubscriptionID uint            `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseCode   int             `json:"response_code"` // Mã HTTP lần gửi gần nhất, 0 là không nhận được response
+	ResponseBody   string          `json:"response_body"`
+	Error          string          `json:"error"`
+	DurationMs     int64           `json:"duration_ms"`
+	Payload        json.RawMessage `json:"payload"`
+	NextAttemptAt  string          `json:"next_attempt_at,omitempty"` // Lần gửi lại kế tiếp khi còn chờ
+	DeliveredAt    string          `json:"delivered_at,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+}
@@ -0,0 +1,128 @@
+package events
+
+import (
+	"bookstack/internal/messaging"
+	"context"
+	"log"
+	"sync"
+)
+
+// InProcessBus phát sự kiện tới các handler đăng ký trong cùng process, đồng bộ theo thứ tự đăng ký.
+// Dùng khi chạy một process hoặc trong test; lỗi của handler chỉ được ghi log, không thử lại.
+type InProcessBus struct {
+	mu            sync.RWMutex
+	nextId        int
+	subscriptions map[string][]subscription
+}
+
+type subscription struct {
+	id      int
+	handler Handler
+}
+
+func NewInProcessBus() *InProcessBus {
+	return &InProcessBus{subscriptions: map[string][]subscription{}}
+}
+
+func (b *InProcessBus) Publish(ctx context.Context, events ...Event) error {
+	for _, event := range events {
+		env, err := NewEnvelope(event)
+		if err != nil {
+			return err
+		}
+		if err := b.PublishEnvelope(ctx, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *InProcessBus) PublishEnvelope(ctx context.Context, env messaging.Envelope) error {
+	b.mu.RLock()
+	subscriptions := b.subscriptions[env.Type]
+	b.mu.RUnlock()
+	for _, subscription := range subscriptions {
+		if err := subscription.handler(ctx, env); err != nil {
+			log.Printf("Failed to handle event %s (%s): %v", env.ID, env.Type, err)
+		}
+	}
+	return nil
+}
+
+// Subscribe đăng ký handler; queue không có ý nghĩa với bus trong process. ctx bị hủy thì handler bị gỡ.
+func (b *InProcessBus) Subscribe(ctx context.Context, queue string, handler Handler, eventTypes ...string) {
+	b.mu.Lock()
+	b.nextId++
+	id := b.nextId
+	for _, eventType := range eventTypes {
+		b.subscriptions[eventType] = append(b.subscriptions[eventType], subscription{id: id, handler: handler})
+	}
+	b.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for _, eventType := range eventTypes {
+			var kept []subscription
+			for _, subscription := range b.subscriptions[eventType] {
+				if subscription.id != id {
+					kept = append(kept, subscription)
+				}
+			}
+			b.subscriptions[eventType] = kept
+		}
+	}()
+}
+
+// RabbitMQBus phát sự kiện lên messaging.EventsExchange với routing key là loại sự kiện. Mỗi queue đăng ký
+// được hưởng ack thủ công, thử lại có backoff và dead-letter queue của messaging.Client.
+type RabbitMQBus struct {
+	client *messaging.Client
+
+	mu    sync.Mutex
+	bound bool // Đã bind DurableQueues
+}
+
+func NewRabbitMQBus(client *messaging.Client) *RabbitMQBus {
+	return &RabbitMQBus{client: client}
+}
+
+func (b *RabbitMQBus) Publish(ctx context.Context, events ...Event) error {
+	for _, event := range events {
+		env, err := NewEnvelope(event)
+		if err != nil {
+			return err
+		}
+		if err := b.PublishEnvelope(ctx, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *RabbitMQBus) PublishEnvelope(ctx context.Context, env messaging.Envelope) error {
+	if err := b.bindDurableQueues(); err != nil {
+		return err
+	}
+	return b.client.PublishTo(ctx, messaging.EventsExchange, env.Type, env)
+}
+
+func (b *RabbitMQBus) Subscribe(ctx context.Context, queue string, handler Handler, eventTypes ...string) {
+	b.client.Subscribe(ctx, messaging.EventsExchange, queue, eventTypes, messaging.Handler(handler))
+}
+
+// bindDurableQueues bind các queue nghiệp vụ trước lần phát đầu tiên, lỗi thì thử lại ở lần phát sau
+func (b *RabbitMQBus) bindDurableQueues() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.bound {
+		return nil
+	}
+	for queue, eventTypes := range DurableQueues {
+		if err := b.client.Bind(messaging.EventsExchange, queue, eventTypes...); err != nil {
+			return err
+		}
+	}
+	b.bound = true
+	return nil
+}
@@ -0,0 +1,189 @@
+package events
+
+import (
+	"bookstack/internal/messaging"
+	"bookstack/internal/money"
+)
+
+// Loại sự kiện, cũng là routing key trên messaging.EventsExchange
+const (
+	TypeBookPublished       = "book.published"
+	TypeBookUpdated         = "book.updated"
+	TypeBookDeleted         = "book.deleted"
+	TypePageUpdated         = "page.updated"
+	TypeUserRegistered      = "user.registered"
+	TypeOrderCreated        = "order.created"
+	TypeOrderPaid           = "order.paid"
+	TypeOrderShipped        = "order.shipped"
+	TypeOrderDelivered      = "order.delivered"
+	TypeOrderDeliveryFailed = "order.delivery_failed"
+	TypeOrderCancelled      = "order.cancelled"
+	TypeBookStockLow        = "inventory.low_stock"
+)
+
+// BookPublished - Sách mới được tạo hoặc nhập
+type BookPublished struct {
+	BookID     uint   `json:"book_id"`
+	Title      string `json:"title"`
+	Slug       string `json:"slug"`
+	ShelveID   uint   `json:"shelve_id"`
+	Restricted bool   `json:"restricted"`
+	CreatedBy  uint   `json:"created_by"`
+}
+
+// BookUpdated - Thông tin sách (tiêu đề, mô tả, giá, ...) thay đổi
+type BookUpdated struct {
+	BookID    uint   `json:"book_id"`
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
+	UpdatedBy uint   `json:"updated_by"`
+}
+
+// BookDeleted - Sách bị xóa
+type BookDeleted struct {
+	BookID    uint `json:"book_id"`
+	DeletedBy uint `json:"deleted_by"`
+}
+
+// PageUpdated - Nội dung trang thay đổi (sửa hoặc khôi phục phiên bản cũ)
+type PageUpdated struct {
+	PageID    uint   `json:"page_id"`
+	ChapterID uint   `json:"chapter_id"`
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
+	UpdatedBy uint   `json:"updated_by"`
+}
+
+// UserRegistered - Người dùng mới đăng ký tài khoản
+type UserRegistered struct {
+	UserID   uint   `json:"user_id"`
+	Email    string `json:"email"`
+	FullName string `json:"name"`
+}
+
+// OrderCreated - Đơn mới được đặt. Ghi qua outbox cùng transaction tạo đơn nên không bị mất.
+type OrderCreated struct {
+	OrderID uint   `json:"order_id"`
+	Address string `json:"address"`
+}
+
+// OrderPaid - Đơn đã được thanh toán đủ
+type OrderPaid struct {
+	OrderID   uint          `json:"order_id"`
+	UserID    uint          `json:"user_id"`
+	PaymentID uint          `json:"payment_id"`
+	Provider  string        `json:"provider"`
+	Amount    money.Decimal `json:"amount"`
+	Currency  string        `json:"currency"`
+}
+
+// OrderShipped - Shipper đã lấy hàng và đang giao
+type OrderShipped struct {
+	OrderID   uint `json:"order_id"`
+	UserID    uint `json:"user_id"`
+	ShipperID uint `json:"shipper_id,omitempty"`
+}
+
+// OrderDelivered - Đơn đã giao thành công
+type OrderDelivered struct {
+	OrderID   uint `json:"order_id"`
+	UserID    uint `json:"user_id"`
+	ShipperID uint `json:"shipper_id,omitempty"`
+}
+
+// OrderDeliveryFailed - Giao hàng thất bại
+type OrderDeliveryFailed struct {
+	OrderID   uint   `json:"order_id"`
+	UserID    uint   `json:"user_id"`
+	ShipperID uint   `json:"shipper_id,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// OrderCancelled - Đơn bị hủy bởi khách hoặc nhân viên
+type OrderCancelled struct {
+	OrderID uint   `json:"order_id"`
+	UserID  uint   `json:"user_id"`
+	Reason  string `json:"reason"`
+}
+
+// BookStockLow - Số lượng sách có thể bán chạm ngưỡng sắp hết hàng
+type BookStockLow struct {
+	BookID    uint `json:"book_id"`
+	Available int  `json:"available"`
+	Threshold int  `json:"threshold"`
+}
+
+func (BookPublished) EventType() string       { return TypeBookPublished }
+func (BookUpdated) EventType() string         { return TypeBookUpdated }
+func (BookDeleted) EventType() string         { return TypeBookDeleted }
+func (PageUpdated) EventType() string         { return TypePageUpdated }
+func (UserRegistered) EventType() string      { return TypeUserRegistered }
+func (OrderCreated) EventType() string        { return TypeOrderCreated }
+func (OrderPaid) EventType() string           { return TypeOrderPaid }
+func (OrderShipped) EventType() string        { return TypeOrderShipped }
+func (OrderDelivered) EventType() string      { return TypeOrderDelivered }
+func (OrderDeliveryFailed) EventType() string { return TypeOrderDeliveryFailed }
+func (OrderCancelled) EventType() string      { return TypeOrderCancelled }
+func (BookStockLow) EventType() string        { return TypeBookStockLow }
+
+func (BookPublished) EventVersion() int       { return 1 }
+func (BookUpdated) EventVersion() int         { return 1 }
+func (BookDeleted) EventVersion() int         { return 1 }
+func (PageUpdated) EventVersion() int         { return 1 }
+func (UserRegistered) EventVersion() int      { return 1 }
+func (OrderCreated) EventVersion() int        { return 1 }
+func (OrderPaid) EventVersion() int           { return 1 }
+func (OrderShipped) EventVersion() int        { return 1 }
+func (OrderDelivered) EventVersion() int      { return 1 }
+func (OrderDeliveryFailed) EventVersion() int { return 1 }
+func (OrderCancelled) EventVersion() int      { return 1 }
+func (BookStockLow) EventVersion() int        { return 1 }
+
+// Definition mô tả một sự kiện trong danh mục công bố cho bên ngoài
+type Definition struct {
+	Event       Event
+	Description string
+}
+
+// Catalogue là toàn bộ sự kiện được phát, mỗi sự kiện mới phải được thêm vào đây để có JSON Schema
+var Catalogue = []Definition{
+	{BookPublished{}, "A book was created or imported"},
+	{BookUpdated{}, "A book's details (title, description, prices, ...) changed"},
+	{BookDeleted{}, "A book was deleted"},
+	{PageUpdated{}, "A page's content was edited or restored from an earlier revision"},
+	{UserRegistered{}, "A user signed up"},
+	{OrderCreated{}, "An order was placed; delivered at least once through the transactional outbox"},
+	{OrderPaid{}, "An order's payment was captured"},
+	{OrderShipped{}, "The shipper picked the order up and is delivering it"},
+	{OrderDelivered{}, "The order was delivered"},
+	{OrderDeliveryFailed{}, "A delivery attempt failed"},
+	{OrderCancelled{}, "The order was cancelled by the customer or staff"},
+	{BookStockLow{}, "A book's available stock reached its low-stock threshold"},
+}
+
+// Lookup tìm sự kiện trong danh mục theo loại
+func Lookup(eventType string) (Definition, bool) {
+	for _, definition := range Catalogue {
+		if definition.Event.EventType() == eventType {
+			return definition, true
+		}
+	}
+	return Definition{}, false
+}
+
+// DurableQueues là các queue nghiệp vụ luôn được bind vào exchange trước khi phát sự kiện, để sự kiện
+// không bị mất khi consumer chưa chạy
+var DurableQueues = map[string][]string{
+	messaging.QueueNewOrders:      {TypeOrderCreated},
+	messaging.QueueLowStockAlerts: {TypeBookStockLow},
+	messaging.QueueWebhooks:       Types(),
+}
+
+// Types trả về loại của mọi sự kiện trong danh mục
+func Types() []string {
+	types := make([]string, 0, len(Catalogue))
+	for _, definition := range Catalogue {
+		types = append(types, definition.Event.EventType())
+	}
+	return types
+}
@@ -0,0 +1,41 @@
+// Package events là danh mục sự kiện nghiệp vụ (sách, người dùng, đơn hàng) và bus phát sự kiện.
+// Mỗi sự kiện là một struct có loại và phiên bản schema cố định, được bọc trong messaging.Envelope khi gửi đi;
+// JSON Schema của từng sự kiện được sinh từ chính struct để bên ngoài đăng ký nhận.
+package events
+
+import (
+	"bookstack/internal/messaging"
+	"context"
+)
+
+// Event là một sự kiện trong danh mục. Đổi schema payload theo cách không tương thích thì tăng EventVersion.
+type Event interface {
+	EventType() string
+	EventVersion() int
+}
+
+// Handler xử lý một sự kiện nhận từ bus, dùng Decode để đọc payload theo kiểu sự kiện
+type Handler func(ctx context.Context, env messaging.Envelope) error
+
+// EventBus phát sự kiện tới các bên đăng ký
+type EventBus interface {
+	// Publish bọc từng sự kiện trong envelope mới rồi phát đi
+	Publish(ctx context.Context, events ...Event) error
+	// PublishEnvelope phát envelope đã có sẵn, giữ nguyên ID (relay outbox phát lại cùng một sự kiện)
+	PublishEnvelope(ctx context.Context, env messaging.Envelope) error
+	// Subscribe nhận các loại sự kiện eventTypes qua queue cho tới khi ctx bị hủy. Các instance dùng
+	// chung tên queue thì chia nhau xử lý, mỗi sự kiện chỉ tới một instance.
+	Subscribe(ctx context.Context, queue string, handler Handler, eventTypes ...string)
+}
+
+// NewEnvelope bọc sự kiện trong envelope mới
+func NewEnvelope(event Event) (messaging.Envelope, error) {
+	return messaging.NewEnvelope(event.EventType(), event.EventVersion(), event)
+}
+
+// Decode đọc payload của envelope thành sự kiện kiểu T, lỗi khi sai loại hoặc phiên bản
+func Decode[T Event](env messaging.Envelope) (T, error) {
+	var event T
+	err := env.Decode(event.EventType(), event.EventVersion(), &event)
+	return event, err
+}
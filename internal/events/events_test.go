@@ -0,0 +1,87 @@
+package events
+
+import (
+	"bookstack/internal/messaging"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogue(t *testing.T) {
+	seen := map[string]bool{}
+	for _, definition := range Catalogue {
+		eventType := definition.Event.EventType()
+		assert.False(t, seen[eventType], "duplicate event type %s", eventType)
+		seen[eventType] = true
+		assert.NotEmpty(t, definition.Description, eventType)
+
+		found, ok := Lookup(eventType)
+		require.True(t, ok, eventType)
+		assert.Equal(t, definition, found)
+	}
+	for _, eventTypes := range DurableQueues {
+		for _, eventType := range eventTypes {
+			assert.True(t, seen[eventType], "queue bound to unknown event type %s", eventType)
+		}
+	}
+	_, ok := Lookup("book.unknown")
+	assert.False(t, ok)
+}
+
+func TestSchema(t *testing.T) {
+	definition, ok := Lookup(TypeOrderShipped)
+	require.True(t, ok)
+	schema := Schema(definition)
+	assert.Equal(t, "urn:bookstack:event:order.shipped:v1", schema["$id"])
+
+	properties := schema["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"const": TypeOrderShipped}, properties["type"])
+	payload := properties["payload"].(map[string]any)
+	assert.Equal(t, []string{"order_id", "user_id"}, payload["required"])
+	payloadProperties := payload["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "integer", "minimum": 0}, payloadProperties["shipper_id"])
+
+	definition, _ = Lookup(TypeOrderPaid)
+	payload = Schema(definition)["properties"].(map[string]any)["payload"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "number"}, payload["properties"].(map[string]any)["amount"])
+}
+
+func TestDecode(t *testing.T) {
+	env, err := NewEnvelope(OrderCreated{OrderID: 3, Address: "Hoàn Kiếm"})
+	require.NoError(t, err)
+	assert.Equal(t, TypeOrderCreated, env.Type)
+
+	order, err := Decode[OrderCreated](env)
+	require.NoError(t, err)
+	assert.Equal(t, OrderCreated{OrderID: 3, Address: "Hoàn Kiếm"}, order)
+
+	_, err = Decode[BookStockLow](env)
+	assert.ErrorIs(t, err, messaging.ErrInvalidMessage)
+}
+
+func TestInProcessBus(t *testing.T) {
+	bus := NewInProcessBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan string, 4)
+	bus.Subscribe(ctx, "test", func(ctx context.Context, env messaging.Envelope) error {
+		received <- env.Type
+		return nil
+	}, TypeBookPublished, TypeBookDeleted)
+
+	require.NoError(t, bus.Publish(context.Background(), BookPublished{BookID: 1}, BookUpdated{BookID: 1}, BookDeleted{BookID: 1}))
+	assert.Equal(t, TypeBookPublished, <-received)
+	assert.Equal(t, TypeBookDeleted, <-received)
+	assert.Empty(t, received)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		bus.mu.RLock()
+		defer bus.mu.RUnlock()
+		return len(bus.subscriptions[TypeBookPublished]) == 0 && len(bus.subscriptions[TypeBookDeleted]) == 0
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, bus.Publish(context.Background(), BookPublished{BookID: 2}))
+	assert.Empty(t, received)
+}
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"bookstack/internal/money"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	decimalType = reflect.TypeOf(money.Decimal{})
+)
+
+// Schema sinh JSON Schema (draft 2020-12) của envelope chứa sự kiện, payload theo các field JSON của struct sự kiện.
+// Schema không cấm field lạ để bên nhận chấp nhận field được thêm về sau trong cùng phiên bản.
+func Schema(definition Definition) map[string]any {
+	event := definition.Event
+	return map[string]any{
+		"$schema":     schemaDialect,
+		"$id":         "urn:bookstack:event:" + event.EventType() + ":v" + strconv.Itoa(event.EventVersion()),
+		"title":       event.EventType(),
+		"description": definition.Description,
+		"type":        "object",
+		"required":    []string{"id", "type", "version", "occurred_at", "attempt", "payload"},
+		"properties": map[string]any{
+			"id":          map[string]any{"type": "string", "description": "Unique event ID, used to drop redeliveries"},
+			"type":        map[string]any{"const": event.EventType()},
+			"version":     map[string]any{"const": event.EventVersion()},
+			"occurred_at": map[string]any{"type": "string", "format": "date-time"},
+			"attempt":     map[string]any{"type": "integer", "minimum": 0},
+			"payload":     typeSchema(reflect.TypeOf(event)),
+		},
+	}
+}
+
+// typeSchema sinh schema cho kiểu Go theo cách encoding/json mã hóa kiểu đó
+func typeSchema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case decimalType:
+		return map[string]any{"type": "number"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return map[string]any{"anyOf": []any{typeSchema(t.Elem()), map[string]any{"type": "null"}}}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, omitempty, ok := jsonField(field)
+			if !ok {
+				continue
+			}
+			properties[name] = typeSchema(field.Type)
+			if !omitempty {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{"type": "object", "properties": properties, "required": required}
+	}
+	return map[string]any{}
+}
+
+// jsonField đọc tên field và omitempty từ tag json, ok là false với field bị bỏ qua ("-")
+func jsonField(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(","+options+",", ",omitempty,"), true
+}
@@ -0,0 +1,177 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+func chapterFile(chapterIndex int) string {
+	return fmt.Sprintf("chapter-%d.xhtml", chapterIndex+1)
+}
+
+// EPUB xuất tài liệu thành sách điện tử EPUB 3 (kèm toc.ncx để tương thích trình đọc cũ)
+func EPUB(doc Document) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// mimetype phải là file đầu tiên và không được nén
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := mimetype.Write([]byte("application/epub+zip")); err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"META-INF/container.xml", containerXML},
+		{"OEBPS/content.opf", epubPackage(doc)},
+		{"OEBPS/nav.xhtml", epubNav(doc)},
+		{"OEBPS/toc.ncx", epubNCX(doc)},
+		{"OEBPS/style.css", stylesheet},
+	}
+	for i, chapter := range doc.Chapters {
+		content, err := epubChapter(doc, i, chapter)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, struct {
+			name    string
+			content string
+		}{"OEBPS/" + chapterFile(i), content})
+	}
+	for _, file := range files {
+		if err := writeZipFile(zw, file.name, file.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func epubPackage(doc Document) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">` + "\n")
+	b.WriteString(`  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">` + "\n")
+	fmt.Fprintf(&b, "    <dc:identifier id=\"book-id\">%s</dc:identifier>\n", html.EscapeString(doc.Identifier))
+	fmt.Fprintf(&b, "    <dc:title>%s</dc:title>\n", html.EscapeString(doc.Title))
+	fmt.Fprintf(&b, "    <dc:language>%s</dc:language>\n", html.EscapeString(doc.Language))
+	if doc.Author != "" {
+		fmt.Fprintf(&b, "    <dc:creator>%s</dc:creator>\n", html.EscapeString(doc.Author))
+	}
+	if doc.Description != "" {
+		fmt.Fprintf(&b, "    <dc:description>%s</dc:description>\n", html.EscapeString(doc.Description))
+	}
+	for _, tag := range doc.Tags {
+		subject := tag.Name
+		if tag.Value != "" {
+			subject += ": " + tag.Value
+		}
+		fmt.Fprintf(&b, "    <dc:subject>%s</dc:subject>\n", html.EscapeString(subject))
+	}
+	fmt.Fprintf(&b, "    <meta property=\"dcterms:modified\">%s</meta>\n", doc.UpdatedAt.UTC().Format(time.RFC3339))
+	b.WriteString("  </metadata>\n  <manifest>\n")
+	b.WriteString(`    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	b.WriteString(`    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>` + "\n")
+	b.WriteString(`    <item id="style" href="style.css" media-type="text/css"/>` + "\n")
+	for i := range doc.Chapters {
+		fmt.Fprintf(&b, "    <item id=\"chapter-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, chapterFile(i))
+	}
+	b.WriteString("  </manifest>\n  <spine toc=\"ncx\">\n")
+	for i := range doc.Chapters {
+		fmt.Fprintf(&b, "    <itemref idref=\"chapter-%d\"/>\n", i+1)
+	}
+	b.WriteString("  </spine>\n</package>\n")
+	return b.String()
+}
+
+func epubNav(doc Document) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, "<html xmlns=\"http://www.w3.org/1999/xhtml\" xmlns:epub=\"http://www.idpf.org/2007/ops\" xml:lang=\"%s\">\n", html.EscapeString(doc.Language))
+	fmt.Fprintf(&b, "<head><title>%s</title></head>\n<body>\n", html.EscapeString(doc.Title))
+	b.WriteString("<nav epub:type=\"toc\" id=\"toc\">\n<ol>\n")
+	for i, chapter := range doc.Chapters {
+		title := chapter.Title
+		if title == "" {
+			title = doc.Title
+		}
+		fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a>", chapterFile(i), html.EscapeString(title))
+		if len(chapter.Pages) > 0 {
+			b.WriteString("\n<ol>\n")
+			for j, page := range chapter.Pages {
+				fmt.Fprintf(&b, "<li><a href=\"%s#%s\">%s</a></li>\n", chapterFile(i), pageAnchor(i, j), html.EscapeString(page.Title))
+			}
+			b.WriteString("</ol>\n")
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ol>\n</nav>\n</body>\n</html>\n")
+	return b.String()
+}
+
+func epubNCX(doc Document) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">` + "\n")
+	fmt.Fprintf(&b, "<head><meta name=\"dtb:uid\" content=\"%s\"/></head>\n", html.EscapeString(doc.Identifier))
+	fmt.Fprintf(&b, "<docTitle><text>%s</text></docTitle>\n<navMap>\n", html.EscapeString(doc.Title))
+	playOrder := 0
+	for i, chapter := range doc.Chapters {
+		title := chapter.Title
+		if title == "" {
+			title = doc.Title
+		}
+		playOrder++
+		fmt.Fprintf(&b, "<navPoint id=\"nav-%d\" playOrder=\"%d\"><navLabel><text>%s</text></navLabel><content src=\"%s\"/>\n",
+			playOrder, playOrder, html.EscapeString(title), chapterFile(i))
+		for j, page := range chapter.Pages {
+			playOrder++
+			fmt.Fprintf(&b, "<navPoint id=\"nav-%d\" playOrder=\"%d\"><navLabel><text>%s</text></navLabel><content src=\"%s#%s\"/></navPoint>\n",
+				playOrder, playOrder, html.EscapeString(page.Title), chapterFile(i), pageAnchor(i, j))
+		}
+		b.WriteString("</navPoint>\n")
+	}
+	b.WriteString("</navMap>\n</ncx>\n")
+	return b.String()
+}
+
+func epubChapter(doc Document, index int, chapter Chapter) (string, error) {
+	title := chapter.Title
+	if title == "" {
+		title = doc.Title
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, "<html xmlns=\"http://www.w3.org/1999/xhtml\" xml:lang=\"%s\">\n", html.EscapeString(doc.Language))
+	fmt.Fprintf(&b, "<head><title>%s</title><link rel=\"stylesheet\" type=\"text/css\" href=\"style.css\"/></head>\n<body>\n", html.EscapeString(title))
+	fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(title))
+	for j, page := range chapter.Pages {
+		content, err := renderContent(page.Content)
+		if err != nil {
+			return "", fmt.Errorf("failed to render page %q: %w", page.Title, err)
+		}
+		fmt.Fprintf(&b, "<section id=\"%s\">\n<h3>%s</h3>\n%s\n</section>\n", pageAnchor(index, j), html.EscapeString(page.Title), content)
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String(), nil
+}
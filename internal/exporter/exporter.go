@@ -0,0 +1,160 @@
+package exporter
+
+import (
+	"bookstack/utils"
+	"bytes"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/renderer/html"
+	xhtml "golang.org/x/net/html"
+)
+
+const (
+	FormatEPUB     = "epub"
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+	FormatText     = "txt"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Document là cây nội dung cần xuất: sách -> chương -> trang, đã sắp xếp theo Order
+type Document struct {
+	Identifier  string // Định danh duy nhất, dùng cho EPUB
+	Title       string
+	Description string
+	Author      string
+	Language    string
+	Tags        []Tag
+	UpdatedAt   time.Time
+	Chapters    []Chapter
+}
+
+type Tag struct {
+	Name  string
+	Value string
+}
+
+type Chapter struct {
+	Title string
+	Order int
+	Pages []Page
+}
+
+type Page struct {
+	Title   string
+	Order   int
+	Content string // markdown hoặc HTML
+}
+
+// File là kết quả xuất, sẵn sàng trả về cho client
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+type format struct {
+	extension   string
+	contentType string
+	render      func(Document) ([]byte, error)
+}
+
+var formats = map[string]format{
+	FormatEPUB:     {"epub", "application/epub+zip", EPUB},
+	FormatHTML:     {"html", "text/html; charset=utf-8", HTML},
+	FormatMarkdown: {"zip", "application/zip", MarkdownZip},
+	FormatText:     {"txt", "text/plain; charset=utf-8", PlainText},
+}
+
+// Formats trả về tên các định dạng xuất được, sắp xếp theo tên
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Supported cho biết định dạng có xuất được hay không
+func Supported(formatName string) bool {
+	_, ok := formats[formatName]
+	return ok
+}
+
+// Export xuất tài liệu theo định dạng epub, html, markdown hoặc txt
+func Export(doc Document, formatName string) (File, error) {
+	f, ok := formats[formatName]
+	if !ok {
+		return File{}, ErrUnknownFormat
+	}
+	if doc.Language == "" {
+		doc.Language = "vi"
+	}
+	if doc.UpdatedAt.IsZero() {
+		doc.UpdatedAt = time.Now()
+	}
+	data, err := f.render(doc)
+	if err != nil {
+		return File{}, err
+	}
+	name := utils.Slugify(doc.Title)
+	if name == "" {
+		name = "export"
+	}
+	return File{
+		Name:        name + "." + f.extension,
+		ContentType: f.contentType,
+		Data:        data,
+	}, nil
+}
+
+var htmlPattern = regexp.MustCompile(`^\s*<[a-zA-Z!]`)
+
+// isHTML đoán nội dung trang là HTML (bắt đầu bằng thẻ) hay markdown
+func isHTML(content string) bool {
+	return htmlPattern.MatchString(content)
+}
+
+var markdown = goldmark.New(goldmark.WithRendererOptions(html.WithXHTML()))
+
+// renderContent chuyển nội dung trang sang HTML
+func renderContent(content string) (string, error) {
+	if isHTML(content) {
+		return content, nil
+	}
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(content), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// plainContent bỏ các thẻ HTML, giữ lại văn bản
+func plainContent(content string) string {
+	if !isHTML(content) {
+		return strings.TrimSpace(content)
+	}
+	var builder strings.Builder
+	tokenizer := xhtml.NewTokenizer(strings.NewReader(content))
+	for {
+		switch tokenizer.Next() {
+		case xhtml.ErrorToken:
+			return strings.TrimSpace(builder.String())
+		case xhtml.TextToken:
+			builder.Write(tokenizer.Text())
+		case xhtml.StartTagToken, xhtml.EndTagToken, xhtml.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "p", "br", "div", "li", "h1", "h2", "h3", "h4", "h5", "h6", "tr":
+				builder.WriteString("\n")
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleDocument() Document {
+	return Document{
+		Identifier: "urn:bookstack:book:1",
+		Title:      "Lập trình Go",
+		Chapters: []Chapter{
+			{Title: "Chương Một", Order: 1, Pages: []Page{
+				{Title: "Giới thiệu", Order: 1, Content: "# Xin chào\n\nNội dung **đầu tiên**"},
+				{Title: "Cài đặt", Order: 2, Content: "<p>Tải Go &amp; cài đặt</p>"},
+			}},
+		},
+	}
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for i, file := range reader.File {
+		if i == 0 {
+			assert.Equal(t, "mimetype", file.Name)
+		}
+		rc, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[file.Name] = string(content)
+	}
+	return files
+}
+
+func TestExportEPUB(t *testing.T) {
+	file, err := Export(sampleDocument(), FormatEPUB)
+	require.NoError(t, err)
+	assert.Equal(t, "lap-trinh-go.epub", file.Name)
+
+	files := readZip(t, file.Data)
+	assert.Equal(t, "application/epub+zip", files["mimetype"])
+	assert.Contains(t, files, "META-INF/container.xml")
+	assert.Contains(t, files["OEBPS/content.opf"], "<dc:language>vi</dc:language>")
+	assert.Contains(t, files["OEBPS/nav.xhtml"], `href="chapter-1.xhtml#page-1-2"`)
+	assert.Contains(t, files["OEBPS/chapter-1.xhtml"], "<strong>đầu tiên</strong>")
+}
+
+func TestExportMarkdownZip(t *testing.T) {
+	file, err := Export(sampleDocument(), FormatMarkdown)
+	require.NoError(t, err)
+
+	reader, err := zip.NewReader(bytes.NewReader(file.Data), int64(len(file.Data)))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range reader.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"README.md", "01-chuong-mot/README.md", "01-chuong-mot/01-gioi-thieu.md", "01-chuong-mot/02-cai-dat.md"}, names)
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	_, err := Export(sampleDocument(), "pdf")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestPlainContent(t *testing.T) {
+	assert.Equal(t, "Tải Go & cài đặt", plainContent("<p>Tải Go &amp; cài đặt</p>"))
+	assert.Equal(t, "**đậm**", plainContent("  **đậm**  "))
+}
@@ -0,0 +1,79 @@
+package exporter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+const stylesheet = `body { font-family: Georgia, "Times New Roman", serif; line-height: 1.6; color: #222; max-width: 48em; margin: 0 auto; padding: 2em 1em; }
+h1, h2, h3 { font-family: "Helvetica Neue", Arial, sans-serif; line-height: 1.25; }
+h1.book-title { text-align: center; margin-bottom: 0.25em; }
+p.book-description { text-align: center; color: #555; font-style: italic; }
+nav.toc { border: 1px solid #ddd; padding: 1em 1.5em; margin: 2em 0; }
+nav.toc ol { padding-left: 1.25em; }
+section.chapter { page-break-before: always; margin-top: 3em; }
+article.page { margin-top: 2em; }
+pre, code { font-family: Menlo, Consolas, monospace; background: #f6f8fa; }
+pre { padding: 0.75em; overflow-x: auto; }
+img { max-width: 100%; }
+blockquote { border-left: 4px solid #ddd; margin-left: 0; padding-left: 1em; color: #555; }
+`
+
+func chapterAnchor(chapterIndex int) string {
+	return fmt.Sprintf("chapter-%d", chapterIndex+1)
+}
+
+func pageAnchor(chapterIndex, pageIndex int) string {
+	return fmt.Sprintf("page-%d-%d", chapterIndex+1, pageIndex+1)
+}
+
+// HTML xuất tài liệu thành một file HTML duy nhất, CSS được nhúng sẵn
+func HTML(doc Document) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n")
+	fmt.Fprintf(&b, "<html lang=\"%s\">\n<head>\n<meta charset=\"utf-8\">\n", html.EscapeString(doc.Language))
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(doc.Title))
+	if doc.Author != "" {
+		fmt.Fprintf(&b, "<meta name=\"author\" content=\"%s\">\n", html.EscapeString(doc.Author))
+	}
+	fmt.Fprintf(&b, "<style>\n%s</style>\n</head>\n<body>\n", stylesheet)
+
+	fmt.Fprintf(&b, "<h1 class=\"book-title\">%s</h1>\n", html.EscapeString(doc.Title))
+	if doc.Description != "" {
+		fmt.Fprintf(&b, "<p class=\"book-description\">%s</p>\n", html.EscapeString(doc.Description))
+	}
+
+	// Mục lục
+	b.WriteString("<nav class=\"toc\">\n<ol>\n")
+	for i, chapter := range doc.Chapters {
+		if chapter.Title != "" {
+			fmt.Fprintf(&b, "<li><a href=\"#%s\">%s</a>\n<ol>\n", chapterAnchor(i), html.EscapeString(chapter.Title))
+		}
+		for j, page := range chapter.Pages {
+			fmt.Fprintf(&b, "<li><a href=\"#%s\">%s</a></li>\n", pageAnchor(i, j), html.EscapeString(page.Title))
+		}
+		if chapter.Title != "" {
+			b.WriteString("</ol>\n</li>\n")
+		}
+	}
+	b.WriteString("</ol>\n</nav>\n")
+
+	for i, chapter := range doc.Chapters {
+		fmt.Fprintf(&b, "<section class=\"chapter\" id=\"%s\">\n", chapterAnchor(i))
+		if chapter.Title != "" {
+			fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(chapter.Title))
+		}
+		for j, page := range chapter.Pages {
+			content, err := renderContent(page.Content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render page %q: %w", page.Title, err)
+			}
+			fmt.Fprintf(&b, "<article class=\"page\" id=\"%s\">\n<h3>%s</h3>\n%s\n</article>\n",
+				pageAnchor(i, j), html.EscapeString(page.Title), content)
+		}
+		b.WriteString("</section>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,101 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bookstack/utils"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MarkdownZip xuất tài liệu thành file zip: mỗi chương là một thư mục, mỗi trang là một file .md.
+// Thứ tự được giữ bằng tiền tố số và front-matter, README.md chứa thông tin của sách hoặc chương.
+func MarkdownZip(doc Document) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	readme := frontMatter([][2]string{{"title", doc.Title}, {"description", doc.Description}}, doc.Tags)
+	readme += "# " + doc.Title + "\n"
+	if doc.Description != "" {
+		readme += "\n" + doc.Description + "\n"
+	}
+	if err := writeZipFile(zw, "README.md", readme); err != nil {
+		return nil, err
+	}
+
+	for i, chapter := range doc.Chapters {
+		dir := ""
+		if chapter.Title != "" {
+			dir = fmt.Sprintf("%02d-%s/", i+1, slugOr(chapter.Title, "chapter"))
+			// Tên thư mục đã bỏ dấu, giữ tiêu đề gốc của chương trong README.md
+			meta := frontMatter([][2]string{
+				{"title", chapter.Title},
+				{"order", fmt.Sprint(chapter.Order)},
+			}, nil)
+			if err := writeZipFile(zw, dir+"README.md", meta); err != nil {
+				return nil, err
+			}
+		}
+		for j, page := range chapter.Pages {
+			name := fmt.Sprintf("%s%02d-%s.md", dir, j+1, slugOr(page.Title, "page"))
+			body := frontMatter([][2]string{
+				{"title", page.Title},
+				{"order", fmt.Sprint(page.Order)},
+			}, nil)
+			body += "# " + page.Title + "\n\n" + strings.TrimSpace(page.Content) + "\n"
+			if err := writeZipFile(zw, name, body); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func slugOr(title string, fallback string) string {
+	if slug := utils.Slugify(title); slug != "" {
+		return slug
+	}
+	return fallback
+}
+
+// frontMatter tạo phần YAML đầu file markdown
+func frontMatter(fields [][2]string, tags []Tag) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(field[1]); err == nil {
+			fmt.Fprintf(&b, "%s: %s\n", field[0], field[1])
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %q\n", field[0], field[1])
+	}
+	if len(tags) > 0 {
+		b.WriteString("tags:\n")
+		for _, tag := range tags {
+			if tag.Value != "" {
+				fmt.Fprintf(&b, "  - %q\n", tag.Name+":"+tag.Value)
+			} else {
+				fmt.Fprintf(&b, "  - %q\n", tag.Name)
+			}
+		}
+	}
+	b.WriteString("---\n\n")
+	return b.String()
+}
+
+func writeZipFile(zw *zip.Writer, name string, content string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write([]byte(content))
+	return err
+}
@@ -0,0 +1,28 @@
+package exporter
+
+import (
+	"strings"
+)
+
+// PlainText xuất tài liệu thành văn bản thuần
+func PlainText(doc Document) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(doc.Title) + "\n")
+	b.WriteString(strings.Repeat("=", len([]rune(doc.Title))) + "\n\n")
+	if doc.Description != "" {
+		b.WriteString(doc.Description + "\n\n")
+	}
+	for _, chapter := range doc.Chapters {
+		if chapter.Title != "" {
+			b.WriteString("\n" + chapter.Title + "\n")
+			b.WriteString(strings.Repeat("-", len([]rune(chapter.Title))) + "\n\n")
+		}
+		for _, page := range chapter.Pages {
+			b.WriteString(page.Title + "\n\n")
+			if content := plainContent(page.Content); content != "" {
+				b.WriteString(content + "\n\n")
+			}
+		}
+	}
+	return []byte(b.String()), nil
+}
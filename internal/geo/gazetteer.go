@@ -0,0 +1,245 @@
+package geo
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Mức chính xác của tọa độ địa chỉ giao
+const (
+	PrecisionExact    = "exact"    // Khách gửi kèm tọa độ
+	PrecisionWard     = "ward"     // Tâm phường/xã
+	PrecisionDistrict = "district" // Tâm quận/huyện
+	PrecisionProvince = "province" // Tâm tỉnh/thành phố
+)
+
+var ErrInvalidGazetteer = errors.New("invalid gazetteer file")
+
+// Gazetteer mặc định, chỉ gồm các quận trung tâm của vài thành phố lớn. Môi trường thật nên cấu hình
+// GAZETTEER_FILE trỏ tới danh mục hành chính đầy đủ cùng định dạng.
+//
+//go:embed gazetteer.csv
+var defaultGazetteer string
+
+// administrativePrefixes là tiền tố đơn vị hành chính (đã bỏ dấu) được bỏ qua khi so khớp tên,
+// để "Quận Cầu Giấy" và "Cầu Giấy" là một
+var administrativePrefixes = []string{
+	"thanh pho ", "tp ", "tinh ", "quan ", "huyen ", "thi xa ", "phuong ", "xa ", "thi tran ",
+}
+
+// Place là một địa danh trong gazetteer: tỉnh, quận thuộc tỉnh hoặc phường thuộc quận, kèm tọa độ tâm
+type Place struct {
+	Province string
+	District string
+	Ward     string
+	Point    Point
+}
+
+// Precision là mức chính xác khi dùng tâm địa danh làm tọa độ địa chỉ
+func (p Place) Precision() string {
+	switch {
+	case p.Ward != "":
+		return PrecisionWard
+	case p.District != "":
+		return PrecisionDistrict
+	}
+	return PrecisionProvince
+}
+
+type indexedPlace struct {
+	Place
+	level int       // 1 tỉnh, 2 quận, 3 phường
+	names [3]string // Tên đã chuẩn hóa và bỏ tiền tố của từng cấp
+	terms [3]string // Tên đã chuẩn hóa giữ tiền tố, dùng khi dò trong địa chỉ dạng chữ
+}
+
+// Gazetteer tra tọa độ địa chỉ theo danh mục địa danh nạp sẵn trong bộ nhớ
+type Gazetteer struct {
+	places []indexedPlace
+}
+
+func NewGazetteer(places []Place) *Gazetteer {
+	g := &Gazetteer{}
+	for _, place := range places {
+		indexed := indexedPlace{Place: place}
+		for i, name := range []string{place.Province, place.District, place.Ward} {
+			indexed.terms[i] = normalizeName(name)
+			indexed.names[i] = stripPrefix(indexed.terms[i])
+			if name != "" {
+				indexed.level = i + 1
+			}
+		}
+		g.places = append(g.places, indexed)
+	}
+	return g
+}
+
+// ParseGazetteer đọc gazetteer dạng CSV có dòng tiêu đề: province,district,ward,lat,lng.
+// Dòng cấp tỉnh để trống district và ward, dòng cấp quận để trống ward.
+func ParseGazetteer(r io.Reader) (*Gazetteer, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 5
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGazetteer, err)
+	}
+	var places []Place
+	for i, record := range records {
+		if i == 0 {
+			continue
+		}
+		lat, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: invalid lat %q", ErrInvalidGazetteer, i+1, record[3])
+		}
+		lng, err := strconv.ParseFloat(record[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: invalid lng %q", ErrInvalidGazetteer, i+1, record[4])
+		}
+		place := Place{Province: record[0], District: record[1], Ward: record[2], Point: Point{Lat: lat, Lng: lng}}
+		if place.Province == "" || (place.Ward != "" && place.District == "") || !place.Point.Valid() {
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidGazetteer, i+1)
+		}
+		places = append(places, place)
+	}
+	return NewGazetteer(places), nil
+}
+
+// LoadGazetteer nạp gazetteer từ file CSV
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseGazetteer(file)
+}
+
+// DefaultGazetteer nạp gazetteer mặc định đi kèm mã nguồn
+func DefaultGazetteer() *Gazetteer {
+	g, err := ParseGazetteer(strings.NewReader(defaultGazetteer))
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// Geocode tìm địa danh cụ thể nhất khớp địa chỉ có cấu trúc. Các cấp khớp theo tên đã bỏ dấu và tiền tố
+// hành chính; để trống tỉnh thì quận/phường được tìm trên mọi tỉnh.
+func (g *Gazetteer) Geocode(province, district, ward string) (Place, bool) {
+	query := [3]string{
+		stripPrefix(normalizeName(province)),
+		stripPrefix(normalizeName(district)),
+		stripPrefix(normalizeName(ward)),
+	}
+	if query == [3]string{} {
+		return Place{}, false
+	}
+	var best *indexedPlace
+	for i := range g.places {
+		place := &g.places[i]
+		if query[place.level-1] == "" {
+			continue
+		}
+		matched := true
+		for level := 0; level < place.level; level++ {
+			if level == 0 && query[0] == "" {
+				continue
+			}
+			if place.names[level] != query[level] {
+				matched = false
+				break
+			}
+		}
+		if matched && (best == nil || place.level > best.level) {
+			best = place
+		}
+	}
+	if best == nil {
+		return Place{}, false
+	}
+	return best.Place, true
+}
+
+// GeocodeText dò tên địa danh trong địa chỉ dạng chữ ("12 Trần Thái Tông, Cầu Giấy, Hà Nội").
+// Địa danh cụ thể hơn được ưu tiên; cùng cấp thì địa danh khớp thêm nhiều cấp cha hơn đứng trước.
+func (g *Gazetteer) GeocodeText(text string) (Place, bool) {
+	haystack := " " + normalizeName(text) + " "
+	var best *indexedPlace
+	bestScore := 0
+	for i := range g.places {
+		place := &g.places[i]
+		if !place.mentionedIn(haystack, place.level-1) {
+			continue
+		}
+		score := place.level * 10
+		for level := 0; level < place.level-1; level++ {
+			if place.mentionedIn(haystack, level) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = place, score
+		}
+	}
+	if best == nil {
+		return Place{}, false
+	}
+	return best.Place, true
+}
+
+// mentionedIn kiểm tra tên cấp level xuất hiện như một cụm từ trong haystack. Tên chỉ gồm số ("Quận 1")
+// phải đi kèm tiền tố để không nhầm với số nhà.
+func (p *indexedPlace) mentionedIn(haystack string, level int) bool {
+	if strings.Contains(haystack, " "+p.terms[level]+" ") {
+		return true
+	}
+	name := p.names[level]
+	return name != p.terms[level] && !numeric(name) && strings.Contains(haystack, " "+name+" ")
+}
+
+// normalizeName bỏ dấu tiếng Việt, chữ thường và thay dấu câu bằng khoảng trắng ("Q. Cầu Giấy," -> "q cau giay")
+func normalizeName(s string) string {
+	var builder strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ':
+			builder.WriteRune('d')
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(r)
+		default:
+			builder.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+func stripPrefix(name string) string {
+	for _, prefix := range administrativePrefixes {
+		if rest := strings.TrimPrefix(name, prefix); rest != name && rest != "" {
+			return rest
+		}
+	}
+	return name
+}
+
+func numeric(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return s != ""
+}
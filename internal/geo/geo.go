@@ -0,0 +1,134 @@
+// Package geo tính khoảng cách, vùng phục vụ của shipper và tra tọa độ địa chỉ (geocoding)
+// từ gazetteer cục bộ, không cần gọi dịch vụ bản đồ qua mạng.
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const earthRadiusKm = 6371.0
+
+var ErrInvalidServiceArea = errors.New("invalid service area")
+
+// Point là một tọa độ theo độ (WGS84)
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Valid cho biết vĩ độ, kinh độ nằm trong miền hợp lệ
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// Distance tính khoảng cách đường chim bay (công thức haversine) giữa hai điểm, đơn vị km
+func Distance(a, b Point) float64 {
+	lat1 := radians(a.Lat)
+	lat2 := radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBox trả về góc tây nam và đông bắc của khung bao hình tròn tâm center bán kính radiusKm,
+// dùng để lọc sơ bộ trong database trước khi tính khoảng cách chính xác
+func BoundingBox(center Point, radiusKm float64) (Point, Point) {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	dLng := 180.0
+	if cos := math.Cos(radians(center.Lat)); cos > 1e-9 {
+		dLng = math.Min(180, dLat/cos)
+	}
+	return Point{Lat: center.Lat - dLat, Lng: center.Lng - dLng}, Point{Lat: center.Lat + dLat, Lng: center.Lng + dLng}
+}
+
+// Polygon là đa giác theo thứ tự đỉnh, đỉnh cuối tự nối về đỉnh đầu
+type Polygon []Point
+
+// Contains kiểm tra điểm nằm trong đa giác (thuật toán tia, coi kinh/vĩ độ như mặt phẳng - đủ chính xác
+// với vùng cỡ quận/thành phố)
+func (poly Polygon) Contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Centroid là trung bình các đỉnh, dùng làm tâm của vùng đa giác
+func (poly Polygon) Centroid() Point {
+	var c Point
+	if len(poly) == 0 {
+		return c
+	}
+	for _, p := range poly {
+		c.Lat += p.Lat
+		c.Lng += p.Lng
+	}
+	c.Lat /= float64(len(poly))
+	c.Lng /= float64(len(poly))
+	return c
+}
+
+// ServiceArea là vùng phục vụ của shipper: đa giác (khi có từ 3 đỉnh) hoặc hình tròn tâm Center bán kính RadiusKm
+type ServiceArea struct {
+	Center   Point   `json:"center"`
+	RadiusKm float64 `json:"radius_km"`
+	Polygon  Polygon `json:"polygon"`
+}
+
+// IsPolygon cho biết vùng được mô tả bằng đa giác thay vì bán kính
+func (a ServiceArea) IsPolygon() bool {
+	return len(a.Polygon) >= 3
+}
+
+// Validate kiểm tra vùng có đúng một cách mô tả hợp lệ
+func (a ServiceArea) Validate() error {
+	if len(a.Polygon) > 0 {
+		if !a.IsPolygon() {
+			return fmt.Errorf("%w: polygon needs at least 3 points", ErrInvalidServiceArea)
+		}
+		if a.RadiusKm != 0 {
+			return fmt.Errorf("%w: use either a polygon or a radius", ErrInvalidServiceArea)
+		}
+		for _, p := range a.Polygon {
+			if !p.Valid() {
+				return fmt.Errorf("%w: polygon point out of range", ErrInvalidServiceArea)
+			}
+		}
+		return nil
+	}
+	if a.RadiusKm <= 0 {
+		return fmt.Errorf("%w: radius must be positive", ErrInvalidServiceArea)
+	}
+	if !a.Center.Valid() {
+		return fmt.Errorf("%w: center out of range", ErrInvalidServiceArea)
+	}
+	return nil
+}
+
+// Origin là tâm của vùng: tâm hình tròn hoặc trọng tâm đa giác
+func (a ServiceArea) Origin() Point {
+	if a.IsPolygon() {
+		return a.Polygon.Centroid()
+	}
+	return a.Center
+}
+
+// Covers kiểm tra điểm nằm trong vùng phục vụ
+func (a ServiceArea) Covers(p Point) bool {
+	if a.IsPolygon() {
+		return a.Polygon.Contains(p)
+	}
+	return a.RadiusKm > 0 && Distance(a.Center, p) <= a.RadiusKm
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
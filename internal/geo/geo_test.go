@@ -0,0 +1,97 @@
+package geo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	hoanKiem = Point{Lat: 21.0288, Lng: 105.8525}
+	cauGiay  = Point{Lat: 21.0362, Lng: 105.7906}
+)
+
+func TestDistance(t *testing.T) {
+	assert.InDelta(t, 6.5, Distance(hoanKiem, cauGiay), 0.2)
+	assert.InDelta(t, 1137, Distance(hoanKiem, Point{Lat: 10.7769, Lng: 106.7009}), 10)
+	assert.Zero(t, Distance(cauGiay, cauGiay))
+
+	southWest, northEast := BoundingBox(cauGiay, 10)
+	assert.Less(t, southWest.Lat, cauGiay.Lat)
+	assert.Greater(t, northEast.Lng, cauGiay.Lng)
+	assert.InDelta(t, 10, Distance(cauGiay, Point{Lat: northEast.Lat, Lng: cauGiay.Lng}), 0.01)
+}
+
+func TestServiceArea(t *testing.T) {
+	radius := ServiceArea{Center: cauGiay, RadiusKm: 5}
+	assert.NoError(t, radius.Validate())
+	assert.True(t, radius.Covers(Point{Lat: 21.0352, Lng: 105.7946}))
+	assert.False(t, radius.Covers(hoanKiem))
+
+	square := ServiceArea{Polygon: Polygon{
+		{Lat: 21.0, Lng: 105.8}, {Lat: 21.0, Lng: 105.9}, {Lat: 21.1, Lng: 105.9}, {Lat: 21.1, Lng: 105.8},
+	}}
+	assert.NoError(t, square.Validate())
+	assert.True(t, square.Covers(hoanKiem))
+	assert.False(t, square.Covers(cauGiay))
+	assert.InDelta(t, 21.05, square.Origin().Lat, 1e-9)
+
+	assert.ErrorIs(t, ServiceArea{Center: cauGiay}.Validate(), ErrInvalidServiceArea)
+	assert.ErrorIs(t, ServiceArea{Polygon: square.Polygon[:2]}.Validate(), ErrInvalidServiceArea)
+	assert.ErrorIs(t, ServiceArea{Polygon: square.Polygon, RadiusKm: 1}.Validate(), ErrInvalidServiceArea)
+	assert.ErrorIs(t, ServiceArea{Center: Point{Lat: 91}, RadiusKm: 1}.Validate(), ErrInvalidServiceArea)
+}
+
+func TestGeocode(t *testing.T) {
+	g := DefaultGazetteer()
+
+	place, ok := g.Geocode("Hà Nội", "Quận Cầu Giấy", "Dịch Vọng Hậu")
+	require.True(t, ok)
+	assert.Equal(t, "Phường Dịch Vọng Hậu", place.Ward)
+	assert.Equal(t, PrecisionWard, place.Precision())
+
+	// Phường không có trong gazetteer thì lùi về tâm quận
+	place, ok = g.Geocode("ha noi", "cau giay", "Mai Dịch")
+	require.True(t, ok)
+	assert.Equal(t, PrecisionDistrict, place.Precision())
+	assert.Equal(t, "Quận Cầu Giấy", place.District)
+
+	place, ok = g.Geocode("", "Quận 1", "")
+	require.True(t, ok)
+	assert.Equal(t, "Thành phố Hồ Chí Minh", place.Province)
+
+	_, ok = g.Geocode("", "Không Tồn Tại", "")
+	assert.False(t, ok)
+	_, ok = g.Geocode("Đà Lạt", "Quận 1", "")
+	assert.False(t, ok)
+}
+
+func TestGeocodeText(t *testing.T) {
+	g := DefaultGazetteer()
+
+	place, ok := g.GeocodeText("12 Trần Thái Tông, Cầu Giấy, Hà Nội")
+	require.True(t, ok)
+	assert.Equal(t, "Quận Cầu Giấy", place.District)
+	assert.Equal(t, PrecisionDistrict, place.Precision())
+
+	place, ok = g.GeocodeText("1 Lê Duẩn, Phường Bến Nghé, Quận 1, TP. Hồ Chí Minh")
+	require.True(t, ok)
+	assert.Equal(t, "Phường Bến Nghé", place.Ward)
+
+	// Số nhà không được nhầm với "Quận 1"
+	place, ok = g.GeocodeText("1 Nguyễn Huệ, Hồ Chí Minh")
+	require.True(t, ok)
+	assert.Equal(t, PrecisionProvince, place.Precision())
+
+	_, ok = g.GeocodeText("somewhere else")
+	assert.False(t, ok)
+}
+
+func TestParseGazetteer(t *testing.T) {
+	_, err := ParseGazetteer(strings.NewReader("province,district,ward,lat,lng\nHà Nội,,,abc,105\n"))
+	assert.ErrorIs(t, err, ErrInvalidGazetteer)
+	_, err = ParseGazetteer(strings.NewReader("province,district,ward,lat,lng\nHà Nội,,Dịch Vọng,21,105\n"))
+	assert.ErrorIs(t, err, ErrInvalidGazetteer)
+}
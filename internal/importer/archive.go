@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Giới hạn dung lượng giải nén để file zip/EPUB nén rất cao (zip bomb) không làm cạn bộ nhớ. Giới hạn của
+// file tải lên chỉ tính trên dữ liệu đã nén.
+var (
+	maxEntrySize   int64 = 16 << 20  // mỗi file trong archive
+	maxArchiveSize int64 = 256 << 20 // tổng các file đọc ra từ một archive
+)
+
+var ErrArchiveTooLarge = errors.New("archive is too large when decompressed")
+
+// archiveReader đọc các file trong zip và đếm dung lượng đã giải nén trong một lần nhập
+type archiveReader struct {
+	remaining int64
+}
+
+func newArchiveReader() *archiveReader {
+	return &archiveReader{remaining: maxArchiveSize}
+}
+
+// read giải nén một file trong archive. Kích thước trong header do người gửi khai báo nên vẫn đọc qua
+// io.LimitReader và dừng ngay khi vượt giới hạn.
+func (a *archiveReader) read(file *zip.File) ([]byte, error) {
+	if file.UncompressedSize64 > uint64(maxEntrySize) {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrArchiveTooLarge, file.Name, maxEntrySize)
+	}
+	if file.UncompressedSize64 > uint64(a.remaining) {
+		return nil, fmt.Errorf("%w: more than %d bytes in total", ErrArchiveTooLarge, maxArchiveSize)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(io.LimitReader(rc, maxEntrySize+1))
+	if errors.Is(err, zip.ErrFormat) {
+		// archive/zip báo ErrFormat khi dữ liệu giải nén dài hơn kích thước khai báo trong header
+		return nil, fmt.Errorf("%w: %s decompresses to more than its declared %d bytes", ErrArchiveTooLarge, file.Name, file.UncompressedSize64)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxEntrySize {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrArchiveTooLarge, file.Name, maxEntrySize)
+	}
+	a.remaining -= int64(len(content))
+	if a.remaining < 0 {
+		return nil, fmt.Errorf("%w: more than %d bytes in total", ErrArchiveTooLarge, maxArchiveSize)
+	}
+	return content, nil
+}
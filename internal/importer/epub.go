@@ -0,0 +1,163 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Metadata struct {
+		Title       []string `xml:"title"`
+		Description []string `xml:"description"`
+		Subjects    []string `xml:"subject"`
+	} `xml:"metadata"`
+	Manifest []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+func parseEPUB(result *Result, data []byte) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("invalid epub: %w", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+	archive := newArchiveReader()
+
+	var container epubContainer
+	if err := readXML(archive, files, "META-INF/container.xml", &container); err != nil {
+		return fmt.Errorf("invalid epub: %w", err)
+	}
+	if len(container.Rootfiles) == 0 {
+		return fmt.Errorf("invalid epub: no rootfile in container.xml")
+	}
+	opfPath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := readXML(archive, files, opfPath, &pkg); err != nil {
+		return fmt.Errorf("invalid epub: %w", err)
+	}
+
+	book := &result.Book
+	if len(pkg.Metadata.Title) > 0 {
+		book.Title = strings.TrimSpace(pkg.Metadata.Title[0])
+	}
+	if len(pkg.Metadata.Description) > 0 {
+		book.Description = strings.TrimSpace(pkg.Metadata.Description[0])
+	}
+	for _, subject := range pkg.Metadata.Subjects {
+		if tag, ok := parseTag(subject); ok {
+			book.Tags = append(book.Tags, tag)
+		}
+	}
+
+	manifest := make(map[string]int)
+	for i, item := range pkg.Manifest {
+		manifest[item.ID] = i
+	}
+	for _, itemRef := range pkg.Spine {
+		index, ok := manifest[itemRef.IDRef]
+		if !ok {
+			result.warn(opfPath, "spine item %q not found in manifest", itemRef.IDRef)
+			continue
+		}
+		item := pkg.Manifest[index]
+		if strings.Contains(item.Properties, "nav") {
+			continue
+		}
+		href, err := url.PathUnescape(item.Href)
+		if err != nil {
+			href = item.Href
+		}
+		name := resolvePath(opfPath, href)
+		content, err := readFile(archive, files, name)
+		if errors.Is(err, ErrArchiveTooLarge) {
+			return err
+		}
+		if err != nil {
+			result.warn(name, "skipped: %v", err)
+			continue
+		}
+		doc, err := html.Parse(bytes.NewReader(content))
+		if err != nil {
+			result.warn(name, "skipped: invalid xhtml: %v", err)
+			continue
+		}
+
+		chapter, ok := epubChapter(doc)
+		if !ok {
+			result.warn(name, "skipped: document has no content")
+			continue
+		}
+		if chapter.Title == "" {
+			chapter.Title = titleFromFileName(name)
+		}
+		chapter.Order = len(book.Chapters) + 1
+		book.Chapters = append(book.Chapters, chapter)
+	}
+	return nil
+}
+
+// epubChapter biến một file nội dung trong spine thành một chương:
+// heading đầu tiên là tên chương, các heading cấp thấp hơn tiếp theo chia trang
+func epubChapter(doc *html.Node) (Chapter, bool) {
+	blocks := bodyBlocks(doc)
+	if len(blocks) == 0 {
+		return Chapter{}, false
+	}
+	title := documentTitle(doc)
+	if blocks[0].level > 0 {
+		title = blocks[0].title
+		blocks = blocks[1:]
+	}
+	levels := distinctLevels(blocks)
+	if len(levels) == 0 {
+		content := renderBlocks(blocks)
+		if content == "" {
+			return Chapter{}, false
+		}
+		return Chapter{Title: title, Pages: []Page{{Title: title, Order: 1, Content: content}}}, true
+	}
+	chapter := buildSectionChapter(title, blocks, levels[0])
+	return chapter, len(chapter.Pages) > 0
+}
+
+func readFile(archive *archiveReader, files map[string]*zip.File, name string) ([]byte, error) {
+	file, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("missing %s", name)
+	}
+	return archive.read(file)
+}
+
+func readXML(archive *archiveReader, files map[string]*zip.File, name string, target interface{}) error {
+	content, err := readFile(archive, files, name)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(content, target); err != nil {
+		return fmt.Errorf("cant parse %s: %w", name, err)
+	}
+	return nil
+}
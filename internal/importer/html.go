@@ -0,0 +1,251 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// block là một phần tử cấp khối trong body: heading (level 1-6) hoặc nội dung (level 0)
+type block struct {
+	level int
+	title string
+	html  string
+}
+
+type section struct {
+	title  string
+	blocks []block
+}
+
+var headingLevels = map[atom.Atom]int{atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6}
+
+// Các thẻ bao ngoài được mở ra nếu bên trong có heading (ví dụ <section><h3>..</h3>..</section>)
+var wrapperTags = map[atom.Atom]bool{atom.Section: true, atom.Div: true, atom.Article: true, atom.Main: true, atom.Header: true}
+
+func parseHTML(result *Result, fileName string, data []byte) error {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid html: %w", err)
+	}
+	blocks := bodyBlocks(doc)
+	book := &result.Book
+	book.Title = documentTitle(doc)
+
+	// Một h1 duy nhất ở đầu là tiêu đề sách
+	if len(blocks) > 0 && blocks[0].level == 1 && countLevel(blocks, 1) == 1 {
+		book.Title = blocks[0].title
+		blocks = blocks[1:]
+	}
+	if book.Title == "" {
+		book.Title = titleFromFileName(fileName)
+	}
+
+	levels := distinctLevels(blocks)
+	switch len(levels) {
+	case 0:
+		result.warn(fileName, "no headings found, imported as a single page")
+		book.Chapters = []Chapter{{Title: book.Title, Order: 1, Pages: []Page{{
+			Title: book.Title, Order: 1, Content: renderBlocks(blocks),
+		}}}}
+		return nil
+	case 1:
+		// Chỉ có một cấp heading: mỗi heading là một trang trong chương mang tên sách
+		preamble, pages := splitSections(blocks, levels[0])
+		book.Description = plainText(preamble)
+		chapter := Chapter{Title: book.Title, Order: 1}
+		for i, page := range pages {
+			chapter.Pages = append(chapter.Pages, Page{Title: page.title, Order: i + 1, Content: renderBlocks(page.blocks)})
+		}
+		book.Chapters = []Chapter{chapter}
+		return nil
+	}
+
+	preamble, chapters := splitSections(blocks, levels[0])
+	book.Description = plainText(preamble)
+	for i, chapter := range chapters {
+		built := buildSectionChapter(chapter.title, chapter.blocks, levels[1])
+		built.Order = i + 1
+		if len(built.Pages) == 0 {
+			result.warn(fileName, "chapter %q has no content", chapter.title)
+		}
+		book.Chapters = append(book.Chapters, built)
+	}
+	return nil
+}
+
+// buildSectionChapter chia nội dung một chương thành các trang theo heading cấp pageLevel;
+// nội dung đứng trước heading đầu tiên thành trang mở đầu mang tên chương
+func buildSectionChapter(title string, blocks []block, pageLevel int) Chapter {
+	chapter := Chapter{Title: title}
+	preamble, pages := splitSections(blocks, pageLevel)
+	if content := renderBlocks(preamble); content != "" {
+		chapter.Pages = append(chapter.Pages, Page{Title: title, Content: content})
+	}
+	for _, page := range pages {
+		chapter.Pages = append(chapter.Pages, Page{Title: page.title, Content: renderBlocks(page.blocks)})
+	}
+	for i := range chapter.Pages {
+		chapter.Pages[i].Order = i + 1
+	}
+	return chapter
+}
+
+// splitSections cắt danh sách block tại các heading có cấp <= level
+func splitSections(blocks []block, level int) ([]block, []section) {
+	var preamble []block
+	var sections []section
+	for _, b := range blocks {
+		if b.level > 0 && b.level <= level {
+			sections = append(sections, section{title: b.title})
+			continue
+		}
+		if len(sections) == 0 {
+			preamble = append(preamble, b)
+		} else {
+			sections[len(sections)-1].blocks = append(sections[len(sections)-1].blocks, b)
+		}
+	}
+	return preamble, sections
+}
+
+func bodyBlocks(doc *html.Node) []block {
+	body := findElement(doc, atom.Body)
+	if body == nil {
+		return nil
+	}
+	var blocks []block
+	collectBlocks(body, &blocks)
+	return blocks
+}
+
+func collectBlocks(parent *html.Node, blocks *[]block) {
+	for node := parent.FirstChild; node != nil; node = node.NextSibling {
+		switch node.Type {
+		case html.TextNode:
+			if strings.TrimSpace(node.Data) != "" {
+				*blocks = append(*blocks, block{html: html.EscapeString(node.Data)})
+			}
+		case html.ElementNode:
+			if node.DataAtom == atom.Script || node.DataAtom == atom.Style || node.DataAtom == atom.Nav {
+				continue
+			}
+			if level, ok := headingLevels[node.DataAtom]; ok {
+				*blocks = append(*blocks, block{level: level, title: strings.Join(strings.Fields(textContent(node)), " "), html: renderNode(node)})
+				continue
+			}
+			if wrapperTags[node.DataAtom] && containsHeading(node) {
+				collectBlocks(node, blocks)
+				continue
+			}
+			*blocks = append(*blocks, block{html: renderNode(node)})
+		}
+	}
+}
+
+func renderBlocks(blocks []block) string {
+	var parts []string
+	for _, b := range blocks {
+		parts = append(parts, b.html)
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+func renderNode(node *html.Node) string {
+	var buf bytes.Buffer
+	if err := html.Render(&buf, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func plainText(blocks []block) string {
+	var parts []string
+	for _, b := range blocks {
+		doc, err := html.Parse(strings.NewReader(b.html))
+		if err != nil {
+			continue
+		}
+		if text := strings.Join(strings.Fields(textContent(doc)), " "); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var builder strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		builder.WriteString(textContent(child))
+		builder.WriteString(" ")
+	}
+	return builder.String()
+}
+
+func containsHeading(node *html.Node) bool {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if _, ok := headingLevels[child.DataAtom]; ok && child.Type == html.ElementNode {
+			return true
+		}
+		if containsHeading(child) {
+			return true
+		}
+	}
+	return false
+}
+
+func findElement(node *html.Node, tag atom.Atom) *html.Node {
+	if node.Type == html.ElementNode && node.DataAtom == tag {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func documentTitle(doc *html.Node) string {
+	if title := findElement(doc, atom.Title); title != nil {
+		return strings.TrimSpace(textContent(title))
+	}
+	return ""
+}
+
+func countLevel(blocks []block, level int) int {
+	count := 0
+	for _, b := range blocks {
+		if b.level == level {
+			count++
+		}
+	}
+	return count
+}
+
+func distinctLevels(blocks []block) []int {
+	seen := make(map[int]bool)
+	var levels []int
+	for _, b := range blocks {
+		if b.level > 0 && !seen[b.level] {
+			seen[b.level] = true
+			levels = append(levels, b.level)
+		}
+	}
+	sort.Ints(levels)
+	return levels
+}
+
+// resolvePath ghép đường dẫn tương đối trong EPUB với thư mục chứa file tham chiếu
+func resolvePath(base string, href string) string {
+	href, _, _ = strings.Cut(href, "#")
+	return path.Clean(path.Join(path.Dir(base), href))
+}
@@ -0,0 +1,136 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatMarkdown = "markdown" // zip các file .md, mỗi thư mục là một chương
+	FormatEPUB     = "epub"
+	FormatHTML     = "html" // một file HTML, chia chương/trang theo heading
+)
+
+var ErrUnknownFormat = errors.New("unknown import format")
+
+// Book là cây nội dung đọc được từ file nhập, chưa lưu vào database
+type Book struct {
+	Title       string
+	Description string
+	Tags        []Tag
+	Chapters    []Chapter
+}
+
+type Tag struct {
+	Name  string
+	Value string
+}
+
+type Chapter struct {
+	Title string
+	Order int
+	Pages []Page
+}
+
+type Page struct {
+	Title   string
+	Order   int
+	Content string // markdown (nhập từ zip) hoặc HTML (nhập từ EPUB/HTML)
+	Tags    []Tag
+}
+
+// Warning là cảnh báo trên một file khi nhập, không làm dừng quá trình nhập
+type Warning struct {
+	File    string
+	Message string
+}
+
+// Result là kết quả đọc file nhập
+type Result struct {
+	Book     Book
+	Warnings []Warning
+}
+
+func (r *Result) warn(file string, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, Warning{File: file, Message: fmt.Sprintf(format, args...)})
+}
+
+// DetectFormat đoán định dạng từ tên file
+func DetectFormat(fileName string) (string, error) {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".zip":
+		return FormatMarkdown, nil
+	case ".epub":
+		return FormatEPUB, nil
+	case ".html", ".htm", ".xhtml":
+		return FormatHTML, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Parse đọc file nhập theo định dạng; lỗi chỉ trả về khi cả file không dùng được,
+// các vấn đề trên từng file con được ghi vào Warnings
+func Parse(format string, fileName string, data []byte) (Result, error) {
+	var result Result
+	var err error
+	switch format {
+	case FormatMarkdown:
+		err = parseMarkdownZip(&result, data)
+	case FormatEPUB:
+		err = parseEPUB(&result, data)
+	case FormatHTML:
+		err = parseHTML(&result, fileName, data)
+	default:
+		return Result{}, ErrUnknownFormat
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	if result.Book.Title == "" {
+		result.Book.Title = titleFromFileName(fileName)
+	}
+	if len(result.Book.Chapters) == 0 {
+		return Result{}, fmt.Errorf("no pages found in %s", fileName)
+	}
+	return result, nil
+}
+
+var orderPrefix = regexp.MustCompile(`^(\d+)[-_. ]+`)
+
+// splitOrderPrefix tách tiền tố thứ tự của tên file: "02-cai-dat" -> (2, "cai-dat")
+func splitOrderPrefix(name string) (int, string, bool) {
+	match := orderPrefix.FindStringSubmatch(name)
+	if match == nil {
+		return 0, name, false
+	}
+	order, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, name, false
+	}
+	return order, name[len(match[0]):], true
+}
+
+// titleFromFileName tạo tiêu đề từ tên file: "02-cai-dat.md" -> "Cai dat"
+func titleFromFileName(fileName string) string {
+	name := strings.TrimSuffix(path.Base(fileName), path.Ext(fileName))
+	_, name, _ = splitOrderPrefix(name)
+	name = strings.TrimSpace(strings.NewReplacer("-", " ", "_", " ").Replace(name))
+	if name == "" {
+		return "Untitled"
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// parseTag đọc tag dạng "name" hoặc "name:value"
+func parseTag(raw string) (Tag, bool) {
+	name, value, _ := strings.Cut(raw, ":")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Tag{}, false
+	}
+	return Tag{Name: name, Value: strings.TrimSpace(value)}, true
+}
@@ -0,0 +1,208 @@
+package importer
+
+import (
+	"archive/zip"
+	"bookstack/internal/exporter"
+	"bytes"
+	"compress/flate"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// buildLyingZip tạo zip có một file khai báo kích thước giải nén declared nhưng thực tế giải nén ra actual byte
+func buildLyingZip(t *testing.T, name string, declared uint64, actual int) []byte {
+	content := bytes.Repeat([]byte("a"), actual)
+	var compressed bytes.Buffer
+	fw, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+	require.NoError(t, err)
+	_, err = fw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, fw.Close())
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             zip.Deflate,
+		CRC32:              crc32.ChecksumIEEE(content),
+		CompressedSize64:   uint64(compressed.Len()),
+		UncompressedSize64: declared,
+	})
+	require.NoError(t, err)
+	_, err = w.Write(compressed.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestParseRejectsEntryLargerThanDeclared(t *testing.T) {
+	// Vài chục KB nén nhưng giải nén ra 32MB
+	markdown := buildLyingZip(t, "docs/a.md", 100, 32<<20)
+	require.Less(t, len(markdown), 1<<20)
+	_, err := Parse(FormatMarkdown, "docs.zip", markdown)
+	assert.ErrorIs(t, err, ErrArchiveTooLarge)
+
+	epub := buildLyingZip(t, "META-INF/container.xml", 100, 32<<20)
+	_, err = Parse(FormatEPUB, "book.epub", epub)
+	assert.ErrorIs(t, err, ErrArchiveTooLarge)
+}
+
+func TestParseLimitsDecompressedSize(t *testing.T) {
+	entrySize, archiveSize := maxEntrySize, maxArchiveSize
+	maxEntrySize, maxArchiveSize = 1000, 2500
+	defer func() { maxEntrySize, maxArchiveSize = entrySize, archiveSize }()
+
+	page := strings.Repeat("a", 900)
+	_, err := Parse(FormatMarkdown, "docs.zip", buildZip(t, map[string]string{"a.md": page, "b.md": page}))
+	assert.NoError(t, err)
+
+	_, err = Parse(FormatMarkdown, "docs.zip", buildZip(t, map[string]string{"a.md": page + page}))
+	assert.ErrorIs(t, err, ErrArchiveTooLarge, "entry over the per-file limit")
+
+	_, err = Parse(FormatMarkdown, "docs.zip", buildZip(t, map[string]string{"a.md": page, "b.md": page, "c.md": page}))
+	assert.ErrorIs(t, err, ErrArchiveTooLarge, "archive over the total limit")
+}
+
+func TestParseMarkdownZip(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"docs/README.md":                   "---\ntitle: Sổ tay\ntags:\n  - genre:guide\n  - draft\n---\n\nMô tả sách",
+		"docs/02-nang-cao/01-goroutine.md": "# Goroutine\n\nNội dung",
+		"docs/01-co-ban/b.md":              "---\norder: 1\ntags: [level:easy]\n---\n\n# Biến\n\nvar x int",
+		"docs/01-co-ban/a.md":              "---\norder: 2\n---\nKhông có heading",
+		"docs/01-co-ban/sub/c.md":          "# Lồng nhau",
+		"docs/01-co-ban/image.png":         "png",
+	})
+
+	result, err := Parse(FormatMarkdown, "docs.zip", data)
+	require.NoError(t, err)
+
+	book := result.Book
+	assert.Equal(t, "Sổ tay", book.Title)
+	assert.Equal(t, "Mô tả sách", book.Description)
+	assert.Equal(t, []Tag{{"genre", "guide"}, {"draft", ""}}, book.Tags)
+
+	require.Len(t, book.Chapters, 2)
+	assert.Equal(t, "Co ban", book.Chapters[0].Title)
+	assert.Equal(t, "Nang cao", book.Chapters[1].Title)
+
+	pages := book.Chapters[0].Pages
+	require.Len(t, pages, 3)
+	assert.Equal(t, "Biến", pages[0].Title)
+	assert.Equal(t, "var x int", pages[0].Content)
+	assert.Equal(t, []Tag{{"level", "easy"}}, pages[0].Tags)
+	assert.Equal(t, "A", pages[1].Title)
+	assert.Equal(t, "Lồng nhau", pages[2].Title)
+
+	var warnedFiles []string
+	for _, warning := range result.Warnings {
+		warnedFiles = append(warnedFiles, warning.File)
+	}
+	assert.ElementsMatch(t, []string{"docs/01-co-ban/image.png", "docs/01-co-ban/sub/c.md"}, warnedFiles)
+}
+
+func TestParseHTML(t *testing.T) {
+	data := []byte(`<html><head><title>Ignored</title></head><body>
+		<h1>Hướng dẫn</h1>
+		<p>Giới thiệu chung</p>
+		<h2>Chương 1</h2>
+		<p>Mở đầu chương</p>
+		<h3>Trang A</h3><p>Nội dung A</p>
+		<div><h3>Trang B</h3><p>Nội dung B</p><h4>Mục nhỏ</h4></div>
+		<h2>Chương 2</h2><p>Chỉ có một đoạn</p>
+	</body></html>`)
+
+	result, err := Parse(FormatHTML, "guide.html", data)
+	require.NoError(t, err)
+
+	book := result.Book
+	assert.Equal(t, "Hướng dẫn", book.Title)
+	assert.Equal(t, "Giới thiệu chung", book.Description)
+	require.Len(t, book.Chapters, 2)
+
+	first := book.Chapters[0]
+	require.Len(t, first.Pages, 3)
+	assert.Equal(t, []string{"Chương 1", "Trang A", "Trang B"}, []string{first.Pages[0].Title, first.Pages[1].Title, first.Pages[2].Title})
+	assert.Contains(t, first.Pages[2].Content, "<h4>Mục nhỏ</h4>")
+
+	second := book.Chapters[1]
+	require.Len(t, second.Pages, 1)
+	assert.Equal(t, "<p>Chỉ có một đoạn</p>", second.Pages[0].Content)
+}
+
+func TestParseExportedEPUB(t *testing.T) {
+	file, err := exporter.Export(exporter.Document{
+		Identifier: "urn:bookstack:book:1",
+		Title:      "Lập trình Go",
+		Tags:       []exporter.Tag{{Name: "genre", Value: "tech"}},
+		Chapters: []exporter.Chapter{
+			{Title: "Chương Một", Pages: []exporter.Page{
+				{Title: "Giới thiệu", Content: "Xin chào"},
+				{Title: "Cài đặt", Content: "<p>Tải Go</p>"},
+			}},
+			{Title: "Chương Hai", Pages: []exporter.Page{{Title: "Kết", Content: "Hết"}}},
+		},
+	}, exporter.FormatEPUB)
+	require.NoError(t, err)
+
+	result, err := Parse(FormatEPUB, file.Name, file.Data)
+	require.NoError(t, err)
+	assert.Empty(t, result.Warnings)
+
+	book := result.Book
+	assert.Equal(t, "Lập trình Go", book.Title)
+	assert.Equal(t, []Tag{{"genre", "tech"}}, book.Tags)
+	require.Len(t, book.Chapters, 2)
+	assert.Equal(t, "Chương Một", book.Chapters[0].Title)
+	require.Len(t, book.Chapters[0].Pages, 2)
+	assert.Equal(t, "Cài đặt", book.Chapters[0].Pages[1].Title)
+	assert.Equal(t, "<p>Tải Go</p>", book.Chapters[0].Pages[1].Content)
+	assert.Equal(t, 2, book.Chapters[1].Order)
+}
+
+func TestDetectFormat(t *testing.T) {
+	format, err := DetectFormat("Book.EPUB")
+	require.NoError(t, err)
+	assert.Equal(t, FormatEPUB, format)
+
+	_, err = DetectFormat("book.pdf")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestParseExportedMarkdownZip(t *testing.T) {
+	file, err := exporter.Export(exporter.Document{
+		Title: "Lập trình Go",
+		Tags:  []exporter.Tag{{Name: "genre", Value: "tech"}},
+		Chapters: []exporter.Chapter{{Title: "Chương Một", Order: 3, Pages: []exporter.Page{
+			{Title: "Giới thiệu", Order: 5, Content: "Xin chào"},
+		}}},
+	}, exporter.FormatMarkdown)
+	require.NoError(t, err)
+
+	result, err := Parse(FormatMarkdown, file.Name, file.Data)
+	require.NoError(t, err)
+	assert.Empty(t, result.Warnings)
+	assert.Equal(t, Book{
+		Title: "Lập trình Go",
+		Tags:  []Tag{{"genre", "tech"}},
+		Chapters: []Chapter{{Title: "Chương Một", Order: 3, Pages: []Page{
+			{Title: "Giới thiệu", Order: 5, Content: "Xin chào"},
+		}}},
+	}, result.Book)
+}
@@ -0,0 +1,276 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// metadataFiles là các file chứa thông tin của sách (ở thư mục gốc) hoặc của chương (trong thư mục chương)
+var metadataFiles = map[string]bool{"readme.md": true, "index.md": true, "_index.md": true}
+
+type frontMatter struct {
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description"`
+	Order       *int     `yaml:"order"`
+	Tags        []string `yaml:"tags"`
+}
+
+type markdownFile struct {
+	name    string
+	order   int
+	ordered bool
+	meta    frontMatter
+	body    string
+}
+
+type markdownDir struct {
+	name  string
+	meta  *markdownFile
+	pages []markdownFile
+}
+
+func parseMarkdownZip(result *Result, data []byte) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("invalid zip file: %w", err)
+	}
+
+	var names []string
+	files := make(map[string]*zip.File)
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || isHidden(file.Name) {
+			continue
+		}
+		if !strings.EqualFold(path.Ext(file.Name), ".md") && !strings.EqualFold(path.Ext(file.Name), ".markdown") {
+			result.warn(file.Name, "skipped: not a markdown file")
+			continue
+		}
+		names = append(names, file.Name)
+		files[file.Name] = file
+	}
+	root := commonRoot(names)
+	archive := newArchiveReader()
+
+	var bookMeta *markdownFile
+	rootDir := &markdownDir{}
+	dirs := make(map[string]*markdownDir)
+	for _, name := range names {
+		relative := strings.TrimPrefix(name, root)
+		parsed, err := readMarkdownFile(result, archive, name, files[name])
+		if errors.Is(err, ErrArchiveTooLarge) {
+			return err
+		}
+		if err != nil {
+			result.warn(name, "skipped: %v", err)
+			continue
+		}
+
+		parts := strings.Split(relative, "/")
+		if len(parts) == 1 {
+			if metadataFiles[strings.ToLower(parts[0])] {
+				bookMeta = &parsed
+				continue
+			}
+			rootDir.pages = append(rootDir.pages, parsed)
+			continue
+		}
+		if len(parts) > 2 {
+			result.warn(name, "nested directory flattened into chapter %q", parts[0])
+		}
+		dir, ok := dirs[parts[0]]
+		if !ok {
+			dir = &markdownDir{name: parts[0]}
+			dirs[parts[0]] = dir
+		}
+		if len(parts) == 2 && metadataFiles[strings.ToLower(parts[1])] {
+			dir.meta = &parsed
+			continue
+		}
+		dir.pages = append(dir.pages, parsed)
+	}
+
+	book := &result.Book
+	if bookMeta != nil {
+		book.Title = bookMeta.meta.Title
+		book.Description = bookMeta.meta.Description
+		heading, rest := splitHeading(bookMeta.body)
+		if book.Title == "" {
+			book.Title = heading
+		}
+		if book.Description == "" {
+			book.Description = strings.TrimSpace(rest)
+		}
+		book.Tags = parseTags(result, bookMeta.name, bookMeta.meta.Tags)
+	}
+
+	// Các trang ở thư mục gốc được gom vào một chương mang tên sách
+	if len(rootDir.pages) > 0 {
+		book.Chapters = append(book.Chapters, buildMarkdownChapter(result, rootDir, book.Title))
+	}
+	var dirNames []string
+	for name := range dirs {
+		dirNames = append(dirNames, name)
+	}
+	sort.Strings(dirNames)
+	for _, name := range dirNames {
+		dir := dirs[name]
+		if len(dir.pages) == 0 {
+			result.warn(root+name, "skipped: chapter has no pages")
+			continue
+		}
+		book.Chapters = append(book.Chapters, buildMarkdownChapter(result, dir, ""))
+	}
+	sortChapters(book.Chapters)
+	return nil
+}
+
+func buildMarkdownChapter(result *Result, dir *markdownDir, fallbackTitle string) Chapter {
+	order, name, _ := splitOrderPrefix(dir.name)
+	chapter := Chapter{Title: fallbackTitle, Order: order}
+	if dir.name != "" {
+		chapter.Title = titleFromFileName(name)
+	}
+	if dir.meta != nil {
+		if dir.meta.meta.Title != "" {
+			chapter.Title = dir.meta.meta.Title
+		} else if heading, _ := splitHeading(dir.meta.body); heading != "" {
+			chapter.Title = heading
+		}
+		if dir.meta.meta.Order != nil {
+			chapter.Order = *dir.meta.meta.Order
+		}
+	}
+
+	sort.SliceStable(dir.pages, func(i, j int) bool {
+		return dir.pages[i].name < dir.pages[j].name
+	})
+	for i, file := range dir.pages {
+		page := Page{Order: i + 1, Content: file.body}
+		if file.ordered {
+			page.Order = file.order
+		}
+		if file.meta.Order != nil {
+			page.Order = *file.meta.Order
+		}
+		heading, rest := splitHeading(file.body)
+		page.Title = file.meta.Title
+		if page.Title == "" {
+			page.Title = heading
+		}
+		if page.Title == "" {
+			page.Title = titleFromFileName(file.name)
+		}
+		// Bỏ heading trùng với tiêu đề trang để tránh lặp lại khi hiển thị
+		if heading != "" && heading == page.Title {
+			page.Content = rest
+		}
+		page.Content = strings.TrimSpace(page.Content)
+		page.Tags = parseTags(result, file.name, file.meta.Tags)
+		chapter.Pages = append(chapter.Pages, page)
+	}
+	sort.SliceStable(chapter.Pages, func(i, j int) bool {
+		return chapter.Pages[i].Order < chapter.Pages[j].Order
+	})
+	return chapter
+}
+
+func readMarkdownFile(result *Result, archive *archiveReader, name string, file *zip.File) (markdownFile, error) {
+	content, err := archive.read(file)
+	if err != nil {
+		return markdownFile{}, err
+	}
+
+	parsed := markdownFile{name: name}
+	parsed.order, _, parsed.ordered = splitOrderPrefix(path.Base(name))
+	raw, body, ok := splitFrontMatter(strings.ReplaceAll(string(content), "\r\n", "\n"))
+	parsed.body = body
+	if ok {
+		if err := yaml.Unmarshal([]byte(raw), &parsed.meta); err != nil {
+			result.warn(name, "invalid front matter ignored: %v", err)
+		}
+	}
+	return parsed, nil
+}
+
+// splitFrontMatter tách phần YAML nằm giữa hai dòng "---" ở đầu file
+func splitFrontMatter(content string) (string, string, bool) {
+	if !strings.HasPrefix(content, "---\n") {
+		return "", content, false
+	}
+	end := strings.Index(content[4:], "\n---")
+	if end < 0 {
+		return "", content, false
+	}
+	raw := content[4 : 4+end]
+	body := strings.TrimPrefix(content[4+end+4:], "\n")
+	return raw, body, true
+}
+
+// splitHeading lấy heading cấp 1 ở đầu nội dung markdown
+func splitHeading(body string) (string, string) {
+	trimmed := strings.TrimLeft(body, "\n")
+	if !strings.HasPrefix(trimmed, "# ") {
+		return "", body
+	}
+	line, rest, _ := strings.Cut(trimmed, "\n")
+	return strings.TrimSpace(strings.TrimPrefix(line, "# ")), rest
+}
+
+func parseTags(result *Result, file string, raw []string) []Tag {
+	var tags []Tag
+	for _, item := range raw {
+		tag, ok := parseTag(item)
+		if !ok {
+			result.warn(file, "empty tag ignored")
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+func sortChapters(chapters []Chapter) {
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].Order < chapters[j].Order
+	})
+}
+
+// commonRoot trả về thư mục bao ngoài chung nếu cả zip được nén từ một thư mục
+func commonRoot(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	first, _, ok := strings.Cut(names[0], "/")
+	if !ok {
+		return ""
+	}
+	for _, name := range names[1:] {
+		if !strings.HasPrefix(name, first+"/") {
+			return ""
+		}
+	}
+	// Chỉ bỏ khi bên trong vẫn còn thư mục chương hoặc README, tránh nhầm thư mục chương duy nhất
+	for _, name := range names {
+		relative := strings.TrimPrefix(name, first+"/")
+		if strings.Contains(relative, "/") || metadataFiles[strings.ToLower(relative)] {
+			return first + "/"
+		}
+	}
+	return ""
+}
+
+func isHidden(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
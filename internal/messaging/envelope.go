@@ -0,0 +1,71 @@
+package messaging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidMessage     = errors.New("invalid message")
+	ErrUnsupportedVersion = errors.New("unsupported message version")
+)
+
+// Envelope bọc mọi message gửi qua RabbitMQ. Payload có schema theo cặp (Type, Version): khi đổi schema
+// thì tăng Version để consumer cũ nhận ra và đẩy message vào dead-letter thay vì xử lý sai.
+type Envelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Attempt    int             `json:"attempt"` // Số lần xử lý đã thất bại
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// NewEnvelope tạo envelope mới với ID ngẫu nhiên cho payload
+func NewEnvelope(eventType string, version int, payload any) (Envelope, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{
+		ID:         hex.EncodeToString(id),
+		Type:       eventType,
+		Version:    version,
+		OccurredAt: time.Now().UTC(),
+		Payload:    body,
+	}, nil
+}
+
+// DecodeEnvelope đọc envelope từ body message
+func DecodeEnvelope(body []byte) (Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if env.ID == "" || env.Type == "" || env.Version <= 0 || len(env.Payload) == 0 {
+		return Envelope{}, fmt.Errorf("%w: missing id, type, version or payload", ErrInvalidMessage)
+	}
+	return env, nil
+}
+
+// Decode đọc payload vào v sau khi kiểm tra đúng loại và phiên bản consumer hỗ trợ
+func (e Envelope) Decode(eventType string, version int, v any) error {
+	if e.Type != eventType {
+		return fmt.Errorf("%w: expected %s, got %s", ErrInvalidMessage, eventType, e.Type)
+	}
+	if e.Version != version {
+		return fmt.Errorf("%w: %s v%d, supported v%d", ErrUnsupportedVersion, e.Type, e.Version, version)
+	}
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	return nil
+}
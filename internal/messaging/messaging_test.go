@@ -0,0 +1,65 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderCreated struct {
+	OrderID uint   `json:"order_id"`
+	Address string `json:"address"`
+}
+
+func TestEnvelope(t *testing.T) {
+	env, err := NewEnvelope("order.created", 1, orderCreated{OrderID: 7, Address: "Cầu Giấy"})
+	require.NoError(t, err)
+	assert.Len(t, env.ID, 32)
+
+	body, err := json.Marshal(env)
+	require.NoError(t, err)
+	decoded, err := DecodeEnvelope(body)
+	require.NoError(t, err)
+	assert.Equal(t, env.ID, decoded.ID)
+	assert.Zero(t, decoded.Attempt)
+
+	var order orderCreated
+	require.NoError(t, decoded.Decode("order.created", 1, &order))
+	assert.Equal(t, orderCreated{OrderID: 7, Address: "Cầu Giấy"}, order)
+
+	assert.ErrorIs(t, decoded.Decode("inventory.low_stock", 1, &order), ErrInvalidMessage)
+	decoded.Version = 2
+	assert.ErrorIs(t, decoded.Decode("order.created", 1, &order), ErrUnsupportedVersion)
+
+	// Message kiểu cũ không có envelope
+	_, err = DecodeEnvelope([]byte(`{"order_id":7,"address":"Cầu Giấy"}`))
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+	_, err = DecodeEnvelope([]byte(`not json`))
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 6, BaseDelay: 5 * time.Second, MaxDelay: time.Minute}
+	assert.Equal(t, 5*time.Second, policy.Backoff(1))
+	assert.Equal(t, 10*time.Second, policy.Backoff(2))
+	assert.Equal(t, 40*time.Second, policy.Backoff(4))
+	assert.Equal(t, time.Minute, policy.Backoff(5))
+	assert.Equal(t, time.Minute, policy.Backoff(50))
+	assert.Equal(t, []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute}, policy.Delays())
+
+	assert.Equal(t, "new_orders.retry.20s", RetryQueue(QueueNewOrders, policy.Backoff(3)))
+	assert.Equal(t, "new_orders.dlq", DeadLetterQueue(QueueNewOrders))
+
+	assert.Empty(t, RetryPolicy{MaxAttempts: 1, BaseDelay: time.Second}.Delays())
+}
+
+func TestIsPermanent(t *testing.T) {
+	assert.True(t, IsPermanent(Permanent(errors.New("order not found"))))
+	assert.True(t, IsPermanent(ErrUnsupportedVersion))
+	assert.False(t, IsPermanent(errors.New("database unavailable")))
+	assert.Nil(t, Permanent(nil))
+}
@@ -0,0 +1,25 @@
+package messaging
+
+// EventsExchange là topic exchange nhận mọi sự kiện nghiệp vụ, routing key là loại sự kiện ("order.created").
+// Mỗi bên quan tâm tạo queue riêng bind vào exchange theo các loại sự kiện cần nhận.
+const EventsExchange = "bookstack.events"
+
+// Queue nghiệp vụ. Mỗi queue đi kèm các delay queue "<queue>.retry.<delay>" và dead-letter queue "<queue>.dlq".
+const (
+	QueueNewOrders      = "new_orders"
+	QueueLowStockAlerts = "low_stock_alerts"
+	QueueWebhooks       = "webhooks" // Mọi sự kiện, chuyển thành các lần giao webhook cho đối tác
+)
+
+// Queues là các queue nghiệp vụ, dùng để kiểm tra tên queue ở API quản trị dead-letter
+var Queues = []string{QueueNewOrders, QueueLowStockAlerts, QueueWebhooks}
+
+// KnownQueue cho biết queue là queue nghiệp vụ
+func KnownQueue(queue string) bool {
+	for _, known := range Queues {
+		if known == queue {
+			return true
+		}
+	}
+	return false
+}
@@ -1,116 +1,472 @@
 package messaging
 
 import (
-	"bookstack/config"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-type RabbitMQ struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
+const (
+	defaultPrefetch   = 10 // Số message chưa ack tối đa mỗi consumer giữ
+	dialTimeout       = 5 * time.Second
+	reconnectDelay    = time.Second
+	maxReconnectDelay = 30 * time.Second
+
+	headerError    = "x-error"
+	headerQueue    = "x-original-queue"
+	headerFailedAt = "x-failed-at"
+)
+
+var (
+	ErrNotConfirmed = errors.New("message was not confirmed by the broker")
+	ErrUnknownQueue = errors.New("unknown queue")
+)
+
+// Client giữ một kết nối RabbitMQ dùng chung cho cả process. Kết nối được mở khi cần và mở lại khi bị đứt;
+// message được publish kèm xác nhận từ broker (publisher confirms) và consumer ack thủ công sau khi xử lý xong.
+type Client struct {
+	url      string
+	policy   RetryPolicy
+	prefetch int
+
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	channel  *amqp.Channel   // Kênh publish ở chế độ confirm
+	declared map[string]bool // Queue/exchange đã khai báo trên kết nối hiện tại
 }
 
-func NewRabbitMQ(conf *config.Config) (*RabbitMQ, error) {
-	conn, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%s/",
-		conf.RabbitMqUser,
-		conf.RabbitMQPassword,
-		conf.RabbitMQHost,
-		conf.RabbitMQPort))
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RabbitMQ: %v", err)
+func NewClient(url string, policy RetryPolicy) *Client {
+	return &Client{
+		url:      url,
+		policy:   policy,
+		prefetch: defaultPrefetch,
+		declared: map[string]bool{},
 	}
+}
 
-	ch, err := conn.Channel()
-	if err != nil {
-		return nil, fmt.Errorf("failed to open channel: %v", err)
+// Close đóng kết nối, các consumer sẽ tự kết nối lại nếu context của chúng chưa bị hủy
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.channel = nil
+	if c.conn == nil {
+		return nil
 	}
-
-	return &RabbitMQ{
-		conn:    conn,
-		channel: ch,
-	}, nil
+	err := c.conn.Close()
+	c.conn = nil
+	return err
 }
 
-func (r *RabbitMQ) PublishNewOrder(orderID uint, address string) error {
-	queue, err := r.channel.QueueDeclare(
-		"new_orders", // queue name
-		true,         // durable
-		false,        // delete when unused
-		false,        // exclusive
-		false,        // no-wait
-		nil,          // arguments
-	)
+// Publish gửi envelope thẳng vào queue và chờ broker xác nhận đã lưu message
+func (c *Client) Publish(ctx context.Context, queue string, env Envelope) error {
+	msg, err := envelopePublishing(env)
 	if err != nil {
 		return err
 	}
+	return c.publish(ctx, "", queue, func(ch *amqp.Channel) error {
+		return c.declare(ch, queue, func(ch *amqp.Channel) error { return declareTopology(ch, queue, c.policy) })
+	}, msg)
+}
 
-	message := map[string]interface{}{
-		"order_id": orderID,
-		"address":  address,
+// PublishTo gửi envelope tới topic exchange với routing key và chờ broker xác nhận. Message tới khi chưa
+// có queue nào bind theo routing key sẽ bị bỏ, nên queue không được mất message cần Bind trước.
+func (c *Client) PublishTo(ctx context.Context, exchange, routingKey string, env Envelope) error {
+	msg, err := envelopePublishing(env)
+	if err != nil {
+		return err
 	}
+	return c.publish(ctx, exchange, routingKey, func(ch *amqp.Channel) error {
+		return c.declare(ch, "exchange:"+exchange, func(ch *amqp.Channel) error { return declareExchange(ch, exchange) })
+	}, msg)
+}
 
-	body, err := json.Marshal(message)
+// Bind khai báo topic exchange và queue (kèm delay queue, dead-letter queue) nhận các routing key bindings
+func (c *Client) Bind(exchange, queue string, bindings ...string) error {
+	ch, err := c.openChannel()
 	if err != nil {
 		return err
 	}
+	defer ch.Close()
+	return bindTopology(ch, exchange, queue, bindings, c.policy)
+}
 
-	err = r.channel.PublishWithContext(
-		context.Background(),
-		"",         // exchange
-		queue.Name, // routing key
-		false,      // mandatory
-		false,      // immediate
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		})
+func envelopePublishing(env Envelope) (amqp.Publishing, error) {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return amqp.Publishing{}, err
+	}
+	return amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    env.ID,
+		Type:         env.Type,
+		Timestamp:    env.OccurredAt,
+		Body:         body,
+	}, nil
+}
+
+// publish khai báo đích bằng declare rồi gửi msg tới exchange với routingKey. Kết nối cũ có thể
+// đã đứt mà chưa phát hiện nên được thử lại một lần với kết nối mới.
+func (c *Client) publish(ctx context.Context, exchange, routingKey string, declare func(*amqp.Channel) error, msg amqp.Publishing) error {
+	err := c.publishOnce(ctx, exchange, routingKey, declare, msg)
+	if errors.Is(err, amqp.ErrClosed) {
+		err = c.publishOnce(ctx, exchange, routingKey, declare, msg)
+	}
 	return err
 }
 
-func (r *RabbitMQ) ConsumeNewOrders(handler func(orderID uint, address string)) error {
-	queue, err := r.channel.QueueDeclare(
-		"new_orders", // queue name
-		true,         // durable
-		false,        // delete when unused
-		false,        // exclusive
-		false,        // no-wait
-		nil,          // arguments
-	)
+func (c *Client) publishOnce(ctx context.Context, exchange, routingKey string, declare func(*amqp.Channel) error, msg amqp.Publishing) error {
+	ch, err := c.publishChannel()
 	if err != nil {
 		return err
 	}
-
-	msgs, err := r.channel.Consume(
-		queue.Name, // queue
-		"",         // consumer
-		true,       // auto-ack
-		false,      // exclusive
-		false,      // no-local
-		false,      // no-wait
-		nil,        // args
-	)
+	if err := declare(ch); err != nil {
+		return err
+	}
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, msg)
 	if err != nil {
 		return err
 	}
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return ErrNotConfirmed
+	}
+	return nil
+}
 
+// Handler xử lý một message. Trả lỗi thì message được thử lại sau RetryPolicy.Backoff; lỗi Permanent hoặc
+// hết lượt thử thì message vào dead-letter queue.
+type Handler func(ctx context.Context, env Envelope) error
+
+// Consume nhận message của queue trong nền cho tới khi ctx bị hủy, tự kết nối lại khi mất kết nối
+func (c *Client) Consume(ctx context.Context, queue string, handler Handler) {
+	c.run(ctx, queue, func(ch *amqp.Channel) error {
+		return declareTopology(ch, queue, c.policy)
+	}, handler)
+}
+
+// Subscribe bind queue vào topic exchange theo các routing key bindings rồi nhận message như Consume
+func (c *Client) Subscribe(ctx context.Context, exchange, queue string, bindings []string, handler Handler) {
+	c.run(ctx, queue, func(ch *amqp.Channel) error {
+		return bindTopology(ch, exchange, queue, bindings, c.policy)
+	}, handler)
+}
+
+// run chạy consumer trong nền, mỗi lần kết nối lại đều khai báo lại topology bằng setup
+func (c *Client) run(ctx context.Context, queue string, setup func(*amqp.Channel) error, handler Handler) {
 	go func() {
-		for msg := range msgs {
-			var data map[string]interface{}
-			if err := json.Unmarshal(msg.Body, &data); err != nil {
-				log.Printf("Error decoding message: %v", err)
-				continue
+		delay := reconnectDelay
+		for {
+			started, err := c.consume(ctx, queue, setup, handler)
+			if ctx.Err() != nil {
+				return
+			}
+			if started {
+				delay = reconnectDelay
+			}
+			log.Printf("RabbitMQ consumer for %s stopped: %v, reconnecting in %s", queue, err, delay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
 			}
+			delay = min(delay*2, maxReconnectDelay)
+		}
+	}()
+}
+
+// consume chạy một phiên consumer tới khi kênh đóng, started cho biết phiên đã bắt đầu nhận message
+func (c *Client) consume(ctx context.Context, queue string, setup func(*amqp.Channel) error, handler Handler) (bool, error) {
+	ch, err := c.openChannel()
+	if err != nil {
+		return false, err
+	}
+	defer ch.Close()
+	if err := setup(ch); err != nil {
+		return false, err
+	}
+	if err := ch.Qos(c.prefetch, 0, false); err != nil {
+		return false, err
+	}
+	deliveries, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		return false, err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return true, amqp.ErrClosed
+			}
+			c.handle(ctx, queue, delivery, handler)
+		}
+	}
+}
+
+// handle xử lý một delivery. Message lỗi được chuyển sang delay queue hoặc dead-letter queue và chỉ được ack
+// sau khi broker xác nhận đã lưu ở đó; không chuyển được thì nack để message quay lại queue.
+func (c *Client) handle(ctx context.Context, queue string, delivery amqp.Delivery, handler Handler) {
+	env, err := DecodeEnvelope(delivery.Body)
+	if err == nil {
+		err = safeHandle(ctx, env, handler)
+	}
+	if err == nil {
+		delivery.Ack(false)
+		return
+	}
 
-			orderID := uint(data["order_id"].(float64))
-			address := data["address"].(string)
-			handler(orderID, address)
+	msg := amqp.Publishing{
+		ContentType:  delivery.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    delivery.MessageId,
+		Type:         delivery.Type,
+		Timestamp:    delivery.Timestamp,
+		Headers:      amqp.Table{headerError: err.Error(), headerQueue: queue},
+		Body:         delivery.Body,
+	}
+	routingKey := DeadLetterQueue(queue)
+	if env.ID != "" {
+		env.Attempt++
+		if !IsPermanent(err) && env.Attempt < c.policy.MaxAttempts {
+			routingKey = RetryQueue(queue, c.policy.Backoff(env.Attempt))
+		}
+		msg.Body, _ = json.Marshal(env)
+	}
+	if routingKey == DeadLetterQueue(queue) {
+		msg.Headers[headerFailedAt] = time.Now().UTC().Format(time.RFC3339)
+		log.Printf("Message %s from %s moved to dead-letter queue: %v", delivery.MessageId, queue, err)
+	}
+
+	// Delay queue và dead-letter queue nhận message qua default exchange theo tên queue
+	declare := func(ch *amqp.Channel) error {
+		return c.declare(ch, queue, func(ch *amqp.Channel) error { return declareTopology(ch, queue, c.policy) })
+	}
+	if err := c.publish(ctx, "", routingKey, declare, msg); err != nil {
+		log.Printf("Failed to reschedule message %s from %s: %v", delivery.MessageId, queue, err)
+		delivery.Nack(false, true)
+		return
+	}
+	delivery.Ack(false)
+}
+
+// safeHandle gọi handler, panic được đổi thành lỗi để message vẫn được thử lại thay vì làm sập consumer
+func safeHandle(ctx context.Context, env Envelope, handler Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
 		}
 	}()
+	return handler(ctx, env)
+}
+
+// DeadLetter là một message trong dead-letter queue. Envelope để trống khi body không phải envelope hợp lệ.
+type DeadLetter struct {
+	MessageID string
+	Envelope  Envelope
+	Body      []byte
+	Error     string
+	FailedAt  string
+}
 
+// DeadLetters xem tối đa limit message đầu dead-letter queue của queue mà không lấy chúng ra khỏi queue
+func (c *Client) DeadLetters(queue string, limit int) ([]DeadLetter, error) {
+	if !KnownQueue(queue) {
+		return nil, ErrUnknownQueue
+	}
+	ch, err := c.openChannel()
+	if err != nil {
+		return nil, err
+	}
+	// Đóng kênh trả các message đã lấy nhưng chưa ack về dead-letter queue
+	defer ch.Close()
+	if err := declareTopology(ch, queue, c.policy); err != nil {
+		return nil, err
+	}
+
+	letters := []DeadLetter{}
+	for len(letters) < limit {
+		delivery, ok, err := ch.Get(DeadLetterQueue(queue), false)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		letter := DeadLetter{MessageID: delivery.MessageId, Body: delivery.Body}
+		letter.Envelope, _ = DecodeEnvelope(delivery.Body)
+		letter.Error, _ = delivery.Headers[headerError].(string)
+		letter.FailedAt, _ = delivery.Headers[headerFailedAt].(string)
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
+
+// ReplayDeadLetters gửi lại các message trong dead-letter queue về queue gốc với lượt thử đếm lại từ đầu.
+// ids rỗng là gửi lại toàn bộ; message không phải envelope hợp lệ được giữ lại trong dead-letter queue.
+func (c *Client) ReplayDeadLetters(ctx context.Context, queue string, ids []string) (int, error) {
+	if !KnownQueue(queue) {
+		return 0, ErrUnknownQueue
+	}
+	ch, err := c.openChannel()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+	if err := declareTopology(ch, queue, c.policy); err != nil {
+		return 0, err
+	}
+	dlq, err := ch.QueueDeclarePassive(DeadLetterQueue(queue), true, false, false, false, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	replayed := 0
+	// Message không được chọn giữ nguyên chưa ack nên Get lần sau lấy message kế tiếp
+	for i := 0; i < dlq.Messages; i++ {
+		delivery, ok, err := ch.Get(dlq.Name, false)
+		if err != nil {
+			return replayed, err
+		}
+		if !ok {
+			break
+		}
+		env, err := DecodeEnvelope(delivery.Body)
+		if err != nil || (len(wanted) > 0 && !wanted[env.ID]) {
+			continue
+		}
+		env.Attempt = 0
+		if err := c.Publish(ctx, queue, env); err != nil {
+			return replayed, err
+		}
+		if err := delivery.Ack(false); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// declare chạy khai báo fn một lần cho mỗi kết nối theo key
+func (c *Client) declare(ch *amqp.Channel, key string, fn func(*amqp.Channel) error) error {
+	c.mu.Lock()
+	declared := c.declared[key]
+	c.mu.Unlock()
+	if declared {
+		return nil
+	}
+	if err := fn(ch); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.declared[key] = true
+	c.mu.Unlock()
+	return nil
+}
+
+func declareExchange(ch *amqp.Channel, exchange string) error {
+	return ch.ExchangeDeclare(exchange, amqp.ExchangeTopic, true, false, false, false, nil)
+}
+
+// bindTopology khai báo exchange, topology của queue và bind queue theo từng routing key
+func bindTopology(ch *amqp.Channel, exchange, queue string, bindings []string, policy RetryPolicy) error {
+	if err := declareExchange(ch, exchange); err != nil {
+		return err
+	}
+	if err := declareTopology(ch, queue, policy); err != nil {
+		return err
+	}
+	for _, key := range bindings {
+		if err := ch.QueueBind(queue, key, exchange, false, nil); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// declareTopology khai báo queue gốc, dead-letter queue và các delay queue. Delay queue giữ message trong
+// TTL bằng thời gian chờ rồi dead-letter nó về queue gốc qua default exchange.
+func declareTopology(ch *amqp.Channel, queue string, policy RetryPolicy) error {
+	if _, err := ch.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		return err
+	}
+	if _, err := ch.QueueDeclare(DeadLetterQueue(queue), true, false, false, false, nil); err != nil {
+		return err
+	}
+	for _, delay := range policy.Delays() {
+		args := amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queue,
+		}
+		if _, err := ch.QueueDeclare(RetryQueue(queue, delay), true, false, false, false, args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishChannel trả về kênh publish ở chế độ confirm, mở lại kết nối và kênh khi đã đóng
+func (c *Client) publishChannel() (*amqp.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.channel != nil && !c.channel.IsClosed() {
+		return c.channel, nil
+	}
+	conn, err := c.connectLocked()
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	c.channel = ch
+	return ch, nil
+}
+
+// openChannel mở kênh riêng cho consumer hoặc thao tác quản trị, người gọi tự đóng kênh
+func (c *Client) openChannel() (*amqp.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	conn, err := c.connectLocked()
+	if err != nil {
+		return nil, err
+	}
+	return conn.Channel()
+}
+
+func (c *Client) connectLocked() (*amqp.Connection, error) {
+	if c.conn != nil && !c.conn.IsClosed() {
+		return c.conn, nil
+	}
+	conn, err := amqp.DialConfig(c.url, amqp.Config{
+		Heartbeat: 10 * time.Second,
+		Dial:      amqp.DefaultDial(dialTimeout),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	c.conn = conn
+	c.channel = nil
+	c.declared = map[string]bool{}
+	return conn, nil
+}
@@ -0,0 +1,75 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetryPolicy giới hạn số lần xử lý một message và thời gian chờ giữa các lần thử lại
+type RetryPolicy struct {
+	MaxAttempts int           // Tổng số lần xử lý trước khi đưa vào dead-letter queue
+	BaseDelay   time.Duration // Thời gian chờ trước lần thử lại đầu tiên, nhân đôi sau mỗi lần
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 5, BaseDelay: 5 * time.Second, MaxDelay: 5 * time.Minute}
+
+// Backoff là thời gian chờ trước lần thử lại thứ attempt (từ 1): BaseDelay * 2^(attempt-1), tối đa MaxDelay
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Delays là các thời gian chờ khác nhau giữa những lần thử lại, mỗi giá trị ứng với một delay queue
+func (p RetryPolicy) Delays() []time.Duration {
+	var delays []time.Duration
+	for attempt := 1; attempt < p.MaxAttempts; attempt++ {
+		delay := p.Backoff(attempt)
+		if len(delays) == 0 || delays[len(delays)-1] != delay {
+			delays = append(delays, delay)
+		}
+	}
+	return delays
+}
+
+// RetryQueue là delay queue chờ delay trước khi thử lại. Message nằm đó hết TTL thì RabbitMQ dead-letter
+// nó trở lại queue gốc. Tên queue gồm thời gian chờ nên đổi RetryPolicy không đụng queue đã khai báo.
+func RetryQueue(queue string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%s", queue, delay)
+}
+
+// DeadLetterQueue chứa các message hết lượt thử lại hoặc không thể xử lý, chờ quản trị viên xem và gửi lại
+func DeadLetterQueue(queue string) string {
+	return queue + ".dlq"
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent đánh dấu lỗi không thể khắc phục bằng thử lại, message được đưa thẳng vào dead-letter queue
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent cho biết lỗi xử lý không nên thử lại
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent) || errors.Is(err, ErrInvalidMessage) || errors.Is(err, ErrUnsupportedVersion)
+}
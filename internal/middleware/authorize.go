@@ -76,3 +76,36 @@ func (m *Middleware) AuthorizeRole(permission string) gin.HandlerFunc {
 		}
 	}
 }
+
+// RequireVerifiedEmail chặn người dùng chưa xác nhận email, chỉ có tác dụng khi bật REQUIRE_EMAIL_VERIFICATION
+func (m *Middleware) RequireVerifiedEmail() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !m.config.RequireEmailVerification {
+			return
+		}
+		fields := strings.Fields(ctx.GetHeader("Authorization"))
+		if len(fields) != 2 || fields[0] != "Bearer" {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "Missing token"})
+			return
+		}
+		sub, err := utils.ValidateAccessToken(fields[1], m.config.AccessTokenSecret)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": err.Error()})
+			return
+		}
+		id, err := strconv.Atoi(fmt.Sprint(sub))
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "Invalid token"})
+			return
+		}
+		user, err := m.UserRepo.GetUserById(id)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "User not found"})
+			return
+		}
+		if !user.EmailConfirmed {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": "email address is not verified"})
+			return
+		}
+	}
+}
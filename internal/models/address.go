@@ -0,0 +1,23 @@
+package models
+
+import "bookstack/internal/geo"
+
+// Address - Địa chỉ giao hàng có cấu trúc. Lat/Lng rỗng khi không tra được tọa độ,
+// lúc đó việc tìm shipper dựa trên địa chỉ dạng chữ của đơn.
+type Address struct {
+	Province  string   `gorm:"type:varchar(100)" json:"province"` // Tỉnh/thành phố
+	District  string   `gorm:"type:varchar(100)" json:"district"` // Quận/huyện
+	Ward      string   `gorm:"type:varchar(100)" json:"ward"`     // Phường/xã
+	Street    string   `gorm:"type:varchar(255)" json:"street"`   // Số nhà, tên đường
+	Lat       *float64 `json:"lat"`
+	Lng       *float64 `json:"lng"`
+	Precision string   `gorm:"type:varchar(20)" json:"precision"` // geo.Precision*, mức chính xác của tọa độ
+}
+
+// Point trả về tọa độ của địa chỉ nếu có
+func (a Address) Point() *geo.Point {
+	if a.Lat == nil || a.Lng == nil {
+		return nil
+	}
+	return &geo.Point{Lat: *a.Lat, Lng: *a.Lng}
+}
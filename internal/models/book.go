@@ -2,6 +2,8 @@ package models
 
 import (
 	"bookstack/internal/constant"
+	"bookstack/internal/money"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -9,28 +11,31 @@ import (
 // Book đại diện cho cuốn sách
 type Book struct {
 	gorm.Model
-	Price       float64   `json:"price"`       // giá sách vật lý
-	Title       string    `json:"title"`       // Tiêu đề của sách
-	Description string    `json:"description"` // Mô tả của sách
-	Slug        string    `json:"slug"`        // Đường dẫn thân thiện
-	ShelveID    uint      `json:"shelve_id"`   // Khóa ngoại liên kết đến Shelf
-	Shelve      Shelve    `gorm:"foreignKey:ShelveID"`
-	Chapters    []Chapter `gorm:"foreignKey:BookID;constraint:OnDelete:CASCADE" json:"chapters"` // Danh sách chương của sách
-	Tags        []Tag     `gorm:"polymorphic:Entity;polymorphicValue:book" json:"tags"`          // Tags liên kết với sách
-	Comments    []Comment `gorm:"polymorphic:Entity;polymorphicValue:book" json:"comments"`
-	Restricted  bool      `json:"restricted"` // Trường kiểm soát quyền truy cập
-	CreatedBy   uint      `json:"created_by"` // ID của người tạo sách
-	UpdatedBy   uint      `json:"updated_by"` // ID của người cập nhật sách
+	Price        money.Decimal `gorm:"type:decimal(18,8)" json:"price"`         // giá sách vật lý theo tiền tệ gốc (constant.BaseCurrency)
+	DigitalPrice money.Decimal `gorm:"type:decimal(18,8)" json:"digital_price"` // giá sách điện tử theo tiền tệ gốc, 0 là không bán bản điện tử
+	Title        string        `json:"title"`                                   // Tiêu đề của sách
+	Description  string        `json:"description"`                             // Mô tả của sách
+	Slug         string        `json:"slug"`                                    // Đường dẫn thân thiện
+	ShelveID     uint          `json:"shelve_id"`                               // Khóa ngoại liên kết đến Shelf
+	Shelve       Shelve        `gorm:"foreignKey:ShelveID"`
+	Chapters     []Chapter     `gorm:"foreignKey:BookID;constraint:OnDelete:CASCADE" json:"chapters"` // Danh sách chương của sách
+	Tags         []Tag         `gorm:"polymorphic:Entity;polymorphicValue:book" json:"tags"`          // Tags liên kết với sách
+	Comments     []Comment     `gorm:"polymorphic:Entity;polymorphicValue:book" json:"comments"`
+	Restricted   bool          `json:"restricted"`                                               // Trường kiểm soát quyền truy cập
+	CreatedBy    uint          `json:"created_by"`                                               // ID của người tạo sách
+	UpdatedBy    uint          `json:"updated_by"`                                               // ID của người cập nhật sách
+	SearchVector string        `gorm:"type:tsvector;index:,type:gin;->:false;<-:false" json:"-"` // Chỉ mục tìm kiếm toàn văn
 }
 
 // Chapter đại diện cho chương của một cuốn sách
 type Chapter struct {
 	gorm.Model
-	Title      string `json:"title"`                                                         // Tiêu đề chương
-	Order      int    `json:"order"`                                                         // Thứ tự sắp xếp chương
-	BookID     uint   `json:"book_id"`                                                       // Khóa ngoại liên kết đến Book
-	Pages      []Page `gorm:"foreignKey:ChapterID;constraint:OnDelete:CASCADE" json:"pages"` // Danh sách trang của chương
-	Restricted bool   `json:"restricted"`                                                    // Quyền truy cập chương
+	Title        string `json:"title"`                                                         // Tiêu đề chương
+	Order        int    `json:"order"`                                                         // Thứ tự sắp xếp chương
+	BookID       uint   `json:"book_id"`                                                       // Khóa ngoại liên kết đến Book
+	Pages        []Page `gorm:"foreignKey:ChapterID;constraint:OnDelete:CASCADE" json:"pages"` // Danh sách trang của chương
+	Restricted   bool   `json:"restricted"`                                                    // Quyền truy cập chương
+	SearchVector string `gorm:"type:tsvector;index:,type:gin;->:false;<-:false" json:"-"`      // Chỉ mục tìm kiếm toàn văn
 }
 
 // Page đại diện cho trang trong một chương hoặc sách
@@ -44,13 +49,19 @@ type Page struct {
 	Chapter       Chapter        `gorm:"foreignKey:ChapterID"`
 	Restricted    bool           `json:"restricted"` // Quyền truy cập trang
 	PageRevisions []PageRevision `gorm:"foreignKey:PageId"`
+	Tags          []Tag          `gorm:"polymorphic:Entity;polymorphicValue:page" json:"tags"`     // Tags liên kết với trang
+	SearchVector  string         `gorm:"type:tsvector;index:,type:gin;->:false;<-:false" json:"-"` // Chỉ mục tìm kiếm toàn văn
 }
 
+// PageRevision lưu lại nội dung trước đó của trang mỗi khi trang được cập nhật
 type PageRevision struct {
 	gorm.Model
-	PageId         int    `json:"page_id"`
-	Content        string `json:"content"`
-	RevisionNumber int    `json:"revision_number"`
+	PageId         int    `json:"page_id" gorm:"uniqueIndex:idx_page_revision"`
+	Title          string `json:"title"`                                                // Tiêu đề trang tại thời điểm lưu
+	Content        string `json:"content" gorm:"type:text"`                             // Nội dung trang tại thời điểm lưu
+	RevisionNumber int    `json:"revision_number" gorm:"uniqueIndex:idx_page_revision"` // Số thứ tự phiên bản, tăng dần theo từng trang
+	Summary        string `json:"summary"`                                              // Tóm tắt thay đổi
+	CreatedBy      uint   `json:"created_by"`                                           // ID của người tạo phiên bản
 }
 
 // Shelf đại diện cho kệ chứa sách, dùng để phân loại sách theo chủ đề hoặc danh mục
@@ -76,38 +87,116 @@ type Tag struct {
 }
 
 // Comment của sách và kệ sách
-
+// Mỗi người dùng chỉ được chấm điểm một lần trên mỗi entity (bình luận gốc có rating > 0)
 type Comment struct {
 	gorm.Model
-	EntityID   uint   `json:"entity_id"`   // ID của entity (Book hoặc Shelve)
-	EntityType string `json:"entity_type"` // Loại entity (book, shelve)
-	Rating     int    `json:"rating"`
-	Text       string `json:"text"`
-	CreatedBy  int    `json:"created_by"`
-	ParentID   int    `json:"parent_id"`
+	EntityID   uint   `json:"entity_id" gorm:"index:idx_comment_entity;uniqueIndex:idx_comment_rating,where:rating > 0 AND parent_id = 0 AND deleted_at IS NULL"` // ID của entity (Book hoặc Shelve)
+	EntityType string `json:"entity_type" gorm:"index:idx_comment_entity;uniqueIndex:idx_comment_rating"`                                                         // Loại entity (book, shelve)
+	Rating     int    `json:"rating"`                                                                                                                             // Điểm đánh giá 1-5, 0 nếu chỉ bình luận
+	Text       string `json:"text" gorm:"type:text"`
+	CreatedBy  int    `json:"created_by" gorm:"uniqueIndex:idx_comment_rating"`
+	ParentID   int    `json:"parent_id" gorm:"index"` // ID bình luận cha, 0 nếu là bình luận gốc
+	Hidden     bool   `json:"hidden"`                 // Bị người kiểm duyệt ẩn
+	HiddenBy   uint   `json:"hidden_by"`              // ID người kiểm duyệt đã ẩn bình luận
 }
 
 // Order - Đơn hàng
 type Order struct {
 	gorm.Model
-	UserID      uint                 `gorm:"not null" json:"user_id"`
-	User        User                 `gorm:"foreignKey:UserID" json:"user"`
-	ShipperID   *uint                `json:"shipper_id"` // Thay đổi thành con trỏ để cho phép null
-	Shipper     *User                `gorm:"foreignKey:ShipperID" json:"shipper,omitempty"`
-	TotalPrice  float64              `gorm:"type:decimal(10,2)" json:"total_price"`
-	Status      constant.OrderStatus `gorm:"type:int" json:"status"`
-	OrderDetail []OrderDetail        `gorm:"foreignKey:OrderID" json:"order_details"`
-	Address     string               `gorm:"type:varchar(255)" json:"address"`
-	Phone       string               `gorm:"type:varchar(20)" json:"phone"`
+	UserID        uint                 `gorm:"not null" json:"user_id"`
+	User          User                 `gorm:"foreignKey:UserID" json:"user"`
+	ShipperID     *uint                `json:"shipper_id"` // Thay đổi thành con trỏ để cho phép null
+	Shipper       *User                `gorm:"foreignKey:ShipperID" json:"shipper,omitempty"`
+	DispatchState string               `gorm:"type:varchar(20)" json:"dispatch_state"`            // constant.Dispatch*, tiến trình tìm shipper
+	Currency      string               `gorm:"type:varchar(3)" json:"currency"`                   // Tiền tệ khách thanh toán, chốt khi tạo đơn
+	ExchangeRate  money.Decimal        `gorm:"type:decimal(18,8);default:1" json:"exchange_rate"` // Số đơn vị Currency của một đơn vị tiền tệ gốc, chốt khi tạo đơn
+	Subtotal      money.Decimal        `gorm:"type:decimal(18,8)" json:"subtotal"`                // Tổng tiền sách trước giảm giá
+	DiscountTotal money.Decimal        `gorm:"type:decimal(18,8)" json:"discount_total"`          // Tổng tiền giảm (gồm cả phí vận chuyển được miễn)
+	ShippingFee   money.Decimal        `gorm:"type:decimal(18,8)" json:"shipping_fee"`
+	TotalPrice    money.Decimal        `gorm:"type:decimal(18,8)" json:"total_price"` // Subtotal + ShippingFee - DiscountTotal
+	CouponCode    string               `gorm:"type:varchar(50)" json:"coupon_code"`
+	Discounts     []OrderDiscount      `gorm:"foreignKey:OrderID" json:"discounts"`
+	Status        constant.OrderStatus `gorm:"type:int" json:"status"`
+	OrderDetail   []OrderDetail        `gorm:"foreignKey:OrderID" json:"order_details"`
+	Address       string               `gorm:"type:varchar(255)" json:"address"`
+	Location      Address              `gorm:"embedded;embeddedPrefix:shipping_" json:"location"` // Địa chỉ giao có cấu trúc và tọa độ
+	DeliveryETA   *time.Time           `json:"delivery_eta"`                                      // Thời gian dự kiến giao tới do shipper cập nhật
+	DeliveryCode  string               `gorm:"type:varchar(10)" json:"-"`                         // Mã nhận hàng khách đọc cho shipper khi giao
+	CodeAttempts  int                  `gorm:"default:0" json:"-"`                                // Số lần shipper nhập sai mã nhận hàng
+	Phone         string               `gorm:"type:varchar(20)" json:"phone"`
+	PaymentStatus string               `gorm:"type:varchar(20)" json:"payment_status"` // constant.Payment*, tổng hợp từ các giao dịch
+	Payments      []Payment            `gorm:"foreignKey:OrderID" json:"payments,omitempty"`
 }
 
 // OrderDetail - Chi tiết đơn hàng
 type OrderDetail struct {
 	gorm.Model
-	OrderID  uint    `json:"order_id"`
-	Order    Order   `gorm:"foreignKey:OrderID"`
-	BookID   uint    `json:"book_id"`
-	Book     Book    `gorm:"foreignKey:BookID"`
-	Quantity int     `json:"quantity"` // Số lượng sách đặt
-	Price    float64 `json:"price"`    // Giá sách tại thời điểm đặt hàng
+	OrderID  uint          `json:"order_id"`
+	Order    Order         `gorm:"foreignKey:OrderID"`
+	BookID   uint          `json:"book_id"`
+	Book     Book          `gorm:"foreignKey:BookID"`
+	Type     string        `gorm:"type:varchar(10);default:physical" json:"type"` // constant.OrderLinePhysical hoặc OrderLineDigital
+	Quantity int           `json:"quantity"`                                      // Số lượng sách đặt, luôn là 1 với sách điện tử
+	Price    money.Decimal `gorm:"type:decimal(18,8)" json:"price"`               // Giá sách tại thời điểm đặt hàng, theo tiền tệ của đơn
+	// Số lượng đã được hoàn tiền (khách trả lại)
+	RefundedQuantity int `json:"refunded_quantity"`
+}
+
+// CartItem - Một dòng trong giỏ hàng của người dùng đã đăng nhập
+// (giỏ hàng của khách được lưu trong Redis với cùng cấu trúc)
+type CartItem struct {
+	gorm.Model
+	UserID   uint          `gorm:"uniqueIndex:idx_cart_item;not null" json:"user_id"`
+	BookID   uint          `gorm:"uniqueIndex:idx_cart_item;not null" json:"book_id"`
+	Book     Book          `gorm:"foreignKey:BookID" json:"-"`
+	Quantity int           `json:"quantity"`
+	Price    money.Decimal `gorm:"type:decimal(18,8)" json:"price"` // Giá sách (tiền tệ gốc) tại thời điểm thêm vào giỏ, dùng để phát hiện thay đổi giá
+}
+
+// OrderStatusHistory - Lịch sử chuyển trạng thái của đơn hàng
+type OrderStatusHistory struct {
+	gorm.Model
+	OrderID    uint                  `gorm:"index;not null" json:"order_id"`
+	FromStatus *constant.OrderStatus `gorm:"type:int" json:"from_status"` // nil khi đơn vừa được tạo
+	ToStatus   constant.OrderStatus  `gorm:"type:int" json:"to_status"`
+	ActorID    uint                  `json:"actor_id"`                           // 0 khi do hệ thống thực hiện
+	ActorRole  string                `gorm:"type:varchar(20)" json:"actor_role"` // customer, shipper, staff, system
+	Reason     string                `json:"reason"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+// BookStock - Tồn kho của một đầu sách vật lý
+type BookStock struct {
+	gorm.Model
+	BookID            uint `gorm:"uniqueIndex" json:"book_id"`
+	Book              Book `gorm:"foreignKey:BookID" json:"-"`
+	OnHand            int  `json:"on_hand"`             // Số sách thực có trong kho
+	Reserved          int  `json:"reserved"`            // Số sách đang giữ cho các đơn chưa giao
+	LowStockThreshold int  `json:"low_stock_threshold"` // Cảnh báo khi số sách có thể bán <= ngưỡng này
+}
+
+// Available là số sách còn có thể đặt
+func (s BookStock) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+// IsLow cho biết sách đã chạm ngưỡng cảnh báo sắp hết hàng
+func (s BookStock) IsLow() bool {
+	return s.Available() <= s.LowStockThreshold
+}
+
+// StockMovement - Một dòng trong sổ kho, ghi lại mọi thay đổi của tồn kho
+type StockMovement struct {
+	gorm.Model
+	BookID        uint   `gorm:"index" json:"book_id"`
+	OrderID       *uint  `gorm:"index" json:"order_id"` // Đơn hàng gây ra biến động, nếu có
+	Type          string `json:"type"`                  // receipt, reservation, release, sale, adjustment
+	Quantity      int    `json:"quantity"`              // Thay đổi có dấu: của OnHand với receipt/adjustment/sale, của Reserved với reservation/release
+	OnHandAfter   int    `json:"on_hand_after"`
+	ReservedAfter int    `json:"reserved_after"`
+	Reason        string `json:"reason"`
+	CreatedBy     uint   `json:"created_by"`
 }
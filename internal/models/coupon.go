@@ -0,0 +1,65 @@
+package models
+
+import (
+	"bookstack/internal/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Coupon - Mã giảm giá hoặc chương trình khuyến mãi (AutoApply = true thì tự áp dụng, không cần nhập mã)
+type Coupon struct {
+	gorm.Model
+	Code          string        `gorm:"type:varchar(50);uniqueIndex:idx_coupon_code,where:code <> '' AND deleted_at IS NULL" json:"code"` // Mã nhập khi đặt hàng (chữ hoa), rỗng với khuyến mãi tự áp dụng
+	Name          string        `json:"name"`
+	Type          string        `gorm:"type:varchar(20)" json:"type"`       // percentage, fixed, buy_x_get_y, free_shipping
+	Value         money.Decimal `gorm:"type:decimal(18,8)" json:"value"`    // Phần trăm (percentage) hoặc số tiền theo tiền tệ gốc (fixed)
+	BuyQuantity   int           `json:"buy_quantity"`                       // X của buy_x_get_y
+	GetQuantity   int           `json:"get_quantity"`                       // Y của buy_x_get_y
+	ScopeType     string        `gorm:"type:varchar(20)" json:"scope_type"` // "", shelve hoặc tag
+	ScopeShelveID uint          `json:"scope_shelve_id"`
+	ScopeTagName  string        `json:"scope_tag_name"`
+	ScopeTagValue string        `json:"scope_tag_value"`                        // Rỗng thì chỉ so khớp tên tag
+	MinSubtotal   money.Decimal `gorm:"type:decimal(18,8)" json:"min_subtotal"` // Tổng tiền tối thiểu của các sách thuộc phạm vi (tiền tệ gốc)
+	MaxDiscount   money.Decimal `gorm:"type:decimal(18,8)" json:"max_discount"` // Giới hạn số tiền giảm của percentage (tiền tệ gốc), 0 là không giới hạn
+	StartsAt      *time.Time    `json:"starts_at"`
+	EndsAt        *time.Time    `json:"ends_at"`
+	UsageLimit    int           `json:"usage_limit"`    // Tổng số lượt dùng, 0 là không giới hạn
+	PerUserLimit  int           `json:"per_user_limit"` // Số lượt dùng mỗi người, 0 là không giới hạn
+	AutoApply     bool          `json:"auto_apply"`
+	Active        bool          `json:"active"`
+}
+
+// ValidAt cho biết coupon đang bật và nằm trong thời gian hiệu lực
+func (c Coupon) ValidAt(now time.Time) bool {
+	if !c.Active {
+		return false
+	}
+	if c.StartsAt != nil && now.Before(*c.StartsAt) {
+		return false
+	}
+	if c.EndsAt != nil && !now.Before(*c.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// CouponRedemption - Một lượt sử dụng coupon, dùng để giới hạn số lượt dùng
+type CouponRedemption struct {
+	gorm.Model
+	CouponID uint          `gorm:"index;not null" json:"coupon_id"`
+	OrderID  uint          `gorm:"index;not null" json:"order_id"`
+	UserID   uint          `gorm:"index;not null" json:"user_id"`
+	Amount   money.Decimal `gorm:"type:decimal(18,8)" json:"amount"` // Theo tiền tệ của đơn hàng
+}
+
+// OrderDiscount - Một khoản giảm giá đã áp dụng cho đơn hàng
+type OrderDiscount struct {
+	gorm.Model
+	OrderID  uint          `gorm:"index;not null" json:"order_id"`
+	CouponID uint          `json:"coupon_id"`
+	Code     string        `json:"code"`
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	Amount   money.Decimal `gorm:"type:decimal(18,8)" json:"amount"` // Số tiền giảm (với free_shipping là phí vận chuyển được miễn)
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"bookstack/internal/money"
+
+	"gorm.io/gorm"
+)
+
+// ExchangeRate - Tỉ giá quy đổi từ tiền tệ gốc (constant.BaseCurrency) sang một tiền tệ khách có thể thanh toán
+type ExchangeRate struct {
+	gorm.Model
+	Currency  string        `gorm:"type:varchar(3);uniqueIndex;not null" json:"currency"` // Mã ISO 4217, ví dụ VND
+	Rate      money.Decimal `gorm:"type:decimal(18,8);not null" json:"rate"`              // Số đơn vị Currency của một đơn vị tiền tệ gốc
+	UpdatedBy uint          `json:"updated_by"`
+}
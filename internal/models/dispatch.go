@@ -0,0 +1,58 @@
+package models
+
+import (
+	"bookstack/internal/geo"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeliveryOffer - Lời mời giao một đơn hàng gửi cho shipper. Mỗi đơn có tối đa một lời mời đang chờ;
+// shipper từ chối hoặc để hết hạn thì lời mời được chuyển cho ứng viên tiếp theo.
+type DeliveryOffer struct {
+	gorm.Model
+	OrderID     uint       `gorm:"index;not null" json:"order_id"`
+	Order       Order      `gorm:"foreignKey:OrderID" json:"-"`
+	ShipperID   uint       `gorm:"index;not null" json:"shipper_id"`
+	Status      string     `gorm:"type:varchar(20);index" json:"status"` // constant.Offer*
+	Score       int        `json:"score"`                                // Điểm xếp hạng của shipper lúc mời
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at"`
+	Reason      string     `json:"reason"` // Lý do từ chối/hủy
+}
+
+// ShipperServiceArea - Vùng phục vụ của shipper theo tọa độ: đa giác hoặc hình tròn quanh một điểm.
+// Shipper chưa khai báo vùng phục vụ được ghép đơn theo WorkingArea.
+type ShipperServiceArea struct {
+	gorm.Model
+	UserID    uint        `gorm:"uniqueIndex;not null" json:"user_id"`
+	CenterLat float64     `json:"center_lat"`
+	CenterLng float64     `json:"center_lng"`
+	RadiusKm  float64     `json:"radius_km"`
+	Polygon   geo.Polygon `gorm:"type:text;serializer:json" json:"polygon"` // Rỗng khi dùng bán kính
+}
+
+// Area chuyển sang geo.ServiceArea để tính toán
+func (s ShipperServiceArea) Area() geo.ServiceArea {
+	return geo.ServiceArea{
+		Center:   geo.Point{Lat: s.CenterLat, Lng: s.CenterLng},
+		RadiusKm: s.RadiusKm,
+		Polygon:  s.Polygon,
+	}
+}
+
+// DeliveryProof - Bằng chứng của một lần giao đơn: ảnh, chữ ký người nhận và/hoặc mã nhận hàng đã xác minh.
+// Lần giao thất bại cũng được ghi lại cùng lý do.
+type DeliveryProof struct {
+	gorm.Model
+	OrderID       uint     `gorm:"index;not null" json:"order_id"`
+	ShipperID     uint     `gorm:"index;not null" json:"shipper_id"`
+	Outcome       string   `gorm:"type:varchar(20)" json:"outcome"` // constant.DeliveryOutcome*
+	PhotoPath     string   `json:"-"`                               // Khóa file ảnh trong kho lưu trữ, rỗng khi không có
+	SignaturePath string   `json:"-"`                               // Khóa file ảnh chữ ký, rỗng khi không có
+	CodeVerified  bool     `json:"code_verified"`                   // Khách đã đọc đúng mã nhận hàng
+	RecipientName string   `json:"recipient_name"`
+	Reason        string   `json:"reason"` // Lý do giao thất bại hoặc ghi chú
+	Lat           *float64 `json:"lat"`    // Vị trí shipper lúc ghi nhận (không bắt buộc)
+	Lng           *float64 `json:"lng"`
+}
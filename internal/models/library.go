@@ -0,0 +1,13 @@
+package models
+
+import "gorm.io/gorm"
+
+// BookEntitlement - Quyền đọc một cuốn sách điện tử của người dùng, cấp khi đơn mua bản điện tử đã thanh toán
+// và thu hồi (xóa hẳn) khi đơn bị hủy hoặc dòng đơn được hoàn tiền. Mỗi người dùng có tối đa một quyền trên một sách.
+type BookEntitlement struct {
+	gorm.Model
+	UserID  uint `gorm:"uniqueIndex:idx_book_entitlement;not null" json:"user_id"`
+	BookID  uint `gorm:"uniqueIndex:idx_book_entitlement;not null" json:"book_id"`
+	Book    Book `gorm:"foreignKey:BookID" json:"-"`
+	OrderID uint `gorm:"index" json:"order_id"` // Đơn hàng đã cấp quyền
+}
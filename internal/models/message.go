@@ -0,0 +1,52 @@
+package models
+
+import (
+	"bookstack/internal/messaging"
+	"encoding/json"
+	"time"
+)
+
+// OutboxMessage - Sự kiện chờ phát lên bus sự kiện. Được ghi trong cùng transaction với thay đổi nghiệp vụ
+// nên không mất khi broker không sẵn sàng; relay phát lại tới khi broker xác nhận (at-least-once).
+type OutboxMessage struct {
+	ID            uint       `gorm:"primarykey" json:"id"`
+	EventID       string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"event_id"` // ID envelope, consumer dùng để loại trùng
+	Type          string     `gorm:"type:varchar(100);not null" json:"type"`
+	Version       int        `json:"version"`
+	Payload       string     `gorm:"type:text" json:"payload"`
+	OccurredAt    time.Time  `json:"occurred_at"`
+	Attempts      int        `json:"attempts"` // Số lần publish thất bại
+	LastError     string     `json:"last_error"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	PublishedAt   *time.Time `gorm:"index" json:"published_at"`
+}
+
+// NewOutboxMessage tạo dòng outbox cho envelope
+func NewOutboxMessage(env messaging.Envelope) OutboxMessage {
+	return OutboxMessage{
+		EventID:       env.ID,
+		Type:          env.Type,
+		Version:       env.Version,
+		Payload:       string(env.Payload),
+		OccurredAt:    env.OccurredAt,
+		NextAttemptAt: env.OccurredAt,
+	}
+}
+
+// Envelope dựng lại envelope để publish, cùng ID qua mọi lần publish lại
+func (m OutboxMessage) Envelope() messaging.Envelope {
+	return messaging.Envelope{
+		ID:         m.EventID,
+		Type:       m.Type,
+		Version:    m.Version,
+		OccurredAt: m.OccurredAt,
+		Payload:    json.RawMessage(m.Payload),
+	}
+}
+
+// ProcessedMessage - Message một consumer đã xử lý xong, dùng để bỏ qua message bị giao lại
+type ProcessedMessage struct {
+	Consumer    string    `gorm:"primaryKey;type:varchar(50)"`
+	MessageID   string    `gorm:"primaryKey;type:varchar(64)"`
+	ProcessedAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PasswordResetToken - Token đặt lại mật khẩu gửi qua email, chỉ dùng được một lần trước khi hết hạn.
+// Chỉ lưu hash của token nên người đọc được database cũng không dùng được token.
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    int        `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"` // SHA-256 hex của token
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"bookstack/internal/money"
+
+	"gorm.io/gorm"
+)
+
+// Payment - Một lần thanh toán (giao dịch) của đơn hàng qua một cổng thanh toán
+type Payment struct {
+	gorm.Model
+	OrderID        uint          `gorm:"index;not null" json:"order_id"`
+	Order          Order         `gorm:"foreignKey:OrderID" json:"-"`
+	Provider       string        `gorm:"type:varchar(20);not null" json:"provider"` // constant.PaymentProvider*
+	ExternalID     string        `gorm:"type:varchar(64);index" json:"external_id"` // Mã giao dịch phía cổng thanh toán
+	CaptureID      string        `gorm:"type:varchar(64);index" json:"capture_id"`  // Mã lần thu tiền, dùng để hoàn tiền
+	Status         string        `gorm:"type:varchar(20)" json:"status"`            // constant.Payment*
+	Amount         money.Decimal `gorm:"type:decimal(18,8)" json:"amount"`          // Số tiền cần thanh toán
+	CapturedAmount money.Decimal `gorm:"type:decimal(18,8)" json:"captured_amount"` // Số tiền đã thu
+	RefundedAmount money.Decimal `gorm:"type:decimal(18,8)" json:"refunded_amount"` // Số tiền đã hoàn
+	Currency       string        `gorm:"type:varchar(3)" json:"currency"`
+	ApprovalURL    string        `gorm:"type:varchar(255)" json:"approval_url,omitempty"` // Trang người mua xác nhận thanh toán
+}
+
+// Refund - Một lần hoàn tiền cho giao dịch, do nhân viên tạo hoặc ghi nhận từ webhook của cổng thanh toán
+type Refund struct {
+	gorm.Model
+	OrderID    uint          `gorm:"index;not null" json:"order_id"`
+	PaymentID  uint          `gorm:"index;not null" json:"payment_id"`
+	ExternalID string        `gorm:"type:varchar(64);index" json:"external_id"` // Mã hoàn tiền phía cổng thanh toán
+	Amount     money.Decimal `gorm:"type:decimal(18,8)" json:"amount"`
+	Reason     string        `json:"reason"`
+	CreatedBy  uint          `json:"created_by"`                           // 0 khi hoàn tiền được tạo trực tiếp trên cổng thanh toán
+	Status     string        `gorm:"type:varchar(20);index" json:"status"` // constant.Refund*
+	FailReason string        `json:"fail_reason"`                          // Lỗi từ cổng thanh toán khi Status là failed
+	Lines      []RefundLine  `gorm:"foreignKey:RefundID" json:"lines"`
+}
+
+// RefundLine - Số lượng sách của một dòng đơn hàng được hoàn tiền
+type RefundLine struct {
+	gorm.Model
+	RefundID      uint          `gorm:"index;not null" json:"refund_id"`
+	OrderDetailID uint          `gorm:"index" json:"order_detail_id"`
+	BookID        uint          `json:"book_id"`
+	Quantity      int           `json:"quantity"`
+	Amount        money.Decimal `gorm:"type:decimal(18,8)" json:"amount"`
+}
+
+// PaymentWebhookEvent - Sự kiện webhook đã xử lý, dùng để bỏ qua các lần cổng thanh toán gửi lại
+type PaymentWebhookEvent struct {
+	gorm.Model
+	Provider  string `gorm:"type:varchar(20);uniqueIndex:idx_payment_webhook_event;not null" json:"provider"`
+	EventID   string `gorm:"type:varchar(64);uniqueIndex:idx_payment_webhook_event;not null" json:"event_id"`
+	EventType string `gorm:"type:varchar(64)" json:"event_type"`
+	PaymentID *uint  `gorm:"index" json:"payment_id"` // nil khi không tìm thấy giao dịch tương ứng
+}
@@ -1,22 +1,29 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
-	ID             int          `json:"id"`
-	FullName       string       `json:"name"`
-	Email          string       `json:"email"`
-	Password       string       `json:"password"`
-	RememberToken  string       `json:"remember_token"`
-	WorkingArea    string       `json:"working_area"`
-	Phone          string       `json:"phone"`
-	EmailConfirmed bool         `json:"email_confirmed"`
-	ImageId        int          `json:"image_id"`
-	Roles          []Role       `gorm:"many2many:user_roles"`
-	RefreshToken   RefreshToken `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
-	Orders         []Order      `gorm:"foreignKey:UserID;references:ID" json:"orders"`
-	ShipperOrders  []Order      `gorm:"foreignKey:ShipperID;references:ID" json:"shipper_orders"`
+	ID                 int          `json:"id"`
+	FullName           string       `json:"name"`
+	Email              string       `json:"email"`
+	Password           string       `json:"password"`
+	RememberToken      string       `json:"remember_token"`
+	WorkingArea        string       `json:"working_area"`
+	Available          bool         `gorm:"default:true" json:"available"` // Shipper đang nhận lời mời giao đơn
+	Phone              string       `json:"phone"`
+	EmailConfirmed     bool         `json:"email_confirmed"`
+	EmailConfirmedAt   *time.Time   `json:"email_confirmed_at"`
+	VerificationSentAt *time.Time   `json:"-"` // Lần gửi email xác nhận gần nhất, dùng để giới hạn gửi lại
+	ImageId            int          `json:"image_id"`
+	Roles              []Role       `gorm:"many2many:user_roles"`
+	RefreshToken       RefreshToken `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Orders             []Order      `gorm:"foreignKey:UserID;references:ID" json:"orders"`
+	ShipperOrders      []Order      `gorm:"foreignKey:ShipperID;references:ID" json:"shipper_orders"`
 }
 
 // Role struct
@@ -37,7 +44,21 @@ type Permission struct {
 	Name string `gorm:"unique"`
 }
 
-//refreshToken
+// EntityPermission cấp quyền cho một role trên một sách/chương/trang cụ thể,
+// chỉ được xét khi entity bị giới hạn truy cập (Restricted) và ghi đè quyền chung của role
+type EntityPermission struct {
+	gorm.Model
+	EntityType string `gorm:"uniqueIndex:idx_entity_permission;not null" json:"entity_type"` // book, chapter, page
+	EntityID   uint   `gorm:"uniqueIndex:idx_entity_permission;not null" json:"entity_id"`
+	RoleID     uint   `gorm:"uniqueIndex:idx_entity_permission;not null" json:"role_id"`
+	Role       Role   `gorm:"foreignKey:RoleID" json:"-"`
+	CanView    bool   `json:"can_view"`
+	CanCreate  bool   `json:"can_create"`
+	CanUpdate  bool   `json:"can_update"`
+	CanDelete  bool   `json:"can_delete"`
+}
+
+// refreshToken
 type RefreshToken struct {
 	ID     uint   `json:"ID"`
 	Token  string `json:"token"`
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription - Webhook của hệ thống đối tác, nhận các loại sự kiện nghiệp vụ đã đăng ký
+type WebhookSubscription struct {
+	gorm.Model
+	URL         string `gorm:"type:varchar(500);not null" json:"url"`
+	Secret      string `gorm:"type:varchar(100);not null" json:"-"`   // Khóa ký HMAC nội dung gửi đi
+	EventTypes  string `gorm:"type:text;not null" json:"event_types"` // Các loại sự kiện cách nhau bởi dấu phẩy, "*" là mọi sự kiện
+	Description string `gorm:"type:varchar(255)" json:"description"`
+	Active      bool   `json:"active"`
+	CreatedBy   uint   `json:"created_by"`
+}
+
+// EventTypeList trả về các loại sự kiện đã đăng ký
+func (s WebhookSubscription) EventTypeList() []string {
+	if s.EventTypes == "" {
+		return nil
+	}
+	return strings.Split(s.EventTypes, ",")
+}
+
+// Subscribes cho biết subscription nhận loại sự kiện eventType
+func (s WebhookSubscription) Subscribes(eventType string) bool {
+	for _, subscribed := range s.EventTypeList() {
+		if subscribed == "*" || subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery - Một sự kiện cần giao tới một subscription, cũng là nhật ký giao với kết quả lần gửi gần nhất.
+// Mỗi sự kiện chỉ có một lần giao cho mỗi subscription dù bus giao sự kiện nhiều lần.
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primarykey" json:"id"`
+	SubscriptionID uint       `gorm:"uniqueIndex:idx_webhook_delivery_event;not null" json:"subscription_id"`
+	EventID        string     `gorm:"type:varchar(64);uniqueIndex:idx_webhook_delivery_event;not null" json:"event_id"`
+	EventType      string     `gorm:"type:varchar(100);not null" json:"event_type"`
+	Payload        string     `gorm:"type:text" json:"payload"`             // Body gửi đi: envelope JSON của sự kiện
+	Status         string     `gorm:"type:varchar(20);index" json:"status"` // constant.WebhookDelivery*
+	Attempts       int        `json:"attempts"`
+	ResponseCode   int        `json:"response_code"` // Mã HTTP lần gửi gần nhất, 0 là không nhận được response
+	ResponseBody   string     `gorm:"type:text" json:"response_body"`
+	LastError      string     `json:"last_error"`
+	DurationMs     int64      `json:"duration_ms"`
+	NextAttemptAt  time.Time  `gorm:"index" json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
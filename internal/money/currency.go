@@ -0,0 +1,46 @@
+package money
+
+import "strings"
+
+// minorUnits là số chữ số thập phân của đơn vị tiền nhỏ nhất theo ISO 4217 cho các tiền tệ được hỗ trợ
+var minorUnits = map[string]int{
+	"AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CNY": 2, "CZK": 2, "DKK": 2,
+	"EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2, "JOD": 3,
+	"JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3,
+	"PHP": 2, "PLN": 2, "SEK": 2, "SGD": 2, "THB": 2, "TWD": 2, "USD": 2, "VND": 0,
+}
+
+// NormalizeCurrency chuẩn hóa mã tiền tệ về dạng viết hoa, ví dụ "vnd" -> "VND"
+func NormalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// KnownCurrency cho biết mã tiền tệ có trong danh sách hỗ trợ
+func KnownCurrency(currency string) bool {
+	_, ok := minorUnits[currency]
+	return ok
+}
+
+// MinorUnits là số chữ số thập phân của tiền tệ, mặc định 2 với mã không biết
+func MinorUnits(currency string) int {
+	if units, ok := minorUnits[currency]; ok {
+		return units
+	}
+	return 2
+}
+
+// Converter quy đổi số tiền từ tiền tệ gốc của giá sách sang tiền tệ của đơn hàng
+// theo tỉ giá đã chốt: Rate là số đơn vị Currency của một đơn vị tiền tệ gốc
+type Converter struct {
+	Currency string
+	Rate     Decimal
+}
+
+// Convert quy đổi rồi làm tròn tới đơn vị nhỏ nhất của Currency
+func (c Converter) Convert(amount Decimal) (Decimal, error) {
+	converted, err := amount.Mul(c.Rate)
+	if err != nil {
+		return Zero, err
+	}
+	return converted.RoundCurrency(c.Currency)
+}
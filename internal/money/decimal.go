@@ -0,0 +1,339 @@
+// Package money cung cấp kiểu Decimal số thập phân cố định dùng cho tiền và tỉ giá, thay cho float64
+// (float64 không biểu diễn chính xác 0.1, cộng dồn nhiều dòng đơn hàng sẽ lệch vài xu).
+// Decimal được lưu dưới dạng số nguyên int64 với Scale chữ số thập phân, đọc/ghi DB dạng chuỗi
+// và JSON dạng số để giữ tương thích với client. Phép tính tràn phạm vi int64 trả về ErrOverflow thay vì panic.
+//
+// Cột DB lưu Decimal dùng kiểu decimal(18,8) (Precision, Scale): mọi giá trị cột chứa được đều vừa int64 nên
+// Scan không bao giờ tràn, giá trị lớn hơn bị Value từ chối trước khi ghi.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale là số chữ số thập phân Decimal lưu được, đủ cho tỉ giá kiểu 0.00003937
+const Scale = 8
+
+// Precision là tổng số chữ số của cột DB decimal(Precision, Scale) lưu Decimal
+const Precision = 18
+
+const unit = 100000000 // 10^Scale
+
+var (
+	ErrInvalidDecimal = errors.New("invalid decimal")
+	ErrOverflow       = errors.New("decimal overflow")
+	ErrDivisionByZero = errors.New("decimal division by zero")
+)
+
+var (
+	bigUnit = big.NewInt(unit)
+	// Giá trị (đã nhân 10^Scale) phải nhỏ hơn số này để vừa cột decimal(Precision, Scale)
+	maxStored = pow10(Precision)
+)
+
+// Decimal là số thập phân cố định Scale chữ số sau dấu phẩy. Giá trị zero là số 0.
+type Decimal struct {
+	value int64 // Giá trị nhân với 10^Scale
+}
+
+var Zero = Decimal{}
+
+// New tạo Decimal value * 10^exp, ví dụ New(1999, -2) = 19.99. Dùng cho hằng số trong code, tràn thì panic.
+func New(value int64, exp int) Decimal {
+	var d Decimal
+	var err error
+	if exp < -Scale {
+		d, err = fromBig(new(big.Int).SetInt64(value), pow10(-exp-Scale))
+	} else {
+		d, err = fromInt(new(big.Int).Mul(big.NewInt(value), pow10(exp+Scale)))
+	}
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewFromInt tạo Decimal từ số nguyên, dùng cho hằng số trong code
+func NewFromInt(value int64) Decimal {
+	return New(value, 0)
+}
+
+// Parse đọc chuỗi dạng "-123.45". Chuỗi có nhiều hơn Scale chữ số thập phân bị từ chối.
+func Parse(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Zero, fmt.Errorf("%w: empty string", ErrInvalidDecimal)
+	}
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+	integer, fraction, _ := strings.Cut(s, ".")
+	if integer == "" && fraction == "" || !digits(integer) || !digits(fraction) {
+		return Zero, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	if len(fraction) > Scale {
+		return Zero, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidDecimal, s, Scale)
+	}
+	digitsOnly := strings.TrimLeft(integer+fraction+strings.Repeat("0", Scale-len(fraction)), "0")
+	if digitsOnly == "" {
+		return Zero, nil
+	}
+	value, err := strconv.ParseInt(digitsOnly, 10, 64)
+	if err != nil {
+		return Zero, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if negative {
+		value = -value
+	}
+	return Decimal{value: value}, nil
+}
+
+// MustParse như Parse nhưng panic khi lỗi, dùng cho hằng số trong code
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func digits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Add cộng hai Decimal, trả về ErrOverflow khi kết quả vượt phạm vi
+func (d Decimal) Add(other Decimal) (Decimal, error) {
+	return fromInt(new(big.Int).Add(big.NewInt(d.value), big.NewInt(other.value)))
+}
+
+// Sub trừ other, trả về ErrOverflow khi kết quả vượt phạm vi
+func (d Decimal) Sub(other Decimal) (Decimal, error) {
+	return d.Add(other.Neg())
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: -d.value}
+}
+
+// Mul nhân hai Decimal, làm tròn tới Scale chữ số (nửa xa số 0)
+func (d Decimal) Mul(other Decimal) (Decimal, error) {
+	product := new(big.Int).Mul(big.NewInt(d.value), big.NewInt(other.value))
+	return fromBig(product, bigUnit)
+}
+
+// MulInt nhân với số nguyên, ví dụ đơn giá nhân số lượng
+func (d Decimal) MulInt(n int) (Decimal, error) {
+	return fromInt(new(big.Int).Mul(big.NewInt(d.value), big.NewInt(int64(n))))
+}
+
+// Div chia cho other, làm tròn tới Scale chữ số (nửa xa số 0). Chia cho 0 trả về ErrDivisionByZero.
+func (d Decimal) Div(other Decimal) (Decimal, error) {
+	if other.value == 0 {
+		return Zero, ErrDivisionByZero
+	}
+	numerator := new(big.Int).Mul(big.NewInt(d.value), bigUnit)
+	return fromBig(numerator, big.NewInt(other.value))
+}
+
+// Round làm tròn tới places chữ số thập phân (0..Scale), nửa xa số 0
+func (d Decimal) Round(places int) (Decimal, error) {
+	return fromInt(d.round(places))
+}
+
+// RoundCurrency làm tròn tới đơn vị nhỏ nhất của tiền tệ (2 chữ số với USD, 0 với VND)
+func (d Decimal) RoundCurrency(currency string) (Decimal, error) {
+	return d.Round(MinorUnits(currency))
+}
+
+// round trả về giá trị (đã nhân 10^Scale) làm tròn tới places chữ số, có thể vượt phạm vi int64
+func (d Decimal) round(places int) *big.Int {
+	if places >= Scale {
+		return big.NewInt(d.value)
+	}
+	if places < 0 {
+		places = 0
+	}
+	step := pow10(Scale - places)
+	return new(big.Int).Mul(roundDiv(big.NewInt(d.value), step), step)
+}
+
+// Cmp trả về -1, 0, 1 khi d nhỏ hơn, bằng, lớn hơn other
+func (d Decimal) Cmp(other Decimal) int {
+	switch {
+	case d.value < other.value:
+		return -1
+	case d.value > other.value:
+		return 1
+	}
+	return 0
+}
+
+func (d Decimal) Equal(other Decimal) bool       { return d.value == other.value }
+func (d Decimal) LessThan(other Decimal) bool    { return d.value < other.value }
+func (d Decimal) GreaterThan(other Decimal) bool { return d.value > other.value }
+func (d Decimal) IsZero() bool                   { return d.value == 0 }
+func (d Decimal) IsPositive() bool               { return d.value > 0 }
+func (d Decimal) IsNegative() bool               { return d.value < 0 }
+
+func Min(a, b Decimal) Decimal {
+	if a.LessThan(b) {
+		return a
+	}
+	return b
+}
+
+func Max(a, b Decimal) Decimal {
+	if a.GreaterThan(b) {
+		return a
+	}
+	return b
+}
+
+// String trả về dạng thập phân ngắn nhất, ví dụ "19.9", "-3", "0.00003937"
+func (d Decimal) String() string {
+	s := d.StringFixed(Scale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// StringFixed trả về đúng places chữ số thập phân (sau khi làm tròn), ví dụ "19.90"
+func (d Decimal) StringFixed(places int) string {
+	if places < 0 {
+		places = 0
+	}
+	if places > Scale {
+		places = Scale
+	}
+	magnitude := d.round(places)
+	sign := ""
+	if magnitude.Sign() < 0 {
+		sign = "-"
+		magnitude.Neg(magnitude)
+	}
+	integer, fraction := new(big.Int).QuoRem(magnitude, bigUnit, new(big.Int))
+	if places == 0 {
+		return sign + integer.String()
+	}
+	frac := fmt.Sprintf("%0*s", Scale, fraction.String())[:places]
+	return sign + integer.String() + "." + frac
+}
+
+// MarshalJSON ghi Decimal dạng số JSON
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON nhận số JSON hoặc chuỗi chứa số; null là 0
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*d = Zero
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	} else if strings.ContainsAny(s, "eE") {
+		// Số dạng mũ (1e3) từ các client dùng float
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidDecimal, s)
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan đọc cột decimal/numeric của DB
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Zero
+		return nil
+	case []byte:
+		return d.scanString(string(v))
+	case string:
+		return d.scanString(v)
+	case int64:
+		parsed, err := fromInt(new(big.Int).Mul(big.NewInt(v), bigUnit))
+		if err != nil {
+			return err
+		}
+		*d = parsed
+		return nil
+	case float64:
+		return d.scanString(strconv.FormatFloat(v, 'f', Scale, 64))
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrInvalidDecimal, src)
+}
+
+func (d *Decimal) scanString(s string) error {
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value ghi Decimal vào DB dạng chuỗi để không mất độ chính xác. Giá trị không vừa cột decimal(Precision, Scale)
+// bị từ chối để mọi giá trị đã lưu đều đọc lại được.
+func (d Decimal) Value() (driver.Value, error) {
+	if new(big.Int).Abs(big.NewInt(d.value)).Cmp(maxStored) >= 0 {
+		return nil, fmt.Errorf("%w: %s does not fit decimal(%d,%d)", ErrOverflow, d, Precision, Scale)
+	}
+	return d.String(), nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundDiv chia làm tròn nửa xa số 0
+func roundDiv(numerator, denominator *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+	if twice.Cmp(new(big.Int).Abs(denominator)) >= 0 {
+		if (numerator.Sign() < 0) != (denominator.Sign() < 0) {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return quotient
+}
+
+func fromBig(numerator, denominator *big.Int) (Decimal, error) {
+	return fromInt(roundDiv(numerator, denominator))
+}
+
+// fromInt tạo Decimal từ giá trị đã nhân 10^Scale. Phạm vi đối xứng (không nhận math.MinInt64) để Neg không tràn.
+func fromInt(value *big.Int) (Decimal, error) {
+	if !value.IsInt64() || value.Int64() == math.MinInt64 {
+		return Zero, ErrOverflow
+	}
+	return Decimal{value: value.Int64()}, nil
+}
@@ -0,0 +1,149 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"19.99", "19.99"},
+		{"-0.5", "-0.5"},
+		{"+3", "3"},
+		{".25", "0.25"},
+		{"1.", "1"},
+		{"0.00003937", "0.00003937"},
+		{"000120.1000", "120.1"},
+		{"0", "0"},
+	}
+	for _, tt := range tests {
+		d, err := Parse(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, d.String(), tt.in)
+	}
+
+	for _, in := range []string{"", "abc", "1.2.3", "1e3", "0.123456789", "-", ".", "99999999999999999999"} {
+		_, err := Parse(in)
+		assert.Error(t, err, in)
+	}
+}
+
+// must trả về kết quả của phép tính, lỗi thì test dừng
+func must(t *testing.T) func(Decimal, error) Decimal {
+	return func(d Decimal, err error) Decimal {
+		t.Helper()
+		require.NoError(t, err)
+		return d
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	// 0.1 + 0.2 cộng bằng float64 ra 0.30000000000000004
+	assert.Equal(t, "0.3", must(t)(MustParse("0.1").Add(MustParse("0.2"))).String())
+	assert.Equal(t, "-1.5", must(t)(MustParse("1").Sub(MustParse("2.5"))).String())
+	assert.Equal(t, "59.97", must(t)(MustParse("19.99").MulInt(3)).String())
+	assert.Equal(t, "507.746", must(t)(MustParse("19.99").Mul(MustParse("25.4"))).String())
+	assert.Equal(t, "3.33333333", must(t)(MustParse("10").Div(MustParse("3"))).String())
+	assert.Equal(t, "-0.66666667", must(t)(MustParse("-2").Div(MustParse("3"))).String())
+	assert.Equal(t, "0.00012", New(12, -5).String())
+	assert.Equal(t, "1500", New(15, 2).String())
+	assert.True(t, Zero.IsZero())
+	assert.Equal(t, MustParse("1"), Min(MustParse("1"), MustParse("2")))
+	assert.Equal(t, MustParse("2"), Max(MustParse("1"), MustParse("2")))
+	assert.Equal(t, -1, MustParse("1.01").Cmp(MustParse("1.1")))
+}
+
+func TestOverflow(t *testing.T) {
+	largest := Decimal{value: math.MaxInt64}
+	_, err := largest.Add(MustParse("0.00000001"))
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = largest.Neg().Sub(MustParse("0.00000001"))
+	assert.ErrorIs(t, err, ErrOverflow, "math.MinInt64 is outside the symmetric range")
+	_, err = largest.Round(0)
+	assert.ErrorIs(t, err, ErrOverflow)
+	assert.Equal(t, "92233720369", largest.StringFixed(0), "formatting rounds without overflowing")
+
+	// 1.000.000 cuốn sách 25.000.000 VND
+	_, err = MustParse("25000000").MulInt(1000000)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = MustParse("25000000").Mul(MustParse("25000000"))
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = MustParse("1").Div(MustParse("0.00000001"))
+	require.NoError(t, err)
+	_, err = MustParse("1000").Div(MustParse("0.00000001"))
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = MustParse("1").Div(Zero)
+	assert.ErrorIs(t, err, ErrDivisionByZero)
+
+	_, err = Converter{Currency: "VND", Rate: MustParse("25400")}.Convert(MustParse("90000000"))
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestRound(t *testing.T) {
+	assert.Equal(t, "2.68", must(t)(MustParse("2.675").Round(2)).String())
+	assert.Equal(t, "-2.68", must(t)(MustParse("-2.675").Round(2)).String())
+	assert.Equal(t, "2.67", must(t)(MustParse("2.6749").Round(2)).String())
+	assert.Equal(t, "329947", must(t)(MustParse("329946.5").RoundCurrency("VND")).String())
+	assert.Equal(t, "1.235", must(t)(MustParse("1.2345").RoundCurrency("KWD")).String())
+	assert.Equal(t, "19.90", MustParse("19.9").StringFixed(2))
+	assert.Equal(t, "330000", MustParse("329999.5").StringFixed(0))
+	assert.Equal(t, "-0.01", MustParse("-0.005").StringFixed(2))
+}
+
+func TestConverter(t *testing.T) {
+	vnd := Converter{Currency: "VND", Rate: MustParse("25400")}
+	assert.Equal(t, "329946", must(t)(vnd.Convert(MustParse("12.99"))).String())
+
+	usd := Converter{Currency: "USD", Rate: MustParse("0.00003937")}
+	assert.Equal(t, "11.81", must(t)(usd.Convert(MustParse("300000"))).String())
+}
+
+func TestJSON(t *testing.T) {
+	var payload struct {
+		Price Decimal `json:"price"`
+		Fee   Decimal `json:"fee"`
+		Tax   Decimal `json:"tax"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"price":12.5,"fee":"0.10","tax":1e2}`), &payload))
+	assert.Equal(t, "12.5", payload.Price.String())
+	assert.Equal(t, "0.1", payload.Fee.String())
+	assert.Equal(t, "100", payload.Tax.String())
+
+	out, err := json.Marshal(payload)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"price":12.5,"fee":0.1,"tax":100}`, string(out))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price":"abc"}`), &payload))
+}
+
+func TestScanValue(t *testing.T) {
+	var d Decimal
+	require.NoError(t, d.Scan([]byte("1234.5600")))
+	assert.Equal(t, "1234.56", d.String())
+	require.NoError(t, d.Scan(int64(7)))
+	assert.Equal(t, "7", d.String())
+	require.NoError(t, d.Scan(nil))
+	assert.True(t, d.IsZero())
+
+	value, err := MustParse("19.99").Value()
+	require.NoError(t, err)
+	assert.Equal(t, "19.99", value)
+
+	// Giá trị lớn nhất của cột decimal(18,8) đọc lại được, giá trị lớn hơn không được ghi
+	largestStored := "9999999999.99999999"
+	require.NoError(t, d.Scan([]byte(largestStored)))
+	value, err = d.Value()
+	require.NoError(t, err)
+	assert.Equal(t, largestStored, value)
+	_, err = MustParse("10000000000").Value()
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = MustParse("-10000000000").Value()
+	assert.ErrorIs(t, err, ErrOverflow)
+}
@@ -0,0 +1,76 @@
+// Package notification chuyển sự kiện đơn hàng tới các kết nối SSE/WebSocket đang mở của người nhận.
+package notification
+
+import (
+	"sync"
+	"time"
+)
+
+// Event là một thông báo gửi cho một người dùng
+type Event struct {
+	Type      string     `json:"type"`    // constant.Event*
+	UserID    uint       `json:"user_id"` // Người nhận
+	OrderID   uint       `json:"order_id"`
+	Status    string     `json:"status,omitempty"` // Trạng thái đơn sau khi chuyển
+	ShipperID uint       `json:"shipper_id,omitempty"`
+	OfferID   uint       `json:"offer_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Hạn trả lời lời mời giao đơn
+	ETA       *time.Time `json:"eta,omitempty"`        // Thời gian dự kiến giao tới
+	Reason    string     `json:"reason,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Hub giữ các kết nối đang mở trên instance hiện tại theo người nhận. Một người dùng có thể mở
+// nhiều kết nối (nhiều tab, nhiều thiết bị), mỗi kết nối đều nhận đủ sự kiện.
+type Hub struct {
+	mu          sync.RWMutex
+	bufferSize  int
+	subscribers map[uint]map[chan Event]struct{}
+}
+
+func NewHub(bufferSize int) *Hub {
+	return &Hub{
+		bufferSize:  bufferSize,
+		subscribers: make(map[uint]map[chan Event]struct{}),
+	}
+}
+
+// Subscribe mở một kết nối nhận sự kiện của userId. Gọi hàm hủy trả về khi kết nối đóng.
+func (h *Hub) Subscribe(userId uint) (<-chan Event, func()) {
+	ch := make(chan Event, h.bufferSize)
+	h.mu.Lock()
+	if h.subscribers[userId] == nil {
+		h.subscribers[userId] = make(map[chan Event]struct{})
+	}
+	h.subscribers[userId][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userId], ch)
+			if len(h.subscribers[userId]) == 0 {
+				delete(h.subscribers, userId)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Deliver chuyển sự kiện tới mọi kết nối của người nhận mà không chờ; kết nối có bộ đệm đầy bị bỏ qua
+// sự kiện này. Trả về số kết nối đã nhận.
+func (h *Hub) Deliver(event Event) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	delivered := 0
+	for ch := range h.subscribers[event.UserID] {
+		select {
+		case ch <- event:
+			delivered++
+		default:
+		}
+	}
+	return delivered
+}
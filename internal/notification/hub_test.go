@@ -0,0 +1,35 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubDeliver(t *testing.T) {
+	hub := NewHub(1)
+	first, unsubscribeFirst := hub.Subscribe(1)
+	second, unsubscribeSecond := hub.Subscribe(1)
+	other, unsubscribeOther := hub.Subscribe(2)
+	defer unsubscribeOther()
+
+	assert.Equal(t, 2, hub.Deliver(Event{Type: "order.status_changed", UserID: 1, OrderID: 10}))
+	assert.Equal(t, uint(10), (<-first).OrderID)
+	assert.Equal(t, uint(10), (<-second).OrderID)
+	assert.Empty(t, other)
+
+	// Bộ đệm đầy thì sự kiện bị bỏ, không chặn người gửi
+	assert.Equal(t, 1, hub.Deliver(Event{UserID: 2, OrderID: 11}))
+	assert.Equal(t, 0, hub.Deliver(Event{UserID: 2, OrderID: 12}))
+	assert.Equal(t, uint(11), (<-other).OrderID)
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	_, open := <-first
+	assert.False(t, open)
+	assert.Equal(t, 1, hub.Deliver(Event{UserID: 1}))
+
+	unsubscribeSecond()
+	assert.Equal(t, 0, hub.Deliver(Event{UserID: 1}))
+	assert.Equal(t, 0, hub.Deliver(Event{UserID: 3}))
+}
@@ -0,0 +1,47 @@
+package payment
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/money"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// CashOnDeliveryProvider thu tiền mặt khi giao hàng. Giao dịch chờ thanh toán cho tới khi
+// shipper chuyển đơn sang Delivered, việc tất toán được thực hiện cùng lúc chuyển trạng thái đơn.
+type CashOnDeliveryProvider struct{}
+
+func NewCashOnDeliveryProvider() *CashOnDeliveryProvider {
+	return &CashOnDeliveryProvider{}
+}
+
+func (p *CashOnDeliveryProvider) Name() string {
+	return constant.PaymentProviderCashOnDelivery
+}
+
+func (p *CashOnDeliveryProvider) CreateIntent(ctx context.Context, intent Intent) (IntentResult, error) {
+	return IntentResult{
+		ExternalID: fmt.Sprintf("cod-%d", intent.OrderID),
+		Status:     constant.PaymentPending,
+	}, nil
+}
+
+// Capture không dùng được: tiền chỉ được thu khi giao hàng
+func (p *CashOnDeliveryProvider) Capture(ctx context.Context, externalID string) (CaptureResult, error) {
+	return CaptureResult{}, fmt.Errorf("%w: cash on delivery is settled on delivery", ErrNotSupported)
+}
+
+// Refund ghi nhận hoàn tiền mặt, việc trả tiền do nhân viên thực hiện
+func (p *CashOnDeliveryProvider) Refund(ctx context.Context, captureID string, amount money.Decimal, currency string) (RefundResult, error) {
+	return RefundResult{
+		RefundID: fmt.Sprintf("cod-refund-%d", time.Now().UnixNano()),
+		Amount:   amount,
+		Status:   constant.PaymentRefunded,
+	}, nil
+}
+
+func (p *CashOnDeliveryProvider) ParseWebhook(header http.Header, body []byte) (Event, bool, error) {
+	return Event{}, false, fmt.Errorf("%w: cash on delivery has no webhook", ErrNotSupported)
+}
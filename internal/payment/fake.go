@@ -0,0 +1,133 @@
+package payment
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/money"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// FakeProvider là cổng thanh toán trong bộ nhớ dùng cho kiểm thử và môi trường local.
+// Webhook của FakeProvider là Event dạng JSON, không ký.
+type FakeProvider struct {
+	mu       sync.Mutex
+	sequence int
+	intents  map[string]Intent
+	captures map[string]CaptureResult // theo ExternalID
+	refunded map[string]money.Decimal // theo CaptureID
+
+	// FailNext khiến lời gọi tiếp theo trả về lỗi này
+	FailNext error
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		intents:  make(map[string]Intent),
+		captures: make(map[string]CaptureResult),
+		refunded: make(map[string]money.Decimal),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return constant.PaymentProviderFake
+}
+
+func (p *FakeProvider) takeFailure() error {
+	err := p.FailNext
+	p.FailNext = nil
+	return err
+}
+
+func (p *FakeProvider) CreateIntent(ctx context.Context, intent Intent) (IntentResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.takeFailure(); err != nil {
+		return IntentResult{}, err
+	}
+	p.sequence++
+	externalID := fmt.Sprintf("FAKE-ORDER-%d", p.sequence)
+	p.intents[externalID] = intent
+	return IntentResult{
+		ExternalID:  externalID,
+		ApprovalURL: "https://fake.local/approve/" + externalID,
+		Status:      constant.PaymentPending,
+	}, nil
+}
+
+// Capture thu toàn bộ số tiền của giao dịch, gọi lại với cùng giao dịch trả về capture cũ
+func (p *FakeProvider) Capture(ctx context.Context, externalID string) (CaptureResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.takeFailure(); err != nil {
+		return CaptureResult{}, err
+	}
+	if capture, ok := p.captures[externalID]; ok {
+		return capture, nil
+	}
+	intent, ok := p.intents[externalID]
+	if !ok {
+		return CaptureResult{}, fmt.Errorf("fake order %s not found", externalID)
+	}
+	p.sequence++
+	capture := CaptureResult{
+		CaptureID: fmt.Sprintf("FAKE-CAPTURE-%d", p.sequence),
+		Amount:    intent.Amount,
+		Status:    constant.PaymentPaid,
+	}
+	p.captures[externalID] = capture
+	return capture, nil
+}
+
+// Refund hoàn một phần hoặc toàn bộ số tiền đã thu, không cho hoàn quá số đã thu
+func (p *FakeProvider) Refund(ctx context.Context, captureID string, amount money.Decimal, currency string) (RefundResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.takeFailure(); err != nil {
+		return RefundResult{}, err
+	}
+	var captured money.Decimal
+	found := false
+	for _, capture := range p.captures {
+		if capture.CaptureID == captureID {
+			captured, found = capture.Amount, true
+		}
+	}
+	if !found {
+		return RefundResult{}, fmt.Errorf("fake capture %s not found", captureID)
+	}
+	refunded, err := p.refunded[captureID].Add(amount)
+	if err != nil {
+		return RefundResult{}, err
+	}
+	if refunded.GreaterThan(captured) {
+		return RefundResult{}, fmt.Errorf("refund of %s exceeds captured amount", amount)
+	}
+	p.refunded[captureID] = refunded
+	p.sequence++
+	return RefundResult{
+		RefundID: fmt.Sprintf("FAKE-REFUND-%d", p.sequence),
+		Amount:   amount,
+		Status:   constant.PaymentRefunded,
+	}, nil
+}
+
+// Refunded trả về tổng số tiền đã hoàn của một capture
+func (p *FakeProvider) Refunded(captureID string) money.Decimal {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.refunded[captureID]
+}
+
+func (p *FakeProvider) ParseWebhook(header http.Header, body []byte) (Event, bool, error) {
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return Event{}, false, err
+	}
+	if event.ID == "" || event.Type == "" {
+		return Event{}, false, fmt.Errorf("fake webhook event id or type is missing")
+	}
+	return event, true, nil
+}
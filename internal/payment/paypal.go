@@ -0,0 +1,175 @@
+package payment
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/money"
+	"bookstack/internal/paypalwebhook"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/plutov/paypal/v4"
+)
+
+// PaypalProvider thanh toán qua PayPal Orders v2 (intent CAPTURE)
+type PaypalProvider struct {
+	client   *paypal.Client
+	verifier *paypalwebhook.Verifier
+}
+
+func NewPaypalProvider(client *paypal.Client, verifier *paypalwebhook.Verifier) *PaypalProvider {
+	return &PaypalProvider{
+		client:   client,
+		verifier: verifier,
+	}
+}
+
+func (p *PaypalProvider) Name() string {
+	return constant.PaymentProviderPaypal
+}
+
+func (p *PaypalProvider) CreateIntent(ctx context.Context, intent Intent) (IntentResult, error) {
+	value, err := formatAmount(intent.Amount, intent.Currency)
+	if err != nil {
+		return IntentResult{}, err
+	}
+	order, err := p.client.CreateOrder(ctx, paypal.OrderIntentCapture, []paypal.PurchaseUnitRequest{
+		{
+			CustomID: strconv.Itoa(int(intent.OrderID)),
+			Amount: &paypal.PurchaseUnitAmount{
+				Currency: intent.Currency,
+				Value:    value,
+			},
+		},
+	}, nil, nil)
+	if err != nil {
+		return IntentResult{}, err
+	}
+	result := IntentResult{ExternalID: order.ID, Status: constant.PaymentPending}
+	for _, link := range order.Links {
+		if link.Rel == "approve" || link.Rel == "payer-action" {
+			result.ApprovalURL = link.Href
+		}
+	}
+	return result, nil
+}
+
+func (p *PaypalProvider) Capture(ctx context.Context, externalID string) (CaptureResult, error) {
+	resp, err := p.client.CaptureOrder(ctx, externalID, paypal.CaptureOrderRequest{})
+	if err != nil {
+		return CaptureResult{}, err
+	}
+	for _, unit := range resp.PurchaseUnits {
+		if unit.Payments == nil {
+			continue
+		}
+		for _, capture := range unit.Payments.Captures {
+			result := CaptureResult{CaptureID: capture.ID, Status: captureStatus(capture.Status)}
+			if capture.Amount != nil {
+				result.Amount, err = money.Parse(capture.Amount.Value)
+				if err != nil {
+					return CaptureResult{}, fmt.Errorf("paypal capture %s: %w", capture.ID, err)
+				}
+			}
+			return result, nil
+		}
+	}
+	return CaptureResult{}, fmt.Errorf("paypal order %s has no capture", externalID)
+}
+
+func (p *PaypalProvider) Refund(ctx context.Context, captureID string, amount money.Decimal, currency string) (RefundResult, error) {
+	value, err := formatAmount(amount, currency)
+	if err != nil {
+		return RefundResult{}, err
+	}
+	resp, err := p.client.RefundCapture(ctx, captureID, paypal.RefundCaptureRequest{
+		Amount: &paypal.Money{Currency: currency, Value: value},
+	})
+	if err != nil {
+		return RefundResult{}, err
+	}
+	result := RefundResult{RefundID: resp.ID, Status: resp.Status}
+	if resp.Amount != nil {
+		result.Amount, err = money.Parse(resp.Amount.Value)
+		if err != nil {
+			return RefundResult{}, fmt.Errorf("paypal refund %s: %w", resp.ID, err)
+		}
+	}
+	return result, nil
+}
+
+// ParseWebhook xác thực chữ ký PayPal rồi chuẩn hóa các sự kiện capture
+func (p *PaypalProvider) ParseWebhook(header http.Header, body []byte) (Event, bool, error) {
+	if err := p.verifier.Verify(paypalwebhook.HeadersFromRequest(header), body); err != nil {
+		return Event{}, false, err
+	}
+	event, resource, err := paypalwebhook.ParseEvent(body)
+	if err != nil {
+		return Event{}, false, err
+	}
+	return paypalEvent(event, resource)
+}
+
+func paypalEvent(event paypalwebhook.Event, resource paypalwebhook.Resource) (Event, bool, error) {
+	result := Event{
+		ID:         event.ID,
+		ExternalID: resource.OrderID(),
+		CaptureID:  resource.CaptureID(event.EventType),
+	}
+	switch event.EventType {
+	case paypalwebhook.EventCaptureCompleted:
+		result.Type = EventCaptured
+	case paypalwebhook.EventCaptureDenied:
+		result.Type = EventDenied
+	case paypalwebhook.EventCaptureRefunded:
+		result.Type = EventRefunded
+		result.RefundID = resource.ID
+	default:
+		return Event{}, false, nil
+	}
+	if resource.Amount.Value != "" {
+		amount, err := money.Parse(resource.Amount.Value)
+		if err != nil {
+			return Event{}, false, fmt.Errorf("%w: invalid amount %q", paypalwebhook.ErrInvalidEvent, resource.Amount.Value)
+		}
+		result.Amount = amount
+	}
+	return result, true, nil
+}
+
+func captureStatus(status string) string {
+	switch status {
+	case "COMPLETED":
+		return constant.PaymentPaid
+	case "DECLINED", "FAILED":
+		return constant.PaymentDenied
+	}
+	return constant.PaymentPending
+}
+
+// paypalCurrencies là các tiền tệ PayPal nhận cùng số chữ số thập phân PayPal cho phép
+// (HUF và TWD theo ISO có 2 chữ số nhưng PayPal chỉ nhận số nguyên)
+var paypalCurrencies = map[string]int{
+	"AUD": 2, "BRL": 2, "CAD": 2, "CNY": 2, "CZK": 2, "DKK": 2, "EUR": 2, "HKD": 2,
+	"HUF": 0, "ILS": 2, "JPY": 0, "MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "PHP": 2,
+	"PLN": 2, "GBP": 2, "SGD": 2, "SEK": 2, "CHF": 2, "THB": 2, "TWD": 0, "USD": 2,
+}
+
+// formatAmount ghi số tiền theo đúng số chữ số thập phân PayPal yêu cầu cho tiền tệ.
+// Số tiền có phần lẻ nhỏ hơn đơn vị PayPal nhận bị từ chối thay vì làm tròn âm thầm.
+func formatAmount(amount money.Decimal, currency string) (string, error) {
+	places, ok := paypalCurrencies[strings.ToUpper(currency)]
+	if !ok {
+		return "", fmt.Errorf("%w: paypal does not accept %s", ErrUnsupportedCurrency, currency)
+	}
+	rounded, err := amount.Round(places)
+	if err != nil {
+		return "", err
+	}
+	if !rounded.Equal(amount) {
+		return "", fmt.Errorf("%w: %s %s has more than %d decimal places", ErrUnsupportedCurrency, amount, currency, places)
+	}
+	return amount.StringFixed(places), nil
+}
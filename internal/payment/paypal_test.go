@@ -0,0 +1,162 @@
+package payment
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/money"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/plutov/paypal/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// paypalStandIn giả lập các API Orders v2/Payments v2 của PayPal mà PaypalProvider sử dụng
+type paypalStandIn struct {
+	mu       sync.Mutex
+	sequence int
+	amounts  map[string]money.Decimal // mã đơn PayPal -> số tiền
+	captured map[string]money.Decimal // mã capture -> số tiền đã thu
+	refunded map[string]money.Decimal // mã capture -> số tiền đã hoàn
+}
+
+func newPaypalStandIn(t *testing.T) (*paypalStandIn, *paypal.Client) {
+	standIn := &paypalStandIn{
+		amounts:  make(map[string]money.Decimal),
+		captured: make(map[string]money.Decimal),
+		refunded: make(map[string]money.Decimal),
+	}
+	server := httptest.NewServer(http.HandlerFunc(standIn.serve))
+	t.Cleanup(server.Close)
+	client, err := paypal.NewClient("client-id", "secret", server.URL)
+	require.NoError(t, err)
+	return standIn, client
+}
+
+func (s *paypalStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := r.URL.Path
+	switch {
+	case path == "/v1/oauth2/token":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"access_token": "token", "token_type": "Bearer", "expires_in": 3600})
+	case path == "/v2/checkout/orders" && r.Method == http.MethodPost:
+		var body struct {
+			PurchaseUnits []paypal.PurchaseUnitRequest `json:"purchase_units"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.PurchaseUnits) == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"name": "INVALID_REQUEST"})
+			return
+		}
+		s.sequence++
+		id := fmt.Sprintf("PP-ORDER-%d", s.sequence)
+		s.amounts[id] = money.MustParse(body.PurchaseUnits[0].Amount.Value)
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"id":     id,
+			"status": "CREATED",
+			"links":  []map[string]string{{"rel": "approve", "href": "https://www.sandbox.paypal.com/checkoutnow?token=" + id}},
+		})
+	case strings.HasPrefix(path, "/v2/checkout/orders/") && strings.HasSuffix(path, "/capture"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/v2/checkout/orders/"), "/capture")
+		amount, ok := s.amounts[id]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"name": "RESOURCE_NOT_FOUND"})
+			return
+		}
+		captureID := "CAP-" + id
+		s.captured[captureID] = amount
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"id":     id,
+			"status": "COMPLETED",
+			"purchase_units": []map[string]interface{}{{
+				"payments": map[string]interface{}{
+					"captures": []map[string]interface{}{{
+						"id":     captureID,
+						"status": "COMPLETED",
+						"amount": map[string]string{"currency_code": "USD", "value": amount.StringFixed(2)},
+					}},
+				},
+			}},
+		})
+	case strings.HasPrefix(path, "/v2/payments/captures/") && strings.HasSuffix(path, "/refund"):
+		captureID := strings.TrimSuffix(strings.TrimPrefix(path, "/v2/payments/captures/"), "/refund")
+		var body paypal.RefundCaptureRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Amount == nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"name": "INVALID_REQUEST"})
+			return
+		}
+		refunded, err := s.refunded[captureID].Add(money.MustParse(body.Amount.Value))
+		if err != nil || refunded.GreaterThan(s.captured[captureID]) {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"name": "UNPROCESSABLE_ENTITY", "message": "REFUND_AMOUNT_EXCEEDED"})
+			return
+		}
+		s.refunded[captureID] = refunded
+		s.sequence++
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"id":     fmt.Sprintf("REF-%d", s.sequence),
+			"status": "COMPLETED",
+			"amount": map[string]string{"currency_code": body.Amount.Currency, "value": body.Amount.Value},
+		})
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"name": "RESOURCE_NOT_FOUND"})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestPaypalProviderCaptureAndRefund(t *testing.T) {
+	ctx := context.Background()
+	standIn, client := newPaypalStandIn(t)
+	provider := NewPaypalProvider(client, nil)
+
+	intent, err := provider.CreateIntent(ctx, Intent{OrderID: 12, Amount: money.MustParse("25.5"), Currency: "USD"})
+	require.NoError(t, err)
+	assert.Equal(t, "PP-ORDER-1", intent.ExternalID)
+	assert.Equal(t, constant.PaymentPending, intent.Status)
+	assert.Contains(t, intent.ApprovalURL, "PP-ORDER-1")
+
+	capture, err := provider.Capture(ctx, intent.ExternalID)
+	require.NoError(t, err)
+	assert.Equal(t, CaptureResult{CaptureID: "CAP-PP-ORDER-1", Amount: money.MustParse("25.5"), Status: constant.PaymentPaid}, capture)
+
+	refund, err := provider.Refund(ctx, capture.CaptureID, money.NewFromInt(10), "USD")
+	require.NoError(t, err)
+	assert.Equal(t, money.NewFromInt(10), refund.Amount)
+	assert.NotEmpty(t, refund.RefundID)
+
+	_, err = provider.Refund(ctx, capture.CaptureID, money.MustParse("15.51"), "USD")
+	assert.Error(t, err, "refunding more than the remaining captured amount is rejected")
+
+	_, err = provider.Refund(ctx, capture.CaptureID, money.MustParse("15.5"), "USD")
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("25.5"), standIn.refunded[capture.CaptureID])
+
+	_, err = provider.Capture(ctx, "PP-ORDER-404")
+	assert.Error(t, err)
+}
+
+func TestPaypalFormatAmount(t *testing.T) {
+	value, err := formatAmount(money.MustParse("19.9"), "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "19.90", value)
+
+	value, err = formatAmount(money.NewFromInt(1500), "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, "1500", value)
+
+	_, err = formatAmount(money.MustParse("1500.5"), "JPY")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+
+	_, err = formatAmount(money.NewFromInt(329946), "VND")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency, "paypal does not settle in VND")
+}
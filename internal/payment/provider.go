@@ -0,0 +1,116 @@
+// Package payment định nghĩa giao diện chung cho các cổng thanh toán (PayPal, thanh toán khi nhận hàng, ...).
+// Mỗi Provider chỉ làm việc với cổng thanh toán; việc lưu giao dịch và cập nhật đơn hàng do tầng service/repository đảm nhiệm.
+package payment
+
+import (
+	"bookstack/internal/money"
+	"bookstack/internal/paypalwebhook"
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/plutov/paypal/v4"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown payment provider")
+	ErrNotSupported    = errors.New("operation not supported by payment provider")
+	// ErrUnsupportedCurrency: cổng thanh toán không nhận tiền tệ (hoặc số tiền lẻ) của đơn hàng
+	ErrUnsupportedCurrency = errors.New("currency not supported by payment provider")
+)
+
+// Loại sự kiện thanh toán đã chuẩn hóa từ webhook của các cổng thanh toán
+const (
+	EventCaptured = "captured"
+	EventDenied   = "denied"
+	EventRefunded = "refunded"
+)
+
+// Intent là yêu cầu tạo giao dịch thanh toán cho một đơn hàng
+// Amount đã được làm tròn tới đơn vị nhỏ nhất của Currency
+type Intent struct {
+	OrderID  uint
+	Amount   money.Decimal
+	Currency string
+}
+
+// IntentResult là giao dịch vừa tạo phía cổng thanh toán
+type IntentResult struct {
+	ExternalID  string // Mã giao dịch phía cổng thanh toán, dùng để đối chiếu webhook
+	ApprovalURL string // Trang người mua xác nhận thanh toán (nếu có)
+	Status      string // constant.Payment*
+}
+
+// CaptureResult là kết quả thu tiền một giao dịch đã được người mua chấp thuận
+type CaptureResult struct {
+	CaptureID string
+	Amount    money.Decimal
+	Status    string // constant.Payment*
+}
+
+// RefundResult là kết quả hoàn tiền
+type RefundResult struct {
+	RefundID string
+	Amount   money.Decimal
+	Status   string
+}
+
+// Event là sự kiện webhook đã xác thực và chuẩn hóa
+type Event struct {
+	ID         string        `json:"id"`
+	Type       string        `json:"type"`        // Event*
+	ExternalID string        `json:"external_id"` // Mã giao dịch, rỗng nếu cổng thanh toán không gửi kèm
+	CaptureID  string        `json:"capture_id"`
+	RefundID   string        `json:"refund_id"` // Mã hoàn tiền với sự kiện hoàn tiền
+	Amount     money.Decimal `json:"amount"`
+}
+
+// Provider là một cổng thanh toán
+type Provider interface {
+	Name() string
+	CreateIntent(ctx context.Context, intent Intent) (IntentResult, error)
+	Capture(ctx context.Context, externalID string) (CaptureResult, error)
+	Refund(ctx context.Context, captureID string, amount money.Decimal, currency string) (RefundResult, error)
+	// ParseWebhook xác thực và đọc webhook, ok = false khi loại sự kiện không được xử lý
+	ParseWebhook(header http.Header, body []byte) (event Event, ok bool, err error)
+}
+
+// Registry tra cứu provider theo tên
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	registry := &Registry{providers: make(map[string]Provider, len(providers))}
+	for _, provider := range providers {
+		registry.providers[provider.Name()] = provider
+	}
+	return registry
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// Names trả về tên các provider đã đăng ký theo thứ tự bảng chữ cái
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProviderRegistry đăng ký các cổng thanh toán dùng khi chạy ứng dụng
+func NewProviderRegistry(client *paypal.Client, verifier *paypalwebhook.Verifier) *Registry {
+	return NewRegistry(
+		NewPaypalProvider(client, verifier),
+		NewCashOnDeliveryProvider(),
+	)
+}
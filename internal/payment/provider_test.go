@@ -0,0 +1,96 @@
+package payment
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/money"
+	"bookstack/internal/paypalwebhook"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(NewCashOnDeliveryProvider(), NewFakeProvider())
+	assert.Equal(t, []string{constant.PaymentProviderCashOnDelivery, constant.PaymentProviderFake}, registry.Names())
+
+	provider, err := registry.Get(constant.PaymentProviderFake)
+	require.NoError(t, err)
+	assert.Equal(t, constant.PaymentProviderFake, provider.Name())
+
+	_, err = registry.Get("bitcoin")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
+
+func TestFakeProvider(t *testing.T) {
+	ctx := context.Background()
+	provider := NewFakeProvider()
+
+	intent, err := provider.CreateIntent(ctx, Intent{OrderID: 7, Amount: money.MustParse("42.5"), Currency: "USD"})
+	require.NoError(t, err)
+	assert.Equal(t, constant.PaymentPending, intent.Status)
+	assert.NotEmpty(t, intent.ApprovalURL)
+
+	capture, err := provider.Capture(ctx, intent.ExternalID)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("42.5"), capture.Amount)
+	assert.Equal(t, constant.PaymentPaid, capture.Status)
+
+	again, err := provider.Capture(ctx, intent.ExternalID)
+	require.NoError(t, err)
+	assert.Equal(t, capture.CaptureID, again.CaptureID, "capturing twice returns the same capture")
+
+	_, err = provider.Refund(ctx, capture.CaptureID, money.NewFromInt(40), "USD")
+	require.NoError(t, err)
+	_, err = provider.Refund(ctx, capture.CaptureID, money.NewFromInt(5), "USD")
+	assert.Error(t, err, "cannot refund more than captured")
+	assert.Equal(t, money.NewFromInt(40), provider.Refunded(capture.CaptureID))
+
+	provider.FailNext = errors.New("gateway down")
+	_, err = provider.CreateIntent(ctx, Intent{OrderID: 8, Amount: money.NewFromInt(1), Currency: "USD"})
+	assert.EqualError(t, err, "gateway down")
+	_, err = provider.CreateIntent(ctx, Intent{OrderID: 8, Amount: money.NewFromInt(1), Currency: "USD"})
+	assert.NoError(t, err)
+
+	body, err := json.Marshal(Event{ID: "EVT-1", Type: EventCaptured, ExternalID: intent.ExternalID})
+	require.NoError(t, err)
+	event, ok, err := provider.ParseWebhook(nil, body)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, intent.ExternalID, event.ExternalID)
+}
+
+func TestCashOnDeliveryProvider(t *testing.T) {
+	ctx := context.Background()
+	provider := NewCashOnDeliveryProvider()
+
+	intent, err := provider.CreateIntent(ctx, Intent{OrderID: 3, Amount: money.NewFromInt(10), Currency: "VND"})
+	require.NoError(t, err)
+	assert.Equal(t, "cod-3", intent.ExternalID)
+	assert.Equal(t, constant.PaymentPending, intent.Status)
+
+	_, err = provider.Capture(ctx, intent.ExternalID)
+	assert.ErrorIs(t, err, ErrNotSupported)
+	_, _, err = provider.ParseWebhook(nil, nil)
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
+
+func TestPaypalEvent(t *testing.T) {
+	body := []byte(`{"id":"WH-1","event_type":"PAYMENT.CAPTURE.REFUNDED","resource":{"id":"REF-1",
+		"amount":{"currency_code":"USD","value":"12.50"},
+		"links":[{"rel":"up","href":"https://api.paypal.com/v2/payments/captures/CAP-1"}]}}`)
+	event, resource, err := paypalwebhook.ParseEvent(body)
+	require.NoError(t, err)
+	normalized, ok, err := paypalEvent(event, resource)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Event{ID: "WH-1", Type: EventRefunded, CaptureID: "CAP-1", RefundID: "REF-1", Amount: money.MustParse("12.5")}, normalized)
+
+	event.EventType = "CHECKOUT.ORDER.APPROVED"
+	_, ok, err = paypalEvent(event, resource)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
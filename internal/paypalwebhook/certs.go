@@ -0,0 +1,125 @@
+package paypalwebhook
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxCertSize = 64 << 10
+
+// HTTPCertFetcher tải chứng chỉ từ máy chủ của PayPal, kiểm tra chuỗi chứng chỉ và lưu cache theo URL
+type HTTPCertFetcher struct {
+	Client *http.Client
+	Roots  *x509.CertPool // nil thì dùng root CA của hệ thống
+
+	mu    sync.Mutex
+	cache map[string]*x509.Certificate
+}
+
+func NewHTTPCertFetcher() *HTTPCertFetcher {
+	return &HTTPCertFetcher{
+		Client: &http.Client{Timeout: 10 * time.Second},
+		cache:  make(map[string]*x509.Certificate),
+	}
+}
+
+// AllowedCertURL chỉ chấp nhận chứng chỉ được phục vụ qua HTTPS từ tên miền paypal.com
+func AllowedCertURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	return host == "paypal.com" || strings.HasSuffix(host, ".paypal.com")
+}
+
+func (f *HTTPCertFetcher) Fetch(certURL string) (*x509.Certificate, error) {
+	if !AllowedCertURL(certURL) {
+		return nil, fmt.Errorf("certificate url %q is not a paypal url", certURL)
+	}
+	f.mu.Lock()
+	cert, ok := f.cache[certURL]
+	f.mu.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	resp, err := f.Client.Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch certificate: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch certificate: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCertSize))
+	if err != nil {
+		return nil, fmt.Errorf("read certificate: %w", err)
+	}
+	cert, err = ParseCertificateChain(data, f.Roots)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.cache[certURL] = cert
+	f.mu.Unlock()
+	return cert, nil
+}
+
+// ParseCertificateChain đọc chứng chỉ PEM (chứng chỉ ký đứng đầu, sau đó là các chứng chỉ trung gian)
+// và kiểm tra chuỗi tới root CA
+func ParseCertificateChain(data []byte, roots *x509.CertPool) (*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate found")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verify certificate chain: %w", err)
+	}
+	return certs[0], nil
+}
+
+// StaticCertFetcher trả về một chứng chỉ cố định, dùng cho môi trường local và kiểm thử
+type StaticCertFetcher struct {
+	Cert *x509.Certificate
+}
+
+func (f StaticCertFetcher) Fetch(string) (*x509.Certificate, error) {
+	if f.Cert == nil {
+		return nil, fmt.Errorf("no certificate configured")
+	}
+	return f.Cert, nil
+}
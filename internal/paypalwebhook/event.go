@@ -0,0 +1,88 @@
+package paypalwebhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidEvent = errors.New("invalid paypal webhook event")
+
+// Các loại sự kiện thanh toán được xử lý
+const (
+	EventCaptureCompleted = "PAYMENT.CAPTURE.COMPLETED"
+	EventCaptureDenied    = "PAYMENT.CAPTURE.DENIED"
+	EventCaptureRefunded  = "PAYMENT.CAPTURE.REFUNDED"
+)
+
+// Event là phần chung của mọi sự kiện webhook
+type Event struct {
+	ID           string          `json:"id"`
+	EventType    string          `json:"event_type"`
+	ResourceType string          `json:"resource_type"`
+	Resource     json.RawMessage `json:"resource"`
+}
+
+// Resource là các trường của capture/refund dùng để tìm đơn hàng tương ứng
+type Resource struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Amount struct {
+		CurrencyCode string `json:"currency_code"`
+		Value        string `json:"value"`
+	} `json:"amount"`
+	SupplementaryData struct {
+		RelatedIDs struct {
+			OrderID   string `json:"order_id"`
+			CaptureID string `json:"capture_id"`
+		} `json:"related_ids"`
+	} `json:"supplementary_data"`
+	Links []struct {
+		Href string `json:"href"`
+		Rel  string `json:"rel"`
+	} `json:"links"`
+}
+
+// ParseEvent đọc body webhook đã được xác thực chữ ký
+func ParseEvent(body []byte) (Event, Resource, error) {
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return Event{}, Resource{}, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if event.ID == "" || event.EventType == "" {
+		return Event{}, Resource{}, fmt.Errorf("%w: id or event_type is missing", ErrInvalidEvent)
+	}
+	var resource Resource
+	if len(event.Resource) > 0 {
+		if err := json.Unmarshal(event.Resource, &resource); err != nil {
+			return Event{}, Resource{}, fmt.Errorf("%w: resource: %v", ErrInvalidEvent, err)
+		}
+	}
+	return event, resource, nil
+}
+
+// OrderID là mã đơn PayPal (tạo khi người mua chọn thanh toán qua PayPal) mà capture thuộc về
+func (r Resource) OrderID() string {
+	return r.SupplementaryData.RelatedIDs.OrderID
+}
+
+// CaptureID là mã capture của sự kiện: chính resource với sự kiện capture,
+// hoặc capture gốc (liên kết "up") với sự kiện hoàn tiền
+func (r Resource) CaptureID(eventType string) string {
+	if eventType != EventCaptureRefunded {
+		return r.ID
+	}
+	if r.SupplementaryData.RelatedIDs.CaptureID != "" {
+		return r.SupplementaryData.RelatedIDs.CaptureID
+	}
+	for _, link := range r.Links {
+		if link.Rel != "up" {
+			continue
+		}
+		if i := strings.LastIndex(link.Href, "/captures/"); i >= 0 {
+			return strings.Trim(link.Href[i+len("/captures/"):], "/")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+// Package paypalwebhook xác thực chữ ký webhook của PayPal và đọc nội dung sự kiện.
+//
+// PayPal ký chuỗi "transmissionId|transmissionTime|webhookId|crc32(body)" bằng SHA256withRSA,
+// chứng chỉ dùng để kiểm tra được tải từ header PAYPAL-CERT-URL.
+package paypalwebhook
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"time"
+)
+
+const supportedAuthAlgo = "SHA256withRSA"
+
+var ErrInvalidSignature = errors.New("invalid paypal webhook signature")
+
+// Headers là các header truyền kèm webhook dùng để xác thực
+type Headers struct {
+	TransmissionID   string
+	TransmissionTime string
+	TransmissionSig  string
+	CertURL          string
+	AuthAlgo         string
+}
+
+func HeadersFromRequest(header http.Header) Headers {
+	return Headers{
+		TransmissionID:   header.Get("PAYPAL-TRANSMISSION-ID"),
+		TransmissionTime: header.Get("PAYPAL-TRANSMISSION-TIME"),
+		TransmissionSig:  header.Get("PAYPAL-TRANSMISSION-SIG"),
+		CertURL:          header.Get("PAYPAL-CERT-URL"),
+		AuthAlgo:         header.Get("PAYPAL-AUTH-ALGO"),
+	}
+}
+
+// CertFetcher lấy chứng chỉ ký webhook theo URL trong header
+type CertFetcher interface {
+	Fetch(url string) (*x509.Certificate, error)
+}
+
+// Verifier kiểm tra chữ ký webhook của một webhook ID đã đăng ký với PayPal
+type Verifier struct {
+	WebhookID string
+	Certs     CertFetcher
+	Now       func() time.Time
+}
+
+func NewVerifier(webhookID string, certs CertFetcher) *Verifier {
+	return &Verifier{
+		WebhookID: webhookID,
+		Certs:     certs,
+		Now:       time.Now,
+	}
+}
+
+// SignedMessage là chuỗi PayPal ký cho một lần gửi webhook
+func SignedMessage(headers Headers, webhookID string, body []byte) string {
+	return fmt.Sprintf("%s|%s|%s|%d", headers.TransmissionID, headers.TransmissionTime, webhookID, crc32.ChecksumIEEE(body))
+}
+
+// Verify trả về ErrInvalidSignature nếu body không được PayPal ký cho webhook này
+func (v *Verifier) Verify(headers Headers, body []byte) error {
+	if v.WebhookID == "" {
+		return fmt.Errorf("%w: webhook id is not configured", ErrInvalidSignature)
+	}
+	if headers.TransmissionID == "" || headers.TransmissionTime == "" || headers.TransmissionSig == "" || headers.CertURL == "" {
+		return fmt.Errorf("%w: missing transmission headers", ErrInvalidSignature)
+	}
+	if headers.AuthAlgo != supportedAuthAlgo {
+		return fmt.Errorf("%w: unsupported auth algorithm %q", ErrInvalidSignature, headers.AuthAlgo)
+	}
+	signature, err := base64.StdEncoding.DecodeString(headers.TransmissionSig)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+
+	cert, err := v.Certs.Fetch(headers.CertURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	now := v.Now()
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return fmt.Errorf("%w: certificate is not valid at %s", ErrInvalidSignature, now.Format(time.RFC3339))
+	}
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: certificate key is not RSA", ErrInvalidSignature)
+	}
+
+	digest := sha256.Sum256([]byte(SignedMessage(headers, v.WebhookID, body)))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package paypalwebhook
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebhookID = "WH-TEST-123"
+
+// fakeSigner ký webhook giống PayPal bằng một chứng chỉ tự tạo
+type fakeSigner struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newFakeSigner(t *testing.T) fakeSigner {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "messageverificationcerts.paypal.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return fakeSigner{key: key, cert: cert}
+}
+
+func (s fakeSigner) sign(t *testing.T, webhookID string, body []byte) Headers {
+	headers := Headers{
+		TransmissionID:   "b2f1c7e0-1111-2222-3333-444455556666",
+		TransmissionTime: time.Now().UTC().Format(time.RFC3339),
+		CertURL:          "https://api.sandbox.paypal.com/v1/notifications/certs/CERT-TEST",
+		AuthAlgo:         supportedAuthAlgo,
+	}
+	digest := sha256.Sum256([]byte(SignedMessage(headers, webhookID, body)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	headers.TransmissionSig = base64.StdEncoding.EncodeToString(signature)
+	return headers
+}
+
+func TestVerify(t *testing.T) {
+	signer := newFakeSigner(t)
+	verifier := NewVerifier(testWebhookID, StaticCertFetcher{Cert: signer.cert})
+	body := []byte(`{"id":"WH-EVT-1","event_type":"PAYMENT.CAPTURE.COMPLETED"}`)
+
+	t.Run("valid signature", func(t *testing.T) {
+		assert.NoError(t, verifier.Verify(signer.sign(t, testWebhookID, body), body))
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		headers := signer.sign(t, testWebhookID, body)
+		tampered := []byte(`{"id":"WH-EVT-1","event_type":"PAYMENT.CAPTURE.REFUNDED"}`)
+		assert.ErrorIs(t, verifier.Verify(headers, tampered), ErrInvalidSignature)
+	})
+
+	t.Run("signed for another webhook", func(t *testing.T) {
+		headers := signer.sign(t, "WH-OTHER", body)
+		assert.ErrorIs(t, verifier.Verify(headers, body), ErrInvalidSignature)
+	})
+
+	t.Run("signed by another key", func(t *testing.T) {
+		other := newFakeSigner(t)
+		headers := other.sign(t, testWebhookID, body)
+		assert.ErrorIs(t, verifier.Verify(headers, body), ErrInvalidSignature)
+	})
+
+	t.Run("missing headers", func(t *testing.T) {
+		assert.ErrorIs(t, verifier.Verify(Headers{}, body), ErrInvalidSignature)
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		headers := signer.sign(t, testWebhookID, body)
+		headers.AuthAlgo = "SHA1withRSA"
+		assert.ErrorIs(t, verifier.Verify(headers, body), ErrInvalidSignature)
+	})
+
+	t.Run("expired certificate", func(t *testing.T) {
+		expired := NewVerifier(testWebhookID, StaticCertFetcher{Cert: signer.cert})
+		expired.Now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		assert.ErrorIs(t, expired.Verify(signer.sign(t, testWebhookID, body), body), ErrInvalidSignature)
+	})
+
+	t.Run("webhook id not configured", func(t *testing.T) {
+		unconfigured := NewVerifier("", StaticCertFetcher{Cert: signer.cert})
+		assert.ErrorIs(t, unconfigured.Verify(signer.sign(t, "", body), body), ErrInvalidSignature)
+	})
+}
+
+func TestAllowedCertURL(t *testing.T) {
+	assert.True(t, AllowedCertURL("https://api.paypal.com/v1/notifications/certs/CERT-1"))
+	assert.True(t, AllowedCertURL("https://api.sandbox.paypal.com/v1/notifications/certs/CERT-1"))
+	assert.False(t, AllowedCertURL("http://api.paypal.com/v1/notifications/certs/CERT-1"))
+	assert.False(t, AllowedCertURL("https://paypal.com.attacker.example/cert"))
+	assert.False(t, AllowedCertURL("https://evilpaypal.com/cert"))
+}
+
+func TestParseEvent(t *testing.T) {
+	capture := []byte(`{"id":"WH-1","event_type":"PAYMENT.CAPTURE.COMPLETED","resource":{"id":"CAP-1",
+		"supplementary_data":{"related_ids":{"order_id":"PP-ORDER-1"}}}}`)
+	event, resource, err := ParseEvent(capture)
+	require.NoError(t, err)
+	assert.Equal(t, "WH-1", event.ID)
+	assert.Equal(t, "PP-ORDER-1", resource.OrderID())
+	assert.Equal(t, "CAP-1", resource.CaptureID(event.EventType))
+
+	refund := []byte(`{"id":"WH-2","event_type":"PAYMENT.CAPTURE.REFUNDED","resource":{"id":"REF-1",
+		"links":[{"rel":"self","href":"https://api.paypal.com/v2/payments/refunds/REF-1"},
+		{"rel":"up","href":"https://api.paypal.com/v2/payments/captures/CAP-1"}]}}`)
+	event, resource, err = ParseEvent(refund)
+	require.NoError(t, err)
+	assert.Equal(t, "", resource.OrderID())
+	assert.Equal(t, "CAP-1", resource.CaptureID(event.EventType))
+
+	_, _, err = ParseEvent([]byte(`{"event_type":"PAYMENT.CAPTURE.COMPLETED"}`))
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+}
@@ -0,0 +1,202 @@
+// Package pricing tính giá đơn hàng: tổng tiền sách, các khoản giảm giá từ coupon/khuyến mãi và phí vận chuyển.
+// Mọi số tiền tính theo tiền tệ của đơn hàng; số tiền cố định của coupon (theo tiền tệ gốc) được quy đổi bằng tỉ giá đã chốt.
+// Package không truy cập DB, việc kiểm tra thời hạn và số lượt dùng của coupon do tầng repository đảm nhiệm.
+package pricing
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"bookstack/internal/money"
+	"sort"
+)
+
+// Line là một dòng của đơn hàng cùng thông tin dùng để xét phạm vi coupon
+type Line struct {
+	BookID    uint
+	ShelveID  uint
+	Tags      []models.Tag
+	UnitPrice money.Decimal // Đơn giá theo tiền tệ của đơn hàng
+	Quantity  int
+}
+
+// Discount là một khoản giảm đã áp dụng
+type Discount struct {
+	Coupon   models.Coupon
+	Amount   money.Decimal
+	Shipping bool // Khoản giảm là phí vận chuyển
+}
+
+// Breakdown là bảng giá của đơn hàng
+type Breakdown struct {
+	Subtotal      money.Decimal
+	ShippingFee   money.Decimal
+	Discounts     []Discount
+	DiscountTotal money.Decimal
+	Total         money.Decimal
+}
+
+// Calculate áp dụng lần lượt các coupon lên đơn hàng. Coupon không đủ điều kiện (sai phạm vi,
+// chưa đạt tổng tối thiểu, không giảm được gì) bị bỏ qua. Tổng giảm của tiền sách không vượt quá Subtotal.
+// shippingFee và đơn giá các dòng đã theo tiền tệ của đơn; converter quy đổi số tiền của coupon.
+// Số tiền vượt phạm vi của money.Decimal trả về money.ErrOverflow.
+func Calculate(lines []Line, coupons []models.Coupon, shippingFee money.Decimal, converter money.Converter) (Breakdown, error) {
+	var breakdown Breakdown
+	var err error
+	if breakdown.ShippingFee, err = shippingFee.RoundCurrency(converter.Currency); err != nil {
+		return Breakdown{}, err
+	}
+	subtotal, err := linesSubtotal(lines)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	if breakdown.Subtotal, err = subtotal.RoundCurrency(converter.Currency); err != nil {
+		return Breakdown{}, err
+	}
+
+	itemDiscountLeft := breakdown.Subtotal
+	shippingDiscounted := false
+	for _, coupon := range coupons {
+		amount, shipping, err := Evaluate(coupon, lines, breakdown.ShippingFee, converter)
+		if err != nil {
+			return Breakdown{}, err
+		}
+		if shipping {
+			if shippingDiscounted {
+				continue
+			}
+			shippingDiscounted = true
+		} else {
+			amount = money.Min(amount, itemDiscountLeft)
+			if itemDiscountLeft, err = itemDiscountLeft.Sub(amount); err != nil {
+				return Breakdown{}, err
+			}
+		}
+		if !amount.IsPositive() {
+			continue
+		}
+		breakdown.Discounts = append(breakdown.Discounts, Discount{Coupon: coupon, Amount: amount, Shipping: shipping})
+		if breakdown.DiscountTotal, err = breakdown.DiscountTotal.Add(amount); err != nil {
+			return Breakdown{}, err
+		}
+	}
+	total, err := breakdown.Subtotal.Add(breakdown.ShippingFee)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	if breakdown.Total, err = total.Sub(breakdown.DiscountTotal); err != nil {
+		return Breakdown{}, err
+	}
+	return breakdown, nil
+}
+
+// Evaluate trả về số tiền (theo tiền tệ của đơn) một coupon giảm được trên các dòng đơn hàng
+// và cho biết đó có phải giảm phí vận chuyển
+func Evaluate(coupon models.Coupon, lines []Line, shippingFee money.Decimal, converter money.Converter) (money.Decimal, bool, error) {
+	var eligible []Line
+	for _, line := range lines {
+		if InScope(coupon, line) {
+			eligible = append(eligible, line)
+		}
+	}
+	eligibleSubtotal, err := linesSubtotal(eligible)
+	if err != nil {
+		return money.Zero, false, err
+	}
+	minSubtotal, err := converter.Convert(coupon.MinSubtotal)
+	if err != nil {
+		return money.Zero, false, err
+	}
+	if len(eligible) == 0 || eligibleSubtotal.LessThan(minSubtotal) {
+		return money.Zero, coupon.Type == constant.CouponFreeShipping, nil
+	}
+
+	switch coupon.Type {
+	case constant.CouponPercentage:
+		percent := money.Min(coupon.Value, money.NewFromInt(100))
+		amount, err := eligibleSubtotal.Mul(percent)
+		if err != nil {
+			return money.Zero, false, err
+		}
+		if amount, err = amount.Div(money.NewFromInt(100)); err != nil {
+			return money.Zero, false, err
+		}
+		if amount, err = amount.RoundCurrency(converter.Currency); err != nil {
+			return money.Zero, false, err
+		}
+		if coupon.MaxDiscount.IsPositive() {
+			maxDiscount, err := converter.Convert(coupon.MaxDiscount)
+			if err != nil {
+				return money.Zero, false, err
+			}
+			amount = money.Min(amount, maxDiscount)
+		}
+		return amount, false, nil
+	case constant.CouponFixedAmount:
+		value, err := converter.Convert(coupon.Value)
+		if err != nil {
+			return money.Zero, false, err
+		}
+		return money.Min(value, eligibleSubtotal), false, nil
+	case constant.CouponBuyXGetY:
+		amount, err := buyXGetY(eligible, coupon.BuyQuantity, coupon.GetQuantity)
+		return amount, false, err
+	case constant.CouponFreeShipping:
+		return shippingFee, true, nil
+	}
+	return money.Zero, false, nil
+}
+
+// linesSubtotal là tổng đơn giá nhân số lượng của các dòng
+func linesSubtotal(lines []Line) (money.Decimal, error) {
+	subtotal := money.Zero
+	for _, line := range lines {
+		amount, err := line.UnitPrice.MulInt(line.Quantity)
+		if err != nil {
+			return money.Zero, err
+		}
+		if subtotal, err = subtotal.Add(amount); err != nil {
+			return money.Zero, err
+		}
+	}
+	return subtotal, nil
+}
+
+// InScope cho biết dòng đơn hàng thuộc phạm vi áp dụng của coupon
+func InScope(coupon models.Coupon, line Line) bool {
+	switch coupon.ScopeType {
+	case constant.CouponScopeAll:
+		return true
+	case constant.CouponScopeShelve:
+		return line.ShelveID == coupon.ScopeShelveID
+	case constant.CouponScopeTag:
+		for _, tag := range line.Tags {
+			if tag.Name == coupon.ScopeTagName && (coupon.ScopeTagValue == "" || tag.Value == coupon.ScopeTagValue) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buyXGetY: cứ mỗi X + Y cuốn thuộc phạm vi thì Y cuốn rẻ nhất được tặng
+func buyXGetY(lines []Line, buy int, get int) (money.Decimal, error) {
+	if buy <= 0 || get <= 0 {
+		return money.Zero, nil
+	}
+	var prices []money.Decimal
+	for _, line := range lines {
+		for i := 0; i < line.Quantity; i++ {
+			prices = append(prices, line.UnitPrice)
+		}
+	}
+	free := len(prices) / (buy + get) * get
+	sort.Slice(prices, func(i, j int) bool { return prices[i].LessThan(prices[j]) })
+	amount := money.Zero
+	for _, price := range prices[:free] {
+		var err error
+		if amount, err = amount.Add(price); err != nil {
+			return money.Zero, err
+		}
+	}
+	return amount, nil
+}
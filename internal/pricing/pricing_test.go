@@ -0,0 +1,126 @@
+package pricing
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"bookstack/internal/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var baseCurrency = money.Converter{Currency: constant.BaseCurrency, Rate: money.NewFromInt(1)}
+
+func TestCalculate(t *testing.T) {
+	lines := []Line{
+		{BookID: 1, ShelveID: 1, UnitPrice: money.NewFromInt(10), Quantity: 2, Tags: []models.Tag{{Name: "genre", Value: "fiction"}}},
+		{BookID: 2, ShelveID: 2, UnitPrice: money.NewFromInt(20), Quantity: 1},
+		{BookID: 3, ShelveID: 2, UnitPrice: money.NewFromInt(5), Quantity: 3},
+	}
+
+	tests := []struct {
+		name      string
+		coupons   []models.Coupon
+		discounts []string
+		total     string
+	}{
+		{
+			name:  "no coupon",
+			total: "58",
+		},
+		{
+			name:      "percentage on whole order capped by max discount",
+			coupons:   []models.Coupon{{Type: constant.CouponPercentage, Value: money.NewFromInt(20), MaxDiscount: money.NewFromInt(5)}},
+			discounts: []string{"5"},
+			total:     "53",
+		},
+		{
+			name:      "fixed amount scoped to shelve",
+			coupons:   []models.Coupon{{Type: constant.CouponFixedAmount, Value: money.NewFromInt(50), ScopeType: constant.CouponScopeShelve, ScopeShelveID: 2}},
+			discounts: []string{"35"},
+			total:     "23",
+		},
+		{
+			name:      "percentage scoped to tag value",
+			coupons:   []models.Coupon{{Type: constant.CouponPercentage, Value: money.NewFromInt(50), ScopeType: constant.CouponScopeTag, ScopeTagName: "genre", ScopeTagValue: "fiction"}},
+			discounts: []string{"10"},
+			total:     "48",
+		},
+		{
+			name:      "buy two get one frees the cheapest books",
+			coupons:   []models.Coupon{{Type: constant.CouponBuyXGetY, BuyQuantity: 2, GetQuantity: 1}},
+			discounts: []string{"10"},
+			total:     "48",
+		},
+		{
+			name:      "free shipping",
+			coupons:   []models.Coupon{{Type: constant.CouponFreeShipping}},
+			discounts: []string{"3"},
+			total:     "55",
+		},
+		{
+			name:    "minimum subtotal not reached",
+			coupons: []models.Coupon{{Type: constant.CouponFixedAmount, Value: money.NewFromInt(5), MinSubtotal: money.NewFromInt(100)}},
+			total:   "58",
+		},
+		{
+			name: "item discounts never exceed subtotal",
+			coupons: []models.Coupon{
+				{Type: constant.CouponFixedAmount, Value: money.NewFromInt(40)},
+				{Type: constant.CouponFixedAmount, Value: money.NewFromInt(40)},
+				{Type: constant.CouponFreeShipping},
+			},
+			discounts: []string{"40", "15", "3"},
+			total:     "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown, err := Calculate(lines, tt.coupons, constant.StandardShippingFee, baseCurrency)
+			require.NoError(t, err)
+			assert.Equal(t, "55", breakdown.Subtotal.String())
+			var discounts []string
+			for _, discount := range breakdown.Discounts {
+				discounts = append(discounts, discount.Amount.String())
+			}
+			assert.Equal(t, tt.discounts, discounts)
+			assert.Equal(t, tt.total, breakdown.Total.String())
+		})
+	}
+}
+
+func TestCalculateInOrderCurrency(t *testing.T) {
+	vnd := money.Converter{Currency: "VND", Rate: money.MustParse("25400")}
+	unitPrice, err := vnd.Convert(money.MustParse("12.99"))
+	require.NoError(t, err)
+	shippingFee, err := vnd.Convert(constant.StandardShippingFee)
+	require.NoError(t, err)
+	lines := []Line{
+		{BookID: 1, UnitPrice: unitPrice, Quantity: 3},
+	}
+	coupons := []models.Coupon{
+		{Type: constant.CouponPercentage, Value: money.MustParse("12.5"), MaxDiscount: money.NewFromInt(10)},
+		{Type: constant.CouponFixedAmount, Value: money.MustParse("0.99"), MinSubtotal: money.NewFromInt(30)},
+	}
+
+	breakdown, err := Calculate(lines, coupons, shippingFee, vnd)
+	require.NoError(t, err)
+	assert.Equal(t, "989838", breakdown.Subtotal.String())
+	assert.Equal(t, "76200", breakdown.ShippingFee.String())
+	var discounts []string
+	for _, discount := range breakdown.Discounts {
+		discounts = append(discounts, discount.Amount.String())
+	}
+	// 12.5% của 989838 = 123729.75, làm tròn 123730 và chưa tới mức tối đa 10 USD = 254000 VND
+	assert.Equal(t, []string{"123730", "25146"}, discounts)
+	assert.Equal(t, "917162", breakdown.Total.String())
+}
+
+func TestCalculateOverflow(t *testing.T) {
+	vnd := money.Converter{Currency: "VND", Rate: money.MustParse("25400")}
+	lines := []Line{{BookID: 1, UnitPrice: money.MustParse("254000"), Quantity: 1000000}}
+	_, err := Calculate(lines, nil, money.Zero, vnd)
+	assert.ErrorIs(t, err, money.ErrOverflow)
+}
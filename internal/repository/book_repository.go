@@ -1,35 +1,50 @@
 package repository
 
 import (
+	"bookstack/internal/constant"
 	"bookstack/internal/dto/request"
 	"bookstack/internal/models"
 	"fmt"
 
 	"github.com/jinzhu/copier"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BookRepository interface {
 	//book
 	CreateCompleteBook(int, request.CompleteBookCreateRequest) (models.Book, error)
 	CreateBook(int, request.BookCreateRequest) (models.Book, error)
-	GetAllBook() ([]models.Book, error)
+	ImportBook(models.Book) (models.Book, error)
+	GetAllBook(Viewer) ([]models.Book, error)
+	GetBookById(int) (models.Book, error)
+	GetBookTree(int, Viewer) (models.Book, error)
 	UpdateBook(int, request.BookCreateRequest) (models.Book, error)
 	DeleteBook(int) error
 	//shelve
 	CreateShelve(int, request.ShelveCreateRequest) (models.Shelve, error)
 	GetShelves() ([]models.Shelve, error)
+	GetShelveById(int) (models.Shelve, error)
 	DeleteShelve(int) error
 	//chapter
 	CreateChapter(uint, request.BookChapterRequest) (models.Chapter, error)
-	GetChaptersOfBook(int) ([]models.Chapter, error)
+	GetChaptersOfBook(int, Viewer) ([]models.Chapter, error)
+	GetChapterById(int) (models.Chapter, error)
+	GetChapterTree(int, Viewer) (models.Chapter, error)
 	DeleteChapter(int) error
 	UpdateChapter(int, request.BookChapterRequest) (models.Chapter, error)
 	//page
 	AddPage(uint, request.PageRequest) (models.Page, error)
-	GetPageChapter(int) ([]models.Page, error)
+	GetPageChapter(int, Viewer) ([]models.Page, error)
+	GetPageById(int) (models.Page, error)
 	DeletePage(int) error
-	UpdatePage(int, request.PageRequest) (models.Page, error)
+	UpdatePage(int, int, request.PageRequest) (models.Page, error)
+	//page revision
+	GetPageRevisions(int) ([]models.PageRevision, error)
+	GetPageRevision(int, int) (models.PageRevision, error)
+	RestorePageRevision(int, int, int) (models.Page, error)
+	//search
+	Search(SearchFilter) ([]SearchHit, error)
 }
 
 type BookRepositoryImpl struct {
@@ -53,17 +68,15 @@ func (b *BookRepositoryImpl) UpdateChapter(chapterId int, request request.BookCh
 	if err != nil {
 		return models.Chapter{}, err
 	}
+	if err := b.refreshChapterSearchVector(b.DB, chapter.ID); err != nil {
+		return models.Chapter{}, fmt.Errorf("failed to index chapter: %w", err)
+	}
 	return chapter, nil
 }
 
-func (b *BookRepositoryImpl) UpdatePage(pageId int, request request.PageRequest) (models.Page, error) {
+func (b *BookRepositoryImpl) UpdatePage(pageId int, userId int, request request.PageRequest) (models.Page, error) {
 	var page models.Page
 
-	// Tìm page theo ID
-	if err := b.DB.Where("id = ?", pageId).First(&page).Error; err != nil {
-		return models.Page{}, err // Trả về lỗi nếu không tìm thấy
-	}
-
 	// Cập nhật các trường cụ thể từ request
 	updates := map[string]interface{}{}
 	if request.Title != "" {
@@ -75,17 +88,107 @@ func (b *BookRepositoryImpl) UpdatePage(pageId int, request request.PageRequest)
 
 	// Nếu không có gì để cập nhật
 	if len(updates) == 0 {
+		if err := b.DB.Where("id = ?", pageId).First(&page).Error; err != nil {
+			return models.Page{}, err // Trả về lỗi nếu không tìm thấy
+		}
 		return page, nil
 	}
 
-	// Cập nhật dữ liệu
-	if err := b.DB.Model(&page).Updates(updates).Error; err != nil {
+	// Lưu phiên bản cũ và cập nhật trong cùng một transaction
+	err := b.DB.Transaction(func(tx *gorm.DB) error {
+		// Khóa dòng page để các lần sửa đồng thời lần lượt lưu đúng nội dung trước đó và số phiên bản
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", pageId).First(&page).Error; err != nil {
+			return err // Trả về lỗi nếu không tìm thấy
+		}
+		if err := b.snapshotPage(tx, page, userId, request.Summary); err != nil {
+			return err
+		}
+		if err := tx.Model(&page).Updates(updates).Error; err != nil {
+			return err
+		}
+		return b.refreshPageSearchVector(tx, page.ID)
+	})
+	if err != nil {
 		return models.Page{}, err
 	}
 
 	return page, nil
 }
 
+func (b *BookRepositoryImpl) GetPageRevisions(pageId int) ([]models.PageRevision, error) {
+	var revisions []models.PageRevision
+	err := b.DB.Where("page_id = ?", pageId).Order("revision_number DESC").Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (b *BookRepositoryImpl) GetPageRevision(pageId int, revisionNumber int) (models.PageRevision, error) {
+	var revision models.PageRevision
+	err := b.DB.Where("page_id = ? AND revision_number = ?", pageId, revisionNumber).First(&revision).Error
+	if err != nil {
+		return models.PageRevision{}, err
+	}
+	return revision, nil
+}
+
+func (b *BookRepositoryImpl) RestorePageRevision(pageId int, revisionNumber int, userId int) (models.Page, error) {
+	var page models.Page
+	err := b.DB.Transaction(func(tx *gorm.DB) error {
+		// Khóa dòng page như UpdatePage để việc khôi phục không chạy xen với một lần sửa
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", pageId).First(&page).Error; err != nil {
+			return err
+		}
+		var revision models.PageRevision
+		if err := tx.Where("page_id = ? AND revision_number = ?", pageId, revisionNumber).First(&revision).Error; err != nil {
+			return fmt.Errorf("revision not found: %w", err)
+		}
+		// Nội dung hiện tại cũng được lưu lại để có thể hoàn tác việc khôi phục
+		summary := fmt.Sprintf("Restored from revision %d", revisionNumber)
+		if err := b.snapshotPage(tx, page, userId, summary); err != nil {
+			return err
+		}
+		err := tx.Model(&page).Updates(map[string]interface{}{
+			"title":   revision.Title,
+			"content": revision.Content,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return b.refreshPageSearchVector(tx, page.ID)
+	})
+	if err != nil {
+		return models.Page{}, err
+	}
+	return page, nil
+}
+
+// snapshotPage lưu nội dung hiện tại của trang thành một phiên bản mới, page phải được đọc với khóa FOR UPDATE
+// trong cùng transaction để nội dung và số phiên bản không bị lần sửa khác chen vào
+func (b *BookRepositoryImpl) snapshotPage(tx *gorm.DB, page models.Page, userId int, summary string) error {
+	var lastNumber int
+	err := tx.Model(&models.PageRevision{}).
+		Where("page_id = ?", page.ID).
+		Select("COALESCE(MAX(revision_number), 0)").
+		Scan(&lastNumber).Error
+	if err != nil {
+		return err
+	}
+	revision := models.PageRevision{
+		PageId:         int(page.ID),
+		Title:          page.Title,
+		Content:        page.Content,
+		RevisionNumber: lastNumber + 1,
+		Summary:        summary,
+		CreatedBy:      uint(userId),
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return fmt.Errorf("failed to save page revision: %w", err)
+	}
+	return nil
+}
+
 func (b *BookRepositoryImpl) DeleteChapter(chapterId int) error {
 	var chapter models.Chapter
 	err := b.DB.Where("id = ?", chapterId).Delete(&chapter).Error
@@ -103,6 +206,15 @@ func (b *BookRepositoryImpl) DeletePage(pageId int) error {
 	}
 	return nil
 }
+func (b *BookRepositoryImpl) GetShelveById(shelveId int) (models.Shelve, error) {
+	var shelve models.Shelve
+	err := b.DB.Where("id = ?", shelveId).First(&shelve).Error
+	if err != nil {
+		return models.Shelve{}, err
+	}
+	return shelve, nil
+}
+
 func (b *BookRepositoryImpl) DeleteShelve(shelveId int) error {
 	var shelve models.Shelve
 	err := b.DB.Where("id = ?", shelveId).Delete(&shelve).Error
@@ -125,6 +237,7 @@ func (b *BookRepositoryImpl) UpdateBook(bookId int, request request.BookCreateRe
 	book.Slug = request.Slug
 	book.Restricted = request.Restricted
 	book.Price = request.Price
+	book.DigitalPrice = request.DigitalPrice
 
 	// Chỉ cập nhật ShelveID nếu được cung cấp trong request
 	if request.ShelveID > 0 {
@@ -168,12 +281,13 @@ func (b *BookRepositoryImpl) UpdateBook(bookId int, request request.BookCreateRe
 
 	// Lưu sách với các trường đã cập nhật
 	err = b.DB.Model(&book).Updates(map[string]interface{}{
-		"title":       book.Title,
-		"description": book.Description,
-		"slug":        book.Slug,
-		"restricted":  book.Restricted,
-		"price":       book.Price,
-		"shelve_id":   book.ShelveID,
+		"title":         book.Title,
+		"description":   book.Description,
+		"slug":          book.Slug,
+		"restricted":    book.Restricted,
+		"price":         book.Price,
+		"digital_price": book.DigitalPrice,
+		"shelve_id":     book.ShelveID,
 	}).Error
 	if err != nil {
 		return models.Book{}, err
@@ -187,6 +301,10 @@ func (b *BookRepositoryImpl) UpdateBook(bookId int, request request.BookCreateRe
 		}
 	}
 
+	if err := b.refreshBookSearchVector(b.DB, book.ID); err != nil {
+		return models.Book{}, fmt.Errorf("failed to index book: %w", err)
+	}
+
 	return book, nil
 }
 
@@ -253,6 +371,39 @@ func (b *BookRepositoryImpl) CreateCompleteBook(userId int, req request.Complete
 	return book, nil
 }
 
+// ImportBook tạo cả cây sách -> chương -> trang (kèm tags) trong một transaction
+func (b *BookRepositoryImpl) ImportBook(book models.Book) (models.Book, error) {
+	err := b.DB.Transaction(func(tx *gorm.DB) error {
+		var shelve models.Shelve
+		if err := tx.First(&shelve, book.ShelveID).Error; err != nil {
+			return fmt.Errorf("failed to find shelve: %w", err)
+		}
+		if err := tx.Omit("Shelve").Create(&book).Error; err != nil {
+			return fmt.Errorf("failed to create book: %w", err)
+		}
+		book.Shelve = shelve
+
+		if err := b.refreshBookSearchVector(tx, book.ID); err != nil {
+			return fmt.Errorf("failed to index book: %w", err)
+		}
+		for _, chapter := range book.Chapters {
+			if err := b.refreshChapterSearchVector(tx, chapter.ID); err != nil {
+				return fmt.Errorf("failed to index chapter: %w", err)
+			}
+			for _, page := range chapter.Pages {
+				if err := b.refreshPageSearchVector(tx, page.ID); err != nil {
+					return fmt.Errorf("failed to index page: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Book{}, err
+	}
+	return book, nil
+}
+
 func (b *BookRepositoryImpl) CreatePage(request request.PageRequest) (models.Page, error) {
 	var page models.Page
 	err := copier.Copy(&page, request)
@@ -266,18 +417,34 @@ func (b *BookRepositoryImpl) CreatePage(request request.PageRequest) (models.Pag
 	if err != nil {
 		return models.Page{}, err
 	}
+	if err := b.refreshPageSearchVector(b.DB, page.ID); err != nil {
+		return models.Page{}, fmt.Errorf("failed to index page: %w", err)
+	}
 	return page, err
 }
 
-func (b *BookRepositoryImpl) GetPageChapter(chapterId int) ([]models.Page, error) {
+func (b *BookRepositoryImpl) GetPageChapter(chapterId int, viewer Viewer) ([]models.Page, error) {
 	var pages []models.Page
-	err := b.DB.Where("chapter_id = ?", chapterId).Find(&pages).Error
+	visible, args := visibleCondition("pages", constant.EntityTypePage, viewer)
+	err := b.DB.Where("chapter_id = ?", chapterId).
+		Where(visible, args...).
+		Order(`"order"`).
+		Find(&pages).Error
 	if err != nil {
 		return nil, err
 	}
 	return pages, nil
 }
 
+func (b *BookRepositoryImpl) GetPageById(pageId int) (models.Page, error) {
+	var page models.Page
+	err := b.DB.Where("id = ?", pageId).First(&page).Error
+	if err != nil {
+		return models.Page{}, err
+	}
+	return page, nil
+}
+
 func (b *BookRepositoryImpl) AddPage(chapterId uint, request request.PageRequest) (models.Page, error) {
 	var page models.Page
 	err := copier.Copy(&page, request)
@@ -292,17 +459,49 @@ func (b *BookRepositoryImpl) AddPage(chapterId uint, request request.PageRequest
 	if err != nil {
 		return models.Page{}, err
 	}
+	if err := b.refreshPageSearchVector(b.DB, page.ID); err != nil {
+		return models.Page{}, fmt.Errorf("failed to index page: %w", err)
+	}
 	return page, err
 }
 
-func (b *BookRepositoryImpl) GetChaptersOfBook(bookID int) ([]models.Chapter, error) {
+func (b *BookRepositoryImpl) GetChaptersOfBook(bookID int, viewer Viewer) ([]models.Chapter, error) {
 	var chapters []models.Chapter
-	err := b.DB.Where("book_id = ?", bookID).Find(&chapters).Error
+	visible, args := visibleCondition("chapters", constant.EntityTypeChapter, viewer)
+	err := b.DB.Where("book_id = ?", bookID).
+		Where(visible, args...).
+		Order(`"order"`).
+		Find(&chapters).Error
 	if err != nil {
 		return nil, err
 	}
 	return chapters, nil
 }
+
+func (b *BookRepositoryImpl) GetChapterById(chapterId int) (models.Chapter, error) {
+	var chapter models.Chapter
+	err := b.DB.Where("id = ?", chapterId).First(&chapter).Error
+	if err != nil {
+		return models.Chapter{}, err
+	}
+	return chapter, nil
+}
+
+// GetChapterTree lấy chương kèm các trang mà người xem được phép đọc
+func (b *BookRepositoryImpl) GetChapterTree(chapterId int, viewer Viewer) (models.Chapter, error) {
+	var chapter models.Chapter
+	pageVisible, pageArgs := visibleCondition("pages", constant.EntityTypePage, viewer)
+	err := b.DB.Preload("Pages", func(db *gorm.DB) *gorm.DB {
+		return db.Where(pageVisible, pageArgs...).Order(`"order"`)
+	}).
+		Where("id = ?", chapterId).
+		First(&chapter).Error
+	if err != nil {
+		return models.Chapter{}, err
+	}
+	return chapter, nil
+}
+
 func (b *BookRepositoryImpl) CreateChapter(bookId uint, request request.BookChapterRequest) (models.Chapter, error) {
 	var chapter models.Chapter
 	err := copier.Copy(&chapter, request)
@@ -318,18 +517,56 @@ func (b *BookRepositoryImpl) CreateChapter(bookId uint, request request.BookChap
 	if result.Error != nil {
 		return models.Chapter{}, result.Error
 	}
+	if err := b.refreshChapterSearchVector(b.DB, chapter.ID); err != nil {
+		return models.Chapter{}, fmt.Errorf("failed to index chapter: %w", err)
+	}
 	return chapter, nil
 
 }
-func (b *BookRepositoryImpl) GetAllBook() ([]models.Book, error) {
+func (b *BookRepositoryImpl) GetAllBook(viewer Viewer) ([]models.Book, error) {
 	var books []models.Book
-	err := b.DB.Preload("Chapters").Preload("Tags").Preload("Shelve").Find(&books).Error
+	visible, args := visibleCondition("books", constant.EntityTypeBook, viewer)
+	chapterVisible, chapterArgs := visibleCondition("chapters", constant.EntityTypeChapter, viewer)
+	err := b.DB.Preload("Chapters", append([]interface{}{chapterVisible}, chapterArgs...)...).
+		Preload("Tags").
+		Preload("Shelve").
+		Where(visible, args...).
+		Find(&books).Error
 	if err != nil {
 		return []models.Book{}, err
 	}
 	return books, nil
 }
 
+func (b *BookRepositoryImpl) GetBookById(bookId int) (models.Book, error) {
+	var book models.Book
+	err := b.DB.Where("id = ?", bookId).First(&book).Error
+	if err != nil {
+		return models.Book{}, err
+	}
+	return book, nil
+}
+
+// GetBookTree lấy sách kèm các chương, trang mà người xem được phép đọc, sắp xếp theo thứ tự
+func (b *BookRepositoryImpl) GetBookTree(bookId int, viewer Viewer) (models.Book, error) {
+	var book models.Book
+	chapterVisible, chapterArgs := visibleCondition("chapters", constant.EntityTypeChapter, viewer)
+	pageVisible, pageArgs := visibleCondition("pages", constant.EntityTypePage, viewer)
+	err := b.DB.Preload("Chapters", func(db *gorm.DB) *gorm.DB {
+		return db.Where(chapterVisible, chapterArgs...).Order(`"order"`)
+	}).
+		Preload("Chapters.Pages", func(db *gorm.DB) *gorm.DB {
+			return db.Where(pageVisible, pageArgs...).Order(`"order"`)
+		}).
+		Preload("Tags").
+		Where("id = ?", bookId).
+		First(&book).Error
+	if err != nil {
+		return models.Book{}, err
+	}
+	return book, nil
+}
+
 func (b *BookRepositoryImpl) FindTagByName(name string, entityType string) (*models.Tag, error) {
 	var tag models.Tag
 	result := b.DB.Where("name = ? AND entity_type = ?", name, entityType).Limit(1).Find(&tag)
@@ -456,5 +693,9 @@ func (b *BookRepositoryImpl) CreateBook(userId int, request request.BookCreateRe
 		}
 	}
 
+	if err := b.refreshBookSearchVector(b.DB, result.ID); err != nil {
+		return models.Book{}, fmt.Errorf("failed to index book: %w", err)
+	}
+
 	return result, nil
 }
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"bookstack/internal/dto/request"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestUpdatePageSnapshotsLockedPage(t *testing.T) {
+	db, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewBookRepositoryImpl(db)
+
+	mock.ExpectBegin()
+	// Trang được đọc trong transaction với khóa dòng trước khi lưu phiên bản
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "pages" WHERE id = $1`)+`.*FOR UPDATE`).
+		WithArgs(5, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content"}).AddRow(5, "Cài đặt", "nội dung cũ"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(revision_number), 0) FROM "page_revisions" WHERE page_id = $1`)).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "page_revisions"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 5, "Cài đặt", "nội dung cũ", 3, "sửa lỗi", 9).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "pages" SET "content"=$1`)).
+		WithArgs("nội dung mới", sqlmock.AnyArg(), 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE pages SET search_vector`)).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	page, err := repo.UpdatePage(5, 9, request.PageRequest{Content: "nội dung mới", Summary: "sửa lỗi"})
+	require.NoError(t, err)
+	assert.Equal(t, "nội dung mới", page.Content)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestorePageRevisionLocksPage(t *testing.T) {
+	db, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewBookRepositoryImpl(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "pages" WHERE id = $1`)+`.*FOR UPDATE`).
+		WithArgs(5, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content"}).AddRow(5, "Cài đặt", "nội dung"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "page_revisions" WHERE (page_id = $1 AND revision_number = $2)`)).
+		WithArgs(5, 7, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	_, err := repo.RestorePageRevision(5, 7, 9)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"bookstack/config"
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Cấu hình text search của PostgreSQL, dùng "simple" vì nội dung chủ yếu là tiếng Việt
+const searchConfig = "simple"
+
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10"
+
+// SearchFilter là truy vấn tìm kiếm đã được phân tích
+type SearchFilter struct {
+	Terms  string               // Từ khóa tìm kiếm toàn văn
+	Tags   []request.TagRequest // Lọc sách theo tag (name, value)
+	Viewer Viewer               // Người tìm kiếm, dùng để ẩn các entity bị giới hạn
+	Limit  int
+	Offset int
+}
+
+// SearchHit là một kết quả tìm kiếm (sách, chương hoặc trang)
+type SearchHit struct {
+	EntityType string
+	EntityID   uint
+	BookID     uint
+	ChapterID  uint
+	Title      string
+	Snippet    string
+	Rank       float64
+}
+
+// refreshBookSearchVector cập nhật chỉ mục tìm kiếm của sách
+func (b *BookRepositoryImpl) refreshBookSearchVector(db *gorm.DB, bookId uint) error {
+	return db.Exec(`UPDATE books SET search_vector =
+		setweight(to_tsvector('`+searchConfig+`', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('`+searchConfig+`', coalesce(description, '')), 'B')
+		WHERE id = ?`, bookId).Error
+}
+
+// refreshChapterSearchVector cập nhật chỉ mục tìm kiếm của chương
+func (b *BookRepositoryImpl) refreshChapterSearchVector(db *gorm.DB, chapterId uint) error {
+	return db.Exec(`UPDATE chapters SET search_vector =
+		setweight(to_tsvector('`+searchConfig+`', coalesce(title, '')), 'A')
+		WHERE id = ?`, chapterId).Error
+}
+
+// refreshPageSearchVector cập nhật chỉ mục tìm kiếm của trang
+func (b *BookRepositoryImpl) refreshPageSearchVector(db *gorm.DB, pageId uint) error {
+	return db.Exec(`UPDATE pages SET search_vector =
+		setweight(to_tsvector('`+searchConfig+`', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('`+searchConfig+`', coalesce(content, '')), 'B')
+		WHERE id = ?`, pageId).Error
+}
+
+func (b *BookRepositoryImpl) Search(filter SearchFilter) ([]SearchHit, error) {
+	var hits []SearchHit
+
+	// Điều kiện lọc theo tag áp dụng cho sách chứa kết quả
+	var tagSQL strings.Builder
+	var tagArgs []interface{}
+	for _, tag := range filter.Tags {
+		tagSQL.WriteString(` AND b.id IN (SELECT entity_id FROM tags WHERE entity_type = 'book' AND deleted_at IS NULL AND name = ?`)
+		tagArgs = append(tagArgs, tag.Name)
+		if tag.Value != "" {
+			tagSQL.WriteString(` AND value = ?`)
+			tagArgs = append(tagArgs, tag.Value)
+		}
+		tagSQL.WriteString(`)`)
+	}
+
+	bookVisible, bookArgs := visibleCondition("b", constant.EntityTypeBook, filter.Viewer)
+	chapterVisible, chapterArgs := visibleCondition("c", constant.EntityTypeChapter, filter.Viewer)
+	pageVisible, pageArgs := visibleCondition("p", constant.EntityTypePage, filter.Viewer)
+
+	// Chỉ lọc theo tag, không có từ khóa: trả về danh sách sách
+	if strings.TrimSpace(filter.Terms) == "" {
+		query := `SELECT 'book' AS entity_type, b.id AS entity_id, b.id AS book_id, 0 AS chapter_id,
+			b.title, left(b.description, 200) AS snippet, 0 AS rank
+			FROM books b
+			WHERE b.deleted_at IS NULL AND ` + bookVisible + tagSQL.String() + `
+			ORDER BY b.title LIMIT ? OFFSET ?`
+		var args []interface{}
+		args = append(args, bookArgs...)
+		args = append(args, tagArgs...)
+		args = append(args, filter.Limit, filter.Offset)
+		if err := b.DB.Raw(query, args...).Scan(&hits).Error; err != nil {
+			return nil, err
+		}
+		return hits, nil
+	}
+
+	tsQuery := `websearch_to_tsquery('` + searchConfig + `', ?)`
+	headline := func(column string) string {
+		return `ts_headline('` + searchConfig + `', coalesce(` + column + `, ''), q, '` + headlineOptions + `')`
+	}
+
+	query := `
+		SELECT 'book' AS entity_type, b.id AS entity_id, b.id AS book_id, 0 AS chapter_id,
+			b.title, ` + headline("b.description") + ` AS snippet, ts_rank(b.search_vector, q) AS rank
+		FROM books b, ` + tsQuery + ` q
+		WHERE b.deleted_at IS NULL AND b.search_vector @@ q
+			AND ` + bookVisible + tagSQL.String() + `
+		UNION ALL
+		SELECT 'chapter', c.id, b.id, c.id,
+			c.title, ` + headline("c.title") + `, ts_rank(c.search_vector, q)
+		FROM chapters c JOIN books b ON b.id = c.book_id, ` + tsQuery + ` q
+		WHERE c.deleted_at IS NULL AND b.deleted_at IS NULL AND c.search_vector @@ q
+			AND ` + chapterVisible + ` AND ` + bookVisible + tagSQL.String() + `
+		UNION ALL
+		SELECT 'page', p.id, b.id, c.id,
+			p.title, ` + headline("p.content") + `, ts_rank(p.search_vector, q)
+		FROM pages p JOIN chapters c ON c.id = p.chapter_id JOIN books b ON b.id = c.book_id, ` + tsQuery + ` q
+		WHERE p.deleted_at IS NULL AND c.deleted_at IS NULL AND b.deleted_at IS NULL AND p.search_vector @@ q
+			AND ` + pageVisible + ` AND ` + chapterVisible + ` AND ` + bookVisible + tagSQL.String() + `
+		ORDER BY rank DESC LIMIT ? OFFSET ?`
+
+	// Tham số theo đúng thứ tự xuất hiện của dấu ? trong từng câu SELECT
+	var args []interface{}
+	args = append(args, filter.Terms)
+	args = append(args, bookArgs...)
+	args = append(args, tagArgs...)
+	args = append(args, filter.Terms)
+	args = append(args, chapterArgs...)
+	args = append(args, bookArgs...)
+	args = append(args, tagArgs...)
+	args = append(args, filter.Terms)
+	args = append(args, pageArgs...)
+	args = append(args, chapterArgs...)
+	args = append(args, bookArgs...)
+	args = append(args, tagArgs...)
+	args = append(args, filter.Limit, filter.Offset)
+
+	if err := b.DB.Raw(query, args...).Scan(&hits).Error; err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// BackfillSearchVectors tạo chỉ mục tìm kiếm cho dữ liệu có từ trước khi thêm cột search_vector
+func BackfillSearchVectors() {
+	db := config.DB
+	statements := []string{
+		`UPDATE books SET search_vector =
+			setweight(to_tsvector('` + searchConfig + `', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('` + searchConfig + `', coalesce(description, '')), 'B')
+			WHERE search_vector IS NULL`,
+		`UPDATE chapters SET search_vector =
+			setweight(to_tsvector('` + searchConfig + `', coalesce(title, '')), 'A')
+			WHERE search_vector IS NULL`,
+		`UPDATE pages SET search_vector =
+			setweight(to_tsvector('` + searchConfig + `', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('` + searchConfig + `', coalesce(content, '')), 'B')
+			WHERE search_vector IS NULL`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			log.Printf("Failed to backfill search vectors: %v", err)
+			return
+		}
+	}
+	log.Println("Backfilled search vectors")
+}
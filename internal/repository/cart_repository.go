@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CartOwner xác định giỏ hàng: theo người dùng nếu đã đăng nhập, ngược lại theo mã giỏ của khách
+type CartOwner struct {
+	UserID     uint
+	GuestToken string
+}
+
+func (o CartOwner) IsGuest() bool {
+	return o.UserID == 0
+}
+
+type CartRepository interface {
+	GetItems(owner CartOwner) ([]models.CartItem, error)
+	SaveItem(owner CartOwner, item models.CartItem) error
+	RemoveItem(owner CartOwner, bookId uint) error
+	Clear(owner CartOwner) error
+	GetBooks(bookIds []uint) (map[uint]models.Book, error)
+}
+
+type CartRepositoryImpl struct {
+	DB    *gorm.DB
+	Redis *redis.Client
+}
+
+func NewCartRepositoryImpl(db *gorm.DB, redisClient *redis.Client) CartRepository {
+	return &CartRepositoryImpl{
+		DB:    db,
+		Redis: redisClient,
+	}
+}
+
+// guestCartKey là key Redis (hash bookId -> CartItem JSON) của giỏ hàng khách
+func guestCartKey(token string) string {
+	return "cart:guest:" + token
+}
+
+func (r *CartRepositoryImpl) GetItems(owner CartOwner) ([]models.CartItem, error) {
+	if owner.IsGuest() {
+		values, err := r.Redis.HGetAll(context.Background(), guestCartKey(owner.GuestToken)).Result()
+		if err != nil {
+			return nil, err
+		}
+		items := make([]models.CartItem, 0, len(values))
+		for _, value := range values {
+			var item models.CartItem
+			if err := json.Unmarshal([]byte(value), &item); err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].BookID < items[j].BookID })
+		return items, nil
+	}
+
+	var items []models.CartItem
+	if err := r.DB.Where("user_id = ?", owner.UserID).Order("id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// SaveItem thêm hoặc ghi đè một dòng trong giỏ (số lượng và giá là giá trị tuyệt đối)
+func (r *CartRepositoryImpl) SaveItem(owner CartOwner, item models.CartItem) error {
+	if owner.IsGuest() {
+		ctx := context.Background()
+		key := guestCartKey(owner.GuestToken)
+		value, err := json.Marshal(models.CartItem{BookID: item.BookID, Quantity: item.Quantity, Price: item.Price})
+		if err != nil {
+			return err
+		}
+		pipe := r.Redis.TxPipeline()
+		pipe.HSet(ctx, key, strconv.FormatUint(uint64(item.BookID), 10), value)
+		pipe.Expire(ctx, key, constant.GuestCartTTL)
+		_, err = pipe.Exec(ctx)
+		return err
+	}
+
+	item.UserID = owner.UserID
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "book_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity", "price", "updated_at", "deleted_at"}),
+	}).Create(&item).Error
+}
+
+func (r *CartRepositoryImpl) RemoveItem(owner CartOwner, bookId uint) error {
+	if owner.IsGuest() {
+		return r.Redis.HDel(context.Background(), guestCartKey(owner.GuestToken), strconv.FormatUint(uint64(bookId), 10)).Err()
+	}
+	return r.DB.Unscoped().Where("user_id = ? AND book_id = ?", owner.UserID, bookId).Delete(&models.CartItem{}).Error
+}
+
+func (r *CartRepositoryImpl) Clear(owner CartOwner) error {
+	if owner.IsGuest() {
+		return r.Redis.Del(context.Background(), guestCartKey(owner.GuestToken)).Err()
+	}
+	return r.DB.Unscoped().Where("user_id = ?", owner.UserID).Delete(&models.CartItem{}).Error
+}
+
+// GetBooks lấy thông tin (và giá hiện tại) của các sách trong giỏ
+func (r *CartRepositoryImpl) GetBooks(bookIds []uint) (map[uint]models.Book, error) {
+	books := make(map[uint]models.Book)
+	if len(bookIds) == 0 {
+		return books, nil
+	}
+	var found []models.Book
+	if err := r.DB.Where("id IN ?", bookIds).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	for _, book := range found {
+		books[book.ID] = book
+	}
+	return books, nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"bookstack/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RatingSummary là thống kê điểm đánh giá của một entity
+type RatingSummary struct {
+	Average   float64
+	Count     int
+	Histogram map[int]int // điểm (1-5) -> số lượt
+}
+
+type CommentRepository interface {
+	CreateComment(models.Comment) (models.Comment, error)
+	GetCommentById(int) (models.Comment, error)
+	GetComments(entityType string, entityId uint) ([]models.Comment, error)
+	UpdateComment(models.Comment) (models.Comment, error)
+	DeleteCommentThread(int) error
+	SetCommentHidden(commentId int, hidden bool, moderatorId uint) error
+	HasRated(entityType string, entityId uint, userId int, excludeCommentId uint) (bool, error)
+	GetRatingSummaries(entityType string, entityIds []uint) (map[uint]RatingSummary, error)
+}
+
+type CommentRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewCommentRepositoryImpl(Db *gorm.DB) CommentRepository {
+	return &CommentRepositoryImpl{
+		DB: Db,
+	}
+}
+
+func (r *CommentRepositoryImpl) CreateComment(comment models.Comment) (models.Comment, error) {
+	if err := r.DB.Create(&comment).Error; err != nil {
+		return models.Comment{}, err
+	}
+	return comment, nil
+}
+
+func (r *CommentRepositoryImpl) GetCommentById(commentId int) (models.Comment, error) {
+	var comment models.Comment
+	err := r.DB.Where("id = ?", commentId).First(&comment).Error
+	if err != nil {
+		return models.Comment{}, err
+	}
+	return comment, nil
+}
+
+func (r *CommentRepositoryImpl) GetComments(entityType string, entityId uint) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := r.DB.Where("entity_type = ? AND entity_id = ?", entityType, entityId).
+		Order("created_at").
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *CommentRepositoryImpl) UpdateComment(comment models.Comment) (models.Comment, error) {
+	err := r.DB.Model(&comment).Select("Text", "Rating").Updates(comment).Error
+	if err != nil {
+		return models.Comment{}, err
+	}
+	return comment, nil
+}
+
+// DeleteCommentThread xóa bình luận cùng toàn bộ các trả lời của nó
+func (r *CommentRepositoryImpl) DeleteCommentThread(commentId int) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		ids := []int{commentId}
+		for len(ids) > 0 {
+			if err := tx.Where("id IN ?", ids).Delete(&models.Comment{}).Error; err != nil {
+				return err
+			}
+			var children []int
+			if err := tx.Model(&models.Comment{}).Where("parent_id IN ?", ids).Pluck("id", &children).Error; err != nil {
+				return err
+			}
+			ids = children
+		}
+		return nil
+	})
+}
+
+func (r *CommentRepositoryImpl) SetCommentHidden(commentId int, hidden bool, moderatorId uint) error {
+	hiddenBy := moderatorId
+	if !hidden {
+		hiddenBy = 0
+	}
+	return r.DB.Model(&models.Comment{}).
+		Where("id = ?", commentId).
+		Updates(map[string]interface{}{
+			"hidden":    hidden,
+			"hidden_by": hiddenBy,
+		}).Error
+}
+
+func (r *CommentRepositoryImpl) HasRated(entityType string, entityId uint, userId int, excludeCommentId uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.Comment{}).
+		Where("entity_type = ? AND entity_id = ? AND created_by = ? AND parent_id = 0 AND rating > 0 AND id <> ?",
+			entityType, entityId, userId, excludeCommentId).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *CommentRepositoryImpl) GetRatingSummaries(entityType string, entityIds []uint) (map[uint]RatingSummary, error) {
+	summaries := make(map[uint]RatingSummary)
+	if len(entityIds) == 0 {
+		return summaries, nil
+	}
+	var rows []struct {
+		EntityID uint
+		Rating   int
+		Total    int
+	}
+	// Bình luận bị ẩn không được tính vào điểm
+	err := r.DB.Model(&models.Comment{}).
+		Select("entity_id, rating, count(*) AS total").
+		Where("entity_type = ? AND entity_id IN ? AND parent_id = 0 AND rating > 0 AND hidden = false", entityType, entityIds).
+		Group("entity_id, rating").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sums := make(map[uint]int)
+	for _, row := range rows {
+		summary, ok := summaries[row.EntityID]
+		if !ok {
+			summary.Histogram = make(map[int]int)
+		}
+		summary.Histogram[row.Rating] = row.Total
+		summary.Count += row.Total
+		sums[row.EntityID] += row.Rating * row.Total
+		summaries[row.EntityID] = summary
+	}
+	for entityId, summary := range summaries {
+		summary.Average = float64(sums[entityId]) / float64(summary.Count)
+		summaries[entityId] = summary
+	}
+	return summaries, nil
+}
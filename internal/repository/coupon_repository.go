@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrCouponNotApplicable = errors.New("coupon cannot be applied")
+
+type CouponRepository interface {
+	CreateCoupon(models.Coupon) (models.Coupon, error)
+	UpdateCoupon(models.Coupon) (models.Coupon, error)
+	DeleteCoupon(uint) error
+	GetCouponById(uint) (models.Coupon, error)
+	GetCoupons() ([]models.Coupon, error)
+}
+
+type CouponRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewCouponRepositoryImpl(Db *gorm.DB) CouponRepository {
+	return &CouponRepositoryImpl{
+		DB: Db,
+	}
+}
+
+func (r *CouponRepositoryImpl) CreateCoupon(coupon models.Coupon) (models.Coupon, error) {
+	if err := r.DB.Create(&coupon).Error; err != nil {
+		return models.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+func (r *CouponRepositoryImpl) UpdateCoupon(coupon models.Coupon) (models.Coupon, error) {
+	if err := r.DB.Save(&coupon).Error; err != nil {
+		return models.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+func (r *CouponRepositoryImpl) DeleteCoupon(couponId uint) error {
+	return r.DB.Delete(&models.Coupon{}, couponId).Error
+}
+
+func (r *CouponRepositoryImpl) GetCouponById(couponId uint) (models.Coupon, error) {
+	var coupon models.Coupon
+	if err := r.DB.First(&coupon, couponId).Error; err != nil {
+		return models.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+func (r *CouponRepositoryImpl) GetCoupons() ([]models.Coupon, error) {
+	var coupons []models.Coupon
+	if err := r.DB.Order("id DESC").Find(&coupons).Error; err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+// couponUsage đếm số lượt dùng coupon (toàn bộ và của một người) trên các đơn chưa bị hủy/thất bại
+func couponUsage(tx *gorm.DB, couponId uint, userId uint) (int64, int64, error) {
+	var usage struct {
+		Total  int64
+		ByUser int64
+	}
+	err := tx.Raw(`SELECT count(*) AS total, count(*) FILTER (WHERE r.user_id = ?) AS by_user
+		FROM coupon_redemptions r JOIN orders o ON o.id = r.order_id
+		WHERE r.coupon_id = ? AND r.deleted_at IS NULL AND o.deleted_at IS NULL AND o.status NOT IN ?`,
+		userId, couponId, []constant.OrderStatus{constant.Cancelled, constant.Failed}).
+		Scan(&usage).Error
+	return usage.Total, usage.ByUser, err
+}
+
+// withinUsageLimits kiểm tra coupon còn lượt dùng chung và lượt dùng của người dùng
+func withinUsageLimits(tx *gorm.DB, coupon models.Coupon, userId uint) (bool, error) {
+	if coupon.UsageLimit <= 0 && coupon.PerUserLimit <= 0 {
+		return true, nil
+	}
+	total, byUser, err := couponUsage(tx, coupon.ID, userId)
+	if err != nil {
+		return false, err
+	}
+	if coupon.UsageLimit > 0 && total >= int64(coupon.UsageLimit) {
+		return false, nil
+	}
+	if coupon.PerUserLimit > 0 && byUser >= int64(coupon.PerUserLimit) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// applicableCoupons lấy các khuyến mãi tự áp dụng còn hiệu lực và coupon theo mã (nếu có) của người dùng.
+// lock = true thì khóa các dòng coupon để việc đếm lượt dùng không bị vượt giới hạn khi đặt hàng đồng thời.
+// Mã coupon không hợp lệ trả về ErrCouponNotApplicable, khuyến mãi tự áp dụng hết lượt thì bị bỏ qua.
+func applicableCoupons(tx *gorm.DB, code string, userId uint, lock bool) ([]models.Coupon, error) {
+	now := time.Now()
+	query := tx
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var promotions []models.Coupon
+	if err := query.Where("auto_apply = ? AND active = ?", true, true).Order("id").Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	var coupons []models.Coupon
+	for _, promotion := range promotions {
+		if !promotion.ValidAt(now) {
+			continue
+		}
+		ok, err := withinUsageLimits(tx, promotion, userId)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			coupons = append(coupons, promotion)
+		}
+	}
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return coupons, nil
+	}
+	var coupon models.Coupon
+	err := query.Where("code = ? AND auto_apply = ?", code, false).First(&coupon).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: coupon %s not found", ErrCouponNotApplicable, code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !coupon.ValidAt(now) {
+		return nil, fmt.Errorf("%w: coupon %s is not active", ErrCouponNotApplicable, code)
+	}
+	ok, err := withinUsageLimits(tx, coupon, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: coupon %s has reached its usage limit", ErrCouponNotApplicable, code)
+	}
+	return append(coupons, coupon), nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"bookstack/internal/money"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrCurrencyNotSupported = errors.New("currency not supported")
+
+type CurrencyRepository interface {
+	GetRates() ([]models.ExchangeRate, error)
+	GetConverter(currency string) (money.Converter, error)
+	SetRate(models.ExchangeRate) (models.ExchangeRate, error)
+	DeleteRate(currency string) error
+}
+
+type CurrencyRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewCurrencyRepositoryImpl(db *gorm.DB) CurrencyRepository {
+	return &CurrencyRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (r *CurrencyRepositoryImpl) GetRates() ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	if err := r.DB.Order("currency").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (r *CurrencyRepositoryImpl) GetConverter(currency string) (money.Converter, error) {
+	return currencyConverter(r.DB, currency)
+}
+
+// SetRate tạo hoặc cập nhật tỉ giá của một tiền tệ
+func (r *CurrencyRepositoryImpl) SetRate(rate models.ExchangeRate) (models.ExchangeRate, error) {
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_by", "updated_at"}),
+	}).Create(&rate).Error
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+	return rate, nil
+}
+
+// DeleteRate ngừng nhận thanh toán bằng tiền tệ; các đơn đã tạo vẫn giữ tỉ giá đã chốt
+func (r *CurrencyRepositoryImpl) DeleteRate(currency string) error {
+	result := r.DB.Unscoped().Where("currency = ?", currency).Delete(&models.ExchangeRate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// currencyConverter lấy tỉ giá hiện tại để quy đổi giá sang tiền tệ của đơn.
+// Tiền tệ gốc luôn có tỉ giá 1, tiền tệ khác phải có trong bảng tỉ giá.
+func currencyConverter(tx *gorm.DB, currency string) (money.Converter, error) {
+	currency = money.NormalizeCurrency(currency)
+	if currency == "" || currency == constant.BaseCurrency {
+		return money.Converter{Currency: constant.BaseCurrency, Rate: money.NewFromInt(1)}, nil
+	}
+	var rate models.ExchangeRate
+	err := tx.Where("currency = ?", currency).First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return money.Converter{}, fmt.Errorf("%w: %s", ErrCurrencyNotSupported, currency)
+	}
+	if err != nil {
+		return money.Converter{}, err
+	}
+	return money.Converter{Currency: rate.Currency, Rate: rate.Rate}, nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDeliveryProofRequired = errors.New("delivery proof is required")
+	ErrInvalidDeliveryCode   = errors.New("invalid delivery code")
+	ErrDeliveryCodeLocked    = errors.New("delivery code is locked after too many wrong attempts")
+)
+
+type DeliveryRepository interface {
+	RecordDelivery(proof models.DeliveryProof, code string) (models.Order, error)
+	GetDeliveryProofs(orderId uint) ([]models.DeliveryProof, error)
+	GetDeliveryProof(proofId uint) (models.DeliveryProof, error)
+}
+
+type DeliveryRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewDeliveryRepositoryImpl(db *gorm.DB) DeliveryRepository {
+	return &DeliveryRepositoryImpl{
+		DB: db,
+	}
+}
+
+// RecordDelivery ghi nhận kết quả giao đơn của shipper và chuyển đơn sang Delivered hoặc Failed.
+// Giao thành công cần ít nhất một bằng chứng: ảnh, chữ ký hoặc mã nhận hàng đúng. Mã sai được đếm
+// (kể cả khi có ảnh/chữ ký) và đơn giữ nguyên trạng thái; sai quá MaxDeliveryCodeAttempts lần thì mã bị khóa.
+func (d *DeliveryRepositoryImpl) RecordDelivery(proof models.DeliveryProof, code string) (models.Order, error) {
+	var order models.Order
+	codeRejected := false
+	err := d.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockOrder(tx, proof.OrderID)
+		if err != nil {
+			return err
+		}
+		to := constant.Delivered
+		if proof.Outcome == constant.DeliveryOutcomeFailed {
+			to = constant.Failed
+		}
+		actor := OrderActor{UserID: proof.ShipperID, Role: constant.OrderActorShipper}
+		// Kiểm tra quyền trước để shipper khác không dò được mã nhận hàng
+		if err := CheckOrderTransition(order, to, actor); err != nil {
+			return err
+		}
+
+		if to == constant.Delivered && code != "" {
+			err := checkDeliveryCode(order, code)
+			if errors.Is(err, ErrInvalidDeliveryCode) {
+				codeRejected = true
+				return tx.Model(&order).Update("code_attempts", gorm.Expr("code_attempts + 1")).Error
+			}
+			if err != nil {
+				return err
+			}
+			proof.CodeVerified = true
+		}
+		if err := checkDeliveryProof(proof); err != nil {
+			return err
+		}
+
+		if err := transitionOrder(tx, &order, to, actor, proof.Reason); err != nil {
+			return err
+		}
+		return tx.Create(&proof).Error
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+	if codeRejected {
+		return models.Order{}, ErrInvalidDeliveryCode
+	}
+	return order, nil
+}
+
+func (d *DeliveryRepositoryImpl) GetDeliveryProofs(orderId uint) ([]models.DeliveryProof, error) {
+	var proofs []models.DeliveryProof
+	if err := d.DB.Where("order_id = ?", orderId).Order("created_at, id").Find(&proofs).Error; err != nil {
+		return nil, err
+	}
+	return proofs, nil
+}
+
+func (d *DeliveryRepositoryImpl) GetDeliveryProof(proofId uint) (models.DeliveryProof, error) {
+	var proof models.DeliveryProof
+	if err := d.DB.First(&proof, proofId).Error; err != nil {
+		return models.DeliveryProof{}, err
+	}
+	return proof, nil
+}
+
+// checkDeliveryCode so mã khách đọc với mã nhận hàng của đơn
+func checkDeliveryCode(order models.Order, code string) error {
+	if order.CodeAttempts >= constant.MaxDeliveryCodeAttempts {
+		return ErrDeliveryCodeLocked
+	}
+	if order.DeliveryCode == "" || subtle.ConstantTimeCompare([]byte(order.DeliveryCode), []byte(code)) != 1 {
+		return ErrInvalidDeliveryCode
+	}
+	return nil
+}
+
+// checkDeliveryProof kiểm tra bằng chứng đủ cho kết quả giao: giao thành công cần ảnh, chữ ký hoặc mã đúng;
+// giao thất bại cần lý do
+func checkDeliveryProof(proof models.DeliveryProof) error {
+	switch proof.Outcome {
+	case constant.DeliveryOutcomeDelivered:
+		if proof.PhotoPath == "" && proof.SignaturePath == "" && !proof.CodeVerified {
+			return fmt.Errorf("%w: photo, signature or delivery code", ErrDeliveryProofRequired)
+		}
+	case constant.DeliveryOutcomeFailed:
+		if proof.Reason == "" {
+			return fmt.Errorf("%w: reason of the failed delivery", ErrDeliveryProofRequired)
+		}
+	default:
+		return fmt.Errorf("%w: unknown outcome %q", ErrDeliveryProofRequired, proof.Outcome)
+	}
+	return nil
+}
+
+// newDeliveryCode sinh mã nhận hàng ngẫu nhiên gồm DeliveryCodeLength chữ số
+func newDeliveryCode() (string, error) {
+	code := make([]byte, constant.DeliveryCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+	return string(code), nil
+}
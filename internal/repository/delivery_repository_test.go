@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDeliveryCode(t *testing.T) {
+	order := models.Order{DeliveryCode: "042917"}
+	assert.NoError(t, checkDeliveryCode(order, "042917"))
+	assert.ErrorIs(t, checkDeliveryCode(order, "42917"), ErrInvalidDeliveryCode)
+	assert.ErrorIs(t, checkDeliveryCode(models.Order{}, ""), ErrInvalidDeliveryCode)
+
+	order.CodeAttempts = constant.MaxDeliveryCodeAttempts
+	assert.ErrorIs(t, checkDeliveryCode(order, "042917"), ErrDeliveryCodeLocked)
+}
+
+func TestCheckDeliveryProof(t *testing.T) {
+	delivered := models.DeliveryProof{Outcome: constant.DeliveryOutcomeDelivered}
+	assert.ErrorIs(t, checkDeliveryProof(delivered), ErrDeliveryProofRequired)
+	delivered.SignaturePath = "orders/1/signature.png"
+	assert.NoError(t, checkDeliveryProof(delivered))
+	assert.NoError(t, checkDeliveryProof(models.DeliveryProof{Outcome: constant.DeliveryOutcomeDelivered, CodeVerified: true}))
+
+	failed := models.DeliveryProof{Outcome: constant.DeliveryOutcomeFailed}
+	assert.ErrorIs(t, checkDeliveryProof(failed), ErrDeliveryProofRequired)
+	failed.Reason = "customer not at home"
+	assert.NoError(t, checkDeliveryProof(failed))
+}
+
+func TestNewDeliveryCode(t *testing.T) {
+	code, err := newDeliveryCode()
+	assert.NoError(t, err)
+	assert.Regexp(t, `^[0-9]{6}$`, code)
+}
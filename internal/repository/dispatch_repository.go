@@ -0,0 +1,355 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dispatch"
+	"bookstack/internal/geo"
+	"bookstack/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const shipperRoleName = "shipper"
+
+var (
+	ErrOfferClosed     = errors.New("delivery offer is no longer open")
+	ErrNotDispatchable = errors.New("order does not need a shipper")
+	ErrNotShipper      = errors.New("user is not a shipper")
+)
+
+// activeOrderStatuses là các trạng thái đơn còn tính vào tải của shipper
+var activeOrderStatuses = []constant.OrderStatus{constant.Pending, constant.Confirmed, constant.Processing, constant.Shipped}
+
+type DispatchRepository interface {
+	OfferOrder(orderId uint, now time.Time) (*models.DeliveryOffer, error)
+	RespondOffer(offerId uint, shipperId uint, accept bool, reason string, now time.Time) (models.DeliveryOffer, error)
+	ExpireOffers(now time.Time) ([]uint, error)
+	GetShipperOffers(shipperId uint) ([]models.DeliveryOffer, error)
+	GetManualDispatchOrders() ([]models.Order, error)
+	AssignOrder(orderId uint, shipperId uint) (models.Order, error)
+	SetShipperAvailability(shipperId uint, available bool) error
+	GetServiceArea(shipperId uint) (models.ShipperServiceArea, error)
+	SetServiceArea(area models.ShipperServiceArea) (models.ShipperServiceArea, error)
+	ClearServiceArea(shipperId uint) error
+}
+
+type DispatchRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewDispatchRepositoryImpl(db *gorm.DB) DispatchRepository {
+	return &DispatchRepositoryImpl{
+		DB: db,
+	}
+}
+
+// OfferOrder mời shipper xếp hạng cao nhất chưa từ chối/bỏ lỡ đơn này. Đơn đã có lời mời đang chờ thì trả về
+// lời mời đó; không còn ứng viên thì chuyển đơn sang chờ gán tay và trả về nil.
+// Đơn không cần shipper (đã có shipper, đã giao/hủy, chỉ có sách điện tử) cũng trả về nil.
+func (d *DispatchRepositoryImpl) OfferOrder(orderId uint, now time.Time) (*models.DeliveryOffer, error) {
+	var offer *models.DeliveryOffer
+	err := d.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, orderId)
+		if err != nil {
+			return err
+		}
+		if !needsShipper(order) {
+			return nil
+		}
+
+		var offers []models.DeliveryOffer
+		if err := tx.Where("order_id = ?", order.ID).Find(&offers).Error; err != nil {
+			return err
+		}
+		excluded := make(map[uint]bool)
+		for i := range offers {
+			if offers[i].Status == constant.OfferPending && now.Before(offers[i].ExpiresAt) {
+				offer = &offers[i]
+				return nil
+			}
+			excluded[offers[i].ShipperID] = true
+		}
+
+		candidates, err := dispatchCandidates(tx)
+		if err != nil {
+			return err
+		}
+		destination := dispatch.Destination{Address: order.Address, Point: order.Location.Point()}
+		ranked := dispatch.Rank(destination, candidates, excluded, constant.MaxActiveOrdersShipper)
+		if len(ranked) == 0 {
+			return tx.Model(&order).Update("dispatch_state", constant.DispatchManual).Error
+		}
+		offer = &models.DeliveryOffer{
+			OrderID:   order.ID,
+			ShipperID: ranked[0].ShipperID,
+			Status:    constant.OfferPending,
+			Score:     ranked[0].Score,
+			ExpiresAt: now.Add(constant.DeliveryOfferTimeout),
+		}
+		if err := tx.Create(offer).Error; err != nil {
+			return err
+		}
+		return tx.Model(&order).Update("dispatch_state", constant.DispatchOffering).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+// RespondOffer ghi nhận shipper nhận (accept) hoặc từ chối lời mời. Nhận lời mời đã quá hạn thì lời mời
+// bị đánh dấu hết hạn và trả về với trạng thái expired. Đơn không còn cần shipper thì lời mời bị hủy.
+func (d *DispatchRepositoryImpl) RespondOffer(offerId uint, shipperId uint, accept bool, reason string, now time.Time) (models.DeliveryOffer, error) {
+	var offer models.DeliveryOffer
+	err := d.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND shipper_id = ?", offerId, shipperId).
+			First(&offer).Error
+		if err != nil {
+			return err
+		}
+		if offer.Status != constant.OfferPending {
+			return fmt.Errorf("%w: offer is %s", ErrOfferClosed, offer.Status)
+		}
+		order, err := lockOrder(tx, offer.OrderID)
+		if err != nil {
+			return err
+		}
+		offer.Order = order
+
+		offer.RespondedAt = &now
+		switch {
+		case !needsShipper(order):
+			offer.Status = constant.OfferCancelled
+			offer.Reason = "order no longer needs a shipper"
+		case !accept:
+			offer.Status = constant.OfferDeclined
+			offer.Reason = reason
+		case !now.Before(offer.ExpiresAt):
+			offer.Status = constant.OfferExpired
+		default:
+			offer.Status = constant.OfferAccepted
+			err := tx.Model(&order).Updates(map[string]interface{}{
+				"shipper_id":     shipperId,
+				"dispatch_state": constant.DispatchAssigned,
+			}).Error
+			if err != nil {
+				return err
+			}
+			offer.Order.ShipperID = &shipperId
+			offer.Order.DispatchState = constant.DispatchAssigned
+		}
+		return tx.Save(&offer).Error
+	})
+	if err != nil {
+		return models.DeliveryOffer{}, err
+	}
+	return offer, nil
+}
+
+// ExpireOffers đánh dấu hết hạn các lời mời quá hạn chưa được trả lời, trả về ID các đơn cần mời shipper khác
+func (d *DispatchRepositoryImpl) ExpireOffers(now time.Time) ([]uint, error) {
+	var orderIds []uint
+	err := d.DB.Transaction(func(tx *gorm.DB) error {
+		var offers []models.DeliveryOffer
+		// Bỏ qua lời mời đang được shipper trả lời
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND expires_at <= ?", constant.OfferPending, now).
+			Find(&offers).Error
+		if err != nil || len(offers) == 0 {
+			return err
+		}
+		var ids []uint
+		for _, offer := range offers {
+			ids = append(ids, offer.ID)
+			orderIds = append(orderIds, offer.OrderID)
+		}
+		return tx.Model(&models.DeliveryOffer{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":       constant.OfferExpired,
+			"responded_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderIds, nil
+}
+
+// GetShipperOffers lấy các lời mời đang chờ shipper trả lời, kèm đơn hàng
+func (d *DispatchRepositoryImpl) GetShipperOffers(shipperId uint) ([]models.DeliveryOffer, error) {
+	var offers []models.DeliveryOffer
+	err := d.DB.Preload("Order").
+		Where("shipper_id = ? AND status = ?", shipperId, constant.OfferPending).
+		Order("expires_at").
+		Find(&offers).Error
+	if err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
+
+// GetManualDispatchOrders lấy các đơn không shipper nào nhận, cần nhân viên gán tay
+func (d *DispatchRepositoryImpl) GetManualDispatchOrders() ([]models.Order, error) {
+	var orders []models.Order
+	err := d.DB.Preload("OrderDetail").Preload("OrderDetail.Book").
+		Where("dispatch_state = ? AND shipper_id IS NULL AND status IN ?", constant.DispatchManual, activeOrderStatuses).
+		Order("created_at").
+		Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// AssignOrder gán tay đơn cho shipper (kể cả gán lại khi đơn chưa giao đi) và hủy lời mời đang chờ
+func (d *DispatchRepositoryImpl) AssignOrder(orderId uint, shipperId uint) (models.Order, error) {
+	var order models.Order
+	err := d.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockOrder(tx, orderId)
+		if err != nil {
+			return err
+		}
+		if !awaitingShipment(order) {
+			return fmt.Errorf("%w: order is %s", ErrNotDispatchable, order.Status)
+		}
+		isShipper, err := hasRole(tx, shipperId, shipperRoleName)
+		if err != nil {
+			return err
+		}
+		if !isShipper {
+			return fmt.Errorf("%w: %d", ErrNotShipper, shipperId)
+		}
+		err = tx.Model(&models.DeliveryOffer{}).
+			Where("order_id = ? AND status = ?", order.ID, constant.OfferPending).
+			Updates(map[string]interface{}{
+				"status": constant.OfferCancelled,
+				"reason": "assigned manually",
+			}).Error
+		if err != nil {
+			return err
+		}
+		order.ShipperID = &shipperId
+		order.DispatchState = constant.DispatchAssigned
+		return tx.Model(&order).Updates(map[string]interface{}{
+			"shipper_id":     shipperId,
+			"dispatch_state": constant.DispatchAssigned,
+		}).Error
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+	return order, nil
+}
+
+func (d *DispatchRepositoryImpl) SetShipperAvailability(shipperId uint, available bool) error {
+	return d.DB.Model(&models.User{}).Where("id = ?", shipperId).Update("available", available).Error
+}
+
+func (d *DispatchRepositoryImpl) GetServiceArea(shipperId uint) (models.ShipperServiceArea, error) {
+	var area models.ShipperServiceArea
+	if err := d.DB.Where("user_id = ?", shipperId).First(&area).Error; err != nil {
+		return models.ShipperServiceArea{}, err
+	}
+	return area, nil
+}
+
+// SetServiceArea tạo hoặc thay vùng phục vụ của shipper
+func (d *DispatchRepositoryImpl) SetServiceArea(area models.ShipperServiceArea) (models.ShipperServiceArea, error) {
+	err := d.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"center_lat", "center_lng", "radius_km", "polygon", "updated_at"}),
+	}).Create(&area).Error
+	if err != nil {
+		return models.ShipperServiceArea{}, err
+	}
+	return d.GetServiceArea(area.UserID)
+}
+
+// ClearServiceArea xóa vùng phục vụ, shipper quay về ghép đơn theo WorkingArea
+func (d *DispatchRepositoryImpl) ClearServiceArea(shipperId uint) error {
+	return d.DB.Unscoped().Where("user_id = ?", shipperId).Delete(&models.ShipperServiceArea{}).Error
+}
+
+// awaitingShipment cho biết đơn có hàng cần giao và chưa được giao đi
+func awaitingShipment(order models.Order) bool {
+	switch order.Status {
+	case constant.Pending, constant.Confirmed, constant.Processing:
+		return !digitalOnly(order)
+	}
+	return false
+}
+
+// needsShipper cho biết đơn đang chờ giao và chưa có shipper
+func needsShipper(order models.Order) bool {
+	return order.ShipperID == nil && awaitingShipment(order)
+}
+
+// dispatchCandidates lấy mọi shipper kèm số đơn đang giao và vùng phục vụ của từng người
+func dispatchCandidates(tx *gorm.DB) ([]dispatch.Candidate, error) {
+	var shippers []models.User
+	err := tx.Joins("JOIN user_roles ON users.id = user_roles.user_id").
+		Joins("JOIN roles ON user_roles.role_id = roles.id").
+		Where("roles.name = ?", shipperRoleName).
+		Find(&shippers).Error
+	if err != nil || len(shippers) == 0 {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(shippers))
+	for _, shipper := range shippers {
+		ids = append(ids, uint(shipper.ID))
+	}
+	var loads []struct {
+		ShipperID uint
+		Count     int
+	}
+	err = tx.Model(&models.Order{}).
+		Select("shipper_id, count(*) AS count").
+		Where("shipper_id IN ? AND status IN ?", ids, activeOrderStatuses).
+		Group("shipper_id").
+		Scan(&loads).Error
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[uint]int, len(loads))
+	for _, load := range loads {
+		active[load.ShipperID] = load.Count
+	}
+	var serviceAreas []models.ShipperServiceArea
+	if err := tx.Where("user_id IN ?", ids).Find(&serviceAreas).Error; err != nil {
+		return nil, err
+	}
+	areas := make(map[uint]*geo.ServiceArea, len(serviceAreas))
+	for _, serviceArea := range serviceAreas {
+		area := serviceArea.Area()
+		areas[serviceArea.UserID] = &area
+	}
+
+	candidates := make([]dispatch.Candidate, 0, len(shippers))
+	for _, shipper := range shippers {
+		candidates = append(candidates, dispatch.Candidate{
+			ShipperID:    uint(shipper.ID),
+			WorkingArea:  shipper.WorkingArea,
+			ServiceArea:  areas[uint(shipper.ID)],
+			Available:    shipper.Available,
+			ActiveOrders: active[uint(shipper.ID)],
+		})
+	}
+	return candidates, nil
+}
+
+func hasRole(tx *gorm.DB, userId uint, roleName string) (bool, error) {
+	var count int64
+	err := tx.Table("user_roles").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.user_id = ? AND roles.name = ?", userId, roleName).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
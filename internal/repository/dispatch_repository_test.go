@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNeedsShipper(t *testing.T) {
+	shipperId := uint(9)
+	paperback := []models.OrderDetail{{BookID: 1, Type: constant.OrderLinePhysical, Quantity: 1}}
+	ebook := []models.OrderDetail{{BookID: 2, Type: constant.OrderLineDigital, Quantity: 1}}
+
+	tests := []struct {
+		name     string
+		order    models.Order
+		expected bool
+	}{
+		{"new order", models.Order{Status: constant.Pending, OrderDetail: paperback}, true},
+		{"order in processing", models.Order{Status: constant.Processing, OrderDetail: paperback}, true},
+		{"order already assigned", models.Order{Status: constant.Confirmed, ShipperID: &shipperId, OrderDetail: paperback}, false},
+		{"order already shipped", models.Order{Status: constant.Shipped, OrderDetail: paperback}, false},
+		{"cancelled order", models.Order{Status: constant.Cancelled, OrderDetail: paperback}, false},
+		{"ebook only order", models.Order{Status: constant.Confirmed, OrderDetail: ebook}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, needsShipper(tt.order))
+		})
+	}
+}
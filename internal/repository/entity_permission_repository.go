@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const adminRoleName = "admin"
+
+// Viewer là người đang truy cập, dùng để lọc các entity bị giới hạn trong câu truy vấn
+type Viewer struct {
+	UserID          uint
+	RoleIDs         []uint
+	IsAdmin         bool
+	EntitledBookIDs []uint // Sách điện tử đã mua: được xem toàn bộ chương, trang của sách
+}
+
+// EntitledTo cho biết viewer đã mua bản điện tử của sách
+func (v Viewer) EntitledTo(bookId uint) bool {
+	for _, id := range v.EntitledBookIDs {
+		if id == bookId {
+			return true
+		}
+	}
+	return false
+}
+
+type EntityPermissionRepository interface {
+	GetViewer(userId int) (Viewer, error)
+	HasPermission(userId int, permission string) (bool, error)
+	FindRoleByName(string) (*models.Role, error)
+	FindEntityPermissions(entityType string, entityId uint, roleIds []uint) ([]models.EntityPermission, error)
+	GetEntityPermissions(entityType string, entityId uint) ([]models.EntityPermission, error)
+	SaveEntityPermission(models.EntityPermission) (models.EntityPermission, error)
+	DeleteEntityPermission(entityType string, entityId uint, roleId uint) error
+}
+
+type EntityPermissionRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewEntityPermissionRepositoryImpl(Db *gorm.DB) EntityPermissionRepository {
+	return &EntityPermissionRepositoryImpl{
+		DB: Db,
+	}
+}
+
+func (e *EntityPermissionRepositoryImpl) GetViewer(userId int) (Viewer, error) {
+	// Người dùng chưa đăng nhập không có role nào
+	if userId == 0 {
+		return Viewer{}, nil
+	}
+	var roles []models.Role
+	err := e.DB.Joins("JOIN user_roles ON roles.id = user_roles.role_id").
+		Where("user_roles.user_id = ?", userId).
+		Find(&roles).Error
+	if err != nil {
+		return Viewer{}, err
+	}
+	viewer := Viewer{UserID: uint(userId)}
+	for _, role := range roles {
+		viewer.RoleIDs = append(viewer.RoleIDs, role.ID)
+		if role.Name == adminRoleName {
+			viewer.IsAdmin = true
+		}
+	}
+	viewer.EntitledBookIDs, err = entitledBookIDs(e.DB, viewer.UserID)
+	if err != nil {
+		return Viewer{}, err
+	}
+	return viewer, nil
+}
+
+// HasPermission kiểm tra người dùng có permission thông qua một trong các role của mình
+func (e *EntityPermissionRepositoryImpl) HasPermission(userId int, permission string) (bool, error) {
+	if userId == 0 {
+		return false, nil
+	}
+	var count int64
+	err := e.DB.Table("user_roles").
+		Joins("JOIN role_permissions ON role_permissions.role_id = user_roles.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("user_roles.user_id = ? AND permissions.name = ?", userId, permission).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (e *EntityPermissionRepositoryImpl) FindRoleByName(name string) (*models.Role, error) {
+	var role models.Role
+	err := e.DB.Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (e *EntityPermissionRepositoryImpl) FindEntityPermissions(entityType string, entityId uint, roleIds []uint) ([]models.EntityPermission, error) {
+	var permissions []models.EntityPermission
+	if len(roleIds) == 0 {
+		return permissions, nil
+	}
+	err := e.DB.Where("entity_type = ? AND entity_id = ? AND role_id IN ?", entityType, entityId, roleIds).
+		Find(&permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (e *EntityPermissionRepositoryImpl) GetEntityPermissions(entityType string, entityId uint) ([]models.EntityPermission, error) {
+	var permissions []models.EntityPermission
+	err := e.DB.Preload("Role").
+		Where("entity_type = ? AND entity_id = ?", entityType, entityId).
+		Find(&permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (e *EntityPermissionRepositoryImpl) SaveEntityPermission(permission models.EntityPermission) (models.EntityPermission, error) {
+	var existing models.EntityPermission
+	result := e.DB.Where("entity_type = ? AND entity_id = ? AND role_id = ?",
+		permission.EntityType, permission.EntityID, permission.RoleID).Limit(1).Find(&existing)
+	if result.Error != nil {
+		return models.EntityPermission{}, result.Error
+	}
+	// Đã có quyền cho role này thì ghi đè
+	if result.RowsAffected > 0 {
+		permission.ID = existing.ID
+		permission.CreatedAt = existing.CreatedAt
+	}
+	if err := e.DB.Save(&permission).Error; err != nil {
+		return models.EntityPermission{}, err
+	}
+	return permission, nil
+}
+
+func (e *EntityPermissionRepositoryImpl) DeleteEntityPermission(entityType string, entityId uint, roleId uint) error {
+	// Xóa hẳn để có thể cấp lại quyền mà không vướng unique index
+	return e.DB.Unscoped().
+		Where("entity_type = ? AND entity_id = ? AND role_id = ?", entityType, entityId, roleId).
+		Delete(&models.EntityPermission{}).Error
+}
+
+// visibleCondition trả về điều kiện SQL giữ lại các entity mà viewer được xem
+func visibleCondition(alias string, entityType string, viewer Viewer) (string, []interface{}) {
+	if viewer.IsAdmin {
+		return "TRUE", nil
+	}
+	conditions := []string{alias + ".restricted = false"}
+	var args []interface{}
+	if len(viewer.RoleIDs) > 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM entity_permissions ep"+
+			" WHERE ep.deleted_at IS NULL AND ep.entity_type = ? AND ep.entity_id = "+alias+".id"+
+			" AND ep.role_id IN ? AND ep.can_view = true)")
+		args = append(args, entityType, viewer.RoleIDs)
+	}
+	// Sách điện tử đã mua: sách và mọi chương, trang bên trong
+	if len(viewer.EntitledBookIDs) > 0 {
+		var condition string
+		switch entityType {
+		case constant.EntityTypeBook:
+			condition = alias + ".id IN ?"
+		case constant.EntityTypeChapter:
+			condition = alias + ".book_id IN ?"
+		case constant.EntityTypePage:
+			condition = alias + ".chapter_id IN (SELECT id FROM chapters WHERE book_id IN ?)"
+		}
+		if condition != "" {
+			conditions = append(conditions, condition)
+			args = append(args, viewer.EntitledBookIDs)
+		}
+	}
+	if len(conditions) == 1 {
+		return conditions[0], args
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
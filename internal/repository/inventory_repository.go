@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"errors"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInvalidStockLevel = errors.New("stock cannot go below the reserved quantity")
+)
+
+type InventoryRepository interface {
+	GetStock(bookId uint) (models.BookStock, error)
+	GetStocks(bookIds []uint, lowOnly bool) ([]models.BookStock, error)
+	AdjustStock(bookId uint, quantity int, movementType string, reason string, userId uint) (models.BookStock, error)
+	SetLowStockThreshold(bookId uint, threshold int) (models.BookStock, error)
+	GetMovements(bookId uint, limit int) ([]models.StockMovement, error)
+}
+
+type InventoryRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewInventoryRepositoryImpl(Db *gorm.DB) InventoryRepository {
+	return &InventoryRepositoryImpl{
+		DB: Db,
+	}
+}
+
+func (r *InventoryRepositoryImpl) GetStock(bookId uint) (models.BookStock, error) {
+	var stock models.BookStock
+	result := r.DB.Where("book_id = ?", bookId).Limit(1).Find(&stock)
+	if result.Error != nil {
+		return models.BookStock{}, result.Error
+	}
+	// Sách chưa từng nhập kho
+	if result.RowsAffected == 0 {
+		return models.BookStock{BookID: bookId, LowStockThreshold: constant.DefaultLowStockThreshold}, nil
+	}
+	return stock, nil
+}
+
+func (r *InventoryRepositoryImpl) GetStocks(bookIds []uint, lowOnly bool) ([]models.BookStock, error) {
+	var stocks []models.BookStock
+	query := r.DB.Order("book_id")
+	if len(bookIds) > 0 {
+		query = query.Where("book_id IN ?", bookIds)
+	}
+	if lowOnly {
+		query = query.Where("on_hand - reserved <= low_stock_threshold")
+	}
+	if err := query.Find(&stocks).Error; err != nil {
+		return nil, err
+	}
+	return stocks, nil
+}
+
+func (r *InventoryRepositoryImpl) AdjustStock(bookId uint, quantity int, movementType string, reason string, userId uint) (models.BookStock, error) {
+	var stock models.BookStock
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Book{}, bookId).Error; err != nil {
+			return fmt.Errorf("book not found: %w", err)
+		}
+		stocks, err := lockStocks(tx, []uint{bookId})
+		if err != nil {
+			return err
+		}
+		stock = *stocks[bookId]
+		if stock.OnHand+quantity < stock.Reserved {
+			return ErrInvalidStockLevel
+		}
+		stock.OnHand += quantity
+		return recordMovement(tx, &stock, models.StockMovement{
+			Type:      movementType,
+			Quantity:  quantity,
+			Reason:    reason,
+			CreatedBy: userId,
+		})
+	})
+	if err != nil {
+		return models.BookStock{}, err
+	}
+	return stock, nil
+}
+
+func (r *InventoryRepositoryImpl) SetLowStockThreshold(bookId uint, threshold int) (models.BookStock, error) {
+	var stock models.BookStock
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Book{}, bookId).Error; err != nil {
+			return fmt.Errorf("book not found: %w", err)
+		}
+		stocks, err := lockStocks(tx, []uint{bookId})
+		if err != nil {
+			return err
+		}
+		stock = *stocks[bookId]
+		stock.LowStockThreshold = threshold
+		return tx.Save(&stock).Error
+	})
+	if err != nil {
+		return models.BookStock{}, err
+	}
+	return stock, nil
+}
+
+func (r *InventoryRepositoryImpl) GetMovements(bookId uint, limit int) ([]models.StockMovement, error) {
+	var movements []models.StockMovement
+	err := r.DB.Where("book_id = ?", bookId).
+		Order("id DESC").
+		Limit(limit).
+		Find(&movements).Error
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// lockStocks khóa (SELECT ... FOR UPDATE) dòng tồn kho của các sách, tạo dòng mới nếu chưa có.
+// Khóa theo thứ tự book_id tăng dần để hai giao dịch đồng thời không bị deadlock.
+func lockStocks(tx *gorm.DB, bookIds []uint) (map[uint]*models.BookStock, error) {
+	if len(bookIds) == 0 {
+		return map[uint]*models.BookStock{}, nil
+	}
+	ids := append([]uint(nil), bookIds...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, bookId := range ids {
+		stock := models.BookStock{BookID: bookId, LowStockThreshold: constant.DefaultLowStockThreshold}
+		err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "book_id"}}, DoNothing: true}).
+			Create(&stock).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var stocks []models.BookStock
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("book_id IN ?", ids).
+		Order("book_id").
+		Find(&stocks).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]*models.BookStock, len(stocks))
+	for i := range stocks {
+		result[stocks[i].BookID] = &stocks[i]
+	}
+	for _, bookId := range ids {
+		if result[bookId] == nil {
+			return nil, fmt.Errorf("stock of book %d not found", bookId)
+		}
+	}
+	return result, nil
+}
+
+// recordMovement lưu tồn kho mới và ghi một dòng vào sổ kho
+func recordMovement(tx *gorm.DB, stock *models.BookStock, movement models.StockMovement) error {
+	if err := tx.Save(stock).Error; err != nil {
+		return err
+	}
+	movement.BookID = stock.BookID
+	movement.OnHandAfter = stock.OnHand
+	movement.ReservedAfter = stock.Reserved
+	return tx.Create(&movement).Error
+}
+
+// reserveStock giữ hàng cho các dòng của đơn mới, trả lỗi ErrInsufficientStock nếu không đủ
+func reserveStock(tx *gorm.DB, orderId uint, details []models.OrderDetail, userId uint) error {
+	quantities := orderQuantities(details)
+	stocks, err := lockStocks(tx, mapKeys(quantities))
+	if err != nil {
+		return err
+	}
+	for bookId, quantity := range quantities {
+		stock := stocks[bookId]
+		if stock.Available() < quantity {
+			return fmt.Errorf("%w: book %d has %d available, %d requested", ErrInsufficientStock, bookId, stock.Available(), quantity)
+		}
+		stock.Reserved += quantity
+		err := recordMovement(tx, stock, models.StockMovement{
+			OrderID:   &orderId,
+			Type:      constant.StockReservation,
+			Quantity:  quantity,
+			CreatedBy: userId,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// holdsReservation cho biết đơn ở trạng thái này vẫn đang giữ hàng trong kho
+func holdsReservation(status constant.OrderStatus) bool {
+	switch status {
+	case constant.Delivered, constant.Cancelled, constant.Returned, constant.Failed:
+		return false
+	}
+	return true
+}
+
+// settleOrderStock cập nhật kho khi đơn chuyển trạng thái: giao thành công thì xuất kho,
+// hủy hoặc thất bại thì trả lại phần đã giữ. Đơn phải được load kèm OrderDetail.
+func settleOrderStock(tx *gorm.DB, order models.Order, to constant.OrderStatus, userId uint) error {
+	if !holdsReservation(order.Status) || holdsReservation(to) {
+		return nil
+	}
+	movementType := constant.StockRelease
+	if to == constant.Delivered {
+		movementType = constant.StockSale
+	}
+
+	quantities := orderQuantities(order.OrderDetail)
+	stocks, err := lockStocks(tx, mapKeys(quantities))
+	if err != nil {
+		return err
+	}
+	for bookId, quantity := range quantities {
+		stock := stocks[bookId]
+		stock.Reserved -= quantity
+		if movementType == constant.StockSale {
+			stock.OnHand -= quantity
+		}
+		err := recordMovement(tx, stock, models.StockMovement{
+			OrderID:   &order.ID,
+			Type:      movementType,
+			Quantity:  -quantity,
+			CreatedBy: userId,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restockOrderItems nhập lại kho các cuốn sách khách trả của một đơn đã giao
+func restockOrderItems(tx *gorm.DB, orderId uint, quantities map[uint]int, userId uint) error {
+	stocks, err := lockStocks(tx, mapKeys(quantities))
+	if err != nil {
+		return err
+	}
+	for bookId, quantity := range quantities {
+		stock := stocks[bookId]
+		stock.OnHand += quantity
+		err := recordMovement(tx, stock, models.StockMovement{
+			OrderID:   &orderId,
+			Type:      constant.StockReturn,
+			Quantity:  quantity,
+			CreatedBy: userId,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderQuantities tổng số sách in theo từng đầu sách; sách điện tử không đi qua kho
+func orderQuantities(details []models.OrderDetail) map[uint]int {
+	quantities := make(map[uint]int)
+	for _, detail := range details {
+		if detail.Type == constant.OrderLineDigital {
+			continue
+		}
+		quantities[detail.BookID] += detail.Quantity
+	}
+	return quantities
+}
+
+func mapKeys(quantities map[uint]int) []uint {
+	keys := make([]uint, 0, len(quantities))
+	for key := range quantities {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHoldsReservation(t *testing.T) {
+	for _, status := range []constant.OrderStatus{constant.Pending, constant.Confirmed, constant.Processing, constant.Shipped} {
+		assert.True(t, holdsReservation(status), "status %v", status)
+	}
+	for _, status := range []constant.OrderStatus{constant.Delivered, constant.Cancelled, constant.Returned, constant.Failed} {
+		assert.False(t, holdsReservation(status), "status %v", status)
+	}
+}
+
+func TestOrderQuantities(t *testing.T) {
+	details := []models.OrderDetail{
+		{BookID: 1, Quantity: 2},
+		{BookID: 2, Quantity: 1},
+		{BookID: 1, Quantity: 3},
+	}
+	assert.Equal(t, map[uint]int{1: 5, 2: 1}, orderQuantities(details))
+}
+
+func TestBookStockAvailability(t *testing.T) {
+	stock := models.BookStock{OnHand: 10, Reserved: 4, LowStockThreshold: 5}
+	assert.Equal(t, 6, stock.Available())
+	assert.False(t, stock.IsLow())
+
+	stock.Reserved = 5
+	assert.True(t, stock.IsLow())
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LibraryRepository interface {
+	GetLibrary(userId uint) ([]models.BookEntitlement, error)
+	HasEntitlement(userId uint, bookId uint) (bool, error)
+}
+
+type LibraryRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewLibraryRepositoryImpl(db *gorm.DB) LibraryRepository {
+	return &LibraryRepositoryImpl{
+		DB: db,
+	}
+}
+
+// GetLibrary lấy các sách điện tử người dùng đã mua, mới mua trước
+func (l *LibraryRepositoryImpl) GetLibrary(userId uint) ([]models.BookEntitlement, error) {
+	var entitlements []models.BookEntitlement
+	err := l.DB.Preload("Book").Where("user_id = ?", userId).Order("created_at DESC, id DESC").Find(&entitlements).Error
+	if err != nil {
+		return nil, err
+	}
+	return entitlements, nil
+}
+
+func (l *LibraryRepositoryImpl) HasEntitlement(userId uint, bookId uint) (bool, error) {
+	var count int64
+	err := l.DB.Model(&models.BookEntitlement{}).Where("user_id = ? AND book_id = ?", userId, bookId).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// entitledBookIDs lấy ID các sách điện tử người dùng đã mua
+func entitledBookIDs(db *gorm.DB, userId uint) ([]uint, error) {
+	var bookIds []uint
+	err := db.Model(&models.BookEntitlement{}).Where("user_id = ?", userId).Order("book_id").Pluck("book_id", &bookIds).Error
+	if err != nil {
+		return nil, err
+	}
+	return bookIds, nil
+}
+
+// digitalOnly cho biết đơn (đã load OrderDetail) chỉ gồm sách điện tử, không có gì cần giao
+func digitalOnly(order models.Order) bool {
+	if len(order.OrderDetail) == 0 {
+		return false
+	}
+	for _, detail := range order.OrderDetail {
+		if detail.Type != constant.OrderLineDigital {
+			return false
+		}
+	}
+	return true
+}
+
+// entitlementActive cho biết các dòng sách điện tử của đơn có được cấp quyền đọc hay không:
+// đơn đã thu tiền (kể cả khi mới hoàn một phần) và chưa bị hủy/trả/thất bại.
+func entitlementActive(order models.Order) bool {
+	switch order.PaymentStatus {
+	case constant.PaymentPaid, constant.PaymentPartiallyRefunded:
+	default:
+		return false
+	}
+	switch order.Status {
+	case constant.Cancelled, constant.Returned, constant.Failed:
+		return false
+	}
+	return true
+}
+
+// syncOrderEntitlements đồng bộ quyền đọc theo trạng thái hiện tại của đơn: cấp cho các dòng sách điện tử
+// chưa hoàn tiền của đơn đã thanh toán, thu hồi trong các trường hợp còn lại. Gọi lại nhiều lần không đổi kết quả.
+// Người dùng đã có quyền từ đơn khác thì giữ nguyên quyền đó.
+func syncOrderEntitlements(tx *gorm.DB, orderId uint) error {
+	var order models.Order
+	if err := tx.Preload("OrderDetail").First(&order, orderId).Error; err != nil {
+		return err
+	}
+	active := entitlementActive(order)
+	for _, detail := range order.OrderDetail {
+		if detail.Type != constant.OrderLineDigital {
+			continue
+		}
+		if active && detail.RefundedQuantity < detail.Quantity {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "book_id"}},
+				DoNothing: true,
+			}).Create(&models.BookEntitlement{
+				UserID:  order.UserID,
+				BookID:  detail.BookID,
+				OrderID: order.ID,
+			}).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		// Xóa hẳn để có thể mua lại mà không vướng unique index
+		err := tx.Unscoped().
+			Where("user_id = ? AND book_id = ? AND order_id = ?", order.UserID, detail.BookID, order.ID).
+			Delete(&models.BookEntitlement{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/events"
+	"bookstack/internal/messaging"
+	"bookstack/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MessageRepository lưu outbox của các sự kiện chờ publish và dấu các message consumer đã xử lý
+type MessageRepository interface {
+	RelayOutbox(limit int, now time.Time, publish func(models.OutboxMessage) error) (int, error)
+	ProcessOnce(consumer string, messageId string, handle func() error) (bool, error)
+	Purge(before time.Time) error
+}
+
+type MessageRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewMessageRepositoryImpl(db *gorm.DB) MessageRepository {
+	return &MessageRepositoryImpl{
+		DB: db,
+	}
+}
+
+// RelayOutbox publish tối đa limit sự kiện tới hạn theo thứ tự ghi. Các dòng được khóa SKIP LOCKED nên nhiều
+// instance chạy relay cùng lúc không publish trùng. Lần publish lỗi đầu tiên được hẹn thử lại theo backoff và
+// dừng lượt relay, vì broker lỗi thì các sự kiện sau cũng sẽ lỗi. Trả về số sự kiện đã publish.
+func (r *MessageRepositoryImpl) RelayOutbox(limit int, now time.Time, publish func(models.OutboxMessage) error) (int, error) {
+	published := 0
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var messages []models.OutboxMessage
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND next_attempt_at <= ?", now).
+			Order("id").Limit(limit).Find(&messages).Error
+		if err != nil {
+			return err
+		}
+		for _, message := range messages {
+			if err := publish(message); err != nil {
+				return tx.Model(&message).Updates(map[string]interface{}{
+					"attempts":        message.Attempts + 1,
+					"last_error":      err.Error(),
+					"next_attempt_at": now.Add(outboxBackoff(message.Attempts + 1)),
+				}).Error
+			}
+			if err := tx.Model(&message).Update("published_at", now).Error; err != nil {
+				return err
+			}
+			published++
+		}
+		return nil
+	})
+	return published, err
+}
+
+// ProcessOnce chạy handle nếu consumer chưa xử lý message. Dấu đã xử lý được ghi trong transaction giữ tới khi
+// handle xong: lần giao trùng đồng thời phải chờ rồi bỏ qua, handle lỗi thì dấu bị rollback để message được
+// xử lý lại. Trả về false khi message đã được xử lý trước đó.
+func (r *MessageRepositoryImpl) ProcessOnce(consumer string, messageId string, handle func() error) (bool, error) {
+	processed := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ProcessedMessage{
+			Consumer:    consumer,
+			MessageID:   messageId,
+			ProcessedAt: time.Now(),
+		})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := handle(); err != nil {
+			return err
+		}
+		processed = true
+		return nil
+	})
+	return processed, err
+}
+
+// Purge xóa sự kiện đã publish và dấu message đã xử lý trước thời điểm before
+func (r *MessageRepositoryImpl) Purge(before time.Time) error {
+	if err := r.DB.Where("published_at < ?", before).Delete(&models.OutboxMessage{}).Error; err != nil {
+		return err
+	}
+	return r.DB.Where("processed_at < ?", before).Delete(&models.ProcessedMessage{}).Error
+}
+
+// enqueueOutbox ghi sự kiện vào outbox trong transaction tx, sự kiện chỉ được phát khi transaction commit
+func enqueueOutbox(tx *gorm.DB, event events.Event) error {
+	env, err := events.NewEnvelope(event)
+	if err != nil {
+		return err
+	}
+	message := models.NewOutboxMessage(env)
+	return tx.Create(&message).Error
+}
+
+// outboxBackoff là thời gian chờ trước lần publish lại thứ attempt: 1s, 2s, 4s, ... tối đa constant.OutboxMaxBackoff
+func outboxBackoff(attempt int) time.Duration {
+	return messaging.RetryPolicy{BaseDelay: time.Second, MaxDelay: constant.OutboxMaxBackoff}.Backoff(attempt)
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/events"
+	"bookstack/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxMessageEnvelope(t *testing.T) {
+	env, err := events.NewEnvelope(events.OrderCreated{OrderID: 12, Address: "Cầu Giấy"})
+	require.NoError(t, err)
+
+	message := models.NewOutboxMessage(env)
+	assert.Equal(t, env.OccurredAt, message.NextAttemptAt)
+	assert.Nil(t, message.PublishedAt)
+
+	// Publish lại phải giữ nguyên ID để consumer loại trùng
+	relayed := message.Envelope()
+	assert.Equal(t, env.ID, relayed.ID)
+	order, err := events.Decode[events.OrderCreated](relayed)
+	require.NoError(t, err)
+	assert.Equal(t, uint(12), order.OrderID)
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, outboxBackoff(1))
+	assert.Equal(t, 8*time.Second, outboxBackoff(4))
+	assert.Equal(t, constant.OutboxMaxBackoff, outboxBackoff(20))
+}
@@ -3,21 +3,28 @@ package repository
 import (
 	"bookstack/internal/constant"
 	"bookstack/internal/dto/request"
+	"bookstack/internal/events"
 	"bookstack/internal/models"
+	"bookstack/internal/money"
+	"bookstack/internal/pricing"
+	"errors"
 
 	"fmt"
-	"strconv"
+	"strings"
 
 	"github.com/jinzhu/copier"
 	"gorm.io/gorm"
 )
 
+var ErrInvalidOrderLine = errors.New("invalid order line")
+
 type OrderRepository interface {
 	CreateOrder(request.OrderRequest, int) (models.Order, error)
+	QuoteOrder(request.OrderRequest, int) (models.Order, error)
 	GetOrder(int) (models.Order, error)
 	GetUserOrder(int) ([]models.Order, error)
-	CancelOrder(int) error
-	UpdateOrderStatus(webhookPayload map[string]interface{}) error
+	TransitionOrder(orderId uint, to constant.OrderStatus, actor OrderActor, reason string) (models.Order, error)
+	GetStatusHistory(orderId uint) ([]models.OrderStatusHistory, error)
 }
 
 type OrderRepositoryImpl struct {
@@ -32,7 +39,7 @@ func NewOrderRepositoryImpl(db *gorm.DB) OrderRepository {
 
 func (o *OrderRepositoryImpl) GetUserOrder(userId int) ([]models.Order, error) {
 	var orders []models.Order
-	err := o.DB.Preload("OrderDetail").Preload("OrderDetail.Book").Where("user_id = ?", userId).Find(&orders).Error
+	err := o.DB.Preload("OrderDetail").Preload("OrderDetail.Book").Preload("Discounts").Where("user_id = ?", userId).Find(&orders).Error
 	if err != nil {
 		return nil, err
 	}
@@ -49,18 +56,69 @@ func (o *OrderRepositoryImpl) GetOrder(id int) (models.Order, error) {
 	return orders, err
 }
 
-func (o *OrderRepositoryImpl) GetBookPrice(bookId int) (float64, error) {
-	var book models.Book
-	err := o.DB.Where("id = ?", bookId).First(&book).Error
+func (o *OrderRepositoryImpl) CreateOrder(request request.OrderRequest, userId int) (models.Order, error) {
+	var order models.Order
+	err := o.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = priceOrder(tx, request, userId, true)
+		if err != nil {
+			return err
+		}
+		// Mã nhận hàng khách đọc cho shipper để xác nhận đã giao
+		if !digitalOnly(order) {
+			order.DeliveryCode, err = newDeliveryCode()
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+		err = tx.Create(&models.OrderStatusHistory{
+			OrderID:   order.ID,
+			ToStatus:  constant.Pending,
+			ActorID:   order.UserID,
+			ActorRole: constant.OrderActorCustomer,
+		}).Error
+		if err != nil {
+			return err
+		}
+		// Ghi nhận lượt dùng coupon để áp giới hạn số lượt
+		for _, discount := range order.Discounts {
+			err := tx.Create(&models.CouponRedemption{
+				CouponID: discount.CouponID,
+				OrderID:  order.ID,
+				UserID:   order.UserID,
+				Amount:   discount.Amount,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		// Giữ hàng trong kho, khóa dòng tồn kho để không bán quá số lượng (sách điện tử không giữ hàng)
+		if err := reserveStock(tx, order.ID, order.OrderDetail, order.UserID); err != nil {
+			return err
+		}
+		// Sự kiện đơn mới cho service2 mời shipper, chỉ được publish khi đơn đã lưu
+		return enqueueOutbox(tx, events.OrderCreated{OrderID: order.ID, Address: order.Address})
+	})
 	if err != nil {
-		return 0, err
+		return models.Order{}, err
 	}
-	return book.Price, err
+
+	return order, nil
 }
 
-func (o *OrderRepositoryImpl) CreateOrder(request request.OrderRequest, userId int) (models.Order, error) {
+// QuoteOrder tính bảng giá của đơn hàng (giảm giá, phí vận chuyển) mà không tạo đơn
+func (o *OrderRepositoryImpl) QuoteOrder(request request.OrderRequest, userId int) (models.Order, error) {
+	return priceOrder(o.DB, request, userId, false)
+}
+
+// priceOrder dựng đơn hàng từ request: chốt tiền tệ và tỉ giá, quy đổi giá hiện tại của sách,
+// áp dụng coupon/khuyến mãi và phí vận chuyển. Mọi số tiền của đơn theo tiền tệ đã chốt.
+func priceOrder(tx *gorm.DB, request request.OrderRequest, userId int, lock bool) (models.Order, error) {
 	var order models.Order
-	var totalPrice float64
 
 	// Copy thông tin từ request sang order (chỉ copy được các field cùng kiểu)
 	err := copier.Copy(&order, request)
@@ -70,68 +128,169 @@ func (o *OrderRepositoryImpl) CreateOrder(request request.OrderRequest, userId i
 
 	// Copy các trường không được copy tự động
 	order.Address = request.Address
+	if location := request.Location; location != nil {
+		order.Location = models.Address{
+			Province:  location.Province,
+			District:  location.District,
+			Ward:      location.Ward,
+			Street:    location.Street,
+			Lat:       location.Lat,
+			Lng:       location.Lng,
+			Precision: location.Precision,
+		}
+	}
 	order.Phone = request.Phone
 	order.UserID = uint(userId)
 
+	converter, err := currencyConverter(tx, request.Currency)
+	if err != nil {
+		return models.Order{}, err
+	}
+	order.Currency = converter.Currency
+	order.ExchangeRate = converter.Rate
+
+	// Gộp số lượng nếu một sách xuất hiện nhiều lần trong request (sách in và sách điện tử là hai dòng riêng)
+	quantities := make(map[orderLineKey]int)
+	var keys []orderLineKey
+	for _, detail := range request.OrderDetails {
+		if detail.Quantity <= 0 {
+			return models.Order{}, fmt.Errorf("quantity of book %d must be positive", detail.BookID)
+		}
+		if detail.Quantity > constant.MaxCartQuantity {
+			return models.Order{}, fmt.Errorf("%w: quantity of book %d cannot exceed %d", ErrInvalidOrderLine, detail.BookID, constant.MaxCartQuantity)
+		}
+		key := orderLineKey{bookId: detail.BookID, lineType: detail.Type}
+		if key.lineType == "" {
+			key.lineType = constant.OrderLinePhysical
+		}
+		if key.lineType != constant.OrderLinePhysical && key.lineType != constant.OrderLineDigital {
+			return models.Order{}, fmt.Errorf("%w: unknown line type %q", ErrInvalidOrderLine, detail.Type)
+		}
+		if _, ok := quantities[key]; !ok {
+			keys = append(keys, key)
+		}
+		quantities[key] += detail.Quantity
+		if quantities[key] > constant.MaxCartQuantity {
+			return models.Order{}, fmt.Errorf("%w: quantity of book %d cannot exceed %d", ErrInvalidOrderLine, detail.BookID, constant.MaxCartQuantity)
+		}
+	}
+	if len(keys) == 0 {
+		return models.Order{}, fmt.Errorf("order has no books")
+	}
+
 	// Chuyển đổi OrderDetailRequest thành OrderDetail
 	var orderDetails []models.OrderDetail
-	for _, detail := range request.OrderDetails {
+	var lines []pricing.Line
+	physical := false
+	for _, key := range keys {
 		// Lấy thông tin sách từ DB
 		var book models.Book
-		if err := o.DB.First(&book, detail.BookID).Error; err != nil {
+		if err := tx.Preload("Tags").First(&book, key.bookId).Error; err != nil {
 			return models.Order{}, fmt.Errorf("book not found: %w", err)
 		}
+		price := book.Price
+		if key.lineType == constant.OrderLineDigital {
+			if err := checkDigitalLine(tx, book, quantities[key], order.UserID); err != nil {
+				return models.Order{}, err
+			}
+			price = book.DigitalPrice
+		} else {
+			physical = true
+		}
+		unitPrice, err := converter.Convert(price)
+		if err != nil {
+			return models.Order{}, fmt.Errorf("%w: price of book %d is out of range: %w", ErrInvalidOrderLine, key.bookId, err)
+		}
+		lines = append(lines, pricing.Line{
+			BookID:    key.bookId,
+			ShelveID:  book.ShelveID,
+			Tags:      book.Tags,
+			UnitPrice: unitPrice,
+			Quantity:  quantities[key],
+		})
 
+		book.Tags = nil
 		orderDetails = append(orderDetails, models.OrderDetail{
-			BookID:   detail.BookID,
-			Quantity: detail.Quantity,
-			Price:    book.Price,
+			BookID:   key.bookId,
+			Type:     key.lineType,
+			Quantity: quantities[key],
+			Price:    unitPrice,
 			Book:     book,
 		})
-		totalPrice += book.Price * float64(detail.Quantity)
 	}
 
-	// Gán giá trị còn thiếu vào Order
-	order.OrderDetail = orderDetails
-	order.TotalPrice = totalPrice
-	order.Status = constant.Pending
-
-	// Lưu vào DB với preload Book
-	err = o.DB.Preload("OrderDetail.Book").Create(&order).Error
+	coupons, err := applicableCoupons(tx, request.CouponCode, order.UserID, lock)
 	if err != nil {
 		return models.Order{}, err
 	}
+	// Đơn chỉ có sách điện tử không cần giao hàng
+	shippingFee := money.Zero
+	if physical {
+		if shippingFee, err = converter.Convert(constant.StandardShippingFee); err != nil {
+			return models.Order{}, err
+		}
+	}
+	breakdown, err := pricing.Calculate(lines, coupons, shippingFee, converter)
+	if err != nil {
+		return models.Order{}, fmt.Errorf("%w: order total is out of range: %w", ErrInvalidOrderLine, err)
+	}
+
+	// Mã coupon người dùng nhập phải thực sự giảm được tiền cho đơn
+	code := strings.ToUpper(strings.TrimSpace(request.CouponCode))
+	if code != "" {
+		applied := false
+		for _, discount := range breakdown.Discounts {
+			if discount.Coupon.Code == code {
+				applied = true
+			}
+		}
+		if !applied {
+			return models.Order{}, fmt.Errorf("%w: coupon %s does not apply to this order", ErrCouponNotApplicable, code)
+		}
+	}
 
+	// Gán giá trị còn thiếu vào Order
+	order.OrderDetail = orderDetails
+	order.Subtotal = breakdown.Subtotal
+	order.ShippingFee = breakdown.ShippingFee
+	order.DiscountTotal = breakdown.DiscountTotal
+	order.TotalPrice = breakdown.Total
+	order.CouponCode = code
+	for _, discount := range breakdown.Discounts {
+		order.Discounts = append(order.Discounts, models.OrderDiscount{
+			CouponID: discount.Coupon.ID,
+			Code:     discount.Coupon.Code,
+			Name:     discount.Coupon.Name,
+			Type:     discount.Coupon.Type,
+			Amount:   discount.Amount,
+		})
+	}
+	order.Status = constant.Pending
 	return order, nil
 }
 
-func (o *OrderRepositoryImpl) CancelOrder(orderId int) error {
-	var order models.Order
-	err := o.DB.Where("id = ?", orderId).Find(&order).Error
-	if err != nil {
-		return err
-	}
-	order.Status = constant.Cancelled
-	err = o.DB.Save(order).Error
-	if err != nil {
-		return err
-	}
-	return nil
+// orderLineKey xác định một dòng đơn hàng: một cuốn sách theo loại sách in hoặc sách điện tử
+type orderLineKey struct {
+	bookId   uint
+	lineType string
 }
 
-func (o *OrderRepositoryImpl) UpdateOrderStatus(webhookPayload map[string]interface{}) error {
-	// Extract order ID from webhook payload
-	orderID, ok := webhookPayload["order_id"].(string)
-	if !ok {
-		return fmt.Errorf("invalid order ID in webhook payload")
+// checkDigitalLine kiểm tra dòng sách điện tử: sách có bán bản điện tử, mỗi đơn chỉ mua một bản
+// và người mua chưa sở hữu sách đó
+func checkDigitalLine(tx *gorm.DB, book models.Book, quantity int, userId uint) error {
+	if !book.DigitalPrice.IsPositive() {
+		return fmt.Errorf("%w: book %d is not sold as an ebook", ErrInvalidOrderLine, book.ID)
 	}
-
-	// Convert order ID to uint
-	id, err := strconv.ParseUint(orderID, 10, 64)
+	if quantity != 1 {
+		return fmt.Errorf("%w: ebook %d can only be bought once per order", ErrInvalidOrderLine, book.ID)
+	}
+	var count int64
+	err := tx.Model(&models.BookEntitlement{}).Where("user_id = ? AND book_id = ?", userId, book.ID).Count(&count).Error
 	if err != nil {
-		return fmt.Errorf("invalid order ID format: %v", err)
+		return err
 	}
-
-	// Update order status to Confirmed
-	return o.DB.Model(&models.Order{}).Where("id = ?", id).Update("status", constant.Confirmed).Error
+	if count > 0 {
+		return fmt.Errorf("%w: ebook %d is already in your library", ErrInvalidOrderLine, book.ID)
+	}
+	return nil
 }
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidTransition    = errors.New("invalid order status transition")
+	ErrTransitionNotAllowed = errors.New("order status transition not allowed")
+)
+
+// OrderActor là người (hoặc hệ thống) thực hiện chuyển trạng thái đơn hàng
+type OrderActor struct {
+	UserID uint
+	Role   string // constant.OrderActor*
+}
+
+// orderTransitions là bảng chuyển trạng thái hợp lệ: from -> to -> các vai trò được phép thực hiện.
+// Cancelled, Returned và Failed là trạng thái cuối.
+var orderTransitions = map[constant.OrderStatus]map[constant.OrderStatus][]string{
+	constant.Pending: {
+		constant.Confirmed: {constant.OrderActorSystem, constant.OrderActorStaff},
+		constant.Cancelled: {constant.OrderActorCustomer, constant.OrderActorStaff, constant.OrderActorSystem},
+	},
+	constant.Confirmed: {
+		constant.Processing: {constant.OrderActorStaff},
+		constant.Delivered:  {constant.OrderActorSystem}, // Chỉ đơn toàn sách điện tử, ngay khi thanh toán xong
+		constant.Cancelled:  {constant.OrderActorCustomer, constant.OrderActorStaff, constant.OrderActorSystem},
+	},
+	constant.Processing: {
+		constant.Shipped:   {constant.OrderActorShipper, constant.OrderActorStaff},
+		constant.Cancelled: {constant.OrderActorStaff},
+	},
+	constant.Shipped: {
+		constant.Delivered: {constant.OrderActorShipper, constant.OrderActorStaff},
+		constant.Failed:    {constant.OrderActorShipper, constant.OrderActorStaff},
+	},
+	constant.Delivered: {
+		constant.Returned: {constant.OrderActorStaff},
+	},
+}
+
+// CheckOrderTransition kiểm tra đơn có thể chuyển sang trạng thái `to` bởi actor hay không.
+// Khách chỉ thao tác trên đơn của mình, shipper chỉ thao tác trên đơn được giao cho mình.
+func CheckOrderTransition(order models.Order, to constant.OrderStatus, actor OrderActor) error {
+	roles, ok := orderTransitions[order.Status][to]
+	if !ok {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, order.Status, to)
+	}
+	allowed := false
+	for _, role := range roles {
+		if role == actor.Role {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s cannot move order from %s to %s", ErrTransitionNotAllowed, actor.Role, order.Status, to)
+	}
+	if order.Status == constant.Confirmed && to == constant.Delivered && !digitalOnly(order) {
+		return fmt.Errorf("%w: order has books to ship", ErrTransitionNotAllowed)
+	}
+	switch actor.Role {
+	case constant.OrderActorCustomer:
+		if order.UserID != actor.UserID {
+			return fmt.Errorf("%w: order does not belong to user %d", ErrTransitionNotAllowed, actor.UserID)
+		}
+	case constant.OrderActorShipper:
+		if order.ShipperID == nil || *order.ShipperID != actor.UserID {
+			return fmt.Errorf("%w: order is not assigned to shipper %d", ErrTransitionNotAllowed, actor.UserID)
+		}
+	}
+	return nil
+}
+
+// lockOrder khóa đơn hàng (SELECT ... FOR UPDATE) kèm chi tiết đơn để chuyển trạng thái
+func lockOrder(tx *gorm.DB, orderId uint) (models.Order, error) {
+	var order models.Order
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("OrderDetail").
+		Where("id = ?", orderId).
+		First(&order).Error
+	return order, err
+}
+
+// transitionOrder chuyển trạng thái đơn đã khóa: kiểm tra bảng chuyển trạng thái, cập nhật kho,
+// tất toán giao dịch thanh toán, ghi lịch sử và cấp/thu hồi sách điện tử. Chuyển sang đúng trạng thái hiện tại là no-op.
+func transitionOrder(tx *gorm.DB, order *models.Order, to constant.OrderStatus, actor OrderActor, reason string) error {
+	if order.Status == to {
+		return nil
+	}
+	if err := CheckOrderTransition(*order, to, actor); err != nil {
+		return err
+	}
+	if err := settleOrderStock(tx, *order, to, actor.UserID); err != nil {
+		return err
+	}
+	if err := settleOrderPayments(tx, *order, to); err != nil {
+		return err
+	}
+	from := order.Status
+	if err := tx.Model(order).Update("status", to).Error; err != nil {
+		return err
+	}
+	order.Status = to
+	err := tx.Create(&models.OrderStatusHistory{
+		OrderID:    order.ID,
+		FromStatus: &from,
+		ToStatus:   to,
+		ActorID:    actor.UserID,
+		ActorRole:  actor.Role,
+		Reason:     reason,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return syncOrderEntitlements(tx, order.ID)
+}
+
+// updateOrderStatus khóa đơn rồi chuyển trạng thái trong một transaction
+func updateOrderStatus(db *gorm.DB, orderId uint, to constant.OrderStatus, actor OrderActor, reason string) (models.Order, error) {
+	var order models.Order
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockOrder(tx, orderId)
+		if err != nil {
+			return err
+		}
+		return transitionOrder(tx, &order, to, actor, reason)
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+	return order, nil
+}
+
+func (o *OrderRepositoryImpl) TransitionOrder(orderId uint, to constant.OrderStatus, actor OrderActor, reason string) (models.Order, error) {
+	return updateOrderStatus(o.DB, orderId, to, actor, reason)
+}
+
+func (o *OrderRepositoryImpl) GetStatusHistory(orderId uint) ([]models.OrderStatusHistory, error) {
+	var history []models.OrderStatusHistory
+	err := o.DB.Where("order_id = ?", orderId).Order("created_at, id").Find(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOrderTransition(t *testing.T) {
+	shipperId := uint(9)
+	order := func(status constant.OrderStatus) models.Order {
+		return models.Order{UserID: 5, ShipperID: &shipperId, Status: status}
+	}
+	customer := OrderActor{UserID: 5, Role: constant.OrderActorCustomer}
+	otherCustomer := OrderActor{UserID: 6, Role: constant.OrderActorCustomer}
+	shipper := OrderActor{UserID: 9, Role: constant.OrderActorShipper}
+	otherShipper := OrderActor{UserID: 10, Role: constant.OrderActorShipper}
+	staff := OrderActor{UserID: 1, Role: constant.OrderActorStaff}
+	system := OrderActor{Role: constant.OrderActorSystem}
+
+	tests := []struct {
+		name     string
+		from     constant.OrderStatus
+		to       constant.OrderStatus
+		actor    OrderActor
+		expected error
+	}{
+		{"customer cancels pending order", constant.Pending, constant.Cancelled, customer, nil},
+		{"customer cancels another customer's order", constant.Pending, constant.Cancelled, otherCustomer, ErrTransitionNotAllowed},
+		{"customer cannot cancel delivered order", constant.Delivered, constant.Cancelled, customer, ErrInvalidTransition},
+		{"customer cannot cancel order in processing", constant.Processing, constant.Cancelled, customer, ErrTransitionNotAllowed},
+		{"payment confirms pending order", constant.Pending, constant.Confirmed, system, nil},
+		{"staff processes confirmed order", constant.Confirmed, constant.Processing, staff, nil},
+		{"assigned shipper delivers order", constant.Shipped, constant.Delivered, shipper, nil},
+		{"other shipper cannot deliver order", constant.Shipped, constant.Delivered, otherShipper, ErrTransitionNotAllowed},
+		{"shipper cannot skip shipping", constant.Processing, constant.Delivered, shipper, ErrInvalidTransition},
+		{"failed is final", constant.Failed, constant.Processing, staff, ErrInvalidTransition},
+		{"staff accepts return", constant.Delivered, constant.Returned, staff, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckOrderTransition(order(tt.from), tt.to, tt.actor)
+			if tt.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckOrderTransitionDigitalOnly(t *testing.T) {
+	system := OrderActor{Role: constant.OrderActorSystem}
+	staff := OrderActor{UserID: 1, Role: constant.OrderActorStaff}
+	ebook := models.OrderDetail{BookID: 1, Type: constant.OrderLineDigital, Quantity: 1}
+	paperback := models.OrderDetail{BookID: 2, Type: constant.OrderLinePhysical, Quantity: 1}
+
+	digital := models.Order{Status: constant.Confirmed, OrderDetail: []models.OrderDetail{ebook}}
+	mixed := models.Order{Status: constant.Confirmed, OrderDetail: []models.OrderDetail{ebook, paperback}}
+
+	assert.NoError(t, CheckOrderTransition(digital, constant.Delivered, system))
+	assert.ErrorIs(t, CheckOrderTransition(digital, constant.Delivered, staff), ErrTransitionNotAllowed)
+	assert.ErrorIs(t, CheckOrderTransition(mixed, constant.Delivered, system), ErrTransitionNotAllowed)
+}
+
+func TestEntitlementActive(t *testing.T) {
+	tests := []struct {
+		status        constant.OrderStatus
+		paymentStatus string
+		expected      bool
+	}{
+		{constant.Pending, constant.PaymentPending, false},
+		{constant.Confirmed, constant.PaymentPaid, true},
+		{constant.Delivered, constant.PaymentPartiallyRefunded, true},
+		{constant.Delivered, constant.PaymentRefunded, false},
+		{constant.Cancelled, constant.PaymentPaid, false},
+		{constant.Returned, constant.PaymentPartiallyRefunded, false},
+	}
+	for _, tt := range tests {
+		order := models.Order{Status: tt.status, PaymentStatus: tt.paymentStatus}
+		assert.Equal(t, tt.expected, entitlementActive(order), "%s/%s", tt.status, tt.paymentStatus)
+	}
+}
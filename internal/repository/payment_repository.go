@@ -0,0 +1,535 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"bookstack/internal/money"
+	"bookstack/internal/payment"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPaymentNotAllowed = errors.New("order cannot be paid")
+	ErrRefundNotAllowed  = errors.New("refund not allowed")
+)
+
+// PaymentEvent là sự kiện webhook đã xác thực của một cổng thanh toán
+type PaymentEvent struct {
+	Provider   string
+	EventID    string
+	EventType  string // payment.Event*
+	ExternalID string // Mã giao dịch, ưu tiên dùng để tìm giao dịch
+	CaptureID  string // Mã capture, dùng khi sự kiện không kèm mã giao dịch (hoàn tiền)
+	RefundID   string // Mã hoàn tiền, dùng để bỏ qua các lần hoàn tiền do chính hệ thống tạo
+	Amount     money.Decimal
+}
+
+type PaymentRepository interface {
+	CreatePayment(models.Payment) (models.Payment, error)
+	GetPayment(paymentId uint) (models.Payment, error)
+	GetOrderPayments(orderId uint) ([]models.Payment, error)
+	GetRefundOrder(orderId uint) (models.Order, error)
+	GetRefunds(orderId uint) ([]models.Refund, error)
+	CapturePayment(paymentId uint, event PaymentEvent) (models.Payment, error)
+	ReserveRefund(refund models.Refund) (models.Refund, error)
+	CompleteRefund(refundId uint, externalId string, restock bool) (models.Refund, error)
+	FailRefund(refundId uint, reason string) error
+	ApplyPaymentEvent(PaymentEvent) (models.Payment, bool, error)
+}
+
+type PaymentRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewPaymentRepositoryImpl(db *gorm.DB) PaymentRepository {
+	return &PaymentRepositoryImpl{
+		DB: db,
+	}
+}
+
+// CreatePayment lưu giao dịch vừa tạo phía cổng thanh toán. Chỉ đơn đang chờ xác nhận và chưa thanh toán
+// mới tạo được giao dịch. Đơn thanh toán khi nhận hàng được xác nhận ngay.
+func (r *PaymentRepositoryImpl) CreatePayment(payment models.Payment) (models.Payment, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, payment.OrderID)
+		if err != nil {
+			return err
+		}
+		if order.Status != constant.Pending {
+			return fmt.Errorf("%w: order is %s", ErrPaymentNotAllowed, order.Status)
+		}
+		if order.PaymentStatus == constant.PaymentPaid {
+			return fmt.Errorf("%w: order is already paid", ErrPaymentNotAllowed)
+		}
+		if payment.Provider == constant.PaymentProviderCashOnDelivery && digitalOnly(order) {
+			return fmt.Errorf("%w: ebooks cannot be paid on delivery", ErrPaymentNotAllowed)
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&order).Update("payment_status", constant.PaymentPending).Error; err != nil {
+			return err
+		}
+		if payment.Provider == constant.PaymentProviderCashOnDelivery {
+			return transitionOrder(tx, &order, constant.Confirmed, OrderActor{Role: constant.OrderActorSystem}, "cash on delivery")
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Payment{}, err
+	}
+	return payment, nil
+}
+
+func (r *PaymentRepositoryImpl) GetPayment(paymentId uint) (models.Payment, error) {
+	var payment models.Payment
+	if err := r.DB.First(&payment, paymentId).Error; err != nil {
+		return models.Payment{}, err
+	}
+	return payment, nil
+}
+
+func (r *PaymentRepositoryImpl) GetOrderPayments(orderId uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	if err := r.DB.Where("order_id = ?", orderId).Order("id").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// GetRefundOrder lấy đơn hàng kèm chi tiết, khoản giảm giá và giao dịch để tính tiền hoàn
+func (r *PaymentRepositoryImpl) GetRefundOrder(orderId uint) (models.Order, error) {
+	var order models.Order
+	err := r.DB.Preload("OrderDetail").Preload("Discounts").Preload("Payments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&order, orderId).Error
+	if err != nil {
+		return models.Order{}, err
+	}
+	return order, nil
+}
+
+func (r *PaymentRepositoryImpl) GetRefunds(orderId uint) ([]models.Refund, error) {
+	var refunds []models.Refund
+	if err := r.DB.Preload("Lines").Where("order_id = ?", orderId).Order("id").Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+// lockPayment khóa giao dịch (SELECT ... FOR UPDATE)
+func lockPayment(tx *gorm.DB, paymentId uint) (models.Payment, error) {
+	var payment models.Payment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentId).Error
+	return payment, err
+}
+
+// CapturePayment ghi nhận kết quả thu tiền (event là payment.EventCaptured hoặc EventDenied).
+// Webhook của cùng lần thu tiền có thể đến trước, khi đó giao dịch đã được cập nhật và không thay đổi gì.
+func (r *PaymentRepositoryImpl) CapturePayment(paymentId uint, event PaymentEvent) (models.Payment, error) {
+	var attempt models.Payment
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		attempt, err = lockPayment(tx, paymentId)
+		if err != nil {
+			return err
+		}
+		return applyPaymentEvent(tx, &attempt, event)
+	})
+	if err != nil {
+		return models.Payment{}, err
+	}
+	return attempt, nil
+}
+
+// ReserveRefund giữ chỗ cho lần hoàn tiền trước khi gọi cổng thanh toán: trong transaction khóa đơn hàng và
+// giao dịch, kiểm tra số lượng và số tiền còn có thể hoàn (trừ cả các lần hoàn tiền đang chờ) rồi lưu refund
+// ở trạng thái pending. Hai lần hoàn tiền đồng thời vì vậy không thể cùng tới cổng thanh toán khi tổng vượt quá
+// số tiền đã thu. Lần hoàn tiền pending được CompleteRefund hoặc FailRefund kết thúc.
+func (r *PaymentRepositoryImpl) ReserveRefund(refund models.Refund) (models.Refund, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, refund.OrderID)
+		if err != nil {
+			return err
+		}
+		attempt, err := lockPayment(tx, refund.PaymentID)
+		if err != nil {
+			return err
+		}
+		if attempt.OrderID != order.ID {
+			return fmt.Errorf("%w: payment does not belong to order", ErrRefundNotAllowed)
+		}
+
+		var pending []models.Refund
+		err = tx.Preload("Lines").Where("order_id = ? AND status = ?", order.ID, constant.RefundPending).Find(&pending).Error
+		if err != nil {
+			return err
+		}
+		reservedAmount := money.Zero
+		reservedQuantities := make(map[uint]int)
+		for _, other := range pending {
+			if other.PaymentID == attempt.ID {
+				if reservedAmount, err = reservedAmount.Add(other.Amount); err != nil {
+					return err
+				}
+			}
+			for _, line := range other.Lines {
+				reservedQuantities[line.OrderDetailID] += line.Quantity
+			}
+		}
+
+		details := make(map[uint]models.OrderDetail, len(order.OrderDetail))
+		for _, detail := range order.OrderDetail {
+			details[detail.ID] = detail
+		}
+		for _, line := range refund.Lines {
+			detail, ok := details[line.OrderDetailID]
+			if !ok || line.Quantity <= 0 || detail.RefundedQuantity+reservedQuantities[line.OrderDetailID]+line.Quantity > detail.Quantity {
+				return fmt.Errorf("%w: invalid quantity for order line %d", ErrRefundNotAllowed, line.OrderDetailID)
+			}
+			reservedQuantities[line.OrderDetailID] += line.Quantity
+		}
+		committed, err := attempt.RefundedAmount.Add(reservedAmount)
+		if err != nil {
+			return err
+		}
+		if committed, err = committed.Add(refund.Amount); err != nil || committed.GreaterThan(attempt.CapturedAmount) {
+			return fmt.Errorf("%w: refund exceeds captured amount", ErrRefundNotAllowed)
+		}
+		refund.Status = constant.RefundPending
+		return tx.Create(&refund).Error
+	})
+	if err != nil {
+		return models.Refund{}, err
+	}
+	return refund, nil
+}
+
+// CompleteRefund ghi nhận lần hoàn tiền đang giữ chỗ sau khi cổng thanh toán đã hoàn tiền: cập nhật số lượng
+// đã hoàn của từng dòng, số tiền đã hoàn của giao dịch, nhập lại kho (restock, chỉ sách in), thu hồi sách điện
+// tử và chuyển đơn đã giao sang Returned khi mọi cuốn sách đều đã được hoàn tiền.
+func (r *PaymentRepositoryImpl) CompleteRefund(refundId uint, externalId string, restock bool) (models.Refund, error) {
+	var refund models.Refund
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Lines").First(&refund, refundId).Error; err != nil {
+			return err
+		}
+		order, err := lockOrder(tx, refund.OrderID)
+		if err != nil {
+			return err
+		}
+		attempt, err := lockPayment(tx, refund.PaymentID)
+		if err != nil {
+			return err
+		}
+		// Đọc lại trạng thái sau khi đã giữ khóa đơn hàng
+		var status string
+		err = tx.Model(&models.Refund{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", refund.ID).Pluck("status", &status).Error
+		if err != nil {
+			return err
+		}
+		if status != constant.RefundPending {
+			return fmt.Errorf("%w: refund %d is %s", ErrRefundNotAllowed, refund.ID, status)
+		}
+
+		details := make(map[uint]*models.OrderDetail, len(order.OrderDetail))
+		for i := range order.OrderDetail {
+			details[order.OrderDetail[i].ID] = &order.OrderDetail[i]
+		}
+		quantities := make(map[uint]int)
+		for _, line := range refund.Lines {
+			detail, ok := details[line.OrderDetailID]
+			if !ok || detail.RefundedQuantity+line.Quantity > detail.Quantity {
+				return fmt.Errorf("%w: invalid quantity for order line %d", ErrRefundNotAllowed, line.OrderDetailID)
+			}
+			detail.RefundedQuantity += line.Quantity
+			if detail.Type != constant.OrderLineDigital {
+				quantities[detail.BookID] += line.Quantity
+			}
+			if err := tx.Model(detail).Update("refunded_quantity", detail.RefundedQuantity).Error; err != nil {
+				return err
+			}
+		}
+
+		// Webhook hoàn tiền có thể đến trước: khi đó số tiền đã được cộng vào giao dịch và webhook đã tạo một
+		// refund riêng, refund đó được gộp vào lần hoàn tiền này
+		merged := false
+		if externalId != "" {
+			var existing models.Refund
+			result := tx.Where("payment_id = ? AND external_id = ? AND id <> ?", attempt.ID, externalId, refund.ID).Limit(1).Find(&existing)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				if err := tx.Unscoped().Delete(&existing).Error; err != nil {
+					return err
+				}
+				merged = true
+			}
+		}
+		if !merged {
+			refunded, err := attempt.RefundedAmount.Add(refund.Amount)
+			if err != nil || refunded.GreaterThan(attempt.CapturedAmount) {
+				return fmt.Errorf("%w: refund exceeds captured amount", ErrRefundNotAllowed)
+			}
+			refundPayment(&attempt, refunded)
+			if err := tx.Save(&attempt).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&order).Update("payment_status", attempt.Status).Error; err != nil {
+				return err
+			}
+		}
+		refund.Status = constant.RefundCompleted
+		refund.ExternalID = externalId
+		err = tx.Model(&refund).Updates(map[string]interface{}{
+			"status":      refund.Status,
+			"external_id": refund.ExternalID,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if restock && len(quantities) > 0 {
+			if err := restockOrderItems(tx, order.ID, quantities, refund.CreatedBy); err != nil {
+				return err
+			}
+		}
+		if order.Status == constant.Delivered && allRefunded(order.OrderDetail) {
+			actor := OrderActor{UserID: refund.CreatedBy, Role: constant.OrderActorStaff}
+			if err := transitionOrder(tx, &order, constant.Returned, actor, refund.Reason); err != nil {
+				return err
+			}
+		}
+		// Sách điện tử đã hoàn tiền bị thu hồi khỏi thư viện
+		return syncOrderEntitlements(tx, order.ID)
+	})
+	if err != nil {
+		return models.Refund{}, err
+	}
+	return refund, nil
+}
+
+// FailRefund kết thúc lần hoàn tiền đang giữ chỗ khi cổng thanh toán không hoàn tiền, phần giữ chỗ được trả lại
+func (r *PaymentRepositoryImpl) FailRefund(refundId uint, reason string) error {
+	return r.DB.Model(&models.Refund{}).Where("id = ? AND status = ?", refundId, constant.RefundPending).
+		Updates(map[string]interface{}{
+			"status":      constant.RefundFailed,
+			"fail_reason": reason,
+		}).Error
+}
+
+func allRefunded(details []models.OrderDetail) bool {
+	for _, detail := range details {
+		if detail.RefundedQuantity < detail.Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyPaymentEvent ghi nhận sự kiện và cập nhật giao dịch, đơn hàng trong cùng một transaction.
+// Sự kiện đã xử lý trước đó trả về processed = false và không thay đổi gì.
+// Sự kiện không khớp giao dịch nào vẫn được ghi nhận, khi đó payment.ID = 0.
+func (r *PaymentRepositoryImpl) ApplyPaymentEvent(event PaymentEvent) (models.Payment, bool, error) {
+	var attempt models.Payment
+	processed := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		record := models.PaymentWebhookEvent{
+			Provider:  event.Provider,
+			EventID:   event.EventID,
+			EventType: event.EventType,
+		}
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "provider"}, {Name: "event_id"}},
+			DoNothing: true,
+		}).Create(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		processed = true
+
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("provider = ?", event.Provider)
+		switch {
+		case event.ExternalID != "":
+			query = query.Where("external_id = ?", event.ExternalID)
+		case event.CaptureID != "":
+			query = query.Where("capture_id = ?", event.CaptureID)
+		default:
+			return nil
+		}
+		err := query.First(&attempt).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := applyPaymentEvent(tx, &attempt, event); err != nil {
+			return err
+		}
+		return tx.Model(&record).Update("payment_id", attempt.ID).Error
+	})
+	if err != nil {
+		return models.Payment{}, false, err
+	}
+	return attempt, processed, nil
+}
+
+// applyPaymentEvent cập nhật giao dịch đã khóa và đơn hàng của nó theo sự kiện
+func applyPaymentEvent(tx *gorm.DB, attempt *models.Payment, event PaymentEvent) error {
+	if event.EventType == payment.EventRefunded && event.RefundID != "" {
+		// Hoàn tiền do chính hệ thống tạo đã được ghi nhận bởi CompleteRefund
+		var count int64
+		err := tx.Model(&models.Refund{}).Where("payment_id = ? AND external_id = ?", attempt.ID, event.RefundID).Count(&count).Error
+		if err != nil || count > 0 {
+			return err
+		}
+	}
+	order, err := lockOrder(tx, attempt.OrderID)
+	if err != nil {
+		return err
+	}
+	refundedBefore := attempt.RefundedAmount
+	to, reason, ok, err := ApplyPaymentEventToPayment(attempt, event)
+	if err != nil || !ok {
+		return err
+	}
+	if err := tx.Save(attempt).Error; err != nil {
+		return err
+	}
+	if event.EventType == payment.EventRefunded {
+		amount, err := attempt.RefundedAmount.Sub(refundedBefore)
+		if err != nil {
+			return err
+		}
+		err = tx.Create(&models.Refund{
+			OrderID:    order.ID,
+			PaymentID:  attempt.ID,
+			ExternalID: event.RefundID,
+			Amount:     amount,
+			Reason:     "refunded via " + attempt.Provider,
+			Status:     constant.RefundCompleted,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&order).Update("payment_status", attempt.Status).Error; err != nil {
+		return err
+	}
+	order.PaymentStatus = attempt.Status
+	if to != nil {
+		err = transitionOrder(tx, &order, *to, OrderActor{Role: constant.OrderActorSystem}, reason)
+		// Đơn đã đi quá trạng thái có thể tự động chuyển (ví dụ hoàn tiền khi đang giao) thì giữ nguyên
+		if err != nil && !errors.Is(err, ErrInvalidTransition) && !errors.Is(err, ErrTransitionNotAllowed) {
+			return err
+		}
+	}
+	// Đơn chỉ có sách điện tử không cần giao: thanh toán xong là hoàn tất
+	if order.Status == constant.Confirmed && order.PaymentStatus == constant.PaymentPaid && digitalOnly(order) {
+		err := transitionOrder(tx, &order, constant.Delivered, OrderActor{Role: constant.OrderActorSystem}, "ebook delivered")
+		if err != nil {
+			return err
+		}
+	}
+	return syncOrderEntitlements(tx, order.ID)
+}
+
+// ApplyPaymentEventToPayment cập nhật giao dịch theo sự kiện và trả về trạng thái đơn hàng cần chuyển tới
+// (nil: giữ nguyên): thu tiền thành công xác nhận đơn, bị từ chối hoặc hoàn toàn bộ tiền thì hủy đơn.
+// ok = false khi sự kiện không làm thay đổi giao dịch. Số tiền hoàn vượt số tiền còn lại được tính bằng số còn lại.
+func ApplyPaymentEventToPayment(attempt *models.Payment, event PaymentEvent) (*constant.OrderStatus, string, bool, error) {
+	switch event.EventType {
+	case payment.EventCaptured:
+		// Lần thu tiền đã được ghi nhận (qua API capture hoặc webhook trước đó)
+		if attempt.Status != constant.PaymentPending {
+			return nil, "", false, nil
+		}
+		attempt.Status = constant.PaymentPaid
+		if event.CaptureID != "" {
+			attempt.CaptureID = event.CaptureID
+		}
+		attempt.CapturedAmount = attempt.Amount
+		if event.Amount.IsPositive() {
+			attempt.CapturedAmount = event.Amount
+		}
+		to := constant.Confirmed
+		return &to, "payment completed", true, nil
+	case payment.EventDenied:
+		if attempt.Status != constant.PaymentPending {
+			return nil, "", false, nil
+		}
+		attempt.Status = constant.PaymentDenied
+		to := constant.Cancelled
+		return &to, "payment denied", true, nil
+	case payment.EventRefunded:
+		remaining, err := attempt.CapturedAmount.Sub(attempt.RefundedAmount)
+		if err != nil {
+			return nil, "", false, err
+		}
+		amount := remaining
+		if event.Amount.IsPositive() {
+			amount = money.Min(event.Amount, remaining)
+		}
+		refunded, err := attempt.RefundedAmount.Add(amount)
+		if err != nil {
+			return nil, "", false, err
+		}
+		refundPayment(attempt, refunded)
+		if attempt.Status != constant.PaymentRefunded {
+			return nil, "", true, nil
+		}
+		to := constant.Cancelled
+		return &to, "payment refunded", true, nil
+	}
+	return nil, "", false, nil
+}
+
+// refundPayment ghi tổng số tiền đã hoàn của giao dịch và cập nhật trạng thái hoàn một phần/toàn bộ
+func refundPayment(attempt *models.Payment, refunded money.Decimal) {
+	attempt.RefundedAmount = refunded
+	if !attempt.RefundedAmount.LessThan(attempt.CapturedAmount) {
+		attempt.Status = constant.PaymentRefunded
+	} else {
+		attempt.Status = constant.PaymentPartiallyRefunded
+	}
+}
+
+// settleOrderPayments cập nhật các giao dịch khi đơn chuyển trạng thái: giao hàng thành công thì
+// thu tiền các giao dịch thanh toán khi nhận hàng, hủy/thất bại thì hủy các giao dịch chưa thanh toán.
+func settleOrderPayments(tx *gorm.DB, order models.Order, to constant.OrderStatus) error {
+	switch to {
+	case constant.Delivered:
+		result := tx.Model(&models.Payment{}).
+			Where("order_id = ? AND provider = ? AND status = ?", order.ID, constant.PaymentProviderCashOnDelivery, constant.PaymentPending).
+			Updates(map[string]interface{}{
+				"status":          constant.PaymentPaid,
+				"captured_amount": gorm.Expr("amount"),
+				"capture_id":      gorm.Expr("external_id"),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Order{}).Where("id = ?", order.ID).Update("payment_status", constant.PaymentPaid).Error
+	case constant.Cancelled, constant.Failed:
+		err := tx.Model(&models.Payment{}).
+			Where("order_id = ? AND status = ?", order.ID, constant.PaymentPending).
+			Update("status", constant.PaymentCancelled).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Order{}).
+			Where("id = ? AND payment_status = ?", order.ID, constant.PaymentPending).
+			Update("payment_status", constant.PaymentCancelled).Error
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"bookstack/internal/money"
+	"bookstack/internal/payment"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPaymentEventToPayment(t *testing.T) {
+	attempt := models.Payment{Amount: money.NewFromInt(30), Status: constant.PaymentPending}
+
+	to, _, ok, err := ApplyPaymentEventToPayment(&attempt, PaymentEvent{EventType: payment.EventCaptured, CaptureID: "CAP-1"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NotNil(t, to)
+	assert.Equal(t, constant.Confirmed, *to)
+	assert.Equal(t, constant.PaymentPaid, attempt.Status)
+	assert.Equal(t, "CAP-1", attempt.CaptureID)
+	assert.Equal(t, money.NewFromInt(30), attempt.CapturedAmount, "capture without amount takes the payment amount")
+
+	_, _, ok, err = ApplyPaymentEventToPayment(&attempt, PaymentEvent{EventType: payment.EventCaptured, CaptureID: "CAP-1"})
+	require.NoError(t, err)
+	assert.False(t, ok, "a capture already recorded is not applied twice")
+
+	to, _, ok, err = ApplyPaymentEventToPayment(&attempt, PaymentEvent{EventType: payment.EventRefunded, Amount: money.NewFromInt(10)})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, to, "a partial refund keeps the order status")
+	assert.Equal(t, constant.PaymentPartiallyRefunded, attempt.Status)
+	assert.Equal(t, money.NewFromInt(10), attempt.RefundedAmount)
+
+	to, _, ok, err = ApplyPaymentEventToPayment(&attempt, PaymentEvent{EventType: payment.EventRefunded})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NotNil(t, to)
+	assert.Equal(t, constant.Cancelled, *to)
+	assert.Equal(t, constant.PaymentRefunded, attempt.Status)
+	assert.Equal(t, money.NewFromInt(30), attempt.RefundedAmount, "refund without amount refunds the remainder")
+
+	denied := models.Payment{Amount: money.NewFromInt(30), Status: constant.PaymentPending}
+	to, _, ok, err = ApplyPaymentEventToPayment(&denied, PaymentEvent{EventType: payment.EventDenied})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NotNil(t, to)
+	assert.Equal(t, constant.Cancelled, *to)
+	assert.Equal(t, constant.PaymentDenied, denied.Status)
+
+	_, _, ok, err = ApplyPaymentEventToPayment(&denied, PaymentEvent{EventType: "unknown"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	captured := models.Payment{Amount: money.NewFromInt(30), CapturedAmount: money.NewFromInt(30), Status: constant.PaymentPaid}
+	_, _, ok, err = ApplyPaymentEventToPayment(&captured, PaymentEvent{EventType: payment.EventRefunded, Amount: money.MustParse("90000000000")})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, money.NewFromInt(30), captured.RefundedAmount, "a refund larger than the remainder refunds the remainder")
+}
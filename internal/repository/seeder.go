@@ -18,6 +18,15 @@ func SeedRolesAndPermissions() {
 		{Name: constant.DeleteUser},
 		{Name: constant.ReceiveOrder},
 		{Name: constant.UpdateOrderStatus},
+		{Name: constant.ManageEntityPermissions},
+		{Name: constant.ModerateComments},
+		{Name: constant.ManageInventory},
+		{Name: constant.ManageOrders},
+		{Name: constant.ManageCoupons},
+		{Name: constant.ManagePayments},
+		{Name: constant.ManageCurrencies},
+		{Name: constant.ManageMessaging},
+		{Name: constant.ManageWebhooks},
 	}
 
 	// Tạo permissions
@@ -51,12 +60,28 @@ func SeedRolesAndPermissions() {
 		constant.ReadUser,
 		constant.WriteUser,
 		constant.DeleteUser,
+		constant.ManageEntityPermissions,
+		constant.ModerateComments,
+		constant.ManageInventory,
+		constant.ManageOrders,
+		constant.ManageCoupons,
+		constant.ManagePayments,
+		constant.ManageCurrencies,
+		constant.ManageMessaging,
+		constant.ManageWebhooks,
 	}).Find(&adminPermissions)
 
+	// Lấy permissions cho editor
+	var editorPermissions []models.Permission
+	db.Where("name IN ?", []string{
+		constant.ModerateComments,
+	}).Find(&editorPermissions)
+
 	// Lấy permissions cho shipper
 	var shipperPermissions []models.Permission
 	db.Where("name IN ?", []string{
 		constant.ReceiveOrder,
+		constant.UpdateOrderStatus,
 	}).Find(&shipperPermissions)
 
 	// Assign permissions to admin role
@@ -68,6 +93,13 @@ func SeedRolesAndPermissions() {
 		db.Model(&adminRole).Association("Permissions").Append(adminPermissions)
 	}
 
+	// Assign permissions to editor role
+	var editorRole models.Role
+	if err := db.First(&editorRole, "name = ?", "editor").Error; err == nil {
+		db.Model(&editorRole).Association("Permissions").Clear()
+		db.Model(&editorRole).Association("Permissions").Append(editorPermissions)
+	}
+
 	// Assign permissions to shipper role
 	var shipperRole models.Role
 	if err := db.First(&shipperRole, "name = ?", "shipper").Error; err == nil {
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"bookstack/internal/constant"
+	"bookstack/internal/geo"
 	"bookstack/internal/models"
+	"sort"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -13,13 +16,18 @@ type ShipperRepository interface {
 	// Quản lý đơn hàng của shipper
 	AssignOrderToShipper(orderID uint, shipperID uint) error
 	GetOrderInRange(string) ([]models.Order, error)
+	GetOrdersNear(center geo.Point, radiusKm float64) ([]models.Order, error)
 	GetOrdersByShipper(shipperID uint) ([]models.Order, error)
-	UpdateOrderStatus(orderID uint, status constant.OrderStatus) error
+	UpdateOrderStatus(orderID uint, status constant.OrderStatus, actor OrderActor, reason string) (models.Order, error)
 	GetPendingOrders() ([]models.Order, error)
 	ReceiveOrder(orderId int, userId int) error
 	GetReceivedOrders(userId int) ([]models.Order, error)
+	SetDeliveryETA(orderId uint, shipperId uint, eta time.Time) (models.Order, error)
 }
 
+// etaOrderStatuses là các trạng thái shipper còn cập nhật được thời gian dự kiến giao tới
+var etaOrderStatuses = []constant.OrderStatus{constant.Confirmed, constant.Processing, constant.Shipped}
+
 type shipperRepository struct {
 	db *gorm.DB
 }
@@ -37,7 +45,28 @@ func (r *shipperRepository) GetReceivedOrders(userId int) ([]models.Order, error
 }
 
 func (r *shipperRepository) ReceiveOrder(orderId int, userId int) error {
-	return r.db.Model(&models.Order{}).Where("id = ?", orderId).Update("shipper_id", userId).Error
+	return r.db.Model(&models.Order{}).Where("id = ?", orderId).Updates(map[string]interface{}{
+		"shipper_id":     userId,
+		"dispatch_state": constant.DispatchAssigned,
+	}).Error
+}
+
+// SetDeliveryETA lưu thời gian dự kiến giao tới của đơn đang được shipper giao
+func (r *shipperRepository) SetDeliveryETA(orderId uint, shipperId uint, eta time.Time) (models.Order, error) {
+	result := r.db.Model(&models.Order{}).
+		Where("id = ? AND shipper_id = ? AND status IN ?", orderId, shipperId, etaOrderStatuses).
+		Update("delivery_eta", eta)
+	if result.Error != nil {
+		return models.Order{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.Order{}, gorm.ErrRecordNotFound
+	}
+	var order models.Order
+	if err := r.db.First(&order, orderId).Error; err != nil {
+		return models.Order{}, err
+	}
+	return order, nil
 }
 
 func (r *shipperRepository) GetOrderInRange(place string) ([]models.Order, error) {
@@ -49,6 +78,35 @@ func (r *shipperRepository) GetOrderInRange(place string) ([]models.Order, error
 	return orders, nil
 }
 
+// GetOrdersNear lấy các đơn có tọa độ giao trong bán kính radiusKm quanh center, gần nhất đứng trước.
+// Database chỉ lọc theo khung bao, khoảng cách chính xác được tính lại ở đây.
+func (r *shipperRepository) GetOrdersNear(center geo.Point, radiusKm float64) ([]models.Order, error) {
+	southWest, northEast := geo.BoundingBox(center, radiusKm)
+	var candidates []models.Order
+	err := r.db.Where("shipping_lat BETWEEN ? AND ? AND shipping_lng BETWEEN ? AND ?",
+		southWest.Lat, northEast.Lat, southWest.Lng, northEast.Lng).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+	distances := make(map[uint]float64, len(candidates))
+	var orders []models.Order
+	for _, order := range candidates {
+		point := order.Location.Point()
+		if point == nil {
+			continue
+		}
+		if distance := geo.Distance(center, *point); distance <= radiusKm {
+			distances[order.ID] = distance
+			orders = append(orders, order)
+		}
+	}
+	sort.SliceStable(orders, func(i, j int) bool {
+		return distances[orders[i].ID] < distances[orders[j].ID]
+	})
+	return orders, nil
+}
+
 func (r *shipperRepository) GetAllShipper(roleName string) ([]models.User, error) {
 	var shippers []models.User
 	err := r.db.
@@ -74,8 +132,8 @@ func (r *shipperRepository) GetOrdersByShipper(shipperID uint) ([]models.Order,
 	return orders, nil
 }
 
-func (r *shipperRepository) UpdateOrderStatus(orderID uint, status constant.OrderStatus) error {
-	return r.db.Model(&models.Order{}).Where("id = ?", orderID).Update("status", status).Error
+func (r *shipperRepository) UpdateOrderStatus(orderID uint, status constant.OrderStatus, actor OrderActor, reason string) (models.Order, error) {
+	return updateOrderStatus(r.db, orderID, status, actor, reason)
 }
 
 func (r *shipperRepository) GetPendingOrders() ([]models.Order, error) {
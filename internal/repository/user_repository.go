@@ -7,9 +7,11 @@ import (
 	"bookstack/utils"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jinzhu/copier"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
@@ -27,6 +29,11 @@ type UserRepository interface {
 	DeleteToken(token string) error
 	DeleteUserToken(userId int) error
 	GetUserEmail(userId int) (string, error)
+	ConfirmEmail(userId int, email string, at time.Time) (bool, error)
+	MarkVerificationSent(userId int, now time.Time, interval time.Duration) (bool, error)
+	CreatePasswordResetToken(token models.PasswordResetToken, interval time.Duration) (bool, error)
+	ResetPassword(tokenHash string, passwordHash string, now time.Time) (int, error)
+	ChangePassword(userId int, passwordHash string) error
 }
 
 type UserRepositoryImpl struct {
@@ -162,18 +169,16 @@ func (r *UserRepositoryImpl) UpdateUser(id int, updateRequest request.UserUpdate
 	}
 
 	// Copy nhưng bỏ qua giá trị rỗng
+	previousEmail := user.Email
 	err = copier.CopyWithOption(&user, &updateRequest, copier.Option{IgnoreEmpty: true})
 	if err != nil {
 		return models.User{}, err
 	}
-	if updateRequest.Password != "" {
-		password, err := utils.Hashpassword(updateRequest.Password)
-		if err != nil {
-			return models.User{}, err
-		}
-		user.Password = password
+	// Email mới phải được xác nhận lại
+	if user.Email != previousEmail {
+		user.EmailConfirmed = false
+		user.EmailConfirmedAt = nil
 	}
-
 	// Cập nhật dữ liệu
 	err = r.db.Save(&user).Error
 	if err != nil {
@@ -233,3 +238,93 @@ func (u *UserRepositoryImpl) FindIfUserHasRole(userID uint, roles []models.Role)
 
 	return nil // ✅ User có ít nhất một Role phù hợp
 }
+
+// ConfirmEmail đánh dấu email đã xác nhận nếu người dùng vẫn dùng email đó, trả về false khi email đã đổi
+func (r *UserRepositoryImpl) ConfirmEmail(userId int, email string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.User{}).Where("id = ? AND email = ?", userId, email).Updates(map[string]interface{}{
+		"email_confirmed":    true,
+		"email_confirmed_at": at,
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkVerificationSent ghi nhận lần gửi email xác nhận nếu lần gửi trước đã cách ít nhất interval.
+// Kiểm tra và ghi trong cùng một câu lệnh nên các request đồng thời chỉ có một request được gửi.
+func (r *UserRepositoryImpl) MarkVerificationSent(userId int, now time.Time, interval time.Duration) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND (verification_sent_at IS NULL OR verification_sent_at <= ?)", userId, now.Add(-interval)).
+		Update("verification_sent_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreatePasswordResetToken lưu token đặt lại mật khẩu mới và hủy các token chưa dùng trước đó của người dùng.
+// Trả về false (không lưu) khi token gần nhất được tạo chưa quá interval.
+func (r *UserRepositoryImpl) CreatePasswordResetToken(token models.PasswordResetToken, interval time.Duration) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Khóa dòng user để các request đồng thời của cùng người dùng chạy lần lượt
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, token.UserID).Error; err != nil {
+			return err
+		}
+		var recent int64
+		err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND created_at > ?", token.UserID, token.CreatedAt.Add(-interval)).
+			Count(&recent).Error
+		if err != nil || recent > 0 {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND used_at IS NULL", token.UserID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&token).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+// ResetPassword dùng token đặt lại mật khẩu còn hạn để đổi mật khẩu, đánh dấu token đã dùng và thu hồi
+// refresh token của người dùng trong cùng transaction. Trả về ID người dùng, gorm.ErrRecordNotFound khi token
+// không tồn tại, đã dùng hoặc đã hết hạn.
+func (r *UserRepositoryImpl) ResetPassword(tokenHash string, passwordHash string, now time.Time) (int, error) {
+	var token models.PasswordResetToken
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+			First(&token).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&token).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return updatePassword(tx, token.UserID, passwordHash)
+	})
+	return token.UserID, err
+}
+
+// ChangePassword đổi mật khẩu và thu hồi refresh token của người dùng
+func (r *UserRepositoryImpl) ChangePassword(userId int, passwordHash string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return updatePassword(tx, userId, passwordHash)
+	})
+}
+
+// updatePassword lưu mật khẩu mới, xóa refresh token để mọi phiên đăng nhập phải đăng nhập lại và hủy các
+// token đặt lại mật khẩu chưa dùng
+func updatePassword(tx *gorm.DB, userId int, passwordHash string) error {
+	result := tx.Model(&models.User{}).Where("id = ?", userId).Update("password", passwordHash)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	if err := tx.Where("user_id = ?", userId).Delete(&models.RefreshToken{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ? AND used_at IS NULL", userId).Delete(&models.PasswordResetToken{}).Error
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestResetPasswordConsumesTokenAndRevokesSessions(t *testing.T) {
+	db, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewUserRepositoryImpl(db, nil)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	// Chỉ token chưa dùng và còn hạn mới được chọn
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "password_reset_tokens" WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2`)+`.*FOR UPDATE`).
+		WithArgs("hash", now, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at"}).AddRow(3, 7, "hash", now.Add(time.Hour)))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "password_reset_tokens" SET "used_at"=$1 WHERE "id" = $2`)).
+		WithArgs(now, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "password"=$1`)).
+		WithArgs("new-hash", sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "refresh_tokens" WHERE user_id = $1`)).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "password_reset_tokens" WHERE user_id = $1 AND used_at IS NULL`)).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	userId, err := repo.ResetPassword("hash", "new-hash", now)
+	require.NoError(t, err)
+	assert.Equal(t, 7, userId)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResetPasswordRejectsUsedOrExpiredToken(t *testing.T) {
+	db, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewUserRepositoryImpl(db, nil)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "password_reset_tokens" WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2`)).
+		WithArgs("hash", now, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at"}))
+	mock.ExpectRollback()
+
+	_, err := repo.ResetPassword("hash", "new-hash", now)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	// Không đổi mật khẩu khi token không hợp lệ
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangePasswordRevokesSessions(t *testing.T) {
+	db, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewUserRepositoryImpl(db, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "password"=$1`)).
+		WithArgs("new-hash", sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "refresh_tokens" WHERE user_id = $1`)).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "password_reset_tokens" WHERE user_id = $1 AND used_at IS NULL`)).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.ChangePassword(7, "new-hash"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
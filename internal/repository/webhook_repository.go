@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeliverFunc gửi một lần giao tới subscription và ghi kết quả vào delivery
+type DeliverFunc func(subscription models.WebhookSubscription, delivery *models.WebhookDelivery)
+
+type WebhookRepository interface {
+	CreateSubscription(models.WebhookSubscription) (models.WebhookSubscription, error)
+	UpdateSubscription(models.WebhookSubscription) (models.WebhookSubscription, error)
+	DeleteSubscription(uint) error
+	GetSubscription(uint) (models.WebhookSubscription, error)
+	GetSubscriptions() ([]models.WebhookSubscription, error)
+	EnqueueDeliveries(eventId string, eventType string, payload string, now time.Time) (int, error)
+	CreateDelivery(models.WebhookDelivery) (models.WebhookDelivery, error)
+	GetDelivery(uint) (models.WebhookDelivery, error)
+	GetDeliveries(subscriptionId uint, limit int) ([]models.WebhookDelivery, error)
+	Deliver(deliveryId uint, send DeliverFunc) (models.WebhookDelivery, error)
+	DeliverDue(limit int, now time.Time, send DeliverFunc) (int, error)
+	Redeliver(deliveryId uint, now time.Time) error
+	Purge(before time.Time) error
+}
+
+type WebhookRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewWebhookRepositoryImpl(db *gorm.DB) WebhookRepository {
+	return &WebhookRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (r *WebhookRepositoryImpl) CreateSubscription(subscription models.WebhookSubscription) (models.WebhookSubscription, error) {
+	if err := r.DB.Create(&subscription).Error; err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	return subscription, nil
+}
+
+func (r *WebhookRepositoryImpl) UpdateSubscription(subscription models.WebhookSubscription) (models.WebhookSubscription, error) {
+	if err := r.DB.Save(&subscription).Error; err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	return subscription, nil
+}
+
+// DeleteSubscription xóa subscription cùng các lần giao chưa gửi xong, nhật ký các lần giao đã xong được giữ lại
+func (r *WebhookRepositoryImpl) DeleteSubscription(subscriptionId uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("subscription_id = ? AND status = ?", subscriptionId, constant.WebhookDeliveryPending).
+			Delete(&models.WebhookDelivery{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&models.WebhookSubscription{}, subscriptionId).Error
+	})
+}
+
+func (r *WebhookRepositoryImpl) GetSubscription(subscriptionId uint) (models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := r.DB.First(&subscription, subscriptionId).Error; err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	return subscription, nil
+}
+
+func (r *WebhookRepositoryImpl) GetSubscriptions() ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := r.DB.Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// EnqueueDeliveries tạo lần giao cho mọi subscription đang bật có đăng ký loại sự kiện. Sự kiện bị bus giao lại
+// không tạo thêm lần giao (unique theo subscription và ID sự kiện). Trả về số lần giao mới.
+func (r *WebhookRepositoryImpl) EnqueueDeliveries(eventId string, eventType string, payload string, now time.Time) (int, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := r.DB.Where("active").Find(&subscriptions).Error; err != nil {
+		return 0, err
+	}
+	var deliveries []models.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(eventType) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        eventId,
+			EventType:      eventType,
+			Payload:        payload,
+			Status:         constant.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+	result := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries)
+	return int(result.RowsAffected), result.Error
+}
+
+func (r *WebhookRepositoryImpl) CreateDelivery(delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	if err := r.DB.Create(&delivery).Error; err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return delivery, nil
+}
+
+func (r *WebhookRepositoryImpl) GetDelivery(deliveryId uint) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.DB.First(&delivery, deliveryId).Error; err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return delivery, nil
+}
+
+// GetDeliveries trả về nhật ký giao của subscription, mới nhất trước
+func (r *WebhookRepositoryImpl) GetDeliveries(subscriptionId uint, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.DB.Where("subscription_id = ?", subscriptionId).Order("id DESC").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Deliver gửi ngay một lần giao đang chờ, lần giao đã xong (do relay gửi trước) được trả về nguyên trạng
+func (r *WebhookRepositoryImpl) Deliver(deliveryId uint, send DeliverFunc) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, deliveryId).Error
+		if err != nil || delivery.Status != constant.WebhookDeliveryPending {
+			return err
+		}
+		return deliverWebhook(tx, &delivery, send)
+	})
+	return delivery, err
+}
+
+// DeliverDue gửi tối đa limit lần giao tới hạn của các subscription đang bật. Mỗi lần giao được khóa SKIP LOCKED
+// trong transaction riêng nên nhiều instance cùng chạy không gửi trùng, và endpoint chậm của một đối tác chỉ
+// giữ khóa một dòng. Trả về số lần đã gửi.
+func (r *WebhookRepositoryImpl) DeliverDue(limit int, now time.Time, send DeliverFunc) (int, error) {
+	sent := 0
+	for sent < limit {
+		found := false
+		err := r.DB.Transaction(func(tx *gorm.DB) error {
+			var delivery models.WebhookDelivery
+			result := tx.Clauses(clause.Locking{
+				Strength: "UPDATE",
+				Table:    clause.Table{Name: "webhook_deliveries"},
+				Options:  "SKIP LOCKED",
+			}).
+				Joins("JOIN webhook_subscriptions ON webhook_subscriptions.id = webhook_deliveries.subscription_id"+
+					" AND webhook_subscriptions.deleted_at IS NULL AND webhook_subscriptions.active").
+				Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", constant.WebhookDeliveryPending, now).
+				Order("webhook_deliveries.id").Limit(1).Find(&delivery)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			found = true
+			return deliverWebhook(tx, &delivery, send)
+		})
+		if err != nil || !found {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// Redeliver đưa lần giao về trạng thái chờ gửi với số lần thử được đặt lại
+func (r *WebhookRepositoryImpl) Redeliver(deliveryId uint, now time.Time) error {
+	return r.DB.Model(&models.WebhookDelivery{}).Where("id = ?", deliveryId).Updates(map[string]interface{}{
+		"status":          constant.WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+	}).Error
+}
+
+// Purge xóa nhật ký các lần giao đã xong trước thời điểm before
+func (r *WebhookRepositoryImpl) Purge(before time.Time) error {
+	return r.DB.Where("status <> ? AND updated_at < ?", constant.WebhookDeliveryPending, before).
+		Delete(&models.WebhookDelivery{}).Error
+}
+
+// deliverWebhook gửi lần giao đã được khóa trong tx rồi lưu kết quả
+func deliverWebhook(tx *gorm.DB, delivery *models.WebhookDelivery, send DeliverFunc) error {
+	var subscription models.WebhookSubscription
+	if err := tx.First(&subscription, delivery.SubscriptionID).Error; err != nil {
+		return err
+	}
+	send(subscription, delivery)
+	return tx.Save(delivery).Error
+}
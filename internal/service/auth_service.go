@@ -3,13 +3,20 @@ package service
 import (
 	"bookstack/config"
 	"bookstack/helper"
+	"bookstack/internal/constant"
 	"bookstack/internal/dto/request"
+	"bookstack/internal/events"
 	"bookstack/internal/models"
 	"bookstack/internal/repository"
 	"bookstack/utils"
+	"errors"
 	"fmt"
+	"net/url"
+	"time"
 
 	"github.com/jinzhu/copier"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 type AuthService interface {
@@ -18,17 +25,39 @@ type AuthService interface {
 	Logout(token string, userId int) error
 	SaveRefreshToken(string, int) error
 	RefreshToken(token string, signedKey string) (string, string, error)
+	SendVerificationEmail(userId int) error
+	VerifyEmail(token string) (models.User, error)
+	ForgotPassword(email string) error
+	ResetPassword(token string, newPassword string) error
+	ChangePassword(userId int, currentPassword string, newPassword string) error
 }
 
+// Lỗi xác nhận email được dùng lại ở tầng controller
+var (
+	ErrInvalidVerificationToken = utils.ErrInvalidVerificationToken
+	ErrVerificationTokenExpired = utils.ErrVerificationTokenExpired
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+	ErrVerificationRateLimited  = errors.New("verification email was sent too recently")
+)
+
+// Lỗi đặt lại và đổi mật khẩu được dùng lại ở tầng controller
+var (
+	ErrInvalidResetToken = errors.New("password reset token is invalid or expired")
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	ErrWeakPassword      = fmt.Errorf("password must be at least %d characters", constant.MinPasswordLength)
+)
+
 type AuthServiceImpl struct {
 	repo   repository.UserRepository
 	config *config.Config
+	bus    events.EventBus
 }
 
-func NewAuthServiceImpl(repo repository.UserRepository, conf *config.Config) AuthService {
+func NewAuthServiceImpl(repo repository.UserRepository, conf *config.Config, bus events.EventBus) AuthService {
 	return &AuthServiceImpl{
 		repo:   repo,
 		config: conf,
+		bus:    bus,
 	}
 }
 func (s *AuthServiceImpl) SaveRefreshToken(token string, userId int) error {
@@ -57,6 +86,7 @@ func (s *AuthServiceImpl) Register(user request.UserCreateRequest) (models.User,
 	if err != nil {
 		return models.User{}, err
 	}
+	publishEvents(s.bus, events.UserRegistered{UserID: uint(responseUser.ID), Email: responseUser.Email, FullName: responseUser.FullName})
 	return responseUser, nil
 }
 func (s *AuthServiceImpl) Login(email, password string) (string, string, int, error) {
@@ -133,3 +163,148 @@ func (a *AuthServiceImpl) RefreshToken(token string, signedKey string) (string,
 
 	return accessToken, newRefreshToken, nil
 }
+
+// SendVerificationEmail gửi link xác nhận email có token ký và hết hạn. Mỗi người dùng chỉ được gửi một lần
+// trong constant.EmailVerificationResendInterval.
+func (s *AuthServiceImpl) SendVerificationEmail(userId int) error {
+	user, err := s.repo.GetUserById(userId)
+	if err != nil {
+		return err
+	}
+	if user.EmailConfirmed {
+		return ErrEmailAlreadyVerified
+	}
+	now := time.Now()
+	marked, err := s.repo.MarkVerificationSent(userId, now, constant.EmailVerificationResendInterval)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		return fmt.Errorf("%w, try again in %s", ErrVerificationRateLimited, constant.EmailVerificationResendInterval)
+	}
+	ttl := s.config.EmailVerificationTTL
+	if ttl <= 0 {
+		ttl = constant.DefaultEmailVerificationTTL
+	}
+	token := utils.SignEmailVerification(utils.EmailVerification{
+		UserID:  uint(user.ID),
+		Email:   user.Email,
+		Expires: now.Add(ttl).Unix(),
+	}, s.config.EmailVerificationSecret)
+	link := s.config.AppBaseURL + "/auth/verify?token=" + url.QueryEscape(token)
+	return utils.SendVerificationEmail(user.Email, user.FullName, link)
+}
+
+// VerifyEmail xác nhận email theo token trong link. Token của email cũ (người dùng đã đổi email) không còn hiệu lực.
+func (s *AuthServiceImpl) VerifyEmail(token string) (models.User, error) {
+	verification, err := utils.ParseEmailVerification(token, s.config.EmailVerificationSecret, time.Now())
+	if err != nil {
+		return models.User{}, err
+	}
+	user, err := s.repo.GetUserById(int(verification.UserID))
+	if err != nil || user.Email != verification.Email {
+		return models.User{}, ErrInvalidVerificationToken
+	}
+	// Bấm lại link đã dùng thì giữ nguyên thời điểm xác nhận
+	if user.EmailConfirmed {
+		return *user, nil
+	}
+	now := time.Now()
+	confirmed, err := s.repo.ConfirmEmail(user.ID, user.Email, now)
+	if err != nil {
+		return models.User{}, err
+	}
+	if !confirmed {
+		return models.User{}, ErrInvalidVerificationToken
+	}
+	user.EmailConfirmed = true
+	user.EmailConfirmedAt = &now
+	return *user, nil
+}
+
+// ForgotPassword gửi link đặt lại mật khẩu tới email nếu email thuộc về một người dùng. Email không tồn tại,
+// yêu cầu lặp lại trong constant.PasswordResetRequestInterval hay lỗi gửi email đều không trả lỗi, và email được
+// gửi ở goroutine riêng, để cả kết quả lẫn thời gian phản hồi không lộ email nào đã đăng ký.
+func (s *AuthServiceImpl) ForgotPassword(email string) error {
+	user, err := s.repo.GetUserByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	token, tokenHash, err := utils.NewPasswordResetToken()
+	if err != nil {
+		logrus.Printf("Failed to generate password reset token for user %d: %v", user.ID, err)
+		return nil
+	}
+	ttl := s.config.PasswordResetTTL
+	if ttl <= 0 {
+		ttl = constant.DefaultPasswordResetTTL
+	}
+	now := time.Now()
+	created, err := s.repo.CreatePasswordResetToken(models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, constant.PasswordResetRequestInterval)
+	if err != nil {
+		logrus.Printf("Failed to create password reset token for user %d: %v", user.ID, err)
+		return nil
+	}
+	if !created {
+		return nil
+	}
+	link, err := url.Parse(s.config.PasswordResetURL)
+	if err != nil {
+		logrus.Printf("Invalid password reset URL %q: %v", s.config.PasswordResetURL, err)
+		return nil
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	go func() {
+		if err := utils.SendPasswordResetEmail(user.Email, user.FullName, link.String()); err != nil {
+			logrus.Printf("Failed to send password reset email to user %d: %v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+// ResetPassword đặt mật khẩu mới bằng token trong email, token chỉ dùng được một lần. Mọi refresh token của
+// người dùng bị thu hồi.
+func (s *AuthServiceImpl) ResetPassword(token string, newPassword string) error {
+	if len(newPassword) < constant.MinPasswordLength {
+		return ErrWeakPassword
+	}
+	passwordHash, err := utils.Hashpassword(newPassword)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.ResetPassword(utils.HashPasswordResetToken(token), passwordHash, time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidResetToken
+	}
+	return err
+}
+
+// ChangePassword đổi mật khẩu của người dùng đang đăng nhập sau khi kiểm tra mật khẩu hiện tại. Mọi refresh
+// token của người dùng bị thu hồi.
+func (s *AuthServiceImpl) ChangePassword(userId int, currentPassword string, newPassword string) error {
+	user, err := s.repo.GetUserById(userId)
+	if err != nil {
+		return err
+	}
+	if utils.VerifyPassword(user.Password, currentPassword) != nil {
+		return ErrIncorrectPassword
+	}
+	if len(newPassword) < constant.MinPasswordLength {
+		return ErrWeakPassword
+	}
+	passwordHash, err := utils.Hashpassword(newPassword)
+	if err != nil {
+		return err
+	}
+	return s.repo.ChangePassword(userId, passwordHash)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bookstack/config"
+	"bookstack/internal/models"
+	"bookstack/internal/repository"
+	"bookstack/utils"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// passwordRepository giữ người dùng, refresh token và token đặt lại mật khẩu trong bộ nhớ với cùng quy tắc như
+// UserRepositoryImpl: token chỉ dùng được khi chưa dùng và còn hạn, đổi mật khẩu xóa refresh token
+type passwordRepository struct {
+	repository.UserRepository
+	users         map[int]*models.User
+	refreshTokens map[int]string
+	resetTokens   map[string]*models.PasswordResetToken
+	createErr     error
+}
+
+func newPasswordRepository(t *testing.T, password string) *passwordRepository {
+	hash, err := utils.Hashpassword(password)
+	require.NoError(t, err)
+	user := &models.User{Email: "reader@example.com", Password: hash}
+	user.ID = 7
+	return &passwordRepository{
+		users:         map[int]*models.User{user.ID: user},
+		refreshTokens: map[int]string{user.ID: "refresh"},
+		resetTokens:   map[string]*models.PasswordResetToken{},
+	}
+}
+
+func (r *passwordRepository) GetUserByEmail(email string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *passwordRepository) GetUserById(id int) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (r *passwordRepository) CreatePasswordResetToken(token models.PasswordResetToken, interval time.Duration) (bool, error) {
+	if r.createErr != nil {
+		return false, r.createErr
+	}
+	r.resetTokens[token.TokenHash] = &token
+	return true, nil
+}
+
+func (r *passwordRepository) ResetPassword(tokenHash string, passwordHash string, now time.Time) (int, error) {
+	token, ok := r.resetTokens[tokenHash]
+	if !ok || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return 0, gorm.ErrRecordNotFound
+	}
+	token.UsedAt = &now
+	return token.UserID, r.ChangePassword(token.UserID, passwordHash)
+}
+
+func (r *passwordRepository) ChangePassword(userId int, passwordHash string) error {
+	r.users[userId].Password = passwordHash
+	delete(r.refreshTokens, userId)
+	return nil
+}
+
+func (r *passwordRepository) addResetToken(token string, expiresAt time.Time) {
+	hash := utils.HashPasswordResetToken(token)
+	r.resetTokens[hash] = &models.PasswordResetToken{UserID: 7, TokenHash: hash, ExpiresAt: expiresAt}
+}
+
+func TestResetPasswordTokenWorksOnce(t *testing.T) {
+	repo := newPasswordRepository(t, "old-password")
+	repo.addResetToken("token", time.Now().Add(time.Hour))
+	s := NewAuthServiceImpl(repo, &config.Config{}, nil)
+
+	require.NoError(t, s.ResetPassword("token", "new-password"))
+	assert.NoError(t, utils.VerifyPassword(repo.users[7].Password, "new-password"))
+	assert.NotContains(t, repo.refreshTokens, 7, "refresh tokens are revoked")
+
+	err := s.ResetPassword("token", "another-password")
+	assert.ErrorIs(t, err, ErrInvalidResetToken)
+	assert.NoError(t, utils.VerifyPassword(repo.users[7].Password, "new-password"))
+}
+
+func TestResetPasswordRejectsExpiredToken(t *testing.T) {
+	repo := newPasswordRepository(t, "old-password")
+	repo.addResetToken("token", time.Now().Add(-time.Minute))
+	s := NewAuthServiceImpl(repo, &config.Config{}, nil)
+
+	assert.ErrorIs(t, s.ResetPassword("token", "new-password"), ErrInvalidResetToken)
+	assert.ErrorIs(t, s.ResetPassword("unknown", "new-password"), ErrInvalidResetToken)
+	assert.NoError(t, utils.VerifyPassword(repo.users[7].Password, "old-password"))
+	assert.Contains(t, repo.refreshTokens, 7)
+}
+
+func TestChangePasswordRevokesRefreshTokens(t *testing.T) {
+	repo := newPasswordRepository(t, "old-password")
+	s := NewAuthServiceImpl(repo, &config.Config{}, nil)
+
+	assert.ErrorIs(t, s.ChangePassword(7, "wrong-password", "new-password"), ErrIncorrectPassword)
+	assert.ErrorIs(t, s.ChangePassword(7, "old-password", "short"), ErrWeakPassword)
+	assert.Contains(t, repo.refreshTokens, 7)
+
+	require.NoError(t, s.ChangePassword(7, "old-password", "new-password"))
+	assert.NoError(t, utils.VerifyPassword(repo.users[7].Password, "new-password"))
+	assert.NotContains(t, repo.refreshTokens, 7)
+}
+
+func TestForgotPasswordDoesNotRevealRegisteredEmails(t *testing.T) {
+	repo := newPasswordRepository(t, "old-password")
+	s := NewAuthServiceImpl(repo, &config.Config{}, nil)
+
+	assert.NoError(t, s.ForgotPassword("unknown@example.com"))
+
+	// Lỗi chỉ xảy ra với email đã đăng ký được ghi log thay vì trả về
+	repo.createErr = errors.New("database is down")
+	assert.NoError(t, s.ForgotPassword("reader@example.com"))
+}
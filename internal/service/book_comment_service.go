@@ -0,0 +1,234 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrDuplicateRating = errors.New("you have already rated this item")
+	ErrInvalidComment  = errors.New("invalid comment")
+)
+
+const commentTimeLayout = "2006-01-02 15:04:05"
+
+// authorizeCommentEntity kiểm tra entity tồn tại và người dùng được xem nó
+func (b *BookServiceImpl) authorizeCommentEntity(userId int, entityType string, entityId uint) error {
+	switch entityType {
+	case constant.EntityTypeBook:
+		return b.authorizeBook(userId, constant.EntityView, int(entityId))
+	case constant.EntityTypeShelve:
+		if _, err := b.repo.GetShelveById(int(entityId)); err != nil {
+			return fmt.Errorf("shelve not found: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("comments are not supported on %s", entityType)
+}
+
+func (b *BookServiceImpl) isModerator(userId int) (bool, error) {
+	viewer, err := b.permissionRepo.GetViewer(userId)
+	if err != nil {
+		return false, err
+	}
+	if viewer.IsAdmin {
+		return true, nil
+	}
+	return b.permissionRepo.HasPermission(userId, constant.ModerateComments)
+}
+
+// validateComment kiểm tra nội dung và điểm: chỉ bình luận gốc được chấm điểm, mỗi người một lần
+func (b *BookServiceImpl) validateComment(comment models.Comment) error {
+	if comment.Rating < 0 || comment.Rating > 5 {
+		return fmt.Errorf("%w: rating must be between 1 and 5", ErrInvalidComment)
+	}
+	if comment.ParentID != 0 && comment.Rating != 0 {
+		return fmt.Errorf("%w: replies cannot carry a rating", ErrInvalidComment)
+	}
+	if strings.TrimSpace(comment.Text) == "" && comment.Rating == 0 {
+		return fmt.Errorf("%w: text or rating is required", ErrInvalidComment)
+	}
+	if comment.Rating == 0 {
+		return nil
+	}
+	rated, err := b.commentRepo.HasRated(comment.EntityType, comment.EntityID, comment.CreatedBy, comment.ID)
+	if err != nil {
+		return err
+	}
+	if rated {
+		return ErrDuplicateRating
+	}
+	return nil
+}
+
+func (b *BookServiceImpl) CreateComment(entityType string, entityId uint, userId int, request request.CommentRequest) (models.Comment, error) {
+	if userId == 0 {
+		return models.Comment{}, ErrAccessDenied
+	}
+	if err := b.authorizeCommentEntity(userId, entityType, entityId); err != nil {
+		return models.Comment{}, err
+	}
+	comment := models.Comment{
+		EntityType: entityType,
+		EntityID:   entityId,
+		Text:       strings.TrimSpace(request.Text),
+		Rating:     request.Rating,
+		CreatedBy:  userId,
+		ParentID:   int(request.ParentID),
+	}
+	if comment.ParentID != 0 {
+		parent, err := b.commentRepo.GetCommentById(comment.ParentID)
+		if err != nil {
+			return models.Comment{}, fmt.Errorf("parent comment not found: %w", err)
+		}
+		if parent.EntityType != entityType || parent.EntityID != entityId {
+			return models.Comment{}, fmt.Errorf("%w: parent comment belongs to another item", ErrInvalidComment)
+		}
+	}
+	if err := b.validateComment(comment); err != nil {
+		return models.Comment{}, err
+	}
+	return b.commentRepo.CreateComment(comment)
+}
+
+func (b *BookServiceImpl) UpdateComment(commentId int, userId int, request request.CommentRequest) (models.Comment, error) {
+	comment, err := b.commentRepo.GetCommentById(commentId)
+	if err != nil {
+		return models.Comment{}, fmt.Errorf("comment not found: %w", err)
+	}
+	// Chỉ tác giả được sửa bình luận của mình
+	if userId == 0 || comment.CreatedBy != userId {
+		return models.Comment{}, ErrAccessDenied
+	}
+	if err := b.authorizeCommentEntity(userId, comment.EntityType, comment.EntityID); err != nil {
+		return models.Comment{}, err
+	}
+	comment.Text = strings.TrimSpace(request.Text)
+	comment.Rating = request.Rating
+	if err := b.validateComment(comment); err != nil {
+		return models.Comment{}, err
+	}
+	return b.commentRepo.UpdateComment(comment)
+}
+
+func (b *BookServiceImpl) DeleteComment(commentId int, userId int) error {
+	comment, err := b.commentRepo.GetCommentById(commentId)
+	if err != nil {
+		return fmt.Errorf("comment not found: %w", err)
+	}
+	// Tác giả hoặc người kiểm duyệt được xóa
+	if userId == 0 {
+		return ErrAccessDenied
+	}
+	if comment.CreatedBy != userId {
+		moderator, err := b.isModerator(userId)
+		if err != nil {
+			return err
+		}
+		if !moderator {
+			return ErrAccessDenied
+		}
+	}
+	return b.commentRepo.DeleteCommentThread(commentId)
+}
+
+func (b *BookServiceImpl) SetCommentHidden(commentId int, moderatorId int, hidden bool) (models.Comment, error) {
+	comment, err := b.commentRepo.GetCommentById(commentId)
+	if err != nil {
+		return models.Comment{}, fmt.Errorf("comment not found: %w", err)
+	}
+	if err := b.commentRepo.SetCommentHidden(commentId, hidden, uint(moderatorId)); err != nil {
+		return models.Comment{}, err
+	}
+	comment.Hidden = hidden
+	return comment, nil
+}
+
+func (b *BookServiceImpl) GetComments(entityType string, entityId uint, userId int) ([]response.CommentResponse, error) {
+	if err := b.authorizeCommentEntity(userId, entityType, entityId); err != nil {
+		return nil, err
+	}
+	comments, err := b.commentRepo.GetComments(entityType, entityId)
+	if err != nil {
+		return nil, err
+	}
+	moderator, err := b.isModerator(userId)
+	if err != nil {
+		return nil, err
+	}
+	return buildCommentTree(comments, moderator), nil
+}
+
+func (b *BookServiceImpl) GetRatingSummaries(entityType string, entityIds []uint) (map[uint]response.RatingSummaryResponse, error) {
+	summaries, err := b.commentRepo.GetRatingSummaries(entityType, entityIds)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]response.RatingSummaryResponse, len(entityIds))
+	for _, entityId := range entityIds {
+		summary := summaries[entityId]
+		histogram := map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+		for rating, count := range summary.Histogram {
+			histogram[rating] = count
+		}
+		result[entityId] = response.RatingSummaryResponse{
+			Average:   summary.Average,
+			Count:     summary.Count,
+			Histogram: histogram,
+		}
+	}
+	return result, nil
+}
+
+// buildCommentTree dựng cây bình luận theo ParentID. Người không phải kiểm duyệt không thấy nội dung
+// bình luận bị ẩn; bình luận ẩn chỉ được giữ lại làm chỗ trống nếu còn trả lời hiển thị bên dưới.
+func buildCommentTree(comments []models.Comment, moderator bool) []response.CommentResponse {
+	children := make(map[int][]models.Comment)
+	known := make(map[int]bool)
+	for _, comment := range comments {
+		known[int(comment.ID)] = true
+	}
+	for _, comment := range comments {
+		parentId := comment.ParentID
+		// Trả lời của bình luận đã bị xóa được đưa lên cấp gốc
+		if parentId != 0 && !known[parentId] {
+			parentId = 0
+		}
+		children[parentId] = append(children[parentId], comment)
+	}
+
+	var build func(parentId int) []response.CommentResponse
+	build = func(parentId int) []response.CommentResponse {
+		result := []response.CommentResponse{}
+		for _, comment := range children[parentId] {
+			node := response.CommentResponse{
+				ID:         comment.ID,
+				EntityType: comment.EntityType,
+				EntityID:   comment.EntityID,
+				ParentID:   comment.ParentID,
+				Text:       comment.Text,
+				Rating:     comment.Rating,
+				CreatedBy:  comment.CreatedBy,
+				Hidden:     comment.Hidden,
+				CreatedAt:  comment.CreatedAt.Format(commentTimeLayout),
+				UpdatedAt:  comment.UpdatedAt.Format(commentTimeLayout),
+				Replies:    build(int(comment.ID)),
+			}
+			if comment.Hidden && !moderator {
+				if len(node.Replies) == 0 {
+					continue
+				}
+				node.Text = ""
+				node.Rating = 0
+			}
+			result = append(result, node)
+		}
+		return result
+	}
+	return build(0)
+}
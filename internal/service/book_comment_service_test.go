@@ -0,0 +1,41 @@
+package service
+
+import (
+	"bookstack/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestBuildCommentTree(t *testing.T) {
+	comment := func(id uint, parentId int, text string, hidden bool) models.Comment {
+		return models.Comment{Model: gorm.Model{ID: id}, ParentID: parentId, Text: text, Rating: 0, Hidden: hidden}
+	}
+	comments := []models.Comment{
+		comment(1, 0, "root", false),
+		comment(2, 1, "reply", false),
+		comment(3, 0, "spam", true),
+		comment(4, 0, "hidden with replies", true),
+		comment(5, 4, "answer", false),
+		comment(6, 99, "orphan of a deleted comment", false),
+	}
+
+	t.Run("moderator sees hidden comments", func(t *testing.T) {
+		tree := buildCommentTree(comments, true)
+		assert.Len(t, tree, 4)
+		assert.Equal(t, "spam", tree[1].Text)
+	})
+
+	t.Run("readers do not see hidden content", func(t *testing.T) {
+		tree := buildCommentTree(comments, false)
+		var texts []string
+		for _, node := range tree {
+			texts = append(texts, node.Text)
+		}
+		assert.Equal(t, []string{"root", "", "orphan of a deleted comment"}, texts)
+		assert.Equal(t, "reply", tree[0].Replies[0].Text)
+		assert.True(t, tree[1].Hidden)
+		assert.Equal(t, "answer", tree[1].Replies[0].Text)
+	})
+}
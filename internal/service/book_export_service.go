@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/exporter"
+	"bookstack/internal/models"
+	"fmt"
+)
+
+func (b *BookServiceImpl) ExportBook(bookId int, userId int, format string) (exporter.File, error) {
+	if err := b.authorizeBook(userId, constant.EntityView, bookId); err != nil {
+		return exporter.File{}, err
+	}
+	viewer, err := b.permissionRepo.GetViewer(userId)
+	if err != nil {
+		return exporter.File{}, err
+	}
+	book, err := b.repo.GetBookTree(bookId, viewer)
+	if err != nil {
+		return exporter.File{}, fmt.Errorf("book not found: %w", err)
+	}
+
+	doc := exporter.Document{
+		Identifier:  fmt.Sprintf("urn:bookstack:book:%d", book.ID),
+		Title:       book.Title,
+		Description: book.Description,
+		UpdatedAt:   book.UpdatedAt,
+	}
+	for _, tag := range book.Tags {
+		doc.Tags = append(doc.Tags, exporter.Tag{Name: tag.Name, Value: tag.Value})
+	}
+	for _, chapter := range book.Chapters {
+		doc.Chapters = append(doc.Chapters, toExportChapter(chapter))
+	}
+	return exporter.Export(doc, format)
+}
+
+func (b *BookServiceImpl) ExportChapter(chapterId int, userId int, format string) (exporter.File, error) {
+	if err := b.authorizeChapter(userId, constant.EntityView, chapterId); err != nil {
+		return exporter.File{}, err
+	}
+	viewer, err := b.permissionRepo.GetViewer(userId)
+	if err != nil {
+		return exporter.File{}, err
+	}
+	chapter, err := b.repo.GetChapterTree(chapterId, viewer)
+	if err != nil {
+		return exporter.File{}, fmt.Errorf("chapter not found: %w", err)
+	}
+
+	doc := exporter.Document{
+		Identifier: fmt.Sprintf("urn:bookstack:chapter:%d", chapter.ID),
+		Title:      chapter.Title,
+		UpdatedAt:  chapter.UpdatedAt,
+		Chapters:   []exporter.Chapter{toExportChapter(chapter)},
+	}
+	return exporter.Export(doc, format)
+}
+
+func (b *BookServiceImpl) ExportPage(pageId int, userId int, format string) (exporter.File, error) {
+	if err := b.authorizePage(userId, constant.EntityView, pageId); err != nil {
+		return exporter.File{}, err
+	}
+	page, err := b.repo.GetPageById(pageId)
+	if err != nil {
+		return exporter.File{}, fmt.Errorf("page not found: %w", err)
+	}
+
+	// Trang xuất riêng lẻ được coi là một chương không có tiêu đề
+	doc := exporter.Document{
+		Identifier: fmt.Sprintf("urn:bookstack:page:%d", page.ID),
+		Title:      page.Title,
+		UpdatedAt:  page.UpdatedAt,
+		Chapters: []exporter.Chapter{{
+			Pages: []exporter.Page{toExportPage(page)},
+		}},
+	}
+	return exporter.Export(doc, format)
+}
+
+func toExportChapter(chapter models.Chapter) exporter.Chapter {
+	result := exporter.Chapter{
+		Title: chapter.Title,
+		Order: chapter.Order,
+	}
+	for _, page := range chapter.Pages {
+		result.Pages = append(result.Pages, toExportPage(page))
+	}
+	return result
+}
+
+func toExportPage(page models.Page) exporter.Page {
+	return exporter.Page{
+		Title:   page.Title,
+		Order:   page.Order,
+		Content: page.Content,
+	}
+}
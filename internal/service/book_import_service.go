@@ -0,0 +1,73 @@
+package service
+
+import (
+	"bookstack/internal/dto/request"
+	"bookstack/internal/importer"
+	"bookstack/internal/models"
+	"bookstack/utils"
+)
+
+// ImportBook đọc file markdown zip / EPUB / HTML và tạo sách mới cùng toàn bộ chương, trang
+func (b *BookServiceImpl) ImportBook(userId int, request request.ImportBookRequest, fileName string, data []byte) (models.Book, []importer.Warning, error) {
+	format := request.Format
+	if format == "" {
+		detected, err := importer.DetectFormat(fileName)
+		if err != nil {
+			return models.Book{}, nil, err
+		}
+		format = detected
+	}
+	result, err := importer.Parse(format, fileName, data)
+	if err != nil {
+		return models.Book{}, nil, err
+	}
+	if request.Title != "" {
+		result.Book.Title = request.Title
+	}
+
+	book := models.Book{
+		Title:       result.Book.Title,
+		Description: result.Book.Description,
+		Slug:        utils.Slugify(result.Book.Title),
+		ShelveID:    request.ShelveID,
+		Restricted:  request.Restricted,
+		CreatedBy:   uint(userId),
+		UpdatedBy:   uint(userId),
+		Tags:        toModelTags(result.Book.Tags),
+	}
+	for _, chapter := range result.Book.Chapters {
+		modelChapter := models.Chapter{
+			Title: chapter.Title,
+			Order: chapter.Order,
+		}
+		for _, page := range chapter.Pages {
+			modelChapter.Pages = append(modelChapter.Pages, models.Page{
+				Title:   page.Title,
+				Slug:    utils.Slugify(page.Title),
+				Content: page.Content,
+				Order:   page.Order,
+				Tags:    toModelTags(page.Tags),
+			})
+		}
+		book.Chapters = append(book.Chapters, modelChapter)
+	}
+
+	book, err = b.repo.ImportBook(book)
+	if err != nil {
+		return models.Book{}, nil, err
+	}
+	b.publishBookPublished(book, userId)
+	return book, result.Warnings, nil
+}
+
+func toModelTags(tags []importer.Tag) []models.Tag {
+	var result []models.Tag
+	for i, tag := range tags {
+		result = append(result, models.Tag{
+			Name:  tag.Name,
+			Value: tag.Value,
+			Order: i,
+		})
+	}
+	return result
+}
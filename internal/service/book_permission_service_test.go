@@ -0,0 +1,68 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"bookstack/internal/repository"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizeChain(t *testing.T) {
+	// Role 2 được xem sách 1 và sửa chương 10
+	grantsByEntity := map[string][]models.EntityPermission{
+		"book:1":     {{EntityType: constant.EntityTypeBook, EntityID: 1, RoleID: 2, CanView: true}},
+		"chapter:10": {{EntityType: constant.EntityTypeChapter, EntityID: 10, RoleID: 2, CanView: true, CanUpdate: true}},
+	}
+	lookup := func(entityType string, entityId uint, roleIds []uint) ([]models.EntityPermission, error) {
+		var result []models.EntityPermission
+		for _, permission := range grantsByEntity[fmt.Sprintf("%s:%d", entityType, entityId)] {
+			for _, roleId := range roleIds {
+				if permission.RoleID == roleId {
+					result = append(result, permission)
+				}
+			}
+		}
+		return result, nil
+	}
+
+	restrictedBook := securedEntity{constant.EntityTypeBook, 1, true}
+	openBook := securedEntity{constant.EntityTypeBook, 1, false}
+	restrictedChapter := securedEntity{constant.EntityTypeChapter, 10, true}
+	openChapter := securedEntity{constant.EntityTypeChapter, 10, false}
+
+	member := repository.Viewer{UserID: 5, RoleIDs: []uint{2}}
+	stranger := repository.Viewer{UserID: 6, RoleIDs: []uint{3}}
+	anonymous := repository.Viewer{}
+	admin := repository.Viewer{UserID: 1, RoleIDs: []uint{1}, IsAdmin: true}
+	buyer := repository.Viewer{UserID: 7, EntitledBookIDs: []uint{1}}
+
+	tests := []struct {
+		name     string
+		viewer   repository.Viewer
+		action   string
+		chain    []securedEntity
+		expected error
+	}{
+		{"unrestricted content is open to anonymous", anonymous, constant.EntityView, []securedEntity{openChapter, openBook}, nil},
+		{"anonymous cannot view restricted book", anonymous, constant.EntityView, []securedEntity{restrictedBook}, ErrAccessDenied},
+		{"admin bypasses entity permissions", admin, constant.EntityDelete, []securedEntity{restrictedChapter, restrictedBook}, nil},
+		{"role granted view can view restricted book", member, constant.EntityView, []securedEntity{restrictedBook}, nil},
+		{"role without grant cannot view restricted book", stranger, constant.EntityView, []securedEntity{restrictedBook}, ErrAccessDenied},
+		{"view grant does not allow update", member, constant.EntityUpdate, []securedEntity{restrictedBook}, ErrAccessDenied},
+		{"unrestricted chapter inherits action from restricted book", member, constant.EntityUpdate, []securedEntity{openChapter, restrictedBook}, ErrAccessDenied},
+		{"restricted chapter decides action, book only needs view", member, constant.EntityUpdate, []securedEntity{restrictedChapter, restrictedBook}, nil},
+		{"buyer of ebook can view restricted chapter", buyer, constant.EntityView, []securedEntity{restrictedChapter, restrictedBook}, nil},
+		{"ebook purchase does not allow update", buyer, constant.EntityUpdate, []securedEntity{restrictedChapter, restrictedBook}, ErrAccessDenied},
+		{"ebook purchase covers only the bought book", buyer, constant.EntityView, []securedEntity{{constant.EntityTypeBook, 2, true}}, ErrAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizeChain(tt.viewer, tt.action, tt.chain, lookup)
+			assert.Equal(t, tt.expected, err)
+		})
+	}
+}
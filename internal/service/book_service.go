@@ -1,54 +1,174 @@
 package service
 
 import (
+	"bookstack/internal/constant"
 	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/events"
+	"bookstack/internal/exporter"
+	"bookstack/internal/importer"
 	"bookstack/internal/models"
 	"bookstack/internal/repository"
+	"bookstack/utils"
+	"fmt"
+	"regexp"
+	"strings"
 )
 
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+)
+
+// Tag filter trong truy vấn tìm kiếm: [name] hoặc [name:value]
+var searchTagPattern = regexp.MustCompile(`\[([^\]:]+)(?::([^\]]*))?\]`)
+
 type BookService interface {
 	//book
 	CreateCompleteBook(int, request.CompleteBookCreateRequest) (models.Book, error)
 	CreateBook(int, request.BookCreateRequest) (models.Book, error)
-	DeleteBook(int) error
-	UpdateBook(int, request.BookCreateRequest) (models.Book, error)
-	GetAllBook() ([]models.Book, error)
+	DeleteBook(int, int) error
+	UpdateBook(int, int, request.BookCreateRequest) (models.Book, error)
+	GetAllBook(int) ([]models.Book, error)
 	//shelve
 	CreateShelve(int, request.ShelveCreateRequest) (models.Shelve, error)
 	GetShelves() ([]models.Shelve, error)
 	DeleteShelve(int) error
 	//chapter
-	CreateChapter(uint, request.BookChapterRequest) (models.Chapter, error)
-	GetChaptersOfBook(int) ([]models.Chapter, error)
-	DeleteChapter(int) error
-	UpdateChapter(int, request.BookChapterRequest) (models.Chapter, error)
+	CreateChapter(uint, int, request.BookChapterRequest) (models.Chapter, error)
+	GetChaptersOfBook(int, int) ([]models.Chapter, error)
+	DeleteChapter(int, int) error
+	UpdateChapter(int, int, request.BookChapterRequest) (models.Chapter, error)
 	//page
-	AddPage(uint, request.PageRequest) (models.Page, error)
-	GetPageChapter(int) ([]models.Page, error)
-	DeletePage(int) error
-	UpdatePage(int, request.PageRequest) (models.Page, error)
+	AddPage(uint, int, request.PageRequest) (models.Page, error)
+	GetPageChapter(int, int) ([]models.Page, error)
+	DeletePage(int, int) error
+	UpdatePage(int, int, request.PageRequest) (models.Page, error)
+	//page revision
+	GetPageRevisions(int, int) ([]models.PageRevision, error)
+	GetPageRevision(int, int, int) (models.PageRevision, error)
+	DiffPageRevisions(int, int, int, int) (response.PageRevisionDiffResponse, error)
+	RestorePageRevision(int, int, int) (models.Page, error)
+	//search
+	Search(request.SearchRequest, int) ([]response.SearchResultResponse, error)
+	//import
+	ImportBook(int, request.ImportBookRequest, string, []byte) (models.Book, []importer.Warning, error)
+	//export
+	ExportBook(int, int, string) (exporter.File, error)
+	ExportChapter(int, int, string) (exporter.File, error)
+	ExportPage(int, int, string) (exporter.File, error)
+	//comment
+	CreateComment(string, uint, int, request.CommentRequest) (models.Comment, error)
+	UpdateComment(int, int, request.CommentRequest) (models.Comment, error)
+	DeleteComment(int, int) error
+	SetCommentHidden(int, int, bool) (models.Comment, error)
+	GetComments(string, uint, int) ([]response.CommentResponse, error)
+	GetRatingSummaries(string, []uint) (map[uint]response.RatingSummaryResponse, error)
+	//entity permission
+	GetEntityPermissions(string, uint) ([]models.EntityPermission, error)
+	SetEntityPermission(string, uint, request.EntityPermissionRequest) (models.EntityPermission, error)
+	RevokeEntityPermission(string, uint, uint) error
 }
 
 type BookServiceImpl struct {
-	repo repository.BookRepository
+	repo           repository.BookRepository
+	permissionRepo repository.EntityPermissionRepository
+	commentRepo    repository.CommentRepository
+	bus            events.EventBus
 }
 
-func NewBookServiceImpl(repository repository.BookRepository) BookService {
+func NewBookServiceImpl(repository repository.BookRepository, permissionRepo repository.EntityPermissionRepository, commentRepo repository.CommentRepository, bus events.EventBus) BookService {
 	return &BookServiceImpl{
-		repo: repository,
+		repo:           repository,
+		permissionRepo: permissionRepo,
+		commentRepo:    commentRepo,
+		bus:            bus,
 	}
 }
-func (b *BookServiceImpl) UpdateChapter(chapterId int, request request.BookChapterRequest) (models.Chapter, error) {
+func (b *BookServiceImpl) UpdateChapter(chapterId int, userId int, request request.BookChapterRequest) (models.Chapter, error) {
+	if err := b.authorizeChapter(userId, constant.EntityUpdate, chapterId); err != nil {
+		return models.Chapter{}, err
+	}
 	return b.repo.UpdateChapter(chapterId, request)
 }
-func (b *BookServiceImpl) UpdatePage(pageId int, request request.PageRequest) (models.Page, error) {
-	return b.repo.UpdatePage(pageId, request)
+func (b *BookServiceImpl) UpdatePage(pageId int, userId int, request request.PageRequest) (models.Page, error) {
+	if err := b.authorizePage(userId, constant.EntityUpdate, pageId); err != nil {
+		return models.Page{}, err
+	}
+	page, err := b.repo.UpdatePage(pageId, userId, request)
+	if err != nil {
+		return models.Page{}, err
+	}
+	b.publishPageUpdated(page, userId)
+	return page, nil
 }
-func (b *BookServiceImpl) DeleteChapter(chapterId int) error {
+
+func (b *BookServiceImpl) GetPageRevisions(pageId int, userId int) ([]models.PageRevision, error) {
+	if err := b.authorizePage(userId, constant.EntityView, pageId); err != nil {
+		return nil, err
+	}
+	return b.repo.GetPageRevisions(pageId)
+}
+
+func (b *BookServiceImpl) GetPageRevision(pageId int, revisionNumber int, userId int) (models.PageRevision, error) {
+	if err := b.authorizePage(userId, constant.EntityView, pageId); err != nil {
+		return models.PageRevision{}, err
+	}
+	return b.repo.GetPageRevision(pageId, revisionNumber)
+}
+
+func (b *BookServiceImpl) DiffPageRevisions(pageId int, from int, to int, userId int) (response.PageRevisionDiffResponse, error) {
+	if err := b.authorizePage(userId, constant.EntityView, pageId); err != nil {
+		return response.PageRevisionDiffResponse{}, err
+	}
+	if from == to {
+		return response.PageRevisionDiffResponse{}, fmt.Errorf("cannot diff a revision with itself")
+	}
+	fromRevision, err := b.repo.GetPageRevision(pageId, from)
+	if err != nil {
+		return response.PageRevisionDiffResponse{}, fmt.Errorf("revision %d not found: %w", from, err)
+	}
+	toRevision, err := b.repo.GetPageRevision(pageId, to)
+	if err != nil {
+		return response.PageRevisionDiffResponse{}, fmt.Errorf("revision %d not found: %w", to, err)
+	}
+
+	result := response.PageRevisionDiffResponse{
+		PageID: pageId,
+		From:   from,
+		To:     to,
+	}
+	for _, line := range utils.DiffLines(fromRevision.Content, toRevision.Content) {
+		result.Lines = append(result.Lines, response.DiffLineResponse{
+			Type: line.Type,
+			Text: line.Text,
+		})
+	}
+	return result, nil
+}
+
+func (b *BookServiceImpl) RestorePageRevision(pageId int, revisionNumber int, userId int) (models.Page, error) {
+	if err := b.authorizePage(userId, constant.EntityUpdate, pageId); err != nil {
+		return models.Page{}, err
+	}
+	page, err := b.repo.RestorePageRevision(pageId, revisionNumber, userId)
+	if err != nil {
+		return models.Page{}, err
+	}
+	b.publishPageUpdated(page, userId)
+	return page, nil
+}
+func (b *BookServiceImpl) DeleteChapter(chapterId int, userId int) error {
+	if err := b.authorizeChapter(userId, constant.EntityDelete, chapterId); err != nil {
+		return err
+	}
 	return b.repo.DeleteChapter(chapterId)
 }
 
-func (b *BookServiceImpl) DeletePage(pageId int) error {
+func (b *BookServiceImpl) DeletePage(pageId int, userId int) error {
+	if err := b.authorizePage(userId, constant.EntityDelete, pageId); err != nil {
+		return err
+	}
 	return b.repo.DeletePage(pageId)
 }
 
@@ -56,39 +176,83 @@ func (b *BookServiceImpl) DeleteShelve(shelveId int) error {
 	return b.repo.DeleteShelve(shelveId)
 }
 
-func (b *BookServiceImpl) UpdateBook(bookId int, request request.BookCreateRequest) (models.Book, error) {
-	return b.repo.UpdateBook(bookId, request)
+func (b *BookServiceImpl) UpdateBook(bookId int, userId int, request request.BookCreateRequest) (models.Book, error) {
+	if err := b.authorizeBook(userId, constant.EntityUpdate, bookId); err != nil {
+		return models.Book{}, err
+	}
+	book, err := b.repo.UpdateBook(bookId, request)
+	if err != nil {
+		return models.Book{}, err
+	}
+	publishEvents(b.bus, events.BookUpdated{BookID: book.ID, Title: book.Title, Slug: book.Slug, UpdatedBy: uint(userId)})
+	return book, nil
 }
 
-func (b *BookServiceImpl) DeleteBook(bookId int) error {
-	return b.repo.DeleteBook(bookId)
+func (b *BookServiceImpl) DeleteBook(bookId int, userId int) error {
+	if err := b.authorizeBook(userId, constant.EntityDelete, bookId); err != nil {
+		return err
+	}
+	if err := b.repo.DeleteBook(bookId); err != nil {
+		return err
+	}
+	publishEvents(b.bus, events.BookDeleted{BookID: uint(bookId), DeletedBy: uint(userId)})
+	return nil
 }
 
 func (b *BookServiceImpl) GetShelves() ([]models.Shelve, error) {
 	return b.repo.GetShelves()
 }
 func (b *BookServiceImpl) CreateCompleteBook(userId int, request request.CompleteBookCreateRequest) (models.Book, error) {
-	return b.repo.CreateCompleteBook(userId, request)
+	book, err := b.repo.CreateCompleteBook(userId, request)
+	if err != nil {
+		return models.Book{}, err
+	}
+	b.publishBookPublished(book, userId)
+	return book, nil
 }
 
-func (b *BookServiceImpl) GetPageChapter(chapterId int) ([]models.Page, error) {
-	return b.repo.GetPageChapter(chapterId)
+func (b *BookServiceImpl) GetPageChapter(chapterId int, userId int) ([]models.Page, error) {
+	if err := b.authorizeChapter(userId, constant.EntityView, chapterId); err != nil {
+		return nil, err
+	}
+	viewer, err := b.permissionRepo.GetViewer(userId)
+	if err != nil {
+		return nil, err
+	}
+	return b.repo.GetPageChapter(chapterId, viewer)
 }
 
-func (b *BookServiceImpl) AddPage(chapterId uint, request request.PageRequest) (models.Page, error) {
+func (b *BookServiceImpl) AddPage(chapterId uint, userId int, request request.PageRequest) (models.Page, error) {
+	if err := b.authorizeChapter(userId, constant.EntityCreate, int(chapterId)); err != nil {
+		return models.Page{}, err
+	}
 	return b.repo.AddPage(chapterId, request)
 }
 
-func (b *BookServiceImpl) GetChaptersOfBook(bookId int) ([]models.Chapter, error) {
-	return b.repo.GetChaptersOfBook(bookId)
+func (b *BookServiceImpl) GetChaptersOfBook(bookId int, userId int) ([]models.Chapter, error) {
+	if err := b.authorizeBook(userId, constant.EntityView, bookId); err != nil {
+		return nil, err
+	}
+	viewer, err := b.permissionRepo.GetViewer(userId)
+	if err != nil {
+		return nil, err
+	}
+	return b.repo.GetChaptersOfBook(bookId, viewer)
 }
 
-func (b *BookServiceImpl) CreateChapter(bookId uint, request request.BookChapterRequest) (models.Chapter, error) {
+func (b *BookServiceImpl) CreateChapter(bookId uint, userId int, request request.BookChapterRequest) (models.Chapter, error) {
+	if err := b.authorizeBook(userId, constant.EntityCreate, int(bookId)); err != nil {
+		return models.Chapter{}, err
+	}
 	return b.repo.CreateChapter(bookId, request)
 }
 
-func (b *BookServiceImpl) GetAllBook() ([]models.Book, error) {
-	return b.repo.GetAllBook()
+func (b *BookServiceImpl) GetAllBook(userId int) ([]models.Book, error) {
+	viewer, err := b.permissionRepo.GetViewer(userId)
+	if err != nil {
+		return nil, err
+	}
+	return b.repo.GetAllBook(viewer)
 }
 
 func (b *BookServiceImpl) CreateShelve(userId int, request request.ShelveCreateRequest) (models.Shelve, error) {
@@ -96,5 +260,93 @@ func (b *BookServiceImpl) CreateShelve(userId int, request request.ShelveCreateR
 }
 
 func (b *BookServiceImpl) CreateBook(userId int, request request.BookCreateRequest) (models.Book, error) {
-	return b.repo.CreateBook(userId, request)
+	book, err := b.repo.CreateBook(userId, request)
+	if err != nil {
+		return models.Book{}, err
+	}
+	b.publishBookPublished(book, userId)
+	return book, nil
+}
+
+func (b *BookServiceImpl) publishBookPublished(book models.Book, userId int) {
+	publishEvents(b.bus, events.BookPublished{
+		BookID:     book.ID,
+		Title:      book.Title,
+		Slug:       book.Slug,
+		ShelveID:   book.ShelveID,
+		Restricted: book.Restricted,
+		CreatedBy:  uint(userId),
+	})
+}
+
+func (b *BookServiceImpl) publishPageUpdated(page models.Page, userId int) {
+	publishEvents(b.bus, events.PageUpdated{
+		PageID:    page.ID,
+		ChapterID: page.ChapterID,
+		Title:     page.Title,
+		Slug:      page.Slug,
+		UpdatedBy: uint(userId),
+	})
+}
+
+func (b *BookServiceImpl) Search(searchRequest request.SearchRequest, userId int) ([]response.SearchResultResponse, error) {
+	filter := ParseSearchQuery(searchRequest.Query)
+	if filter.Terms == "" && len(filter.Tags) == 0 {
+		return nil, fmt.Errorf("search query is empty")
+	}
+	viewer, err := b.permissionRepo.GetViewer(userId)
+	if err != nil {
+		return nil, err
+	}
+	filter.Viewer = viewer
+
+	pageSize := searchRequest.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultSearchPageSize
+	}
+	if pageSize > maxSearchPageSize {
+		pageSize = maxSearchPageSize
+	}
+	page := searchRequest.Page
+	if page <= 0 {
+		page = 1
+	}
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+
+	hits, err := b.repo.Search(filter)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]response.SearchResultResponse, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, response.SearchResultResponse{
+			EntityType: hit.EntityType,
+			EntityID:   hit.EntityID,
+			BookID:     hit.BookID,
+			ChapterID:  hit.ChapterID,
+			Title:      hit.Title,
+			Snippet:    hit.Snippet,
+			Rank:       hit.Rank,
+		})
+	}
+	return results, nil
+}
+
+// ParseSearchQuery tách các tag filter dạng [name:value] ra khỏi từ khóa tìm kiếm
+func ParseSearchQuery(query string) repository.SearchFilter {
+	var filter repository.SearchFilter
+	for _, match := range searchTagPattern.FindAllStringSubmatch(query, -1) {
+		name := strings.TrimSpace(match[1])
+		if name == "" {
+			continue
+		}
+		filter.Tags = append(filter.Tags, request.TagRequest{
+			Name:  name,
+			Value: strings.TrimSpace(match[2]),
+		})
+	}
+	terms := searchTagPattern.ReplaceAllString(query, " ")
+	filter.Terms = strings.Join(strings.Fields(terms), " ")
+	return filter
 }
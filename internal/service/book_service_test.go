@@ -0,0 +1,48 @@
+package service
+
+import (
+	"bookstack/internal/dto/request"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedTerms string
+		expectedTags  []request.TagRequest
+	}{
+		{
+			name:          "terms only",
+			query:         "warp drive",
+			expectedTerms: "warp drive",
+		},
+		{
+			name:          "tag with value and terms",
+			query:         "[genre:scifi] warp drive",
+			expectedTerms: "warp drive",
+			expectedTags:  []request.TagRequest{{Name: "genre", Value: "scifi"}},
+		},
+		{
+			name:          "tag without value in the middle",
+			query:         "warp [classic]  drive",
+			expectedTerms: "warp drive",
+			expectedTags:  []request.TagRequest{{Name: "classic"}},
+		},
+		{
+			name:         "multiple tags without terms",
+			query:        "[genre:scifi][lang: vi ]",
+			expectedTags: []request.TagRequest{{Name: "genre", Value: "scifi"}, {Name: "lang", Value: "vi"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := ParseSearchQuery(tt.query)
+			assert.Equal(t, tt.expectedTerms, filter.Terms)
+			assert.Equal(t, tt.expectedTags, filter.Tags)
+		})
+	}
+}
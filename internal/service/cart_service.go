@@ -0,0 +1,310 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/models"
+	"bookstack/internal/repository"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var (
+	ErrCartEmpty        = errors.New("cart is empty")
+	ErrCartChanged      = errors.New("cart changed since it was last viewed, please review it before checkout")
+	ErrInvalidCartToken = errors.New("invalid cart token")
+)
+
+var guestTokenPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+type CartService interface {
+	NewGuestToken() (string, error)
+	GetCart(repository.CartOwner) (response.CartResponse, error)
+	AddItem(repository.CartOwner, request.CartItemRequest) (response.CartResponse, error)
+	UpdateItem(repository.CartOwner, uint, request.CartQuantityRequest) (response.CartResponse, error)
+	RemoveItem(repository.CartOwner, uint) (response.CartResponse, error)
+	Clear(repository.CartOwner) error
+	MergeGuestCart(string, int) error
+	Checkout(int, request.CheckoutRequest) (models.Order, error)
+}
+
+type CartServiceImpl struct {
+	repo         repository.CartRepository
+	orderService OrderService
+}
+
+func NewCartServiceImpl(repo repository.CartRepository, orderService OrderService) CartService {
+	return &CartServiceImpl{
+		repo:         repo,
+		orderService: orderService,
+	}
+}
+
+// NewGuestToken tạo mã giỏ hàng ngẫu nhiên cho khách chưa đăng nhập
+func (s *CartServiceImpl) NewGuestToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (s *CartServiceImpl) checkOwner(owner repository.CartOwner) error {
+	if owner.IsGuest() && !guestTokenPattern.MatchString(owner.GuestToken) {
+		return ErrInvalidCartToken
+	}
+	return nil
+}
+
+func (s *CartServiceImpl) GetCart(owner repository.CartOwner) (response.CartResponse, error) {
+	if owner.IsGuest() && owner.GuestToken == "" {
+		return response.CartResponse{Items: []response.CartItemResponse{}}, nil
+	}
+	if err := s.checkOwner(owner); err != nil {
+		return response.CartResponse{}, err
+	}
+	items, err := s.repo.GetItems(owner)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	books, err := s.repo.GetBooks(cartBookIds(items))
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	cart, err := BuildCart(items, books)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	if owner.IsGuest() {
+		cart.GuestToken = owner.GuestToken
+	}
+	return cart, nil
+}
+
+func (s *CartServiceImpl) AddItem(owner repository.CartOwner, request request.CartItemRequest) (response.CartResponse, error) {
+	if err := s.checkOwner(owner); err != nil {
+		return response.CartResponse{}, err
+	}
+	books, err := s.repo.GetBooks([]uint{request.BookID})
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	book, ok := books[request.BookID]
+	if !ok {
+		return response.CartResponse{}, fmt.Errorf("book %d not found", request.BookID)
+	}
+	items, err := s.repo.GetItems(owner)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	quantity := request.Quantity
+	for _, item := range items {
+		if item.BookID == request.BookID {
+			quantity += item.Quantity
+		}
+	}
+	if quantity > constant.MaxCartQuantity {
+		return response.CartResponse{}, fmt.Errorf("quantity cannot exceed %d", constant.MaxCartQuantity)
+	}
+	err = s.repo.SaveItem(owner, models.CartItem{BookID: book.ID, Quantity: quantity, Price: book.Price})
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	return s.GetCart(owner)
+}
+
+// UpdateItem đặt lại số lượng của một dòng, giá được cập nhật theo giá hiện tại của sách
+func (s *CartServiceImpl) UpdateItem(owner repository.CartOwner, bookId uint, request request.CartQuantityRequest) (response.CartResponse, error) {
+	if err := s.checkOwner(owner); err != nil {
+		return response.CartResponse{}, err
+	}
+	if request.Quantity == 0 {
+		return s.RemoveItem(owner, bookId)
+	}
+	if request.Quantity > constant.MaxCartQuantity {
+		return response.CartResponse{}, fmt.Errorf("quantity cannot exceed %d", constant.MaxCartQuantity)
+	}
+	books, err := s.repo.GetBooks([]uint{bookId})
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	book, ok := books[bookId]
+	if !ok {
+		return response.CartResponse{}, fmt.Errorf("book %d not found", bookId)
+	}
+	err = s.repo.SaveItem(owner, models.CartItem{BookID: book.ID, Quantity: request.Quantity, Price: book.Price})
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	return s.GetCart(owner)
+}
+
+func (s *CartServiceImpl) RemoveItem(owner repository.CartOwner, bookId uint) (response.CartResponse, error) {
+	if err := s.checkOwner(owner); err != nil {
+		return response.CartResponse{}, err
+	}
+	if err := s.repo.RemoveItem(owner, bookId); err != nil {
+		return response.CartResponse{}, err
+	}
+	return s.GetCart(owner)
+}
+
+func (s *CartServiceImpl) Clear(owner repository.CartOwner) error {
+	if err := s.checkOwner(owner); err != nil {
+		return err
+	}
+	return s.repo.Clear(owner)
+}
+
+// MergeGuestCart gộp giỏ hàng của khách vào giỏ của người dùng vừa đăng nhập rồi xóa giỏ khách
+func (s *CartServiceImpl) MergeGuestCart(guestToken string, userId int) error {
+	guest := repository.CartOwner{GuestToken: guestToken}
+	if err := s.checkOwner(guest); err != nil {
+		return err
+	}
+	user := repository.CartOwner{UserID: uint(userId)}
+	guestItems, err := s.repo.GetItems(guest)
+	if err != nil {
+		return err
+	}
+	if len(guestItems) == 0 {
+		return nil
+	}
+	userItems, err := s.repo.GetItems(user)
+	if err != nil {
+		return err
+	}
+	for _, item := range MergeCartItems(userItems, guestItems) {
+		if err := s.repo.SaveItem(user, item); err != nil {
+			return err
+		}
+	}
+	return s.repo.Clear(guest)
+}
+
+// Checkout chuyển giỏ hàng thành đơn hàng. Nếu giá sách đã thay đổi hoặc sách không còn bán,
+// giỏ được cập nhật và trả về ErrCartChanged để người dùng xem lại.
+func (s *CartServiceImpl) Checkout(userId int, checkout request.CheckoutRequest) (models.Order, error) {
+	owner := repository.CartOwner{UserID: uint(userId)}
+	items, err := s.repo.GetItems(owner)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if len(items) == 0 {
+		return models.Order{}, ErrCartEmpty
+	}
+	books, err := s.repo.GetBooks(cartBookIds(items))
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	changed := false
+	orderRequest := request.OrderRequest{
+		Address:    checkout.Address,
+		Location:   checkout.Location,
+		Phone:      checkout.Phone,
+		CouponCode: checkout.CouponCode,
+		Currency:   checkout.Currency,
+	}
+	for _, item := range items {
+		book, ok := books[item.BookID]
+		if !ok {
+			changed = true
+			if err := s.repo.RemoveItem(owner, item.BookID); err != nil {
+				return models.Order{}, err
+			}
+			continue
+		}
+		if !book.Price.Equal(item.Price) {
+			changed = true
+			item.Price = book.Price
+			if err := s.repo.SaveItem(owner, item); err != nil {
+				return models.Order{}, err
+			}
+		}
+		orderRequest.OrderDetails = append(orderRequest.OrderDetails, request.OrderDetailRequest{
+			BookID:   item.BookID,
+			Quantity: item.Quantity,
+		})
+	}
+	if changed {
+		return models.Order{}, ErrCartChanged
+	}
+
+	order, err := s.orderService.CreateOrder(orderRequest, userId)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if err := s.repo.Clear(owner); err != nil {
+		return models.Order{}, err
+	}
+	return order, nil
+}
+
+// BuildCart tính giá từng dòng theo giá hiện tại của sách và đánh dấu các dòng đã đổi giá
+func BuildCart(items []models.CartItem, books map[uint]models.Book) (response.CartResponse, error) {
+	cart := response.CartResponse{Items: []response.CartItemResponse{}, Currency: constant.BaseCurrency}
+	for _, item := range items {
+		line := response.CartItemResponse{
+			BookID:     item.BookID,
+			Quantity:   item.Quantity,
+			AddedPrice: item.Price,
+		}
+		book, ok := books[item.BookID]
+		if ok {
+			line.Title = book.Title
+			line.Slug = book.Slug
+			line.Price = book.Price
+			line.Available = true
+			line.PriceChanged = !book.Price.Equal(item.Price)
+			subtotal, err := book.Price.MulInt(item.Quantity)
+			if err != nil {
+				return response.CartResponse{}, err
+			}
+			total, err := cart.TotalPrice.Add(subtotal)
+			if err != nil {
+				return response.CartResponse{}, err
+			}
+			line.Subtotal = subtotal
+			cart.TotalPrice = total
+		}
+		if line.PriceChanged || !line.Available {
+			cart.PriceChanged = true
+		}
+		cart.Items = append(cart.Items, line)
+	}
+	return cart, nil
+}
+
+// MergeCartItems cộng dồn số lượng của giỏ khách vào giỏ người dùng, trả về các dòng cần lưu
+func MergeCartItems(userItems []models.CartItem, guestItems []models.CartItem) []models.CartItem {
+	existing := make(map[uint]models.CartItem, len(userItems))
+	for _, item := range userItems {
+		existing[item.BookID] = item
+	}
+	merged := make([]models.CartItem, 0, len(guestItems))
+	for _, guestItem := range guestItems {
+		item := models.CartItem{BookID: guestItem.BookID, Quantity: guestItem.Quantity, Price: guestItem.Price}
+		if userItem, ok := existing[guestItem.BookID]; ok {
+			item.Quantity += userItem.Quantity
+			item.Price = userItem.Price
+		}
+		if item.Quantity > constant.MaxCartQuantity {
+			item.Quantity = constant.MaxCartQuantity
+		}
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+func cartBookIds(items []models.CartItem) []uint {
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.BookID)
+	}
+	return ids
+}
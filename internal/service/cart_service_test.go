@@ -0,0 +1,53 @@
+package service
+
+import (
+	"bookstack/internal/models"
+	"bookstack/internal/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestBuildCart(t *testing.T) {
+	books := map[uint]models.Book{
+		1: {Model: gorm.Model{ID: 1}, Title: "Go", Price: money.NewFromInt(10)},
+		2: {Model: gorm.Model{ID: 2}, Title: "Rust", Price: money.NewFromInt(12)},
+	}
+	items := []models.CartItem{
+		{BookID: 1, Quantity: 2, Price: money.NewFromInt(10)},
+		{BookID: 2, Quantity: 1, Price: money.NewFromInt(15)},
+		{BookID: 3, Quantity: 1, Price: money.NewFromInt(5)},
+	}
+
+	cart, err := BuildCart(items, books)
+	require.NoError(t, err)
+
+	assert.Len(t, cart.Items, 3)
+	assert.False(t, cart.Items[0].PriceChanged)
+	assert.Equal(t, money.NewFromInt(20), cart.Items[0].Subtotal)
+	assert.True(t, cart.Items[1].PriceChanged)
+	assert.Equal(t, money.NewFromInt(15), cart.Items[1].AddedPrice)
+	assert.Equal(t, money.NewFromInt(12), cart.Items[1].Price)
+	assert.False(t, cart.Items[2].Available)
+	assert.Equal(t, money.NewFromInt(32), cart.TotalPrice)
+	assert.True(t, cart.PriceChanged)
+}
+
+func TestMergeCartItems(t *testing.T) {
+	userItems := []models.CartItem{{BookID: 1, Quantity: 2, Price: money.NewFromInt(10)}}
+	guestItems := []models.CartItem{
+		{BookID: 1, Quantity: 3, Price: money.NewFromInt(9)},
+		{BookID: 2, Quantity: 120, Price: money.NewFromInt(12)},
+		{BookID: 3, Quantity: 1, Price: money.NewFromInt(5)},
+	}
+
+	merged := MergeCartItems(userItems, guestItems)
+
+	assert.Equal(t, []models.CartItem{
+		{BookID: 1, Quantity: 5, Price: money.NewFromInt(10)},
+		{BookID: 2, Quantity: 99, Price: money.NewFromInt(12)},
+		{BookID: 3, Quantity: 1, Price: money.NewFromInt(5)},
+	}, merged)
+}
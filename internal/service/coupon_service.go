@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/models"
+	"bookstack/internal/money"
+	"bookstack/internal/repository"
+	"fmt"
+	"strings"
+)
+
+type CouponService interface {
+	CreateCoupon(request.CouponRequest) (models.Coupon, error)
+	UpdateCoupon(uint, request.CouponRequest) (models.Coupon, error)
+	DeleteCoupon(uint) error
+	GetCoupons() ([]models.Coupon, error)
+}
+
+type CouponServiceImpl struct {
+	repo repository.CouponRepository
+}
+
+func NewCouponServiceImpl(repo repository.CouponRepository) CouponService {
+	return &CouponServiceImpl{
+		repo: repo,
+	}
+}
+
+func (s *CouponServiceImpl) CreateCoupon(request request.CouponRequest) (models.Coupon, error) {
+	var coupon models.Coupon
+	if err := ApplyCouponRequest(&coupon, request); err != nil {
+		return models.Coupon{}, err
+	}
+	return s.repo.CreateCoupon(coupon)
+}
+
+func (s *CouponServiceImpl) UpdateCoupon(couponId uint, request request.CouponRequest) (models.Coupon, error) {
+	coupon, err := s.repo.GetCouponById(couponId)
+	if err != nil {
+		return models.Coupon{}, fmt.Errorf("coupon not found: %w", err)
+	}
+	if err := ApplyCouponRequest(&coupon, request); err != nil {
+		return models.Coupon{}, err
+	}
+	return s.repo.UpdateCoupon(coupon)
+}
+
+func (s *CouponServiceImpl) DeleteCoupon(couponId uint) error {
+	if _, err := s.repo.GetCouponById(couponId); err != nil {
+		return fmt.Errorf("coupon not found: %w", err)
+	}
+	return s.repo.DeleteCoupon(couponId)
+}
+
+func (s *CouponServiceImpl) GetCoupons() ([]models.Coupon, error) {
+	return s.repo.GetCoupons()
+}
+
+// ApplyCouponRequest kiểm tra request theo từng loại coupon rồi gán vào coupon
+func ApplyCouponRequest(coupon *models.Coupon, request request.CouponRequest) error {
+	code := strings.ToUpper(strings.TrimSpace(request.Code))
+	if request.AutoApply {
+		code = ""
+	} else if code == "" {
+		return fmt.Errorf("code is required unless the coupon is applied automatically")
+	}
+
+	switch request.Type {
+	case constant.CouponPercentage:
+		if !request.Value.IsPositive() || request.Value.GreaterThan(money.NewFromInt(100)) {
+			return fmt.Errorf("percentage must be between 0 and 100")
+		}
+	case constant.CouponFixedAmount:
+		if !request.Value.IsPositive() {
+			return fmt.Errorf("amount must be positive")
+		}
+	case constant.CouponBuyXGetY:
+		if request.BuyQuantity <= 0 || request.GetQuantity <= 0 {
+			return fmt.Errorf("buy_quantity and get_quantity must be positive")
+		}
+	case constant.CouponFreeShipping:
+	default:
+		return fmt.Errorf("unknown coupon type %s", request.Type)
+	}
+
+	switch request.ScopeType {
+	case constant.CouponScopeAll:
+	case constant.CouponScopeShelve:
+		if request.ScopeShelveID == 0 {
+			return fmt.Errorf("scope_shelve_id is required for shelve scope")
+		}
+	case constant.CouponScopeTag:
+		if strings.TrimSpace(request.ScopeTagName) == "" {
+			return fmt.Errorf("scope_tag_name is required for tag scope")
+		}
+	default:
+		return fmt.Errorf("unknown scope type %s", request.ScopeType)
+	}
+
+	if request.MinSubtotal.IsNegative() || request.MaxDiscount.IsNegative() {
+		return fmt.Errorf("min_subtotal and max_discount must not be negative")
+	}
+
+	if request.StartsAt != nil && request.EndsAt != nil && !request.EndsAt.After(*request.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+
+	coupon.Code = code
+	coupon.Name = request.Name
+	coupon.Type = request.Type
+	coupon.Value = request.Value
+	coupon.BuyQuantity = request.BuyQuantity
+	coupon.GetQuantity = request.GetQuantity
+	coupon.ScopeType = request.ScopeType
+	coupon.ScopeShelveID = request.ScopeShelveID
+	coupon.ScopeTagName = strings.TrimSpace(request.ScopeTagName)
+	coupon.ScopeTagValue = strings.TrimSpace(request.ScopeTagValue)
+	coupon.MinSubtotal = request.MinSubtotal
+	coupon.MaxDiscount = request.MaxDiscount
+	coupon.StartsAt = request.StartsAt
+	coupon.EndsAt = request.EndsAt
+	coupon.UsageLimit = request.UsageLimit
+	coupon.PerUserLimit = request.PerUserLimit
+	coupon.AutoApply = request.AutoApply
+	coupon.Active = request.Active
+	return nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/models"
+	"bookstack/internal/money"
+	"bookstack/internal/repository"
+	"fmt"
+)
+
+type CurrencyService interface {
+	GetRates() ([]models.ExchangeRate, error)
+	SetRate(currency string, request request.ExchangeRateRequest, userId int) (models.ExchangeRate, error)
+	DeleteRate(currency string) error
+}
+
+type CurrencyServiceImpl struct {
+	repo repository.CurrencyRepository
+}
+
+func NewCurrencyServiceImpl(repo repository.CurrencyRepository) CurrencyService {
+	return &CurrencyServiceImpl{
+		repo: repo,
+	}
+}
+
+func (s *CurrencyServiceImpl) GetRates() ([]models.ExchangeRate, error) {
+	return s.repo.GetRates()
+}
+
+// SetRate đặt tỉ giá cho một tiền tệ; chỉ ảnh hưởng các đơn tạo sau đó
+func (s *CurrencyServiceImpl) SetRate(currency string, request request.ExchangeRateRequest, userId int) (models.ExchangeRate, error) {
+	currency, err := validateRateCurrency(currency)
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+	if !request.Rate.IsPositive() {
+		return models.ExchangeRate{}, fmt.Errorf("%w: rate must be positive", ErrCurrencyNotSupported)
+	}
+	return s.repo.SetRate(models.ExchangeRate{
+		Currency:  currency,
+		Rate:      request.Rate,
+		UpdatedBy: uint(userId),
+	})
+}
+
+func (s *CurrencyServiceImpl) DeleteRate(currency string) error {
+	currency, err := validateRateCurrency(currency)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteRate(currency)
+}
+
+// validateRateCurrency: tiền tệ phải có trong danh sách hỗ trợ và khác tiền tệ gốc (tỉ giá luôn là 1)
+func validateRateCurrency(currency string) (string, error) {
+	currency = money.NormalizeCurrency(currency)
+	if !money.KnownCurrency(currency) {
+		return "", fmt.Errorf("%w: %s", ErrCurrencyNotSupported, currency)
+	}
+	if currency == constant.BaseCurrency {
+		return "", fmt.Errorf("%w: %s is the base currency", ErrCurrencyNotSupported, currency)
+	}
+	return currency, nil
+}
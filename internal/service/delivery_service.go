@@ -0,0 +1,166 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/events"
+	"bookstack/internal/models"
+	"bookstack/internal/repository"
+	"bookstack/internal/storage"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Lỗi bằng chứng giao hàng được dùng lại ở tầng controller
+var (
+	ErrDeliveryProofRequired = repository.ErrDeliveryProofRequired
+	ErrInvalidDeliveryCode   = repository.ErrInvalidDeliveryCode
+	ErrDeliveryCodeLocked    = repository.ErrDeliveryCodeLocked
+	ErrInvalidProofFile      = errors.New("proof must be a PNG, JPEG or WebP image")
+)
+
+// Loại file của bằng chứng giao hàng
+const (
+	ProofPhoto     = "photo"
+	ProofSignature = "signature"
+)
+
+// proofExtensions là định dạng ảnh được nhận làm bằng chứng, theo content type nhận diện từ nội dung file
+var proofExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+}
+
+// DeliveryService cho shipper hoàn tất đơn kèm bằng chứng giao hàng (ảnh, chữ ký người nhận, mã nhận hàng
+// khách nhận khi đặt đơn) hoặc ghi nhận giao thất bại kèm lý do
+type DeliveryService interface {
+	Deliver(orderId uint, shipperId uint, proof request.DeliveryProofRequest, photo []byte, signature []byte) (models.Order, error)
+	FailDelivery(orderId uint, shipperId uint, proof request.DeliveryProofRequest, photo []byte) (models.Order, error)
+	GetProofs(orderId uint) ([]models.DeliveryProof, error)
+	OpenProofFile(proofId uint, kind string) (*os.File, error)
+}
+
+type DeliveryServiceImpl struct {
+	repo     repository.DeliveryRepository
+	store    *storage.LocalStore
+	notifier NotificationService
+	bus      events.EventBus
+}
+
+func NewDeliveryServiceImpl(repo repository.DeliveryRepository, store *storage.LocalStore, notifier NotificationService, bus events.EventBus) DeliveryService {
+	return &DeliveryServiceImpl{
+		repo:     repo,
+		store:    store,
+		notifier: notifier,
+		bus:      bus,
+	}
+}
+
+func (s *DeliveryServiceImpl) Deliver(orderId uint, shipperId uint, proof request.DeliveryProofRequest, photo []byte, signature []byte) (models.Order, error) {
+	if len(photo) == 0 && len(signature) == 0 && proof.Code == "" {
+		return models.Order{}, fmt.Errorf("%w: photo, signature or delivery code", ErrDeliveryProofRequired)
+	}
+	return s.record(orderId, shipperId, constant.DeliveryOutcomeDelivered, proof, photo, signature)
+}
+
+func (s *DeliveryServiceImpl) FailDelivery(orderId uint, shipperId uint, proof request.DeliveryProofRequest, photo []byte) (models.Order, error) {
+	if proof.Reason == "" {
+		return models.Order{}, fmt.Errorf("%w: reason of the failed delivery", ErrDeliveryProofRequired)
+	}
+	return s.record(orderId, shipperId, constant.DeliveryOutcomeFailed, proof, photo, nil)
+}
+
+// record lưu file bằng chứng rồi ghi nhận kết quả giao; ghi nhận không thành thì file vừa lưu bị xóa
+func (s *DeliveryServiceImpl) record(orderId uint, shipperId uint, outcome string, proof request.DeliveryProofRequest, photo []byte, signature []byte) (models.Order, error) {
+	deliveryProof := models.DeliveryProof{
+		OrderID:       orderId,
+		ShipperID:     shipperId,
+		Outcome:       outcome,
+		RecipientName: proof.RecipientName,
+		Reason:        proof.Reason,
+		Lat:           proof.Lat,
+		Lng:           proof.Lng,
+	}
+	var saved []string
+	cleanup := func() {
+		for _, key := range saved {
+			if err := s.store.Remove(key); err != nil {
+				logrus.Printf("Failed to remove delivery proof file %s: %v", key, err)
+			}
+		}
+	}
+	for _, file := range []struct {
+		kind string
+		data []byte
+		key  *string
+	}{
+		{ProofPhoto, photo, &deliveryProof.PhotoPath},
+		{ProofSignature, signature, &deliveryProof.SignaturePath},
+	} {
+		if len(file.data) == 0 {
+			continue
+		}
+		key, err := s.saveProofFile(orderId, file.kind, file.data)
+		if err != nil {
+			cleanup()
+			return models.Order{}, err
+		}
+		saved = append(saved, key)
+		*file.key = key
+	}
+
+	order, err := s.repo.RecordDelivery(deliveryProof, proof.Code)
+	if err != nil {
+		cleanup()
+		return models.Order{}, err
+	}
+	s.notifier.Notify(orderStatusEvents(order, proof.Reason)...)
+	publishEvents(s.bus, orderStatusDomainEvents(order, proof.Reason)...)
+	return order, nil
+}
+
+func (s *DeliveryServiceImpl) saveProofFile(orderId uint, kind string, data []byte) (string, error) {
+	if len(data) > constant.MaxDeliveryProofSize {
+		return "", fmt.Errorf("%w: %s is larger than %d bytes", ErrInvalidProofFile, kind, constant.MaxDeliveryProofSize)
+	}
+	extension, ok := proofExtensions[http.DetectContentType(data)]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrInvalidProofFile, kind)
+	}
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("orders/%d/%s-%s%s", orderId, kind, hex.EncodeToString(random), extension)
+	if err := s.store.Save(key, data); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (s *DeliveryServiceImpl) GetProofs(orderId uint) ([]models.DeliveryProof, error) {
+	return s.repo.GetDeliveryProofs(orderId)
+}
+
+// OpenProofFile mở ảnh (photo) hoặc chữ ký (signature) của một bằng chứng giao hàng
+func (s *DeliveryServiceImpl) OpenProofFile(proofId uint, kind string) (*os.File, error) {
+	proof, err := s.repo.GetDeliveryProof(proofId)
+	if err != nil {
+		return nil, err
+	}
+	key := proof.PhotoPath
+	if kind == ProofSignature {
+		key = proof.SignaturePath
+	}
+	if key == "" {
+		return nil, os.ErrNotExist
+	}
+	return s.store.Open(key)
+}
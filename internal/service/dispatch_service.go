@@ -0,0 +1,197 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/geo"
+	"bookstack/internal/models"
+	"bookstack/internal/notification"
+	"bookstack/internal/repository"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrOfferClosed     = repository.ErrOfferClosed
+	ErrNotDispatchable = repository.ErrNotDispatchable
+	ErrNotShipper      = repository.ErrNotShipper
+
+	ErrInvalidServiceArea = geo.ErrInvalidServiceArea
+)
+
+// DispatchService tự động tìm shipper cho đơn mới: mời lần lượt từng shipper theo thứ hạng
+// (khớp khu vực, ít đơn đang giao, đang nhận đơn), mỗi lời mời có thời hạn; shipper từ chối hoặc
+// không trả lời thì mời người tiếp theo, hết ứng viên thì đơn chờ nhân viên gán tay.
+type DispatchService interface {
+	Dispatch(orderId uint) error
+	DispatchNewOrder(messageId string, orderId uint) error
+	ExpireOffers() error
+	StartOfferSweeper(ctx context.Context)
+	GetOffers(shipperId int) ([]models.DeliveryOffer, error)
+	AcceptOffer(offerId uint, shipperId int) (models.DeliveryOffer, error)
+	DeclineOffer(offerId uint, shipperId int, reason string) (models.DeliveryOffer, error)
+	GetManualOrders() ([]models.Order, error)
+	AssignOrder(orderId uint, shipperId uint) (models.Order, error)
+	SetAvailability(shipperId int, available bool) error
+	GetServiceArea(shipperId int) (models.ShipperServiceArea, error)
+	SetServiceArea(shipperId int, area geo.ServiceArea) (models.ShipperServiceArea, error)
+	ClearServiceArea(shipperId int) error
+}
+
+type DispatchServiceImpl struct {
+	repo     repository.DispatchRepository
+	messages repository.MessageRepository
+	notifier NotificationService
+	now      func() time.Time
+}
+
+func NewDispatchServiceImpl(repo repository.DispatchRepository, messages repository.MessageRepository, notifier NotificationService) DispatchService {
+	return &DispatchServiceImpl{
+		repo:     repo,
+		messages: messages,
+		notifier: notifier,
+		now:      time.Now,
+	}
+}
+
+// Dispatch mời shipper tiếp theo cho đơn (nếu đơn còn cần shipper và chưa có lời mời đang chờ)
+func (s *DispatchServiceImpl) Dispatch(orderId uint) error {
+	offer, err := s.repo.OfferOrder(orderId, s.now())
+	if err != nil {
+		return err
+	}
+	if offer == nil {
+		logrus.Printf("Order %d: no shipper offered (not needed or waiting for manual assignment)", orderId)
+		return nil
+	}
+	logrus.Printf("Order %d offered to shipper %d (score %d) until %s", orderId, offer.ShipperID, offer.Score, offer.ExpiresAt.Format(time.RFC3339))
+	s.notifier.Notify(notification.Event{
+		Type:      constant.EventDeliveryOffer,
+		UserID:    offer.ShipperID,
+		OrderID:   offer.OrderID,
+		OfferID:   offer.ID,
+		ExpiresAt: &offer.ExpiresAt,
+	})
+	return nil
+}
+
+// DispatchNewOrder mời shipper cho đơn mới nhận từ bus sự kiện. Message giao trùng (relay publish lại, broker
+// giao lại) được bỏ qua theo messageId.
+func (s *DispatchServiceImpl) DispatchNewOrder(messageId string, orderId uint) error {
+	processed, err := s.messages.ProcessOnce(constant.ConsumerDispatch, messageId, func() error {
+		return s.Dispatch(orderId)
+	})
+	if err == nil && !processed {
+		logrus.Printf("Order %d: message %s already processed, skipped", orderId, messageId)
+	}
+	return err
+}
+
+// ExpireOffers hết hạn các lời mời quá hạn và mời shipper tiếp theo cho các đơn đó
+func (s *DispatchServiceImpl) ExpireOffers() error {
+	orderIds, err := s.repo.ExpireOffers(s.now())
+	if err != nil {
+		return err
+	}
+	for _, orderId := range orderIds {
+		if err := s.Dispatch(orderId); err != nil {
+			logrus.Printf("Failed to dispatch order %d: %v", orderId, err)
+		}
+	}
+	return nil
+}
+
+// StartOfferSweeper quét lời mời hết hạn theo chu kỳ cho tới khi ctx bị hủy
+func (s *DispatchServiceImpl) StartOfferSweeper(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(constant.DispatchSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.ExpireOffers(); err != nil {
+					logrus.Printf("Failed to expire delivery offers: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (s *DispatchServiceImpl) GetOffers(shipperId int) ([]models.DeliveryOffer, error) {
+	return s.repo.GetShipperOffers(uint(shipperId))
+}
+
+// AcceptOffer nhận đơn theo lời mời. Lời mời đã hết hạn thì đơn được mời cho shipper khác và trả về ErrOfferClosed.
+func (s *DispatchServiceImpl) AcceptOffer(offerId uint, shipperId int) (models.DeliveryOffer, error) {
+	offer, err := s.repo.RespondOffer(offerId, uint(shipperId), true, "", s.now())
+	if err != nil {
+		return models.DeliveryOffer{}, err
+	}
+	if offer.Status != constant.OfferAccepted {
+		s.redispatch(offer)
+		return offer, ErrOfferClosed
+	}
+	s.notifier.Notify(shipperAssignedEvents(offer.Order)...)
+	return offer, nil
+}
+
+// DeclineOffer từ chối lời mời và mời shipper tiếp theo
+func (s *DispatchServiceImpl) DeclineOffer(offerId uint, shipperId int, reason string) (models.DeliveryOffer, error) {
+	offer, err := s.repo.RespondOffer(offerId, uint(shipperId), false, reason, s.now())
+	if err != nil {
+		return models.DeliveryOffer{}, err
+	}
+	s.redispatch(offer)
+	return offer, nil
+}
+
+func (s *DispatchServiceImpl) redispatch(offer models.DeliveryOffer) {
+	if offer.Status == constant.OfferCancelled {
+		return
+	}
+	if err := s.Dispatch(offer.OrderID); err != nil {
+		logrus.Printf("Failed to dispatch order %d: %v", offer.OrderID, err)
+	}
+}
+
+func (s *DispatchServiceImpl) GetManualOrders() ([]models.Order, error) {
+	return s.repo.GetManualDispatchOrders()
+}
+
+func (s *DispatchServiceImpl) AssignOrder(orderId uint, shipperId uint) (models.Order, error) {
+	order, err := s.repo.AssignOrder(orderId, shipperId)
+	if err != nil {
+		return models.Order{}, err
+	}
+	s.notifier.Notify(shipperAssignedEvents(order)...)
+	return order, nil
+}
+
+func (s *DispatchServiceImpl) SetAvailability(shipperId int, available bool) error {
+	return s.repo.SetShipperAvailability(uint(shipperId), available)
+}
+
+func (s *DispatchServiceImpl) GetServiceArea(shipperId int) (models.ShipperServiceArea, error) {
+	return s.repo.GetServiceArea(uint(shipperId))
+}
+
+// SetServiceArea khai báo vùng phục vụ của shipper, dùng để ghép đơn theo khoảng cách thay cho WorkingArea
+func (s *DispatchServiceImpl) SetServiceArea(shipperId int, area geo.ServiceArea) (models.ShipperServiceArea, error) {
+	if err := area.Validate(); err != nil {
+		return models.ShipperServiceArea{}, err
+	}
+	serviceArea := models.ShipperServiceArea{UserID: uint(shipperId), Polygon: area.Polygon}
+	if !area.IsPolygon() {
+		serviceArea.CenterLat = area.Center.Lat
+		serviceArea.CenterLng = area.Center.Lng
+		serviceArea.RadiusKm = area.RadiusKm
+	}
+	return s.repo.SetServiceArea(serviceArea)
+}
+
+func (s *DispatchServiceImpl) ClearServiceArea(shipperId int) error {
+	return s.repo.ClearServiceArea(uint(shipperId))
+}
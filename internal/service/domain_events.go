@@ -0,0 +1,42 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/events"
+	"bookstack/internal/models"
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// publishEvents phát sự kiện nghiệp vụ sau khi thay đổi đã được lưu. Lỗi phát chỉ được ghi log để không
+// làm hỏng nghiệp vụ; sự kiện cần đảm bảo không mất thì ghi qua outbox trong repository.
+func publishEvents(bus events.EventBus, domainEvents ...events.Event) {
+	if len(domainEvents) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constant.MessagePublishTimeout)
+	defer cancel()
+	if err := bus.Publish(ctx, domainEvents...); err != nil {
+		logrus.Printf("Failed to publish domain events: %v", err)
+	}
+}
+
+// orderStatusDomainEvents trả về sự kiện nghiệp vụ ứng với trạng thái mới của đơn, trạng thái khác thì không có sự kiện
+func orderStatusDomainEvents(order models.Order, reason string) []events.Event {
+	var shipperId uint
+	if order.ShipperID != nil {
+		shipperId = *order.ShipperID
+	}
+	switch order.Status {
+	case constant.Shipped:
+		return []events.Event{events.OrderShipped{OrderID: order.ID, UserID: order.UserID, ShipperID: shipperId}}
+	case constant.Delivered:
+		return []events.Event{events.OrderDelivered{OrderID: order.ID, UserID: order.UserID, ShipperID: shipperId}}
+	case constant.Failed:
+		return []events.Event{events.OrderDeliveryFailed{OrderID: order.ID, UserID: order.UserID, ShipperID: shipperId, Reason: reason}}
+	case constant.Cancelled:
+		return []events.Event{events.OrderCancelled{OrderID: order.ID, UserID: order.UserID, Reason: reason}}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/models"
+	"bookstack/internal/repository"
+	"fmt"
+)
+
+const (
+	defaultMovementLimit = 50
+	maxMovementLimit     = 500
+)
+
+// Lỗi của kho được dùng lại ở tầng controller
+var (
+	ErrInsufficientStock = repository.ErrInsufficientStock
+	ErrInvalidStockLevel = repository.ErrInvalidStockLevel
+)
+
+type InventoryService interface {
+	GetStock(uint) (models.BookStock, error)
+	GetStocks(bool) ([]models.BookStock, error)
+	AdjustStock(uint, int, request.StockAdjustmentRequest) (models.BookStock, error)
+	SetLowStockThreshold(uint, request.LowStockThresholdRequest) (models.BookStock, error)
+	GetMovements(uint, int) ([]models.StockMovement, error)
+	LowStockAlerts([]uint) ([]models.BookStock, error)
+}
+
+type InventoryServiceImpl struct {
+	repo repository.InventoryRepository
+}
+
+func NewInventoryServiceImpl(repo repository.InventoryRepository) InventoryService {
+	return &InventoryServiceImpl{
+		repo: repo,
+	}
+}
+
+func (i *InventoryServiceImpl) GetStock(bookId uint) (models.BookStock, error) {
+	return i.repo.GetStock(bookId)
+}
+
+func (i *InventoryServiceImpl) GetStocks(lowOnly bool) ([]models.BookStock, error) {
+	return i.repo.GetStocks(nil, lowOnly)
+}
+
+func (i *InventoryServiceImpl) AdjustStock(bookId uint, userId int, request request.StockAdjustmentRequest) (models.BookStock, error) {
+	movementType := request.Type
+	if movementType == "" {
+		movementType = constant.StockAdjustment
+	}
+	switch movementType {
+	case constant.StockReceipt:
+		if request.Quantity <= 0 {
+			return models.BookStock{}, fmt.Errorf("receipt quantity must be positive")
+		}
+	case constant.StockAdjustment:
+		if request.Quantity == 0 {
+			return models.BookStock{}, fmt.Errorf("adjustment quantity cannot be zero")
+		}
+	default:
+		return models.BookStock{}, fmt.Errorf("type must be %s or %s", constant.StockReceipt, constant.StockAdjustment)
+	}
+	return i.repo.AdjustStock(bookId, request.Quantity, movementType, request.Reason, uint(userId))
+}
+
+func (i *InventoryServiceImpl) SetLowStockThreshold(bookId uint, request request.LowStockThresholdRequest) (models.BookStock, error) {
+	if request.Threshold < 0 {
+		return models.BookStock{}, fmt.Errorf("threshold cannot be negative")
+	}
+	return i.repo.SetLowStockThreshold(bookId, request.Threshold)
+}
+
+func (i *InventoryServiceImpl) GetMovements(bookId uint, limit int) ([]models.StockMovement, error) {
+	if limit <= 0 {
+		limit = defaultMovementLimit
+	}
+	if limit > maxMovementLimit {
+		limit = maxMovementLimit
+	}
+	return i.repo.GetMovements(bookId, limit)
+}
+
+// LowStockAlerts trả về các sách trong danh sách đã chạm ngưỡng sắp hết hàng
+func (i *InventoryServiceImpl) LowStockAlerts(bookIds []uint) ([]models.BookStock, error) {
+	if len(bookIds) == 0 {
+		return nil, nil
+	}
+	return i.repo.GetStocks(bookIds, true)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"bookstack/config"
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/dto/response"
+	"bookstack/internal/exporter"
+	"bookstack/internal/models"
+	"bookstack/internal/repository"
+	"bookstack/utils"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrNotInLibrary        = fmt.Errorf("%w: ebook is not in your library", ErrAccessDenied)
+	ErrInvalidDownloadLink = utils.ErrInvalidDownloadLink
+	ErrDownloadLinkExpired = utils.ErrDownloadLinkExpired
+)
+
+type LibraryService interface {
+	GetLibrary(userId int) ([]models.BookEntitlement, error)
+	CreateDownloadLink(userId int, bookId uint, format string) (response.DownloadLinkResponse, error)
+	Download(bookId uint, format string, request request.DownloadRequest) (exporter.File, error)
+}
+
+type LibraryServiceImpl struct {
+	repo        repository.LibraryRepository
+	bookService BookService
+	config      *config.Config
+	now         func() time.Time
+}
+
+func NewLibraryServiceImpl(repo repository.LibraryRepository, bookService BookService, conf *config.Config) LibraryService {
+	return &LibraryServiceImpl{
+		repo:        repo,
+		bookService: bookService,
+		config:      conf,
+		now:         time.Now,
+	}
+}
+
+// GetLibrary lấy các sách điện tử người dùng đã mua
+func (s *LibraryServiceImpl) GetLibrary(userId int) ([]models.BookEntitlement, error) {
+	return s.repo.GetLibrary(uint(userId))
+}
+
+// CreateDownloadLink tạo link tải có chữ ký, hết hạn sau DownloadLinkTTL, cho sách điện tử đã mua
+func (s *LibraryServiceImpl) CreateDownloadLink(userId int, bookId uint, format string) (response.DownloadLinkResponse, error) {
+	if !exporter.Supported(format) {
+		return response.DownloadLinkResponse{}, exporter.ErrUnknownFormat
+	}
+	if err := s.checkEntitlement(uint(userId), bookId); err != nil {
+		return response.DownloadLinkResponse{}, err
+	}
+	ttl := s.config.DownloadLinkTTL
+	if ttl <= 0 {
+		ttl = constant.DefaultDownloadLinkTTL
+	}
+	expiresAt := s.now().Add(ttl)
+	link := utils.DownloadLink{
+		BookID:  bookId,
+		Format:  format,
+		UserID:  uint(userId),
+		Expires: expiresAt.Unix(),
+	}
+	query := url.Values{}
+	query.Set("user", strconv.FormatUint(uint64(link.UserID), 10))
+	query.Set("expires", strconv.FormatInt(link.Expires, 10))
+	query.Set("signature", utils.SignDownloadLink(link, s.config.DownloadLinkSecret))
+	return response.DownloadLinkResponse{
+		URL:       fmt.Sprintf("/library/downloads/%d/%s?%s", bookId, url.PathEscape(format), query.Encode()),
+		Format:    format,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// Download kiểm tra chữ ký, thời hạn của link và quyền đọc hiện tại (sách đã hoàn tiền thì link cũ không dùng được nữa)
+// rồi xuất sách theo định dạng của link
+func (s *LibraryServiceImpl) Download(bookId uint, format string, request request.DownloadRequest) (exporter.File, error) {
+	link := utils.DownloadLink{
+		BookID:  bookId,
+		Format:  format,
+		UserID:  request.UserID,
+		Expires: request.Expires,
+	}
+	if err := utils.VerifyDownloadLink(link, request.Signature, s.config.DownloadLinkSecret, s.now()); err != nil {
+		return exporter.File{}, err
+	}
+	if err := s.checkEntitlement(request.UserID, bookId); err != nil {
+		return exporter.File{}, err
+	}
+	return s.bookService.ExportBook(int(bookId), int(request.UserID), format)
+}
+
+func (s *LibraryServiceImpl) checkEntitlement(userId uint, bookId uint) error {
+	entitled, err := s.repo.HasEntitlement(userId, bookId)
+	if err != nil {
+		return err
+	}
+	if !entitled {
+		return ErrNotInLibrary
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"bookstack/internal/messaging"
+	"context"
+)
+
+const (
+	defaultDeadLetterLimit = 50
+	maxDeadLetterLimit     = 500
+)
+
+// Lỗi quản trị message được dùng lại ở tầng controller
+var ErrUnknownQueue = messaging.ErrUnknownQueue
+
+// MessageService cho quản trị viên xem và gửi lại các message RabbitMQ nằm trong dead-letter queue
+type MessageService interface {
+	GetDeadLetters(queue string, limit int) ([]messaging.DeadLetter, error)
+	ReplayDeadLetters(queue string, ids []string) (int, error)
+}
+
+type MessageServiceImpl struct {
+	broker *messaging.Client
+}
+
+func NewMessageServiceImpl(broker *messaging.Client) MessageService {
+	return &MessageServiceImpl{
+		broker: broker,
+	}
+}
+
+func (s *MessageServiceImpl) GetDeadLetters(queue string, limit int) ([]messaging.DeadLetter, error) {
+	if limit <= 0 {
+		limit = defaultDeadLetterLimit
+	}
+	if limit > maxDeadLetterLimit {
+		limit = maxDeadLetterLimit
+	}
+	return s.broker.DeadLetters(queue, limit)
+}
+
+// ReplayDeadLetters gửi lại các message có ID trong ids (rỗng là tất cả) về queue gốc, trả về số message đã gửi
+func (s *MessageServiceImpl) ReplayDeadLetters(queue string, ids []string) (int, error) {
+	return s.broker.ReplayDeadLetters(context.Background(), queue, ids)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/models"
+	"bookstack/internal/notification"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// NotificationService đẩy sự kiện đơn hàng tới người dùng đang kết nối SSE/WebSocket. Sự kiện được phát
+// qua Redis pub/sub để mọi instance (service1, service2, nhiều bản chạy song song) cùng nhận và chuyển
+// tới các kết nối mà instance đó đang giữ.
+type NotificationService interface {
+	Notify(events ...notification.Event)
+	Subscribe(userId int) (<-chan notification.Event, func())
+	Listen(ctx context.Context)
+}
+
+type NotificationServiceImpl struct {
+	redis *redis.Client
+	hub   *notification.Hub
+	now   func() time.Time
+}
+
+func NewNotificationServiceImpl(redisClient *redis.Client) NotificationService {
+	return &NotificationServiceImpl{
+		redis: redisClient,
+		hub:   notification.NewHub(constant.NotificationBufferSize),
+		now:   time.Now,
+	}
+}
+
+// Notify phát sự kiện lên Redis. Lỗi phát chỉ được ghi log (thông báo không được làm hỏng nghiệp vụ);
+// khi Redis lỗi, sự kiện vẫn tới các kết nối trên instance hiện tại.
+func (s *NotificationServiceImpl) Notify(events ...notification.Event) {
+	for _, event := range events {
+		if event.CreatedAt.IsZero() {
+			event.CreatedAt = s.now()
+		}
+		payload, err := json.Marshal(event)
+		if err == nil {
+			err = s.redis.Publish(context.Background(), constant.NotificationChannel, payload).Err()
+		}
+		if err != nil {
+			logrus.Printf("Failed to publish %s notification for user %d: %v", event.Type, event.UserID, err)
+			s.hub.Deliver(event)
+		}
+	}
+}
+
+func (s *NotificationServiceImpl) Subscribe(userId int) (<-chan notification.Event, func()) {
+	return s.hub.Subscribe(uint(userId))
+}
+
+// Listen nhận sự kiện từ Redis và chuyển tới kết nối trên instance này cho tới khi ctx bị hủy
+func (s *NotificationServiceImpl) Listen(ctx context.Context) {
+	pubsub := s.redis.Subscribe(ctx, constant.NotificationChannel)
+	go func() {
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event notification.Event
+				if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+					logrus.Printf("Invalid notification payload: %v", err)
+					continue
+				}
+				s.hub.Deliver(event)
+			}
+		}
+	}()
+}
+
+// orderStatusEvents báo trạng thái mới của đơn cho người đặt và shipper của đơn
+func orderStatusEvents(order models.Order, reason string) []notification.Event {
+	event := notification.Event{
+		Type:    constant.EventOrderStatusChanged,
+		UserID:  order.UserID,
+		OrderID: order.ID,
+		Status:  order.Status.String(),
+		Reason:  reason,
+	}
+	events := []notification.Event{event}
+	if order.ShipperID != nil {
+		event.UserID = *order.ShipperID
+		event.ShipperID = *order.ShipperID
+		events[0].ShipperID = *order.ShipperID
+		events = append(events, event)
+	}
+	return events
+}
+
+// shipperAssignedEvents báo cho người đặt và shipper khi đơn có shipper
+func shipperAssignedEvents(order models.Order) []notification.Event {
+	if order.ShipperID == nil {
+		return nil
+	}
+	event := notification.Event{
+		Type:      constant.EventShipperAssigned,
+		UserID:    order.UserID,
+		OrderID:   order.ID,
+		ShipperID: *order.ShipperID,
+	}
+	shipperEvent := event
+	shipperEvent.UserID = *order.ShipperID
+	return []notification.Event{event, shipperEvent}
+}
@@ -2,8 +2,13 @@ package service
 
 import (
 	"bookstack/internal/constant"
+	"bookstack/internal/events"
+	"bookstack/internal/geo"
 	"bookstack/internal/models"
+	"bookstack/internal/notification"
 	"bookstack/internal/repository"
+	"fmt"
+	"time"
 )
 
 type ShipperOrderManageService interface {
@@ -13,21 +18,28 @@ type ShipperOrderManageService interface {
 	// Quản lý đơn hàng của shipper
 	AssignOrderToShipper(orderID uint, shipperID uint) error
 	GetOrdersByShipper(shipperID uint) ([]models.Order, error)
-	UpdateOrderStatus(orderID uint, status constant.OrderStatus) error
+	UpdateOrderStatus(orderID uint, status constant.OrderStatus, shipperID uint, reason string) (models.Order, error)
 	GetPendingOrders() ([]models.Order, error)
 	GetOrderInRange(string) ([]models.Order, error)
+	GetOrdersNear(center geo.Point, radiusKm float64) ([]models.Order, error)
 	// Shipper nhận đơn hàng
 	ReceiveOrder(orderId int, userId int) error
 	GetReceivedOrders(userId int) ([]models.Order, error)
+	// Shipper báo thời gian dự kiến giao tới cho người đặt
+	SetDeliveryETA(orderId uint, shipperId uint, eta time.Time) (models.Order, error)
 }
 
 type shipperOrderManageService struct {
-	ShipperRepository repository.ShipperRepository
+	ShipperRepository   repository.ShipperRepository
+	NotificationService NotificationService
+	Bus                 events.EventBus
 }
 
-func NewOrderManageService(shipperRepository repository.ShipperRepository) ShipperOrderManageService {
+func NewOrderManageService(shipperRepository repository.ShipperRepository, notificationService NotificationService, bus events.EventBus) ShipperOrderManageService {
 	return &shipperOrderManageService{
-		ShipperRepository: shipperRepository,
+		ShipperRepository:   shipperRepository,
+		NotificationService: notificationService,
+		Bus:                 bus,
 	}
 }
 
@@ -43,6 +55,10 @@ func (s *shipperOrderManageService) GetOrderInRange(place string) ([]models.Orde
 	return s.ShipperRepository.GetOrderInRange(place)
 }
 
+func (s *shipperOrderManageService) GetOrdersNear(center geo.Point, radiusKm float64) ([]models.Order, error) {
+	return s.ShipperRepository.GetOrdersNear(center, radiusKm)
+}
+
 // Quản lý shipper
 func (s *shipperOrderManageService) GetAllShipper(roleName string) ([]models.User, error) {
 	return s.ShipperRepository.GetAllShipper(roleName)
@@ -57,8 +73,37 @@ func (s *shipperOrderManageService) GetOrdersByShipper(shipperID uint) ([]models
 	return s.ShipperRepository.GetOrdersByShipper(shipperID)
 }
 
-func (s *shipperOrderManageService) UpdateOrderStatus(orderID uint, status constant.OrderStatus) error {
-	return s.ShipperRepository.UpdateOrderStatus(orderID, status)
+// UpdateOrderStatus cho shipper chuyển trạng thái đơn được giao cho mình. Giao xong hoặc giao thất bại
+// phải đi qua DeliveryService để kèm bằng chứng giao hàng.
+func (s *shipperOrderManageService) UpdateOrderStatus(orderID uint, status constant.OrderStatus, shipperID uint, reason string) (models.Order, error) {
+	if status == constant.Delivered || status == constant.Failed {
+		return models.Order{}, fmt.Errorf("%w: %s requires delivery proof, use the deliver or fail endpoint", ErrTransitionNotAllowed, status)
+	}
+	order, err := s.ShipperRepository.UpdateOrderStatus(orderID, status, repository.OrderActor{
+		UserID: shipperID,
+		Role:   constant.OrderActorShipper,
+	}, reason)
+	if err != nil {
+		return models.Order{}, err
+	}
+	s.NotificationService.Notify(orderStatusEvents(order, reason)...)
+	publishEvents(s.Bus, orderStatusDomainEvents(order, reason)...)
+	return order, nil
+}
+
+func (s *shipperOrderManageService) SetDeliveryETA(orderId uint, shipperId uint, eta time.Time) (models.Order, error) {
+	order, err := s.ShipperRepository.SetDeliveryETA(orderId, shipperId, eta)
+	if err != nil {
+		return models.Order{}, err
+	}
+	s.NotificationService.Notify(notification.Event{
+		Type:      constant.EventDeliveryETA,
+		UserID:    order.UserID,
+		OrderID:   order.ID,
+		ShipperID: shipperId,
+		ETA:       order.DeliveryETA,
+	})
+	return order, nil
 }
 
 func (s *shipperOrderManageService) GetPendingOrders() ([]models.Order, error) {
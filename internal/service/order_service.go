@@ -1,70 +1,186 @@
 package service
 
 import (
+	"bookstack/internal/constant"
 	"bookstack/internal/dto/request"
+	"bookstack/internal/events"
+	"bookstack/internal/geo"
 	"bookstack/internal/models"
 	"bookstack/internal/repository"
-	"context"
-	"strconv"
+	"fmt"
+	"strings"
 
-	"github.com/plutov/paypal/v4"
+	"gorm.io/gorm"
 )
 
 type OrderService interface {
 	CreateOrder(request.OrderRequest, int) (models.Order, error)
-	CancelOrder(int) error
+	QuoteOrder(request.OrderRequest, int) (models.Order, error)
+	CancelOrder(int, int, string) error
+	ChangeOrderStatus(int, int, request.OrderStatusRequest) (models.Order, error)
+	GetOrderTimeline(int, int) (models.Order, []models.OrderStatusHistory, error)
 	GetOrder(userID int) (models.Order, error)
 	GetUserOrder(orderId int) ([]models.Order, error)
-	CreatePaypalOrder(*paypal.Client, int) (*paypal.Order, error)
-	UpdateOrderStatus(webhookPayload map[string]interface{}) error
 }
 
+// Lỗi của đơn hàng được dùng lại ở tầng controller
+var (
+	ErrInvalidTransition    = repository.ErrInvalidTransition
+	ErrTransitionNotAllowed = repository.ErrTransitionNotAllowed
+	ErrCouponNotApplicable  = repository.ErrCouponNotApplicable
+	ErrCurrencyNotSupported = repository.ErrCurrencyNotSupported
+	ErrInvalidOrderLine     = repository.ErrInvalidOrderLine
+)
+
 type OrderServiceImpl struct {
-	repo repository.OrderRepository
+	repo           repository.OrderRepository
+	permissionRepo repository.EntityPermissionRepository
+	geocoder       *geo.Gazetteer
+	notifier       NotificationService
+	bus            events.EventBus
 }
 
-func NewOrderServiceImpl(repository repository.OrderRepository) OrderService {
+func NewOrderServiceImpl(repository repository.OrderRepository, permissionRepo repository.EntityPermissionRepository, geocoder *geo.Gazetteer, notifier NotificationService, bus events.EventBus) OrderService {
 	return &OrderServiceImpl{
-		repo: repository,
+		repo:           repository,
+		permissionRepo: permissionRepo,
+		geocoder:       geocoder,
+		notifier:       notifier,
+		bus:            bus,
 	}
 }
 
-func (o *OrderServiceImpl) UpdateOrderStatus(webhookPayload map[string]interface{}) error {
-	return o.repo.UpdateOrderStatus(webhookPayload)
+func (o *OrderServiceImpl) GetUserOrder(userId int) ([]models.Order, error) {
+	return o.repo.GetUserOrder(userId)
 }
 
-func (o *OrderServiceImpl) CreatePaypalOrder(c *paypal.Client, orderId int) (*paypal.Order, error) {
-	order, err := o.repo.GetOrder(orderId)
-	if err != nil {
-		return nil, err
+func (o *OrderServiceImpl) GetOrder(orderId int) (models.Order, error) {
+	return o.repo.GetOrder(orderId)
+}
+
+func (o *OrderServiceImpl) CreateOrder(request request.OrderRequest, userId int) (models.Order, error) {
+	o.locateAddress(&request)
+	return o.repo.CreateOrder(request, userId)
+}
+
+// QuoteOrder tính trước tổng tiền, giảm giá và phí vận chuyển của đơn hàng
+func (o *OrderServiceImpl) QuoteOrder(request request.OrderRequest, userId int) (models.Order, error) {
+	o.locateAddress(&request)
+	return o.repo.QuoteOrder(request, userId)
+}
+
+// locateAddress chuẩn hóa địa chỉ giao: dựng địa chỉ dạng chữ từ địa chỉ có cấu trúc và tra tọa độ trong
+// gazetteer khi khách không gửi kèm. Đơn chỉ có địa chỉ dạng chữ thì dò tên địa danh trong chuỗi.
+// Không tra được thì đơn vẫn được tạo, chỉ thiếu tọa độ.
+func (o *OrderServiceImpl) locateAddress(req *request.OrderRequest) {
+	location := req.Location
+	if location == nil {
+		if req.Address == "" {
+			return
+		}
+		place, ok := o.geocoder.GeocodeText(req.Address)
+		if !ok {
+			return
+		}
+		req.Location = &request.AddressRequest{
+			Province:  place.Province,
+			District:  place.District,
+			Ward:      place.Ward,
+			Lat:       &place.Point.Lat,
+			Lng:       &place.Point.Lng,
+			Precision: place.Precision(),
+		}
+		return
 	}
 
-	ord, err := c.CreateOrder(context.Background(), paypal.OrderIntentCapture, []paypal.PurchaseUnitRequest{
-		{
-			Amount: &paypal.PurchaseUnitAmount{
-				Currency: "USD",
-				Value:    strconv.FormatFloat(order.TotalPrice, 'f', -1, 64),
-			},
-		},
-	}, nil, nil)
-	if err != nil {
-		return nil, err
+	if req.Address == "" {
+		req.Address = formatAddress(location)
+	}
+	if location.Lat != nil && location.Lng != nil {
+		location.Precision = geo.PrecisionExact
+		return
+	}
+	location.Lat, location.Lng, location.Precision = nil, nil, ""
+	if place, ok := o.geocoder.Geocode(location.Province, location.District, location.Ward); ok {
+		location.Lat = &place.Point.Lat
+		location.Lng = &place.Point.Lng
+		location.Precision = place.Precision()
 	}
-	return ord, nil
 }
 
-func (o *OrderServiceImpl) GetUserOrder(userId int) ([]models.Order, error) {
-	return o.repo.GetUserOrder(userId)
+func formatAddress(location *request.AddressRequest) string {
+	var parts []string
+	for _, part := range []string{location.Street, location.Ward, location.District, location.Province} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
 }
 
-func (o *OrderServiceImpl) GetOrder(orderId int) (models.Order, error) {
-	return o.repo.GetOrder(orderId)
+func (o *OrderServiceImpl) CancelOrder(orderId int, userId int, reason string) error {
+	order, err := o.repo.TransitionOrder(uint(orderId), constant.Cancelled, repository.OrderActor{
+		UserID: uint(userId),
+		Role:   constant.OrderActorCustomer,
+	}, reason)
+	if err != nil {
+		return err
+	}
+	o.notifier.Notify(orderStatusEvents(order, reason)...)
+	publishEvents(o.bus, orderStatusDomainEvents(order, reason)...)
+	return nil
 }
 
-func (o *OrderServiceImpl) CreateOrder(request request.OrderRequest, userId int) (models.Order, error) {
-	return o.repo.CreateOrder(request, userId)
+// ChangeOrderStatus cho nhân viên (quyền manage:orders) chuyển trạng thái đơn theo bảng chuyển trạng thái
+func (o *OrderServiceImpl) ChangeOrderStatus(orderId int, userId int, request request.OrderStatusRequest) (models.Order, error) {
+	status, ok := constant.ParseOrderStatus(request.Status)
+	if !ok {
+		return models.Order{}, fmt.Errorf("%w: unknown status %q", ErrInvalidTransition, request.Status)
+	}
+	order, err := o.repo.TransitionOrder(uint(orderId), status, repository.OrderActor{
+		UserID: uint(userId),
+		Role:   constant.OrderActorStaff,
+	}, request.Reason)
+	if err != nil {
+		return models.Order{}, err
+	}
+	o.notifier.Notify(orderStatusEvents(order, request.Reason)...)
+	publishEvents(o.bus, orderStatusDomainEvents(order, request.Reason)...)
+	return order, nil
+}
+
+// GetOrderTimeline trả về lịch sử trạng thái của đơn cho người đặt, shipper được giao đơn hoặc nhân viên
+func (o *OrderServiceImpl) GetOrderTimeline(orderId int, userId int) (models.Order, []models.OrderStatusHistory, error) {
+	order, err := o.repo.GetOrder(orderId)
+	if err != nil {
+		return models.Order{}, nil, err
+	}
+	if order.ID == 0 {
+		return models.Order{}, nil, gorm.ErrRecordNotFound
+	}
+	if err := checkOrderAccess(o.permissionRepo, order, userId); err != nil {
+		return models.Order{}, nil, err
+	}
+	history, err := o.repo.GetStatusHistory(order.ID)
+	if err != nil {
+		return models.Order{}, nil, err
+	}
+	return order, history, nil
 }
 
-func (o *OrderServiceImpl) CancelOrder(orderId int) error {
-	return o.repo.CancelOrder(orderId)
+// checkOrderAccess chỉ cho người đặt, shipper được giao đơn hoặc nhân viên (quyền manage:orders) xem đơn
+func checkOrderAccess(permissionRepo repository.EntityPermissionRepository, order models.Order, userId int) error {
+	isOwner := userId != 0 && order.UserID == uint(userId)
+	isShipper := userId != 0 && order.ShipperID != nil && *order.ShipperID == uint(userId)
+	if isOwner || isShipper {
+		return nil
+	}
+	isStaff, err := permissionRepo.HasPermission(userId, constant.ManageOrders)
+	if err != nil {
+		return err
+	}
+	if !isStaff {
+		return ErrAccessDenied
+	}
+	return nil
 }
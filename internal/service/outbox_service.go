@@ -0,0 +1,76 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/events"
+	"bookstack/internal/models"
+	"bookstack/internal/repository"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OutboxService phát các sự kiện trong outbox lên bus sự kiện. Sự kiện được publish ít nhất một lần
+// (có thể trùng khi relay dừng giữa chừng), consumer loại trùng theo ID envelope.
+type OutboxService interface {
+	Relay(ctx context.Context) (int, error)
+	StartRelay(ctx context.Context)
+}
+
+type OutboxServiceImpl struct {
+	repo repository.MessageRepository
+	bus  events.EventBus
+	now  func() time.Time
+}
+
+func NewOutboxServiceImpl(repo repository.MessageRepository, bus events.EventBus) OutboxService {
+	return &OutboxServiceImpl{
+		repo: repo,
+		bus:  bus,
+		now:  time.Now,
+	}
+}
+
+// Relay publish một lượt các sự kiện tới hạn, trả về số sự kiện đã publish
+func (s *OutboxServiceImpl) Relay(ctx context.Context) (int, error) {
+	return s.repo.RelayOutbox(constant.OutboxBatchSize, s.now(), func(message models.OutboxMessage) error {
+		ctx, cancel := context.WithTimeout(ctx, constant.MessagePublishTimeout)
+		defer cancel()
+		err := s.bus.PublishEnvelope(ctx, message.Envelope())
+		if err != nil {
+			logrus.Printf("Failed to publish outbox message %s (%s): %v", message.EventID, message.Type, err)
+		}
+		return err
+	})
+}
+
+// StartRelay chạy relay theo chu kỳ cho tới khi ctx bị hủy. Lượt relay đầy batch thì chạy tiếp ngay để xả outbox.
+func (s *OutboxServiceImpl) StartRelay(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(constant.OutboxRelayInterval)
+		defer ticker.Stop()
+		purge := time.NewTicker(constant.OutboxPurgeInterval)
+		defer purge.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for {
+					published, err := s.Relay(ctx)
+					if err != nil {
+						logrus.Printf("Failed to relay outbox: %v", err)
+					}
+					if err != nil || published < constant.OutboxBatchSize {
+						break
+					}
+				}
+			case <-purge.C:
+				if err := s.repo.Purge(s.now().Add(-constant.OutboxRetention)); err != nil {
+					logrus.Printf("Failed to purge outbox: %v", err)
+				}
+			}
+		}
+	}()
+}
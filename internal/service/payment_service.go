@@ -0,0 +1,404 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/events"
+	"bookstack/internal/models"
+	"bookstack/internal/money"
+	"bookstack/internal/payment"
+	"bookstack/internal/paypalwebhook"
+	"bookstack/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Lỗi thanh toán được dùng lại ở tầng controller
+var (
+	ErrUnknownProvider     = payment.ErrUnknownProvider
+	ErrUnsupportedCurrency = payment.ErrUnsupportedCurrency
+	ErrPaymentNotAllowed   = repository.ErrPaymentNotAllowed
+	ErrRefundNotAllowed    = repository.ErrRefundNotAllowed
+	ErrInvalidSignature    = paypalwebhook.ErrInvalidSignature
+	ErrInvalidWebhookData  = paypalwebhook.ErrInvalidEvent
+)
+
+type PaymentService interface {
+	CreatePayment(int, int, request.PaymentRequest) (models.Payment, error)
+	GetOrderPayments(int, int) ([]models.Payment, error)
+	CapturePayment(int, int, int) (models.Payment, error)
+	RefundOrder(int, int, request.RefundRequest) (models.Refund, error)
+	GetRefunds(int) ([]models.Refund, error)
+	HandleWebhook(string, http.Header, []byte) error
+	Providers() []string
+}
+
+type PaymentServiceImpl struct {
+	repo           repository.PaymentRepository
+	orderRepo      repository.OrderRepository
+	permissionRepo repository.EntityPermissionRepository
+	providers      *payment.Registry
+	bus            events.EventBus
+}
+
+func NewPaymentServiceImpl(repo repository.PaymentRepository, orderRepo repository.OrderRepository, permissionRepo repository.EntityPermissionRepository, providers *payment.Registry, bus events.EventBus) PaymentService {
+	return &PaymentServiceImpl{
+		repo:           repo,
+		orderRepo:      orderRepo,
+		permissionRepo: permissionRepo,
+		providers:      providers,
+		bus:            bus,
+	}
+}
+
+func (s *PaymentServiceImpl) Providers() []string {
+	return s.providers.Names()
+}
+
+// CreatePayment tạo giao dịch thanh toán cho đơn của người dùng qua cổng thanh toán được chọn
+func (s *PaymentServiceImpl) CreatePayment(orderId int, userId int, request request.PaymentRequest) (models.Payment, error) {
+	provider, err := s.providers.Get(request.Provider)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	order, err := s.orderRepo.GetOrder(orderId)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if order.ID == 0 {
+		return models.Payment{}, gorm.ErrRecordNotFound
+	}
+	if order.UserID != uint(userId) {
+		return models.Payment{}, ErrAccessDenied
+	}
+	if order.Status != constant.Pending || order.PaymentStatus == constant.PaymentPaid {
+		return models.Payment{}, ErrPaymentNotAllowed
+	}
+
+	intent, err := provider.CreateIntent(context.Background(), payment.Intent{
+		OrderID:  order.ID,
+		Amount:   order.TotalPrice,
+		Currency: order.Currency,
+	})
+	if err != nil {
+		return models.Payment{}, err
+	}
+	return s.repo.CreatePayment(models.Payment{
+		OrderID:     order.ID,
+		Provider:    provider.Name(),
+		ExternalID:  intent.ExternalID,
+		Status:      intent.Status,
+		Amount:      order.TotalPrice,
+		Currency:    order.Currency,
+		ApprovalURL: intent.ApprovalURL,
+	})
+}
+
+// GetOrderPayments trả về các giao dịch của đơn cho người đặt, shipper được giao đơn hoặc nhân viên
+func (s *PaymentServiceImpl) GetOrderPayments(orderId int, userId int) ([]models.Payment, error) {
+	order, err := s.orderRepo.GetOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.ID == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err := checkOrderAccess(s.permissionRepo, order, userId); err != nil {
+		return nil, err
+	}
+	return s.repo.GetOrderPayments(order.ID)
+}
+
+// CapturePayment thu tiền giao dịch người mua đã chấp thuận trên cổng thanh toán và xác nhận đơn.
+// Giao dịch đã thu tiền được trả về nguyên trạng nên client có thể gọi lại an toàn.
+func (s *PaymentServiceImpl) CapturePayment(orderId int, paymentId int, userId int) (models.Payment, error) {
+	attempt, err := s.repo.GetPayment(uint(paymentId))
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if attempt.OrderID != uint(orderId) {
+		return models.Payment{}, gorm.ErrRecordNotFound
+	}
+	order, err := s.orderRepo.GetOrder(orderId)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if order.UserID != uint(userId) {
+		return models.Payment{}, ErrAccessDenied
+	}
+	switch attempt.Status {
+	case constant.PaymentPaid, constant.PaymentPartiallyRefunded, constant.PaymentRefunded:
+		return attempt, nil
+	case constant.PaymentPending:
+	default:
+		return models.Payment{}, fmt.Errorf("%w: payment is %s", ErrPaymentNotAllowed, attempt.Status)
+	}
+
+	provider, err := s.providers.Get(attempt.Provider)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	capture, err := provider.Capture(context.Background(), attempt.ExternalID)
+	if errors.Is(err, payment.ErrNotSupported) {
+		return models.Payment{}, fmt.Errorf("%w: %v", ErrPaymentNotAllowed, err)
+	}
+	if err != nil {
+		return models.Payment{}, err
+	}
+	event := repository.PaymentEvent{
+		EventType: payment.EventCaptured,
+		CaptureID: capture.CaptureID,
+		Amount:    capture.Amount,
+	}
+	switch capture.Status {
+	case constant.PaymentDenied:
+		event.EventType = payment.EventDenied
+	case constant.PaymentPending:
+		// Cổng thanh toán chưa hoàn tất việc thu tiền, kết quả sẽ đến qua webhook
+		return attempt, nil
+	}
+	captured, err := s.repo.CapturePayment(attempt.ID, event)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if captured.Status == constant.PaymentPaid {
+		publishEvents(s.bus, orderPaidEvent(captured, order.UserID))
+	}
+	return captured, nil
+}
+
+func orderPaidEvent(paid models.Payment, userId uint) events.OrderPaid {
+	return events.OrderPaid{
+		OrderID:   paid.OrderID,
+		UserID:    userId,
+		PaymentID: paid.ID,
+		Provider:  paid.Provider,
+		Amount:    paid.CapturedAmount,
+		Currency:  paid.Currency,
+	}
+}
+
+// RefundOrder hoàn tiền cho đơn hàng (nhân viên có quyền manage:payments). Không có dòng nào thì hoàn
+// toàn bộ số tiền còn lại; đơn đã giao được nhập lại kho và chuyển sang Returned khi mọi cuốn sách đã được trả.
+// Đơn đã hủy chỉ được hoàn toàn bộ và không nhập lại kho (hàng giữ cho đơn đã được trả khi hủy).
+// Cổng thanh toán từ chối thì lần hoàn tiền được ghi lại ở trạng thái failed.
+func (s *PaymentServiceImpl) RefundOrder(orderId int, userId int, request request.RefundRequest) (models.Refund, error) {
+	order, err := s.repo.GetRefundOrder(uint(orderId))
+	if err != nil {
+		return models.Refund{}, err
+	}
+	restock := true
+	switch order.Status {
+	case constant.Delivered, constant.Returned:
+	case constant.Cancelled:
+		if len(request.Lines) > 0 {
+			return models.Refund{}, fmt.Errorf("%w: cancelled orders can only be refunded in full", ErrRefundNotAllowed)
+		}
+		restock = false
+	default:
+		return models.Refund{}, fmt.Errorf("%w: order is %s, cancel it or wait for delivery first", ErrRefundNotAllowed, order.Status)
+	}
+
+	attempt, ok := refundablePayment(order.Payments)
+	if !ok {
+		return models.Refund{}, fmt.Errorf("%w: order has no captured payment", ErrRefundNotAllowed)
+	}
+	refund, err := PlanRefund(order, attempt, request.Lines)
+	if err != nil {
+		return models.Refund{}, err
+	}
+	if !restock {
+		refund.Lines = nil
+	}
+	refund.Reason = request.Reason
+	refund.CreatedBy = uint(userId)
+
+	provider, err := s.providers.Get(attempt.Provider)
+	if err != nil {
+		return models.Refund{}, err
+	}
+	// Giữ chỗ trước khi gọi cổng thanh toán để hai lần hoàn tiền đồng thời không cùng rút tiền
+	reserved, err := s.repo.ReserveRefund(refund)
+	if err != nil {
+		return models.Refund{}, err
+	}
+	result, err := provider.Refund(context.Background(), attempt.CaptureID, reserved.Amount, attempt.Currency)
+	if err != nil {
+		if failErr := s.repo.FailRefund(reserved.ID, err.Error()); failErr != nil {
+			logrus.Printf("Failed to release refund %d of order %d: %v", reserved.ID, orderId, failErr)
+		}
+		return models.Refund{}, err
+	}
+	completed, err := s.repo.CompleteRefund(reserved.ID, result.RefundID, restock)
+	if err != nil {
+		// Tiền đã được hoàn trên cổng thanh toán, refund vẫn ở trạng thái pending để đối soát
+		logrus.Printf("Refund %d of order %d succeeded at %s (%s) but could not be recorded: %v",
+			reserved.ID, orderId, attempt.Provider, result.RefundID, err)
+		return models.Refund{}, err
+	}
+	return completed, nil
+}
+
+func (s *PaymentServiceImpl) GetRefunds(orderId int) ([]models.Refund, error) {
+	return s.repo.GetRefunds(uint(orderId))
+}
+
+// refundablePayment chọn giao dịch đã thu tiền và còn tiền có thể hoàn (giao dịch mới nhất trước)
+func refundablePayment(payments []models.Payment) (models.Payment, bool) {
+	for i := len(payments) - 1; i >= 0; i-- {
+		attempt := payments[i]
+		if attempt.Status != constant.PaymentPaid && attempt.Status != constant.PaymentPartiallyRefunded {
+			continue
+		}
+		if attempt.CapturedAmount.GreaterThan(attempt.RefundedAmount) {
+			return attempt, true
+		}
+	}
+	return models.Payment{}, false
+}
+
+// PlanRefund tính số tiền hoàn cho các dòng được trả. Tiền của mỗi dòng là giá lúc đặt nhân số lượng,
+// trừ phần giảm giá tiền sách được chia theo tỉ lệ; tổng không vượt quá số tiền còn có thể hoàn.
+// Không có dòng nào thì hoàn toàn bộ số tiền còn lại (gồm cả phí vận chuyển) cho mọi cuốn chưa được trả.
+func PlanRefund(order models.Order, attempt models.Payment, lines []request.RefundLineRequest) (models.Refund, error) {
+	refundable, err := attempt.CapturedAmount.Sub(attempt.RefundedAmount)
+	if err != nil {
+		return models.Refund{}, err
+	}
+	if !refundable.IsPositive() {
+		return models.Refund{}, fmt.Errorf("%w: nothing left to refund", ErrRefundNotAllowed)
+	}
+	refund := models.Refund{OrderID: order.ID, PaymentID: attempt.ID}
+	itemsPaid, err := itemsPaidAmount(order)
+	if err != nil {
+		return models.Refund{}, err
+	}
+
+	known := make(map[uint]bool, len(order.OrderDetail))
+	for _, detail := range order.OrderDetail {
+		known[detail.ID] = true
+	}
+	requested := make(map[uint]int)
+	for _, line := range lines {
+		if !known[line.OrderDetailID] {
+			return models.Refund{}, fmt.Errorf("%w: order line %d not found", ErrRefundNotAllowed, line.OrderDetailID)
+		}
+		requested[line.OrderDetailID] += line.Quantity
+	}
+	for _, detail := range order.OrderDetail {
+		remaining := detail.Quantity - detail.RefundedQuantity
+		quantity := remaining
+		if len(lines) > 0 {
+			quantity = requested[detail.ID]
+		}
+		if quantity > remaining {
+			return models.Refund{}, fmt.Errorf("%w: only %d of order line %d can be refunded", ErrRefundNotAllowed, remaining, detail.ID)
+		}
+		if quantity <= 0 {
+			continue
+		}
+		amount, err := lineRefundAmount(order, detail, quantity, itemsPaid)
+		if err != nil {
+			return models.Refund{}, err
+		}
+		refund.Lines = append(refund.Lines, models.RefundLine{
+			OrderDetailID: detail.ID,
+			BookID:        detail.BookID,
+			Quantity:      quantity,
+			Amount:        amount,
+		})
+		if refund.Amount, err = refund.Amount.Add(amount); err != nil {
+			return models.Refund{}, err
+		}
+	}
+
+	if len(lines) == 0 || refund.Amount.GreaterThan(refundable) {
+		refund.Amount = refundable
+	}
+	if !refund.Amount.IsPositive() {
+		return models.Refund{}, fmt.Errorf("%w: nothing left to refund", ErrRefundNotAllowed)
+	}
+	return refund, nil
+}
+
+// lineRefundAmount là tiền hoàn cho quantity cuốn của một dòng: giá lúc đặt nhân số lượng, nhân tỉ lệ
+// itemsPaid / Subtotal rồi làm tròn theo tiền tệ của đơn
+func lineRefundAmount(order models.Order, detail models.OrderDetail, quantity int, itemsPaid money.Decimal) (money.Decimal, error) {
+	amount, err := detail.Price.MulInt(quantity)
+	if err != nil {
+		return money.Zero, err
+	}
+	if order.Subtotal.IsPositive() {
+		if amount, err = amount.Mul(itemsPaid); err != nil {
+			return money.Zero, err
+		}
+		if amount, err = amount.Div(order.Subtotal); err != nil {
+			return money.Zero, err
+		}
+	}
+	return amount.RoundCurrency(order.Currency)
+}
+
+// itemsPaidAmount là tiền sách khách thực trả sau các khoản giảm giá tiền sách (không tính miễn phí vận chuyển),
+// tiền hoàn của mỗi dòng được chia theo tỉ lệ itemsPaidAmount / Subtotal
+func itemsPaidAmount(order models.Order) (money.Decimal, error) {
+	paid := order.Subtotal
+	for _, discount := range order.Discounts {
+		if discount.Type != constant.CouponFreeShipping {
+			var err error
+			if paid, err = paid.Sub(discount.Amount); err != nil {
+				return money.Zero, err
+			}
+		}
+	}
+	return money.Max(paid, money.Zero), nil
+}
+
+// HandleWebhook xác thực webhook của cổng thanh toán rồi áp dụng sự kiện lên giao dịch và đơn hàng.
+// Sự kiện đã xử lý (cổng thanh toán gửi lại) và loại sự kiện không hỗ trợ được bỏ qua.
+func (s *PaymentServiceImpl) HandleWebhook(providerName string, header http.Header, body []byte) error {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return err
+	}
+	event, ok, err := provider.ParseWebhook(header, body)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		logrus.Infof("ignoring %s webhook event", providerName)
+		return nil
+	}
+	applied, processed, err := s.repo.ApplyPaymentEvent(repository.PaymentEvent{
+		Provider:   provider.Name(),
+		EventID:    event.ID,
+		EventType:  event.Type,
+		ExternalID: event.ExternalID,
+		CaptureID:  event.CaptureID,
+		RefundID:   event.RefundID,
+		Amount:     event.Amount,
+	})
+	if err != nil {
+		return err
+	}
+	switch {
+	case !processed:
+		logrus.Infof("%s webhook event %s already processed", providerName, event.ID)
+	case applied.ID == 0:
+		logrus.Warnf("%s webhook event %s does not match any payment (external id %q, capture %q)",
+			providerName, event.ID, event.ExternalID, event.CaptureID)
+	case event.Type == payment.EventCaptured && applied.Status == constant.PaymentPaid:
+		order, err := s.orderRepo.GetOrder(int(applied.OrderID))
+		if err != nil {
+			logrus.Printf("Failed to load order %d for paid event: %v", applied.OrderID, err)
+			break
+		}
+		publishEvents(s.bus, orderPaidEvent(applied, order.UserID))
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/models"
+	"bookstack/internal/money"
+	"bookstack/internal/payment"
+	"bookstack/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func refundTestOrder() (models.Order, models.Payment) {
+	order := models.Order{
+		Subtotal:      money.NewFromInt(50),
+		DiscountTotal: money.NewFromInt(13),
+		ShippingFee:   money.NewFromInt(3),
+		TotalPrice:    money.NewFromInt(40),
+		Discounts: []models.OrderDiscount{
+			{Type: constant.CouponPercentage, Amount: money.NewFromInt(10)},
+			{Type: constant.CouponFreeShipping, Amount: money.NewFromInt(3)},
+		},
+		OrderDetail: []models.OrderDetail{
+			{Model: gorm.Model{ID: 1}, BookID: 100, Quantity: 2, Price: money.NewFromInt(15)},
+			{Model: gorm.Model{ID: 2}, BookID: 200, Quantity: 1, Price: money.NewFromInt(20), RefundedQuantity: 1},
+		},
+	}
+	order.ID = 9
+	order.Currency = constant.BaseCurrency
+	attempt := models.Payment{Amount: money.NewFromInt(40), CapturedAmount: money.NewFromInt(40), RefundedAmount: money.NewFromInt(16), Status: constant.PaymentPartiallyRefunded}
+	attempt.ID = 4
+	return order, attempt
+}
+
+func TestPlanRefundLines(t *testing.T) {
+	order, attempt := refundTestOrder()
+
+	refund, err := PlanRefund(order, attempt, []request.RefundLineRequest{{OrderDetailID: 1, Quantity: 1}})
+	require.NoError(t, err)
+	// 15 * (50 - 10) / 50 = 12, phí vận chuyển được miễn không làm giảm tiền sách
+	assert.Equal(t, money.NewFromInt(12), refund.Amount)
+	require.Len(t, refund.Lines, 1)
+	assert.Equal(t, models.RefundLine{OrderDetailID: 1, BookID: 100, Quantity: 1, Amount: money.NewFromInt(12)}, refund.Lines[0])
+
+	_, err = PlanRefund(order, attempt, []request.RefundLineRequest{{OrderDetailID: 2, Quantity: 1}})
+	assert.ErrorIs(t, err, ErrRefundNotAllowed, "line already fully refunded")
+
+	_, err = PlanRefund(order, attempt, []request.RefundLineRequest{{OrderDetailID: 1, Quantity: 2}, {OrderDetailID: 1, Quantity: 1}})
+	assert.ErrorIs(t, err, ErrRefundNotAllowed, "duplicate lines are added up")
+
+	_, err = PlanRefund(order, attempt, []request.RefundLineRequest{{OrderDetailID: 3, Quantity: 1}})
+	assert.ErrorIs(t, err, ErrRefundNotAllowed)
+}
+
+func TestPlanRefundFull(t *testing.T) {
+	order, attempt := refundTestOrder()
+
+	refund, err := PlanRefund(order, attempt, nil)
+	require.NoError(t, err)
+	assert.Equal(t, money.NewFromInt(24), refund.Amount, "full refund returns everything not yet refunded")
+	require.Len(t, refund.Lines, 1)
+	assert.Equal(t, 2, refund.Lines[0].Quantity)
+
+	attempt.RefundedAmount = money.NewFromInt(40)
+	_, err = PlanRefund(order, attempt, nil)
+	assert.ErrorIs(t, err, ErrRefundNotAllowed)
+}
+
+func TestRefundablePayment(t *testing.T) {
+	payments := []models.Payment{
+		{Status: constant.PaymentPaid, CapturedAmount: money.NewFromInt(10)},
+		{Status: constant.PaymentCancelled, Amount: money.NewFromInt(10)},
+		{Status: constant.PaymentRefunded, CapturedAmount: money.NewFromInt(10), RefundedAmount: money.NewFromInt(10)},
+	}
+	attempt, ok := refundablePayment(payments)
+	assert.True(t, ok)
+	assert.Equal(t, constant.PaymentPaid, attempt.Status)
+
+	_, ok = refundablePayment(payments[1:])
+	assert.False(t, ok)
+}
+
+func TestPlanRefundRoundsToCurrency(t *testing.T) {
+	order := models.Order{
+		Currency: "VND",
+		Subtotal: money.NewFromInt(300000),
+		Discounts: []models.OrderDiscount{
+			{Type: constant.CouponFixedAmount, Amount: money.NewFromInt(100000)},
+		},
+		OrderDetail: []models.OrderDetail{
+			{Model: gorm.Model{ID: 1}, BookID: 100, Quantity: 3, Price: money.NewFromInt(100000)},
+		},
+	}
+	attempt := models.Payment{CapturedAmount: money.NewFromInt(230000), Currency: "VND"}
+
+	refund, err := PlanRefund(order, attempt, []request.RefundLineRequest{{OrderDetailID: 1, Quantity: 1}})
+	require.NoError(t, err)
+	// 100000 * 200000 / 300000 = 66666.67 làm tròn tới đồng
+	assert.Equal(t, "66667", refund.Amount.String())
+}
+
+// refundRepository giả lập các bước giữ chỗ và ghi nhận hoàn tiền, ghi lại thứ tự các lời gọi
+type refundRepository struct {
+	repository.PaymentRepository
+	order      models.Order
+	reserveErr error
+	calls      []string
+	failReason string
+}
+
+func (r *refundRepository) GetRefundOrder(orderId uint) (models.Order, error) {
+	return r.order, nil
+}
+
+func (r *refundRepository) ReserveRefund(refund models.Refund) (models.Refund, error) {
+	r.calls = append(r.calls, "reserve")
+	if r.reserveErr != nil {
+		return models.Refund{}, r.reserveErr
+	}
+	refund.ID = 7
+	refund.Status = constant.RefundPending
+	return refund, nil
+}
+
+func (r *refundRepository) CompleteRefund(refundId uint, externalId string, restock bool) (models.Refund, error) {
+	r.calls = append(r.calls, "complete")
+	return models.Refund{Model: gorm.Model{ID: refundId}, ExternalID: externalId, Status: constant.RefundCompleted}, nil
+}
+
+func (r *refundRepository) FailRefund(refundId uint, reason string) error {
+	r.calls = append(r.calls, "fail")
+	r.failReason = reason
+	return nil
+}
+
+func TestRefundOrderReservesBeforeCallingProvider(t *testing.T) {
+	setup := func(t *testing.T) (*refundRepository, *payment.FakeProvider, PaymentService, string) {
+		provider := payment.NewFakeProvider()
+		intent, err := provider.CreateIntent(context.Background(), payment.Intent{Amount: money.NewFromInt(40), Currency: constant.BaseCurrency})
+		require.NoError(t, err)
+		capture, err := provider.Capture(context.Background(), intent.ExternalID)
+		require.NoError(t, err)
+
+		order, attempt := refundTestOrder()
+		order.Status = constant.Delivered
+		attempt.Provider = constant.PaymentProviderFake
+		attempt.CaptureID = capture.CaptureID
+		order.Payments = []models.Payment{attempt}
+		repo := &refundRepository{order: order}
+		return repo, provider, NewPaymentServiceImpl(repo, nil, nil, payment.NewRegistry(provider), nil), capture.CaptureID
+	}
+	lines := request.RefundRequest{Lines: []request.RefundLineRequest{{OrderDetailID: 1, Quantity: 1}}}
+
+	t.Run("provider refunds a reserved refund", func(t *testing.T) {
+		repo, provider, service, captureID := setup(t)
+		refund, err := service.RefundOrder(9, 1, lines)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"reserve", "complete"}, repo.calls)
+		assert.Equal(t, constant.RefundCompleted, refund.Status)
+		assert.True(t, provider.Refunded(captureID).IsPositive())
+	})
+
+	t.Run("rejected reservation never reaches the provider", func(t *testing.T) {
+		repo, provider, service, captureID := setup(t)
+		repo.reserveErr = fmt.Errorf("%w: refund exceeds captured amount", ErrRefundNotAllowed)
+		_, err := service.RefundOrder(9, 1, lines)
+		assert.ErrorIs(t, err, ErrRefundNotAllowed)
+		assert.Equal(t, []string{"reserve"}, repo.calls)
+		assert.True(t, provider.Refunded(captureID).IsZero())
+	})
+
+	t.Run("provider failure releases the reservation", func(t *testing.T) {
+		repo, provider, service, _ := setup(t)
+		provider.FailNext = errors.New("gateway unavailable")
+		_, err := service.RefundOrder(9, 1, lines)
+		assert.EqualError(t, err, "gateway unavailable")
+		assert.Equal(t, []string{"reserve", "fail"}, repo.calls)
+		assert.Equal(t, "gateway unavailable", repo.failReason)
+	})
+}
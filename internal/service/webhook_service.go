@@ -0,0 +1,293 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/events"
+	"bookstack/internal/messaging"
+	"bookstack/internal/models"
+	"bookstack/internal/repository"
+	"bookstack/internal/webhook"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultWebhookDeliveryLimit = 50
+	maxWebhookDeliveryLimit     = 500
+	minWebhookSecretLength      = 16
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook")
+)
+
+// WebhookService quản lý webhook của đối tác và giao sự kiện nghiệp vụ tới đó. Sự kiện nhận từ bus được
+// ghi thành lần giao cho từng subscription, worker gửi kèm chữ ký HMAC và gửi lại theo backoff khi endpoint lỗi.
+type WebhookService interface {
+	CreateSubscription(userId int, request request.WebhookSubscriptionRequest) (models.WebhookSubscription, error)
+	UpdateSubscription(subscriptionId uint, request request.WebhookSubscriptionRequest) (models.WebhookSubscription, error)
+	DeleteSubscription(subscriptionId uint) error
+	GetSubscription(subscriptionId uint) (models.WebhookSubscription, error)
+	GetSubscriptions() ([]models.WebhookSubscription, error)
+	GetDeliveries(subscriptionId uint, limit int) ([]models.WebhookDelivery, error)
+	SendTestEvent(subscriptionId uint) (models.WebhookDelivery, error)
+	Redeliver(subscriptionId uint, deliveryId uint) (models.WebhookDelivery, error)
+	HandleEvent(ctx context.Context, env messaging.Envelope) error
+	DeliverDue() (int, error)
+	StartDelivering(ctx context.Context)
+}
+
+type WebhookServiceImpl struct {
+	repo   repository.WebhookRepository
+	bus    events.EventBus
+	sender *webhook.Sender
+	policy messaging.RetryPolicy
+	now    func() time.Time
+}
+
+func NewWebhookServiceImpl(repo repository.WebhookRepository, bus events.EventBus) WebhookService {
+	return &WebhookServiceImpl{
+		repo:   repo,
+		bus:    bus,
+		sender: webhook.NewSender(constant.WebhookTimeout),
+		policy: messaging.RetryPolicy{
+			MaxAttempts: constant.WebhookMaxAttempts,
+			BaseDelay:   constant.WebhookBaseBackoff,
+			MaxDelay:    constant.WebhookMaxBackoff,
+		},
+		now: time.Now,
+	}
+}
+
+func (s *WebhookServiceImpl) CreateSubscription(userId int, request request.WebhookSubscriptionRequest) (models.WebhookSubscription, error) {
+	subscription := models.WebhookSubscription{CreatedBy: uint(userId), Active: true}
+	if err := applyWebhookRequest(&subscription, request); err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	if subscription.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			return models.WebhookSubscription{}, err
+		}
+		subscription.Secret = secret
+	}
+	return s.repo.CreateSubscription(subscription)
+}
+
+func (s *WebhookServiceImpl) UpdateSubscription(subscriptionId uint, request request.WebhookSubscriptionRequest) (models.WebhookSubscription, error) {
+	subscription, err := s.GetSubscription(subscriptionId)
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	if err := applyWebhookRequest(&subscription, request); err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	return s.repo.UpdateSubscription(subscription)
+}
+
+func (s *WebhookServiceImpl) DeleteSubscription(subscriptionId uint) error {
+	if _, err := s.GetSubscription(subscriptionId); err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(subscriptionId)
+}
+
+func (s *WebhookServiceImpl) GetSubscription(subscriptionId uint) (models.WebhookSubscription, error) {
+	subscription, err := s.repo.GetSubscription(subscriptionId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.WebhookSubscription{}, ErrWebhookNotFound
+	}
+	return subscription, err
+}
+
+func (s *WebhookServiceImpl) GetSubscriptions() ([]models.WebhookSubscription, error) {
+	return s.repo.GetSubscriptions()
+}
+
+func (s *WebhookServiceImpl) GetDeliveries(subscriptionId uint, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetSubscription(subscriptionId); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultWebhookDeliveryLimit
+	}
+	if limit > maxWebhookDeliveryLimit {
+		limit = maxWebhookDeliveryLimit
+	}
+	return s.repo.GetDeliveries(subscriptionId, limit)
+}
+
+// SendTestEvent gửi ngay sự kiện "webhook.test" tới subscription (kể cả khi đang tắt) để đối tác kiểm tra
+// endpoint và cách xác thực chữ ký. Lần giao lỗi được gửi lại theo backoff như sự kiện thật.
+func (s *WebhookServiceImpl) SendTestEvent(subscriptionId uint) (models.WebhookDelivery, error) {
+	subscription, err := s.GetSubscription(subscriptionId)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	env, err := messaging.NewEnvelope(constant.WebhookTestEvent, 1, map[string]any{
+		"subscription_id": subscription.ID,
+		"message":         "This is a test event from Bookstack",
+	})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	body, err := json.Marshal(env)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	delivery, err := s.repo.CreateDelivery(models.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        env.ID,
+		EventType:      env.Type,
+		Payload:        string(body),
+		Status:         constant.WebhookDeliveryPending,
+		NextAttemptAt:  s.now(),
+	})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return s.repo.Deliver(delivery.ID, s.send)
+}
+
+// Redeliver gửi lại ngay một lần giao của subscription với số lần thử được đặt lại
+func (s *WebhookServiceImpl) Redeliver(subscriptionId uint, deliveryId uint) (models.WebhookDelivery, error) {
+	delivery, err := s.repo.GetDelivery(deliveryId)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && delivery.SubscriptionID != subscriptionId) {
+		return models.WebhookDelivery{}, fmt.Errorf("%w: delivery %d", ErrWebhookNotFound, deliveryId)
+	}
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	if err := s.repo.Redeliver(deliveryId, s.now()); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return s.repo.Deliver(deliveryId, s.send)
+}
+
+// HandleEvent ghi sự kiện nhận từ bus thành các lần giao, gửi thật do DeliverDue đảm nhận
+func (s *WebhookServiceImpl) HandleEvent(ctx context.Context, env messaging.Envelope) error {
+	env.Attempt = 0
+	body, err := json.Marshal(env)
+	if err != nil {
+		return messaging.Permanent(err)
+	}
+	_, err = s.repo.EnqueueDeliveries(env.ID, env.Type, string(body), s.now())
+	return err
+}
+
+// DeliverDue gửi một lượt các lần giao tới hạn, trả về số lần đã gửi
+func (s *WebhookServiceImpl) DeliverDue() (int, error) {
+	return s.repo.DeliverDue(constant.WebhookBatchSize, s.now(), s.send)
+}
+
+// StartDelivering nhận mọi sự kiện trong danh mục qua queue webhooks và chạy worker gửi theo chu kỳ cho tới khi
+// ctx bị hủy. Lượt gửi đầy batch thì chạy tiếp ngay.
+func (s *WebhookServiceImpl) StartDelivering(ctx context.Context) {
+	s.bus.Subscribe(ctx, messaging.QueueWebhooks, s.HandleEvent, events.Types()...)
+	go func() {
+		ticker := time.NewTicker(constant.WebhookDeliveryInterval)
+		defer ticker.Stop()
+		purge := time.NewTicker(constant.WebhookPurgeInterval)
+		defer purge.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for {
+					sent, err := s.DeliverDue()
+					if err != nil {
+						logrus.Printf("Failed to deliver webhooks: %v", err)
+					}
+					if err != nil || sent < constant.WebhookBatchSize {
+						break
+					}
+				}
+			case <-purge.C:
+				if err := s.repo.Purge(s.now().Add(-constant.WebhookRetention)); err != nil {
+					logrus.Printf("Failed to purge webhook deliveries: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// send gửi lần giao tới endpoint của subscription và ghi kết quả
+func (s *WebhookServiceImpl) send(subscription models.WebhookSubscription, delivery *models.WebhookDelivery) {
+	response, err := s.sender.Send(context.Background(), webhook.Request{
+		URL:        subscription.URL,
+		Secret:     subscription.Secret,
+		EventType:  delivery.EventType,
+		DeliveryID: fmt.Sprint(delivery.ID),
+		Body:       []byte(delivery.Payload),
+	})
+	if err != nil {
+		logrus.Printf("Failed to deliver webhook %d (%s) to %s: %v", delivery.ID, delivery.EventType, subscription.URL, err)
+	}
+	RecordWebhookAttempt(delivery, response, err, s.policy, s.now())
+}
+
+// RecordWebhookAttempt ghi kết quả một lần gửi vào delivery: thành công thì xong, lỗi thì hẹn gửi lại theo
+// policy hoặc đánh dấu thất bại khi đã hết số lần thử
+func RecordWebhookAttempt(delivery *models.WebhookDelivery, response webhook.Response, err error, policy messaging.RetryPolicy, now time.Time) {
+	delivery.Attempts++
+	delivery.ResponseCode = response.StatusCode
+	delivery.ResponseBody = response.Body
+	delivery.DurationMs = response.Duration.Milliseconds()
+	if err == nil {
+		delivery.Status = constant.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= policy.MaxAttempts {
+		delivery.Status = constant.WebhookDeliveryFailed
+		return
+	}
+	delivery.NextAttemptAt = now.Add(policy.Backoff(delivery.Attempts))
+}
+
+// applyWebhookRequest kiểm tra request rồi gán vào subscription
+func applyWebhookRequest(subscription *models.WebhookSubscription, request request.WebhookSubscriptionRequest) error {
+	endpoint, err := url.Parse(request.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	var eventTypes []string
+	seen := map[string]bool{}
+	for _, eventType := range request.EventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if seen[eventType] {
+			continue
+		}
+		if _, ok := events.Lookup(eventType); !ok && eventType != "*" {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, eventType)
+		}
+		seen[eventType] = true
+		eventTypes = append(eventTypes, eventType)
+	}
+	if request.Secret != "" {
+		if len(request.Secret) < minWebhookSecretLength {
+			return fmt.Errorf("%w: secret must be at least %d characters", ErrInvalidWebhook, minWebhookSecretLength)
+		}
+		subscription.Secret = request.Secret
+	}
+	subscription.URL = request.URL
+	subscription.EventTypes = strings.Join(eventTypes, ",")
+	subscription.Description = request.Description
+	if request.Active != nil {
+		subscription.Active = *request.Active
+	}
+	return nil
+}
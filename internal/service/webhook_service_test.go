@@ -0,0 +1,64 @@
+package service
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/dto/request"
+	"bookstack/internal/messaging"
+	"bookstack/internal/models"
+	"bookstack/internal/webhook"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordWebhookAttempt(t *testing.T) {
+	policy := messaging.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	delivery := models.WebhookDelivery{Status: constant.WebhookDeliveryPending}
+
+	RecordWebhookAttempt(&delivery, webhook.Response{StatusCode: 500, Body: "boom"}, errors.New("500"), policy, now)
+	assert.Equal(t, constant.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, 500, delivery.ResponseCode)
+	assert.Equal(t, now.Add(time.Minute), delivery.NextAttemptAt)
+
+	RecordWebhookAttempt(&delivery, webhook.Response{}, errors.New("timeout"), policy, now)
+	assert.Equal(t, now.Add(2*time.Minute), delivery.NextAttemptAt)
+	assert.Zero(t, delivery.ResponseCode)
+
+	RecordWebhookAttempt(&delivery, webhook.Response{}, errors.New("timeout"), policy, now)
+	assert.Equal(t, constant.WebhookDeliveryFailed, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+
+	delivery = models.WebhookDelivery{Status: constant.WebhookDeliveryPending, LastError: "timeout"}
+	RecordWebhookAttempt(&delivery, webhook.Response{StatusCode: 204, Duration: 120 * time.Millisecond}, nil, policy, now)
+	assert.Equal(t, constant.WebhookDeliverySucceeded, delivery.Status)
+	assert.Empty(t, delivery.LastError)
+	assert.Equal(t, int64(120), delivery.DurationMs)
+	require.NotNil(t, delivery.DeliveredAt)
+}
+
+func TestApplyWebhookRequest(t *testing.T) {
+	subscription := models.WebhookSubscription{Active: true, Secret: "existing-secret-value"}
+	inactive := false
+	err := applyWebhookRequest(&subscription, request.WebhookSubscriptionRequest{
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: []string{"order.paid", "order.shipped", "order.paid"},
+		Active:     &inactive,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "order.paid,order.shipped", subscription.EventTypes)
+	assert.Equal(t, "existing-secret-value", subscription.Secret)
+	assert.False(t, subscription.Active)
+	assert.True(t, subscription.Subscribes("order.shipped"))
+	assert.False(t, subscription.Subscribes("book.published"))
+
+	err = applyWebhookRequest(&subscription, request.WebhookSubscriptionRequest{URL: "https://a.example.com", EventTypes: []string{"order.unknown"}})
+	assert.ErrorIs(t, err, ErrInvalidWebhook)
+	err = applyWebhookRequest(&subscription, request.WebhookSubscriptionRequest{URL: "ftp://a.example.com", EventTypes: []string{"*"}})
+	assert.ErrorIs(t, err, ErrInvalidWebhook)
+	err = applyWebhookRequest(&subscription, request.WebhookSubscriptionRequest{URL: "https://a.example.com", EventTypes: []string{"*"}, Secret: "short"})
+	assert.ErrorIs(t, err, ErrInvalidWebhook)
+}
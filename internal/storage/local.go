@@ -0,0 +1,61 @@
+// Package storage lưu file người dùng tải lên (ảnh giao hàng, chữ ký) trên đĩa cục bộ.
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// LocalStore lưu file dưới thư mục gốc, mỗi file được định danh bằng khóa là đường dẫn tương đối
+type LocalStore struct {
+	dir string
+}
+
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{dir: dir}
+}
+
+// Save ghi file theo khóa, tạo thư mục con nếu cần
+func (s *LocalStore) Save(key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o640)
+}
+
+func (s *LocalStore) Open(key string) (*os.File, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Remove xóa file, file không tồn tại không phải lỗi
+func (s *LocalStore) Remove(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path chuyển khóa thành đường dẫn, từ chối khóa trỏ ra ngoài thư mục gốc
+func (s *LocalStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, clean), nil
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	store := NewLocalStore(t.TempDir())
+
+	require.NoError(t, store.Save("orders/1/photo.png", []byte("png")))
+	file, err := store.Open("orders/1/photo.png")
+	require.NoError(t, err)
+	data, err := io.ReadAll(file)
+	file.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(data))
+
+	require.NoError(t, store.Remove("orders/1/photo.png"))
+	require.NoError(t, store.Remove("orders/1/photo.png"))
+	_, err = store.Open("orders/1/photo.png")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	for _, key := range []string{"", "../secret", "/etc/passwd", "orders/../../secret"} {
+		assert.ErrorIs(t, store.Save(key, nil), ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,140 @@
+// Package webhook gửi sự kiện tới webhook của hệ thống đối tác và ký nội dung để bên nhận xác thực.
+//
+// Chữ ký là HMAC-SHA256 của chuỗi "<timestamp>.<body>" với secret của subscription, gửi trong header
+// X-Bookstack-Signature dạng "sha256=<hex>". Timestamp (giây Unix) được ký cùng để bên nhận từ chối
+// request bị phát lại sau thời hạn.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Bookstack-Event"     // Loại sự kiện, "webhook.test" với sự kiện thử
+	HeaderDelivery  = "X-Bookstack-Delivery"  // ID lần giao, giữ nguyên qua các lần gửi lại
+	HeaderTimestamp = "X-Bookstack-Timestamp" // Thời điểm gửi (giây Unix)
+	HeaderSignature = "X-Bookstack-Signature" // "sha256=" + HMAC-SHA256 hex của "<timestamp>.<body>"
+
+	signaturePrefix = "sha256="
+	userAgent       = "Bookstack-Webhook/1.0"
+)
+
+// Số byte tối đa của response được giữ lại trong nhật ký giao
+const MaxResponseBody = 1024
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpired          = errors.New("webhook timestamp outside the tolerance")
+	ErrUnexpectedStatus = errors.New("webhook endpoint returned a non-2xx status")
+)
+
+// Sign trả về giá trị header X-Bookstack-Signature cho body gửi lúc timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify kiểm tra chữ ký của request nhận được, timestamp lệch quá tolerance so với now thì bị từ chối.
+// Bên nhận dùng hàm này (hoặc cách tính tương đương) để xác thực webhook.
+func Verify(secret string, header http.Header, body []byte, now time.Time, tolerance time.Duration) error {
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing timestamp", ErrInvalidSignature)
+	}
+	if diff := now.Sub(time.Unix(timestamp, 0)); diff > tolerance || diff < -tolerance {
+		return ErrExpired
+	}
+	signature := header.Get(HeaderSignature)
+	if !strings.HasPrefix(signature, signaturePrefix) ||
+		!hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// NewSecret sinh secret ngẫu nhiên cho subscription không tự đặt secret
+func NewSecret() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// Request là một lần gửi webhook
+type Request struct {
+	URL        string
+	Secret     string
+	EventType  string
+	DeliveryID string
+	Body       []byte
+}
+
+// Response là kết quả một lần gửi. StatusCode là 0 khi không nhận được response (lỗi mạng, hết thời gian chờ).
+type Response struct {
+	StatusCode int
+	Body       string // Tối đa MaxResponseBody byte đầu của response
+	Duration   time.Duration
+}
+
+// Sender gửi webhook qua HTTP POST, không tự thử lại; việc thử lại do nơi gọi hẹn lịch
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{
+		client: &http.Client{
+			Timeout: timeout,
+			// Không theo redirect để body đã ký không bị gửi tới địa chỉ khác
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		now: time.Now,
+	}
+}
+
+// Send gửi request, lỗi khi không gửi được hoặc endpoint trả mã ngoài 2xx (ErrUnexpectedStatus)
+func (s *Sender) Send(ctx context.Context, request Request) (Response, error) {
+	timestamp := s.now().Unix()
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, request.URL, bytes.NewReader(request.Body))
+	if err != nil {
+		return Response{}, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("User-Agent", userAgent)
+	httpRequest.Header.Set(HeaderEvent, request.EventType)
+	httpRequest.Header.Set(HeaderDelivery, request.DeliveryID)
+	httpRequest.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpRequest.Header.Set(HeaderSignature, Sign(request.Secret, timestamp, request.Body))
+
+	started := time.Now()
+	httpResponse, err := s.client.Do(httpRequest)
+	response := Response{Duration: time.Since(started)}
+	if err != nil {
+		return response, err
+	}
+	defer httpResponse.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(httpResponse.Body, MaxResponseBody))
+	response.StatusCode = httpResponse.StatusCode
+	response.Body = string(body)
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode > 299 {
+		return response, fmt.Errorf("%w: %d", ErrUnexpectedStatus, httpResponse.StatusCode)
+	}
+	return response, nil
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "whsec_test_secret"
+
+func TestSendSignedRequest(t *testing.T) {
+	received := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "order.paid", r.Header.Get(HeaderEvent))
+		assert.Equal(t, "42", r.Header.Get(HeaderDelivery))
+		received <- Verify(testSecret, r.Header, body, time.Now(), time.Minute)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	response, err := NewSender(time.Second).Send(context.Background(), Request{
+		URL:        server.URL,
+		Secret:     testSecret,
+		EventType:  "order.paid",
+		DeliveryID: "42",
+		Body:       []byte(`{"id":"abc","type":"order.paid"}`),
+	})
+	require.NoError(t, err)
+	require.NoError(t, <-received)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "ok", response.Body)
+}
+
+func TestSendNon2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "try later", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	response, err := NewSender(time.Second).Send(context.Background(), Request{URL: server.URL, Secret: testSecret, Body: []byte(`{}`)})
+	assert.ErrorIs(t, err, ErrUnexpectedStatus)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, "try later\n", response.Body)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"abc"}`)
+	header := http.Header{}
+	header.Set(HeaderTimestamp, "1700000000")
+	header.Set(HeaderSignature, Sign(testSecret, now.Unix(), body))
+
+	assert.NoError(t, Verify(testSecret, header, body, now, time.Minute))
+	assert.ErrorIs(t, Verify("other-secret", header, body, now, time.Minute), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(testSecret, header, []byte(`{"id":"abd"}`), now, time.Minute), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(testSecret, header, body, now.Add(2*time.Minute), time.Minute), ErrExpired)
+
+	header.Del(HeaderTimestamp)
+	assert.ErrorIs(t, Verify(testSecret, header, body, now, time.Minute), ErrInvalidSignature)
+}
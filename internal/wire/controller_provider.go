@@ -12,4 +12,14 @@ var ControllerSet = wire.NewSet(
 	controller.NewBookController,
 	controller.NewOrderController,
 	controller.NewShipperController,
+	controller.NewInventoryController,
+	controller.NewCartController,
+	controller.NewCouponController,
+	controller.NewCurrencyController,
+	controller.NewPaymentController,
+	controller.NewLibraryController,
+	controller.NewNotificationController,
+	controller.NewMessageController,
+	controller.NewEventController,
+	controller.NewWebhookController,
 )
@@ -7,6 +7,7 @@ import (
 	"bookstack/config"
 	"bookstack/internal/controller"
 	"bookstack/internal/middleware"
+	"bookstack/internal/payment"
 
 	"github.com/google/wire"
 )
@@ -15,6 +16,13 @@ var AppSet = wire.NewSet(
 	config.LoadConfig,
 	config.ConnectDB,
 	config.ConnectRedis,
+	config.NewPaypalWebhookVerifier,
+	config.NewGeocoder,
+	config.NewDeliveryProofStore,
+	config.NewMessageBroker,
+	config.NewEventBus,
+	config.ConnectPaypal,
+	payment.NewProviderRegistry,
 	RepositorySet,
 	MiddlerwareSet, // Inject Middleware
 	ServiceSet,
@@ -29,6 +37,16 @@ type App struct {
 	OrderController          *controller.OrderController
 	Middleware               *middleware.Middleware
 	ShipperController        *controller.ShipperController
+	InventoryController      *controller.InventoryController
+	CartController           *controller.CartController
+	CouponController         *controller.CouponController
+	PaymentController        *controller.PaymentController
+	CurrencyController       *controller.CurrencyController
+	LibraryController        *controller.LibraryController
+	NotificationController   *controller.NotificationController
+	MessageController        *controller.MessageController
+	EventController          *controller.EventController
+	WebhookController        *controller.WebhookController
 }
 
 // InitializeUserService khởi tạo UserService tự động
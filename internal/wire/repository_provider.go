@@ -12,4 +12,16 @@ var RepositorySet = wire.NewSet(
 	repository.NewBookRepositoryImpl,
 	repository.NewOrderRepositoryImpl,
 	repository.NewShipperRepository,
+	repository.NewEntityPermissionRepositoryImpl,
+	repository.NewCommentRepositoryImpl,
+	repository.NewInventoryRepositoryImpl,
+	repository.NewCartRepositoryImpl,
+	repository.NewCouponRepositoryImpl,
+	repository.NewCurrencyRepositoryImpl,
+	repository.NewPaymentRepositoryImpl,
+	repository.NewLibraryRepositoryImpl,
+	repository.NewDispatchRepositoryImpl,
+	repository.NewDeliveryRepositoryImpl,
+	repository.NewMessageRepositoryImpl,
+	repository.NewWebhookRepositoryImpl,
 )
@@ -13,4 +13,16 @@ var ServiceSet = wire.NewSet(
 	service.NewBookServiceImpl,
 	service.NewOrderServiceImpl,
 	service.NewOrderManageService,
+	service.NewInventoryServiceImpl,
+	service.NewCartServiceImpl,
+	service.NewCouponServiceImpl,
+	service.NewCurrencyServiceImpl,
+	service.NewPaymentServiceImpl,
+	service.NewLibraryServiceImpl,
+	service.NewDispatchServiceImpl,
+	service.NewNotificationServiceImpl,
+	service.NewDeliveryServiceImpl,
+	service.NewMessageServiceImpl,
+	service.NewWebhookServiceImpl,
+	service.NewOutboxServiceImpl,
 )
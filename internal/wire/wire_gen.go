@@ -10,6 +10,7 @@ import (
 	"bookstack/config"
 	"bookstack/internal/controller"
 	"bookstack/internal/middleware"
+	"bookstack/internal/payment"
 	"bookstack/internal/repository"
 	"bookstack/internal/service"
 	"github.com/google/wire"
@@ -25,21 +26,69 @@ func InitializeApp() (*App, error) {
 	}
 	db := config.ConnectDB(configConfig)
 	userRepository := repository.NewUserRepositoryImpl(db, configConfig)
-	authService := service.NewAuthServiceImpl(userRepository, configConfig)
-	authenticationController := controller.NewAuthenticationController(authService)
+	client := config.NewMessageBroker(configConfig)
+	eventBus := config.NewEventBus(configConfig, client)
+	authService := service.NewAuthServiceImpl(userRepository, configConfig, eventBus)
+	redisClient := config.ConnectRedis(configConfig)
+	cartRepository := repository.NewCartRepositoryImpl(db, redisClient)
+	orderRepository := repository.NewOrderRepositoryImpl(db)
+	entityPermissionRepository := repository.NewEntityPermissionRepositoryImpl(db)
+	gazetteer, err := config.NewGeocoder(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	notificationService := service.NewNotificationServiceImpl(redisClient)
+	orderService := service.NewOrderServiceImpl(orderRepository, entityPermissionRepository, gazetteer, notificationService, eventBus)
+	cartService := service.NewCartServiceImpl(cartRepository, orderService)
 	userService := service.NewUserServiceImpl(userRepository)
+	authenticationController := controller.NewAuthenticationController(authService, cartService, userService)
 	userController := controller.NewUserController(userService)
 	bookRepository := repository.NewBookRepositoryImpl(db)
-	bookService := service.NewBookServiceImpl(bookRepository)
+	commentRepository := repository.NewCommentRepositoryImpl(db)
+	bookService := service.NewBookServiceImpl(bookRepository, entityPermissionRepository, commentRepository, eventBus)
 	bookController := controller.NewBookController(bookService, userService)
-	orderRepository := repository.NewOrderRepositoryImpl(db)
-	orderService := service.NewOrderServiceImpl(orderRepository)
-	orderController := controller.NewOrderController(orderService, userService)
+	inventoryRepository := repository.NewInventoryRepositoryImpl(db)
+	inventoryService := service.NewInventoryServiceImpl(inventoryRepository)
+	messageRepository := repository.NewMessageRepositoryImpl(db)
+	outboxService := service.NewOutboxServiceImpl(messageRepository, eventBus)
+	orderController := controller.NewOrderController(orderService, userService, inventoryService, cartService, outboxService, eventBus)
 	permissionRepository := repository.NewPermissionRepositoryImpl(db)
 	middlewareMiddleware := middleware.NewAuthorizeMiddleware(userRepository, permissionRepository, configConfig)
 	shipperRepository := repository.NewShipperRepository(db)
-	shipperOrderManageService := service.NewOrderManageService(shipperRepository)
-	shipperController := controller.NewShipperController(shipperOrderManageService, userService)
+	shipperOrderManageService := service.NewOrderManageService(shipperRepository, notificationService, eventBus)
+	dispatchRepository := repository.NewDispatchRepositoryImpl(db)
+	dispatchService := service.NewDispatchServiceImpl(dispatchRepository, messageRepository, notificationService)
+	deliveryRepository := repository.NewDeliveryRepositoryImpl(db)
+	localStore := config.NewDeliveryProofStore(configConfig)
+	deliveryService := service.NewDeliveryServiceImpl(deliveryRepository, localStore, notificationService, eventBus)
+	shipperController := controller.NewShipperController(shipperOrderManageService, userService, dispatchService, deliveryService, eventBus)
+	inventoryController := controller.NewInventoryController(inventoryService, userService, eventBus)
+	cartController := controller.NewCartController(cartService, userService)
+	couponRepository := repository.NewCouponRepositoryImpl(db)
+	couponService := service.NewCouponServiceImpl(couponRepository)
+	couponController := controller.NewCouponController(couponService)
+	paymentRepository := repository.NewPaymentRepositoryImpl(db)
+	paypalClient, err := config.ConnectPaypal(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	verifier := config.NewPaypalWebhookVerifier(configConfig)
+	registry := payment.NewProviderRegistry(paypalClient, verifier)
+	paymentService := service.NewPaymentServiceImpl(paymentRepository, orderRepository, entityPermissionRepository, registry, eventBus)
+	paymentController := controller.NewPaymentController(paymentService, userService)
+	currencyRepository := repository.NewCurrencyRepositoryImpl(db)
+	currencyService := service.NewCurrencyServiceImpl(currencyRepository)
+	currencyController := controller.NewCurrencyController(currencyService, userService)
+	libraryRepository := repository.NewLibraryRepositoryImpl(db)
+	libraryService := service.NewLibraryServiceImpl(libraryRepository, bookService, configConfig)
+	libraryController := controller.NewLibraryController(libraryService, userService)
+	notificationController := controller.NewNotificationController(notificationService, userService)
+	messageService := service.NewMessageServiceImpl(client)
+	messageController := controller.NewMessageController(messageService)
+	eventController := controller.NewEventController()
+	webhookRepository := repository.NewWebhookRepositoryImpl(db)
+	webhookService := service.NewWebhookServiceImpl(webhookRepository, eventBus)
+	webhookController := controller.NewWebhookController(webhookService, userService)
 	app := &App{
 		AuthenticationController: authenticationController,
 		UserController:           userController,
@@ -47,13 +96,23 @@ func InitializeApp() (*App, error) {
 		OrderController:          orderController,
 		Middleware:               middlewareMiddleware,
 		ShipperController:        shipperController,
+		InventoryController:      inventoryController,
+		CartController:           cartController,
+		CouponController:         couponController,
+		PaymentController:        paymentController,
+		CurrencyController:       currencyController,
+		LibraryController:        libraryController,
+		NotificationController:   notificationController,
+		MessageController:        messageController,
+		EventController:          eventController,
+		WebhookController:        webhookController,
 	}
 	return app, nil
 }
 
 // injector.go:
 
-var AppSet = wire.NewSet(config.LoadConfig, config.ConnectDB, config.ConnectRedis, RepositorySet,
+var AppSet = wire.NewSet(config.LoadConfig, config.ConnectDB, config.ConnectRedis, config.NewPaypalWebhookVerifier, config.NewGeocoder, config.NewDeliveryProofStore, config.NewMessageBroker, config.NewEventBus, config.ConnectPaypal, payment.NewProviderRegistry, RepositorySet,
 	MiddlerwareSet,
 	ServiceSet,
 	ControllerSet, wire.Struct(new(App), "*"),
@@ -66,4 +125,14 @@ type App struct {
 	OrderController          *controller.OrderController
 	Middleware               *middleware.Middleware
 	ShipperController        *controller.ShipperController
+	InventoryController      *controller.InventoryController
+	CartController           *controller.CartController
+	CouponController         *controller.CouponController
+	PaymentController        *controller.PaymentController
+	CurrencyController       *controller.CurrencyController
+	LibraryController        *controller.LibraryController
+	NotificationController   *controller.NotificationController
+	MessageController        *controller.MessageController
+	EventController          *controller.EventController
+	WebhookController        *controller.WebhookController
 }
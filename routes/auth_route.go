@@ -13,5 +13,11 @@ func AuthRoute(authController controller.AuthenticationController, router *gin.E
 		authRoutes.POST("/register", authController.Register)
 		authRoutes.POST("/logout", authController.Logout)
 		authRoutes.POST("/refresh", authController.RefreshToken)
+		authRoutes.GET("/verify", authController.VerifyEmail)
+		authRoutes.POST("/verify/resend", authController.ResendVerificationEmail)
+		authRoutes.POST("/password/forgot", authController.ForgotPassword)
+		authRoutes.GET("/password/reset", authController.ResetPasswordForm)
+		authRoutes.POST("/password/reset", authController.ResetPassword)
+		authRoutes.POST("/password/change", authController.ChangePassword)
 	}
 }
@@ -1,16 +1,19 @@
 package routes
 
 import (
+	"bookstack/internal/constant"
 	"bookstack/internal/controller"
+	"bookstack/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-func BookRoute(bookController controller.BookController, router *gin.Engine) {
+func BookRoute(bookController controller.BookController, mw *middleware.Middleware, router *gin.Engine) {
 	BookRoutes := router.Group("/book")
 	{
 		//book
 		BookRoutes.POST("/complete", bookController.CreateCompleteBook)
+		BookRoutes.POST("/import", bookController.ImportBook)
 		BookRoutes.POST("/", bookController.CreateBook)
 		BookRoutes.GET("/", bookController.GetBooks)
 		BookRoutes.PUT("/:bookId", bookController.UpdateBook)
@@ -29,5 +32,34 @@ func BookRoute(bookController controller.BookController, router *gin.Engine) {
 		BookRoutes.GET("/chapter/:chapterId/page", bookController.GetPages)
 		BookRoutes.PUT("/chapter/:chapterId/page/:pageId", bookController.UpdatePage)
 		BookRoutes.DELETE("/chapter/:chapterId/page/:pageId", bookController.DeletePage)
+		//page revision
+		BookRoutes.GET("/chapter/:chapterId/page/:pageId/revisions", bookController.GetPageRevisions)
+		BookRoutes.GET("/chapter/:chapterId/page/:pageId/revisions/diff", bookController.DiffPageRevisions)
+		BookRoutes.GET("/chapter/:chapterId/page/:pageId/revisions/:revision", bookController.GetPageRevision)
+		BookRoutes.POST("/chapter/:chapterId/page/:pageId/revisions/:revision/restore", bookController.RestorePageRevision)
+		//export
+		BookRoutes.GET("/:bookId/export/:format", bookController.ExportBook)
+		BookRoutes.GET("/:bookId/chapter/:chapterId/export/:format", bookController.ExportChapter)
+		BookRoutes.GET("/chapter/:chapterId/page/:pageId/export/:format", bookController.ExportPage)
+		//comment
+		BookRoutes.GET("/:bookId/comments", bookController.GetComments(constant.EntityTypeBook, "bookId"))
+		BookRoutes.POST("/:bookId/comments", bookController.CreateComment(constant.EntityTypeBook, "bookId"))
+		BookRoutes.GET("/shelve/:shelveId/comments", bookController.GetComments(constant.EntityTypeShelve, "shelveId"))
+		BookRoutes.POST("/shelve/:shelveId/comments", bookController.CreateComment(constant.EntityTypeShelve, "shelveId"))
+		BookRoutes.PUT("/comments/:commentId", bookController.UpdateComment)
+		BookRoutes.DELETE("/comments/:commentId", bookController.DeleteComment)
+		BookRoutes.PUT("/comments/:commentId/visibility", mw.AuthorizeRole(constant.ModerateComments), bookController.SetCommentVisibility)
+		//entity permission (quyền riêng trên sách/chương/trang bị giới hạn)
+		managePermission := mw.AuthorizeRole(constant.ManageEntityPermissions)
+		BookRoutes.GET("/:bookId/permissions", managePermission, bookController.GetEntityPermissions(constant.EntityTypeBook, "bookId"))
+		BookRoutes.PUT("/:bookId/permissions", managePermission, bookController.SetEntityPermission(constant.EntityTypeBook, "bookId"))
+		BookRoutes.DELETE("/:bookId/permissions/:roleId", managePermission, bookController.RevokeEntityPermission(constant.EntityTypeBook, "bookId"))
+		BookRoutes.GET("/:bookId/chapter/:chapterId/permissions", managePermission, bookController.GetEntityPermissions(constant.EntityTypeChapter, "chapterId"))
+		BookRoutes.PUT("/:bookId/chapter/:chapterId/permissions", managePermission, bookController.SetEntityPermission(constant.EntityTypeChapter, "chapterId"))
+		BookRoutes.DELETE("/:bookId/chapter/:chapterId/permissions/:roleId", managePermission, bookController.RevokeEntityPermission(constant.EntityTypeChapter, "chapterId"))
+		BookRoutes.GET("/chapter/:chapterId/page/:pageId/permissions", managePermission, bookController.GetEntityPermissions(constant.EntityTypePage, "pageId"))
+		BookRoutes.PUT("/chapter/:chapterId/page/:pageId/permissions", managePermission, bookController.SetEntityPermission(constant.EntityTypePage, "pageId"))
+		BookRoutes.DELETE("/chapter/:chapterId/page/:pageId/permissions/:roleId", managePermission, bookController.RevokeEntityPermission(constant.EntityTypePage, "pageId"))
 	}
+	router.GET("/search", bookController.Search)
 }
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"bookstack/internal/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CartRoute(controller controller.CartController, router *gin.Engine) {
+	CartRoutes := router.Group("/cart")
+	{
+		CartRoutes.GET("/", controller.GetCart)
+		CartRoutes.DELETE("/", controller.ClearCart)
+		CartRoutes.POST("/items", controller.AddCartItem)
+		CartRoutes.PUT("/items/:bookId", controller.UpdateCartItem)
+		CartRoutes.DELETE("/items/:bookId", controller.RemoveCartItem)
+	}
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/controller"
+	"bookstack/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CouponRoute(controller controller.CouponController, mw *middleware.Middleware, router *gin.Engine) {
+	CouponRoutes := router.Group("/coupons", mw.AuthorizeRole(constant.ManageCoupons))
+	{
+		CouponRoutes.GET("/", controller.GetCoupons)
+		CouponRoutes.POST("/", controller.CreateCoupon)
+		CouponRoutes.PUT("/:couponId", controller.UpdateCoupon)
+		CouponRoutes.DELETE("/:couponId", controller.DeleteCoupon)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/controller"
+	"bookstack/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CurrencyRoute(controller controller.CurrencyController, mw *middleware.Middleware, router *gin.Engine) {
+	CurrencyRoutes := router.Group("/currencies")
+	{
+		CurrencyRoutes.GET("/", controller.GetCurrencies)
+		CurrencyRoutes.PUT("/:currency", mw.AuthorizeRole(constant.ManageCurrencies), controller.SetExchangeRate)
+		CurrencyRoutes.DELETE("/:currency", mw.AuthorizeRole(constant.ManageCurrencies), controller.DeleteExchangeRate)
+	}
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"bookstack/internal/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+func EventRoute(controller controller.EventController, router *gin.Engine) {
+	EventRoutes := router.Group("/events")
+	{
+		EventRoutes.GET("/schemas", controller.GetEventSchemas)
+		EventRoutes.GET("/schemas/:type", controller.GetEventSchema)
+	}
+}
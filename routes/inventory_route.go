@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/controller"
+	"bookstack/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InventoryRoute(controller controller.InventoryController, mw *middleware.Middleware, router *gin.Engine) {
+	InventoryRoutes := router.Group("/inventory", mw.AuthorizeRole(constant.ManageInventory))
+	{
+		InventoryRoutes.GET("/", controller.GetStocks)
+		InventoryRoutes.GET("/low-stock", controller.GetLowStocks)
+		InventoryRoutes.GET("/:bookId", controller.GetStock)
+		InventoryRoutes.POST("/:bookId/adjust", controller.AdjustStock)
+		InventoryRoutes.PUT("/:bookId/threshold", controller.SetLowStockThreshold)
+		InventoryRoutes.GET("/:bookId/movements", controller.GetMovements)
+	}
+}
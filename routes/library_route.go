@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"bookstack/internal/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+func LibraryRoute(controller controller.LibraryController, router *gin.Engine) {
+	LibraryRoutes := router.Group("/library")
+	{
+		LibraryRoutes.GET("/", controller.GetLibrary)
+		LibraryRoutes.POST("/:bookId/download/:format", controller.CreateDownloadLink)
+		// Link đã ký, không cần token
+		LibraryRoutes.GET("/downloads/:bookId/:format", controller.Download)
+	}
+}
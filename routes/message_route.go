@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/controller"
+	"bookstack/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func MessageRoute(controller controller.MessageController, mw *middleware.Middleware, router *gin.Engine) {
+	MessageRoutes := router.Group("/admin/messages", mw.AuthorizeRole(constant.ManageMessaging))
+	{
+		MessageRoutes.GET("/:queue/dead-letters", controller.GetDeadLetters)
+		MessageRoutes.POST("/:queue/dead-letters/replay", controller.ReplayDeadLetters)
+	}
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"bookstack/internal/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NotificationRoute(controller controller.NotificationController, router *gin.Engine) {
+	NotificationRoutes := router.Group("/notifications")
+	{
+		// Token lấy từ header Authorization hoặc query access_token
+		NotificationRoutes.GET("/stream", controller.Stream)
+		NotificationRoutes.GET("/ws", controller.WebSocket)
+	}
+}
@@ -1,18 +1,22 @@
 package routes
 
 import (
+	"bookstack/internal/constant"
 	"bookstack/internal/controller"
+	"bookstack/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-func OrderRoute(controller controller.OrderController, router *gin.Engine) {
+func OrderRoute(controller controller.OrderController, mw *middleware.Middleware, router *gin.Engine) {
 	OrderRoutes := router.Group("/order")
 	{
-		OrderRoutes.POST("/", controller.CreateOrder)
-		OrderRoutes.POST("/paypal/:orderId", controller.CreatePaypalOrder)
+		OrderRoutes.POST("/", mw.RequireVerifiedEmail(), controller.CreateOrder)
+		OrderRoutes.POST("/checkout", mw.RequireVerifiedEmail(), controller.Checkout)
+		OrderRoutes.POST("/quote", controller.QuoteOrder)
 		OrderRoutes.GET("/", controller.GetUserOrder)
 		OrderRoutes.POST("/:orderId/cancel", controller.CancelOrder)
+		OrderRoutes.GET("/:orderId/timeline", controller.GetOrderTimeline)
+		OrderRoutes.PUT("/:orderId/status", mw.AuthorizeRole(constant.ManageOrders), controller.ChangeOrderStatus)
 	}
-	router.POST("/paypal/webhook", controller.HandlePaypalWebhook)
 }
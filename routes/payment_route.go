@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/controller"
+	"bookstack/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PaymentRoute(controller controller.PaymentController, mw *middleware.Middleware, router *gin.Engine) {
+	OrderPaymentRoutes := router.Group("/order/:orderId/payments")
+	{
+		OrderPaymentRoutes.POST("/", controller.CreatePayment)
+		OrderPaymentRoutes.GET("/", controller.GetOrderPayments)
+		OrderPaymentRoutes.POST("/:paymentId/capture", controller.CapturePayment)
+	}
+	RefundRoutes := router.Group("/order/:orderId/refunds", mw.AuthorizeRole(constant.ManagePayments))
+	{
+		RefundRoutes.POST("/", controller.RefundOrder)
+		RefundRoutes.GET("/", controller.GetRefunds)
+	}
+	PaymentRoutes := router.Group("/payments")
+	{
+		PaymentRoutes.GET("/providers", controller.GetProviders)
+		PaymentRoutes.POST("/webhook/:provider", controller.HandleWebhook)
+	}
+	router.POST("/paypal/webhook", controller.HandlePaypalWebhook)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"bookstack/internal/constant"
+	"bookstack/internal/controller"
+	"bookstack/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WebhookRoute(controller controller.WebhookController, mw *middleware.Middleware, router *gin.Engine) {
+	WebhookRoutes := router.Group("/admin/webhooks", mw.AuthorizeRole(constant.ManageWebhooks))
+	{
+		WebhookRoutes.GET("/", controller.GetWebhooks)
+		WebhookRoutes.POST("/", controller.CreateWebhook)
+		WebhookRoutes.GET("/:webhookId", controller.GetWebhook)
+		WebhookRoutes.PUT("/:webhookId", controller.UpdateWebhook)
+		WebhookRoutes.DELETE("/:webhookId", controller.DeleteWebhook)
+		WebhookRoutes.GET("/:webhookId/deliveries", controller.GetWebhookDeliveries)
+		WebhookRoutes.POST("/:webhookId/deliveries/:deliveryId/redeliver", controller.RedeliverWebhook)
+		WebhookRoutes.POST("/:webhookId/test", controller.SendTestWebhook)
+	}
+}
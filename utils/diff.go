@@ -0,0 +1,142 @@
+package utils
+
+import "strings"
+
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// Số thao tác sửa tối đa mỗi lần tìm điểm chia. Vượt quá thì đoạn còn lại được coi là xóa hết rồi thêm mới,
+// để diff hai bản rất khác nhau không chiếm quá nhiều CPU.
+const maxDiffEdits = 1000
+
+type DiffLine struct {
+	Type string
+	Text string
+}
+
+// DiffLines so sánh hai đoạn văn bản theo từng dòng bằng thuật toán Myers, bộ nhớ dùng tuyến tính theo số dòng
+func DiffLines(oldText, newText string) []DiffLine {
+	var result []DiffLine
+	diffLines(splitLines(oldText), splitLines(newText), &result)
+	return result
+}
+
+func diffLines(a, b []string, result *[]DiffLine) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	appendLines(result, DiffEqual, a[:prefix])
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(middleA) > 0 && len(middleB) > 0 {
+		if x, y, ok := bisect(middleA, middleB); ok {
+			diffLines(middleA[:x], middleB[:y], result)
+			diffLines(middleA[x:], middleB[y:], result)
+		} else {
+			appendLines(result, DiffDelete, middleA)
+			appendLines(result, DiffInsert, middleB)
+		}
+	} else {
+		appendLines(result, DiffDelete, middleA)
+		appendLines(result, DiffInsert, middleB)
+	}
+	appendLines(result, DiffEqual, a[len(a)-suffix:])
+}
+
+// bisect tìm điểm chia (x, y) nằm trên một đường sửa ngắn nhất từ a sang b bằng cách chạy Myers đồng thời từ
+// hai đầu cho tới khi gặp nhau. ok là false khi không gặp nhau trong maxDiffEdits bước.
+func bisect(a, b []string) (x, y int, ok bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	length := 2*maxD + 2
+	forward := make([]int, length)
+	backward := make([]int, length)
+	for i := range forward {
+		forward[i] = -1
+		backward[i] = -1
+	}
+	forward[offset+1] = 0
+	backward[offset+1] = 0
+	delta := n - m
+	// Khi delta lẻ hai đường gặp nhau ở bước tiến, chẵn thì ở bước lùi
+	front := delta%2 != 0
+	// Bỏ qua các đường chéo đã chạy ra ngoài bảng
+	forwardStart, forwardEnd, backwardStart, backwardEnd := 0, 0, 0, 0
+	for d := 0; d < maxD && d < maxDiffEdits; d++ {
+		for k := -d + forwardStart; k <= d-forwardEnd; k += 2 {
+			index := offset + k
+			var x1 int
+			if k == -d || (k != d && forward[index-1] < forward[index+1]) {
+				x1 = forward[index+1]
+			} else {
+				x1 = forward[index-1] + 1
+			}
+			y1 := x1 - k
+			for x1 < n && y1 < m && a[x1] == b[y1] {
+				x1++
+				y1++
+			}
+			forward[index] = x1
+			if x1 > n {
+				forwardEnd += 2
+			} else if y1 > m {
+				forwardStart += 2
+			} else if front {
+				other := offset + delta - k
+				if other >= 0 && other < length && backward[other] != -1 && x1 >= n-backward[other] {
+					return x1, y1, true
+				}
+			}
+		}
+		for k := -d + backwardStart; k <= d-backwardEnd; k += 2 {
+			index := offset + k
+			var x2 int
+			if k == -d || (k != d && backward[index-1] < backward[index+1]) {
+				x2 = backward[index+1]
+			} else {
+				x2 = backward[index-1] + 1
+			}
+			y2 := x2 - k
+			for x2 < n && y2 < m && a[n-x2-1] == b[m-y2-1] {
+				x2++
+				y2++
+			}
+			backward[index] = x2
+			if x2 > n {
+				backwardEnd += 2
+			} else if y2 > m {
+				backwardStart += 2
+			} else if !front {
+				other := offset + delta - k
+				if other >= 0 && other < length && forward[other] != -1 {
+					x1 := forward[other]
+					if x1 >= n-x2 {
+						return x1, offset + x1 - other, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func appendLines(result *[]DiffLine, lineType string, lines []string) {
+	for _, line := range lines {
+		*result = append(*result, DiffLine{Type: lineType, Text: line})
+	}
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldText  string
+		newText  string
+		expected []DiffLine
+	}{
+		{
+			name:     "identical text",
+			oldText:  "a\nb",
+			newText:  "a\nb",
+			expected: []DiffLine{{DiffEqual, "a"}, {DiffEqual, "b"}},
+		},
+		{
+			name:     "line changed in the middle",
+			oldText:  "a\nb\nc",
+			newText:  "a\nx\nc",
+			expected: []DiffLine{{DiffEqual, "a"}, {DiffDelete, "b"}, {DiffInsert, "x"}, {DiffEqual, "c"}},
+		},
+		{
+			name:     "lines appended",
+			oldText:  "a",
+			newText:  "a\r\nb",
+			expected: []DiffLine{{DiffEqual, "a"}, {DiffInsert, "b"}},
+		},
+		{
+			name:     "content cleared",
+			oldText:  "a\nb",
+			newText:  "",
+			expected: []DiffLine{{DiffDelete, "a"}, {DiffDelete, "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DiffLines(tt.oldText, tt.newText))
+		})
+	}
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomText := func() []string {
+		lines := make([]string, random.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + random.Intn(3)))
+		}
+		return lines
+	}
+	for i := 0; i < 500; i++ {
+		a, b := randomText(), randomText()
+		diff := DiffLines(strings.Join(a, "\n"), strings.Join(b, "\n"))
+
+		var oldLines, newLines []string
+		equal := 0
+		for _, line := range diff {
+			if line.Type != DiffInsert {
+				oldLines = append(oldLines, line.Text)
+			}
+			if line.Type != DiffDelete {
+				newLines = append(newLines, line.Text)
+			}
+			if line.Type == DiffEqual {
+				equal++
+			}
+		}
+		joined := func(lines []string) string { return strings.Join(lines, "\n") }
+		assert.Equal(t, joined(a), joined(oldLines), "diff reproduces the old text")
+		assert.Equal(t, joined(b), joined(newLines), "diff reproduces the new text")
+		if len(a) > 0 && len(b) > 0 {
+			assert.Equal(t, lcsLength(a, b), equal, "diff keeps a longest common subsequence of %v and %v", a, b)
+		}
+	}
+}
+
+func TestDiffLinesLargeInput(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 200000; i++ {
+		oldLines = append(oldLines, fmt.Sprint("line ", i))
+		if i%1000 == 0 {
+			newLines = append(newLines, fmt.Sprint("changed ", i))
+		} else {
+			newLines = append(newLines, fmt.Sprint("line ", i))
+		}
+	}
+	diff := DiffLines(strings.Join(oldLines, "\n"), strings.Join(newLines, "\n"))
+	changed := 0
+	for _, line := range diff {
+		if line.Type != DiffEqual {
+			changed++
+		}
+	}
+	assert.Equal(t, 400, changed)
+
+	// Hai văn bản khác nhau hoàn toàn vượt giới hạn thao tác sửa: xóa hết rồi thêm mới
+	var otherLines []string
+	for i := 0; i < 50000; i++ {
+		otherLines = append(otherLines, fmt.Sprint("other ", i))
+	}
+	diff = DiffLines(strings.Join(oldLines[:50000], "\n"), strings.Join(otherLines, "\n"))
+	require.Len(t, diff, 100000)
+	assert.Equal(t, DiffLine{DiffDelete, "line 0"}, diff[0])
+	assert.Equal(t, DiffLine{DiffInsert, "other 49999"}, diff[len(diff)-1])
+}
+
+// lcsLength tính độ dài chuỗi con chung dài nhất bằng quy hoạch động để đối chiếu
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return lcs[0][0]
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidDownloadLink = errors.New("invalid download link")
+	ErrDownloadLinkExpired = errors.New("download link has expired")
+)
+
+// DownloadLink là các tham số của link tải sách điện tử, được ký bằng HMAC-SHA256
+// để không thể đổi sách, định dạng, người tải hay thời điểm hết hạn
+type DownloadLink struct {
+	BookID  uint
+	Format  string
+	UserID  uint
+	Expires int64 // Unix time
+}
+
+func (l DownloadLink) payload() string {
+	return strconv.FormatUint(uint64(l.BookID), 10) + "|" + l.Format + "|" +
+		strconv.FormatUint(uint64(l.UserID), 10) + "|" + strconv.FormatInt(l.Expires, 10)
+}
+
+// SignDownloadLink trả về chữ ký (hex) của link
+func SignDownloadLink(link DownloadLink, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(link.payload()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyDownloadLink kiểm tra chữ ký và thời hạn của link tại thời điểm now
+func VerifyDownloadLink(link DownloadLink, signature string, secret string, now time.Time) error {
+	expected := SignDownloadLink(link, secret)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidDownloadLink
+	}
+	if now.Unix() > link.Expires {
+		return ErrDownloadLinkExpired
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadLink(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	link := DownloadLink{BookID: 3, Format: "epub", UserID: 7, Expires: now.Add(15 * time.Minute).Unix()}
+	signature := SignDownloadLink(link, "secret")
+
+	assert.NoError(t, VerifyDownloadLink(link, signature, "secret", now))
+	assert.ErrorIs(t, VerifyDownloadLink(link, signature, "other", now), ErrInvalidDownloadLink)
+	assert.ErrorIs(t, VerifyDownloadLink(link, signature, "secret", now.Add(16*time.Minute)), ErrDownloadLinkExpired)
+
+	tampered := []DownloadLink{
+		{BookID: 4, Format: "epub", UserID: 7, Expires: link.Expires},
+		{BookID: 3, Format: "html", UserID: 7, Expires: link.Expires},
+		{BookID: 3, Format: "epub", UserID: 8, Expires: link.Expires},
+		{BookID: 3, Format: "epub", UserID: 7, Expires: link.Expires + 3600},
+	}
+	for _, other := range tampered {
+		assert.ErrorIs(t, VerifyDownloadLink(other, signature, "secret", now), ErrInvalidDownloadLink)
+	}
+}
@@ -33,13 +33,14 @@ func OrderNotificationEmail(toEmail, orderId string) error {
 	return nil
 }
 
-func SendVerificationEmail(toEmail, username string) error {
+// SendVerificationEmail gửi link xác nhận email (chứa token có chữ ký) tới người dùng
+func SendVerificationEmail(toEmail, username, link string) error {
 	from := "test@example.com"
 
 	msg := "From: " + from + "\n" +
 		"To: " + toEmail + "\n" +
 		"Subject: Hello " + username + "\n\n" +
-		"Here is your verification link: " + "http://localhost:8080/verify?email=" + toEmail
+		"Here is your verification link: " + link
 
 	auth := smtp.PlainAuth("", mailtrapUser, mailtrapPass, mailtrapHost)
 
@@ -52,3 +53,26 @@ func SendVerificationEmail(toEmail, username string) error {
 	log.Print("Verification email sent successfully")
 	return nil
 }
+
+// SendPasswordResetEmail gửi link đặt lại mật khẩu (chứa token dùng một lần) tới người dùng
+func SendPasswordResetEmail(toEmail, username, link string) error {
+	from := "test@example.com"
+
+	msg := "From: " + from + "\n" +
+		"To: " + toEmail + "\n" +
+		"Subject: Reset your password\n\n" +
+		"Hello " + username + ",\n" +
+		"Use this link to reset your password: " + link + "\n" +
+		"If you did not request a password reset, you can ignore this email."
+
+	auth := smtp.PlainAuth("", mailtrapUser, mailtrapPass, mailtrapHost)
+
+	err := smtp.SendMail(mailtrapHost+":"+mailtrapPort, auth, from, []string{toEmail}, []byte(msg))
+	if err != nil {
+		log.Printf("smtp error: %s", err)
+		return err
+	}
+
+	log.Print("Password reset email sent successfully")
+	return nil
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid email verification token")
+	ErrVerificationTokenExpired = errors.New("email verification token has expired")
+)
+
+// EmailVerification là nội dung token xác nhận email, được ký bằng HMAC-SHA256. Email nằm trong token nên
+// token cũ mất hiệu lực khi người dùng đổi email.
+type EmailVerification struct {
+	UserID  uint
+	Email   string
+	Expires int64 // Unix time
+}
+
+// Tiền tố để chữ ký token xác nhận email không dùng lẫn được với chữ ký khác cùng secret (link tải sách)
+const emailVerificationPurpose = "email-verification"
+
+func (v EmailVerification) payload() string {
+	return strconv.FormatUint(uint64(v.UserID), 10) + "|" + v.Email + "|" + strconv.FormatInt(v.Expires, 10)
+}
+
+func signEmailVerification(payload string, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(emailVerificationPurpose + "|" + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignEmailVerification trả về token gửi trong link xác nhận: payload (base64 URL) "." chữ ký (hex)
+func SignEmailVerification(verification EmailVerification, secret string) string {
+	payload := verification.payload()
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + signEmailVerification(payload, secret)
+}
+
+// ParseEmailVerification kiểm tra chữ ký và thời hạn của token tại thời điểm now
+func ParseEmailVerification(token string, secret string, now time.Time) (EmailVerification, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return EmailVerification{}, ErrInvalidVerificationToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return EmailVerification{}, ErrInvalidVerificationToken
+	}
+	if !hmac.Equal([]byte(signEmailVerification(string(payload), secret)), []byte(signature)) {
+		return EmailVerification{}, ErrInvalidVerificationToken
+	}
+	parts := strings.Split(string(payload), "|")
+	if len(parts) != 3 {
+		return EmailVerification{}, ErrInvalidVerificationToken
+	}
+	userId, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return EmailVerification{}, ErrInvalidVerificationToken
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return EmailVerification{}, ErrInvalidVerificationToken
+	}
+	if now.Unix() > expires {
+		return EmailVerification{}, ErrVerificationTokenExpired
+	}
+	return EmailVerification{UserID: uint(userId), Email: parts[1], Expires: expires}, nil
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailVerification(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verification := EmailVerification{UserID: 7, Email: "reader@example.com", Expires: now.Add(24 * time.Hour).Unix()}
+	token := SignEmailVerification(verification, "secret")
+
+	parsed, err := ParseEmailVerification(token, "secret", now)
+	require.NoError(t, err)
+	assert.Equal(t, verification, parsed)
+
+	_, err = ParseEmailVerification(token, "other", now)
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+	_, err = ParseEmailVerification(token, "secret", now.Add(25*time.Hour))
+	assert.ErrorIs(t, err, ErrVerificationTokenExpired)
+
+	// Đổi người dùng hoặc email trong token thì chữ ký không còn khớp
+	_, signature, _ := strings.Cut(token, ".")
+	forged := SignEmailVerification(EmailVerification{UserID: 8, Email: verification.Email, Expires: verification.Expires}, "secret")
+	payload, _, _ := strings.Cut(forged, ".")
+	_, err = ParseEmailVerification(payload+"."+signature, "secret", now)
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+
+	// Chữ ký link tải sách cùng secret không dùng được làm token
+	link := DownloadLink{BookID: 7, Format: "reader@example.com", Expires: verification.Expires}
+	_, err = ParseEmailVerification(payload+"."+SignDownloadLink(link, "secret"), "secret", now)
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+
+	for _, malformed := range []string{"", "abc", "!!!.00", payload} {
+		_, err = ParseEmailVerification(malformed, "secret", now)
+		assert.ErrorIs(t, err, ErrInvalidVerificationToken, malformed)
+	}
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPasswordResetToken(t *testing.T) {
+	token, hash, err := NewPasswordResetToken()
+	require.NoError(t, err)
+	assert.Len(t, token, 64)
+	assert.Equal(t, HashPasswordResetToken(token), hash, "the stored hash is looked up from the emailed token")
+	assert.NotEqual(t, token, hash, "the token itself is never stored")
+
+	other, _, err := NewPasswordResetToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
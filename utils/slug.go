@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Slugify tạo đường dẫn thân thiện từ tiêu đề, bỏ dấu tiếng Việt ("Chương Một" -> "chuong-mot")
+func Slugify(text string) string {
+	var builder strings.Builder
+	lastDash := true
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue // Bỏ dấu
+		case r == 'đ':
+			builder.WriteRune('d')
+			lastDash = false
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			builder.WriteRune(r)
+			lastDash = false
+		default:
+			if !lastDash {
+				builder.WriteRune('-')
+				lastDash = true
+			}
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "-")
+}